@@ -18,6 +18,7 @@ import (
 	"net/http"
 
 	"github.com/amalgam8/amalgam8/controller/metrics"
+	"github.com/amalgam8/amalgam8/pkg/health"
 	"github.com/ant0ine/go-json-rest/rest"
 )
 
@@ -37,6 +38,8 @@ func NewHealth(reporter metrics.Reporter) *Health {
 func (h *Health) Routes(middlewares ...rest.Middleware) []*rest.Route {
 	routes := []*rest.Route{
 		rest.Get("/health", reportMetric(h.reporter, h.GetHealth, "controller_health")),
+		rest.Get("/health/live", reportMetric(h.reporter, h.GetHealth, "controller_liveness")),
+		rest.Get("/health/ready", reportMetric(h.reporter, h.GetReadiness, "controller_readiness")),
 	}
 
 	for _, route := range routes {
@@ -45,9 +48,25 @@ func (h *Health) Routes(middlewares ...rest.Middleware) []*rest.Route {
 	return routes
 }
 
-// GetHealth performs health check on controller and dependencies
+// GetHealth reports that the controller is alive
 func (h *Health) GetHealth(w rest.ResponseWriter, req *rest.Request) error {
-	// TODO: perform checks on dependencies
 	w.WriteHeader(http.StatusOK)
 	return nil
 }
+
+// GetReadiness runs the health checks of the controller's components, and reports whether it is ready to serve
+// API calls along with the status of each component
+func (h *Health) GetReadiness(w rest.ResponseWriter, req *rest.Request) error {
+	statuses := health.RunChecks()
+
+	code := health.HTTPStatusCodeHealthChecksPass
+	for _, status := range statuses {
+		if !status.Healthy {
+			code = health.HTTPStatusCodeHealthChecksFail
+			break
+		}
+	}
+
+	w.WriteHeader(code)
+	return w.WriteJson(statuses)
+}
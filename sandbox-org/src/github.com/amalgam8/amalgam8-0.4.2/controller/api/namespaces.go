@@ -0,0 +1,123 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/amalgam8/amalgam8/controller/metrics"
+	"github.com/amalgam8/amalgam8/controller/rules"
+	"github.com/amalgam8/amalgam8/controller/webhooks"
+	"github.com/ant0ine/go-json-rest/rest"
+)
+
+// NamespaceList is used to output the namespaces that have rules.
+type NamespaceList struct {
+	Namespaces []rules.NamespaceInfo `json:"namespaces"`
+}
+
+// byNamespace sorts by namespace name
+type byNamespace []rules.NamespaceInfo
+
+// Len of the array
+func (a byNamespace) Len() int {
+	return len(a)
+}
+
+// Swap i and j
+func (a byNamespace) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+// Less i and j
+func (a byNamespace) Less(i, j int) bool {
+	return a[i].Namespace < a[j].Namespace
+}
+
+// Namespace administration API. Its routes must be restricted to the admin namespace.
+type Namespace struct {
+	manager  rules.Manager
+	notifier webhooks.Notifier
+	reporter metrics.Reporter
+}
+
+// NewNamespace constructs a new Namespace administration API. Deletions of namespaces are published to the notifier,
+// unless it is nil.
+func NewNamespace(m rules.Manager, n webhooks.Notifier, r metrics.Reporter) *Namespace {
+	return &Namespace{
+		manager:  m,
+		notifier: n,
+		reporter: r,
+	}
+}
+
+// Routes returns this API's routes wrapped by the middlewares.
+func (n *Namespace) Routes(middlewares ...rest.Middleware) []*rest.Route {
+	routes := []*rest.Route{
+		rest.Get("/v1/admin/namespaces", reportMetric(n.reporter, n.list, "list_namespaces")),
+		rest.Get("/v1/admin/namespaces/#namespace", reportMetric(n.reporter, n.get, "get_namespace")),
+		rest.Delete("/v1/admin/namespaces/#namespace", reportMetric(n.reporter, n.remove, "delete_namespace")),
+	}
+
+	for _, route := range routes {
+		route.Func = rest.WrapMiddlewares(middlewares, route.Func)
+	}
+
+	return routes
+}
+
+func (n *Namespace) list(w rest.ResponseWriter, req *rest.Request) error {
+	infos, err := n.manager.ListNamespaces()
+	if err != nil {
+		handleManagerError(w, req, err)
+		return err
+	}
+
+	sort.Sort(byNamespace(infos))
+
+	w.WriteHeader(http.StatusOK)
+	w.WriteJson(&NamespaceList{Namespaces: infos})
+	return nil
+}
+
+func (n *Namespace) get(w rest.ResponseWriter, req *rest.Request) error {
+	info, err := n.manager.GetNamespace(req.PathParam("namespace"))
+	if err != nil {
+		handleManagerError(w, req, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.WriteJson(&info)
+	return nil
+}
+
+// remove deletes all the rules and the history of a namespace. The webhooks of the namespace are notified of the
+// removed rules.
+func (n *Namespace) remove(w rest.ResponseWriter, req *rest.Request) error {
+	ns := req.PathParam("namespace")
+
+	err := changeRules(n.notifier, ns, req, func() (rules.Change, error) {
+		return n.manager.DeleteNamespace(ns)
+	})
+	if err != nil {
+		handleManagerError(w, req, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
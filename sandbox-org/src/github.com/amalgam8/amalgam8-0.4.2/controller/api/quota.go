@@ -0,0 +1,107 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/amalgam8/amalgam8/controller/metrics"
+	"github.com/amalgam8/amalgam8/controller/middleware"
+	"github.com/amalgam8/amalgam8/controller/rules"
+	"github.com/ant0ine/go-json-rest/rest"
+)
+
+// QuotaUsage describes the quota of a namespace and its usage. Limits that are not set are unlimited.
+type QuotaUsage struct {
+	Namespace string `json:"namespace"`
+
+	Rules struct {
+		Used  int `json:"used"`
+		Limit int `json:"limit,omitempty"`
+	} `json:"rules"`
+
+	RuleSize struct {
+		Limit int `json:"limit,omitempty"`
+	} `json:"rule_size"`
+
+	WriteRate *WriteRateUsage `json:"write_rate,omitempty"`
+}
+
+// WriteRateUsage describes the write rate limit of a namespace, and the number of write requests currently allowed.
+type WriteRateUsage struct {
+	Limit     float64 `json:"limit"`
+	Burst     int     `json:"burst"`
+	Available int     `json:"available"`
+}
+
+// Quota API.
+type Quota struct {
+	manager  rules.Manager
+	quotas   *rules.Quotas
+	limiter  *middleware.RateLimiter
+	reporter metrics.Reporter
+}
+
+// NewQuota constructs a new Quota API, reporting the quotas of namespaces and their usage of the rules in the manager
+// and of the write rate limiter.
+func NewQuota(m rules.Manager, q *rules.Quotas, l *middleware.RateLimiter, r metrics.Reporter) *Quota {
+	return &Quota{
+		manager:  m,
+		quotas:   q,
+		limiter:  l,
+		reporter: r,
+	}
+}
+
+// Routes returns this API's routes wrapped by the middlewares.
+func (q *Quota) Routes(middlewares ...rest.Middleware) []*rest.Route {
+	routes := []*rest.Route{
+		rest.Get("/v1/quota", reportMetric(q.reporter, q.get, "get_quota")),
+	}
+
+	for _, route := range routes {
+		route.Func = rest.WrapMiddlewares(middlewares, route.Func)
+	}
+
+	return routes
+}
+
+func (q *Quota) get(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+	quota := q.quotas.For(ns)
+
+	// A namespace that never had rules uses none
+	info, err := q.manager.GetNamespace(ns)
+	if _, notFound := err.(*rules.NamespaceNotFoundError); err != nil && !notFound {
+		handleManagerError(w, req, err)
+		return err
+	}
+
+	resp := QuotaUsage{Namespace: ns}
+	resp.Rules.Used = info.Rules
+	resp.Rules.Limit = quota.MaxRules
+	resp.RuleSize.Limit = quota.MaxRuleSize
+	if quota.WriteRate > 0 {
+		resp.WriteRate = &WriteRateUsage{
+			Limit:     quota.WriteRate,
+			Burst:     quota.WriteBurst,
+			Available: q.limiter.Available(ns),
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.WriteJson(&resp)
+	return nil
+}
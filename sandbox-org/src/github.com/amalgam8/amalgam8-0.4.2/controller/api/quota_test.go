@@ -0,0 +1,84 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/amalgam8/amalgam8/controller/metrics"
+	"github.com/amalgam8/amalgam8/controller/rules"
+	"github.com/amalgam8/amalgam8/controller/util"
+	"github.com/amalgam8/amalgam8/pkg/auth"
+	"github.com/ant0ine/go-json-rest/rest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quota API", func() {
+
+	var (
+		manager rules.Manager
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		manager = rules.NewMemoryManager(&mockValidator{})
+		quotas := &rules.Quotas{Default: rules.Quota{MaxRules: 10}}
+
+		// Requests are made in the prod namespace
+		authenticate := rest.MiddlewareSimple(func(h rest.HandlerFunc) rest.HandlerFunc {
+			return func(w rest.ResponseWriter, req *rest.Request) {
+				req.Env[util.Namespace] = auth.Namespace("prod")
+				h(w, req)
+			}
+		})
+
+		router, err := rest.MakeRouter(NewQuota(manager, quotas, nil, metrics.NewReporter()).Routes(authenticate)...)
+		Expect(err).NotTo(HaveOccurred())
+
+		a := rest.NewApi()
+		a.SetApp(router)
+		handler = a.MakeHandler()
+	})
+
+	getQuota := func() QuotaUsage {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/quota", nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var usage QuotaUsage
+		Expect(json.Unmarshal(w.Body.Bytes(), &usage)).To(Succeed())
+		return usage
+	}
+
+	It("reports no rules used by a namespace that never had rules", func() {
+		usage := getQuota()
+		Expect(usage.Namespace).To(Equal("prod"))
+		Expect(usage.Rules.Used).To(Equal(0))
+		Expect(usage.Rules.Limit).To(Equal(10))
+	})
+
+	It("counts the rules of the namespace, including disabled rules", func() {
+		_, err := manager.AddRules("prod", []rules.Rule{
+			{Destination: "reviews", Priority: 1},
+			{Destination: "ratings", Priority: 1, Disabled: true},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(getQuota().Rules.Used).To(Equal(2))
+	})
+})
@@ -16,6 +16,8 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"errors"
 
@@ -23,7 +25,10 @@ import (
 	"github.com/amalgam8/amalgam8/controller/metrics"
 	"github.com/amalgam8/amalgam8/controller/rules"
 	"github.com/amalgam8/amalgam8/controller/util/i18n"
+	"github.com/amalgam8/amalgam8/controller/webhooks"
+	"github.com/amalgam8/amalgam8/pkg/labels"
 	"github.com/ant0ine/go-json-rest/rest"
+	"github.com/pborman/uuid"
 )
 
 // RuleList is used to output the results of rule queries.
@@ -32,16 +37,37 @@ type RuleList struct {
 	Revision int64        `json:"revision"`
 }
 
+// RulePromotion describes the changes made to the destination namespace by a rule promotion.
+type RulePromotion struct {
+	Added   []rules.Rule `json:"added"`
+	Removed []rules.Rule `json:"removed"`
+}
+
+// RuleSetRef identifies a set of rules by namespace and revision.
+type RuleSetRef struct {
+	Namespace string `json:"namespace"`
+	Revision  int64  `json:"revision"`
+}
+
+// RuleDiff is used to output the differences between two sets of rules.
+type RuleDiff struct {
+	From RuleSetRef `json:"from"`
+	To   RuleSetRef `json:"to"`
+	rules.Diff
+}
+
 // Rule API.
 type Rule struct {
 	manager  rules.Manager
+	notifier webhooks.Notifier
 	reporter metrics.Reporter
 }
 
-// NewRule constructs a new Rule API.
-func NewRule(m rules.Manager, r metrics.Reporter) *Rule {
+// NewRule constructs a new Rule API. Changes to rules are published to the notifier, unless it is nil.
+func NewRule(m rules.Manager, n webhooks.Notifier, r metrics.Reporter) *Rule {
 	return &Rule{
 		manager:  m,
+		notifier: n,
 		reporter: r,
 	}
 }
@@ -55,6 +81,11 @@ func (r *Rule) Routes(middlewares ...rest.Middleware) []*rest.Route {
 		rest.Put("/v1/rules", reportMetric(r.reporter, r.update, "update_rules")),
 		rest.Delete("/v1/rules", reportMetric(r.reporter, r.remove, "delete_rules")),
 
+		rest.Put("/v1/rules/enable", reportMetric(r.reporter, r.enable, "enable_rules")),
+		rest.Put("/v1/rules/disable", reportMetric(r.reporter, r.disable, "disable_rules")),
+		rest.Post("/v1/rules/promote", reportMetric(r.reporter, r.promote, "promote_rules")),
+		rest.Get("/v1/rules/diff", reportMetric(r.reporter, r.diff, "diff_rules")),
+
 		rest.Get("/v1/rules/routes", reportMetric(r.reporter, r.getRoutes, "get_all_routes")),
 		rest.Get("/v1/rules/actions", reportMetric(r.reporter, r.getActions, "get_all_actions")),
 
@@ -93,7 +124,12 @@ func (r *Rule) add(w rest.ResponseWriter, req *rest.Request) error {
 		}
 	}
 
-	newRules, err := r.manager.AddRules(namespace, ruleList.Rules)
+	var newRules rules.NewRules
+	err := r.change(namespace, req, func() (rules.Change, error) {
+		var err error
+		newRules, err = r.manager.AddRules(namespace, ruleList.Rules)
+		return newRules.Change, err
+	})
 	if err != nil {
 		handleManagerError(w, req, err)
 		return err
@@ -127,6 +163,15 @@ func (r *Rule) list(w rest.ResponseWriter, req *rest.Request) error {
 }
 
 func (r *Rule) get(ns string, f rules.Filter, w rest.ResponseWriter, req *rest.Request) error {
+	selector, err := getSelector(req)
+	if err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidSelector)
+		return err
+	}
+	f.Selector = selector
+	f.IncludeDisabled = includeDisabled(req)
+	f.Source = getSource(req)
+
 	res, err := r.manager.GetRules(ns, f)
 	if err != nil {
 		handleManagerError(w, req, err)
@@ -164,7 +209,10 @@ func (r *Rule) update(w rest.ResponseWriter, req *rest.Request) error {
 		}
 	}
 
-	if err := r.manager.UpdateRules(namespace, ruleList.Rules); err != nil {
+	err := r.change(namespace, req, func() (rules.Change, error) {
+		return r.manager.UpdateRules(namespace, ruleList.Rules)
+	})
+	if err != nil {
 		handleManagerError(w, req, err)
 		return err
 	}
@@ -186,12 +234,20 @@ func (r *Rule) getByRuleType(ruleType int, w rest.ResponseWriter, req *rest.Requ
 	ruleIDs := getQueries("id", req)
 	tags := getQueries("tag", req)
 	destinations := getQueries("destination", req)
+	selector, err := getSelector(req)
+	if err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidSelector)
+		return err
+	}
 
 	filter := rules.Filter{
-		IDs:          ruleIDs,
-		Tags:         tags,
-		Destinations: destinations,
-		RuleType:     ruleType,
+		IDs:             ruleIDs,
+		Tags:            tags,
+		Destinations:    destinations,
+		Selector:        selector,
+		RuleType:        ruleType,
+		IncludeDisabled: includeDisabled(req),
+		Source:          getSource(req),
 	}
 
 	retrievedRules, err := r.manager.GetRules(namespace, filter)
@@ -227,7 +283,18 @@ func (r *Rule) getByRuleType(ruleType int, w rest.ResponseWriter, req *rest.Requ
 }
 
 func (r *Rule) delete(ns string, f rules.Filter, w rest.ResponseWriter, req *rest.Request) error {
-	if err := r.manager.DeleteRules(ns, f); err != nil {
+	selector, err := getSelector(req)
+	if err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidSelector)
+		return err
+	}
+	f.Selector = selector
+	f.IncludeDisabled = true
+
+	err = r.change(ns, req, func() (rules.Change, error) {
+		return r.manager.DeleteRules(ns, f)
+	})
+	if err != nil {
 		handleManagerError(w, req, err)
 		return err
 	}
@@ -251,7 +318,255 @@ func (r *Rule) remove(w rest.ResponseWriter, req *rest.Request) error {
 	return r.delete(ns, f, w, req)
 }
 
+func (r *Rule) enable(w rest.ResponseWriter, req *rest.Request) error {
+	return r.setDisabled(false, w, req)
+}
+
+func (r *Rule) disable(w rest.ResponseWriter, req *rest.Request) error {
+	return r.setDisabled(true, w, req)
+}
+
+func (r *Rule) setDisabled(disabled bool, w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+	ruleIDs := getQueries("id", req)
+	tags := getQueries("tag", req)
+	dests := getQueries("destination", req)
+	selector, err := getSelector(req)
+	if err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidSelector)
+		return err
+	}
+
+	f := rules.Filter{
+		IDs:          ruleIDs,
+		Tags:         tags,
+		Destinations: dests,
+		Selector:     selector,
+	}
+
+	err = r.change(ns, req, func() (rules.Change, error) {
+		if disabled {
+			return r.manager.DisableRules(ns, f)
+		}
+		return r.manager.EnableRules(ns, f)
+	})
+	if err != nil {
+		handleManagerError(w, req, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// promote copies the rules that match the filter from the source namespace into the namespace of the request. In
+// replace mode, the rules matching the filter in the namespace of the request are deleted in the same transaction.
+func (r *Rule) promote(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+	query := req.URL.Query()
+
+	from := query.Get("from")
+	if from == "" {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorMissingSourceNamespace)
+		return errors.New("missing_source_namespace")
+	}
+
+	if from == ns {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorSameSourceNamespace)
+		return errors.New("same_source_namespace")
+	}
+
+	if !IsAuthorized(req, from) {
+		i18n.RestError(w, req, http.StatusForbidden, i18n.ErrorNamespaceNotAuthorized,
+			map[string]interface{}{"Namespace": from})
+		return errors.New("source_namespace_not_authorized")
+	}
+
+	replace := false
+	switch query.Get("mode") {
+	case "", "copy":
+	case "replace":
+		replace = true
+	default:
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidPromotionMode)
+		return errors.New("invalid_promotion_mode")
+	}
+
+	selector, err := getSelector(req)
+	if err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidSelector)
+		return err
+	}
+
+	f := rules.Filter{
+		IDs:             getQueries("id", req),
+		Tags:            getQueries("tag", req),
+		Destinations:    getQueries("destination", req),
+		Selector:        selector,
+		IncludeDisabled: true,
+	}
+
+	source, err := r.manager.GetRules(from, f)
+	if err != nil {
+		handleManagerError(w, req, err)
+		return err
+	}
+
+	promotion := RulePromotion{
+		Added:   source.Rules,
+		Removed: []rules.Rule{},
+	}
+
+	// Rule IDs are namespace specific, so they must not be used to select the rules to replace. Replacing the rules
+	// selected only by IDs would replace all the rules of the namespace, so another filter is required.
+	destFilter := f
+	destFilter.IDs = nil
+
+	if replace && len(f.IDs) > 0 && destFilter.Empty() {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorReplaceFilterRequired)
+		return errors.New("replace_filter_required")
+	}
+
+	if replace {
+		dest, err := r.manager.GetRules(ns, destFilter)
+		if err != nil {
+			handleManagerError(w, req, err)
+			return err
+		}
+		promotion.Removed = dest.Rules
+	}
+
+	if dryRun, err := strconv.ParseBool(query.Get("dry_run")); err == nil && dryRun {
+		w.WriteHeader(http.StatusOK)
+		w.WriteJson(&promotion)
+		return nil
+	}
+
+	copies := make([]rules.Rule, len(source.Rules))
+	copy(copies, source.Rules)
+
+	var newRules rules.NewRules
+	err = r.change(ns, req, func() (rules.Change, error) {
+		var err error
+		if replace {
+			newRules, err = r.manager.SetRules(ns, destFilter, copies)
+		} else if len(copies) > 0 {
+			newRules, err = r.manager.AddRules(ns, copies)
+		}
+		return newRules.Change, err
+	})
+	if err != nil {
+		handleManagerError(w, req, err)
+		return err
+	}
+
+	if newRules.IDs == nil {
+		newRules.IDs = []string{}
+	}
+
+	resp := struct {
+		IDs []string `json:"ids"`
+	}{
+		IDs: newRules.IDs,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteJson(&resp)
+	return nil
+}
+
+// diff compares the rules of two revisions. The revisions are of the namespace of the request, unless a source
+// namespace is specified for the "from" revision. Revisions that are not specified default to the current revision.
+func (r *Rule) diff(w rest.ResponseWriter, req *rest.Request) error {
+	query := req.URL.Query()
+
+	to := RuleSetRef{Namespace: GetNamespace(req)}
+	from := RuleSetRef{Namespace: query.Get("from_namespace")}
+	if from.Namespace == "" {
+		from.Namespace = to.Namespace
+		if query.Get("from") == "" {
+			i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorMissingRevision)
+			return errors.New("missing_revision")
+		}
+	}
+
+	if !IsAuthorized(req, from.Namespace) {
+		i18n.RestError(w, req, http.StatusForbidden, i18n.ErrorNamespaceNotAuthorized,
+			map[string]interface{}{"Namespace": from.Namespace})
+		return errors.New("source_namespace_not_authorized")
+	}
+
+	selector, err := getSelector(req)
+	if err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidSelector)
+		return err
+	}
+
+	f := rules.Filter{
+		Tags:            getQueries("tag", req),
+		Destinations:    getQueries("destination", req),
+		Selector:        selector,
+		IncludeDisabled: true,
+	}
+
+	fromRules, err := r.getRevision(&from, query.Get("from"), f, w, req)
+	if err != nil {
+		return err
+	}
+
+	toRules, err := r.getRevision(&to, query.Get("to"), f, w, req)
+	if err != nil {
+		return err
+	}
+
+	resp := RuleDiff{
+		From: from,
+		To:   to,
+		Diff: rules.DiffRules(fromRules, toRules, from.Namespace == to.Namespace),
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.WriteJson(&resp)
+	return nil
+}
+
+// getRevision returns the filtered rules of the referenced namespace at the given revision, or at the current revision
+// when none is given. The reference is updated with the revision of the returned rules.
+func (r *Rule) getRevision(ref *RuleSetRef, revision string, f rules.Filter, w rest.ResponseWriter,
+	req *rest.Request) ([]rules.Rule, error) {
+	var res rules.RetrievedRules
+	var err error
+
+	if revision == "" {
+		res, err = r.manager.GetRules(ref.Namespace, f)
+	} else {
+		rev, parseErr := strconv.ParseInt(revision, 10, 64)
+		if parseErr != nil || rev < 0 {
+			i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidRevision)
+			return nil, errors.New("invalid_revision")
+		}
+
+		res, err = r.manager.GetRevision(ref.Namespace, rev)
+		res.Rules = rules.FilterRules(f, res.Rules)
+	}
+	if err != nil {
+		handleManagerError(w, req, err)
+		return nil, err
+	}
+
+	ref.Revision = res.Revision
+	return res.Rules, nil
+}
+
 func (r *Rule) set(ns string, f rules.Filter, w rest.ResponseWriter, req *rest.Request) error {
+	selector, err := getSelector(req)
+	if err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidSelector)
+		return err
+	}
+	f.Selector = selector
+	f.IncludeDisabled = true
+
 	ruleList := RuleList{}
 	if err := req.DecodeJsonPayload(&ruleList); err != nil {
 		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidJSON)
@@ -264,7 +579,12 @@ func (r *Rule) set(ns string, f rules.Filter, w rest.ResponseWriter, req *rest.R
 		}
 	}
 
-	newRules, err := r.manager.SetRules(ns, f, ruleList.Rules)
+	var newRules rules.NewRules
+	err = r.change(ns, req, func() (rules.Change, error) {
+		var err error
+		newRules, err = r.manager.SetRules(ns, f, ruleList.Rules)
+		return newRules.Change, err
+	})
 	if err != nil {
 		handleManagerError(w, req, err)
 		return err
@@ -362,11 +682,81 @@ func getQueries(key string, req *rest.Request) []string {
 	return values
 }
 
+// change runs an operation modifying the rules of the namespace, and notifies the webhooks of the namespace about the
+// rules that have changed.
+func (r *Rule) change(ns string, req *rest.Request, op func() (rules.Change, error)) error {
+	return changeRules(r.notifier, ns, req, op)
+}
+
+// changeRules runs an operation modifying the rules of the namespace, and notifies the webhooks of the
+// namespace about the rules changed by the operation, unless the notifier is nil.
+func changeRules(notifier webhooks.Notifier, ns string, req *rest.Request, op func() (rules.Change, error)) error {
+	change, err := op()
+	if err != nil {
+		return err
+	}
+
+	if notifier == nil || len(change.IDs) == 0 {
+		return nil
+	}
+
+	notifier.Notify(webhooks.Event{
+		ID:          uuid.New(),
+		Type:        webhooks.RulesChanged,
+		Namespace:   ns,
+		OldRevision: change.Revision - 1,
+		NewRevision: change.Revision,
+		RuleIDs:     change.IDs,
+		Principal:   GetPrincipal(req),
+		Timestamp:   time.Now(),
+	})
+
+	return nil
+}
+
+// getSelector parses and combines all the label selectors in the request query.
+func getSelector(req *rest.Request) (labels.Selector, error) {
+	return labels.ParseAll(getQueries("selector", req))
+}
+
+// getSource returns the caller identified in the request query, whose sidecar only needs the rules that can apply to
+// its requests, or nil if the request does not identify a caller.
+func getSource(req *rest.Request) *rules.Source {
+	name := req.URL.Query().Get("source")
+	if name == "" {
+		return nil
+	}
+
+	return &rules.Source{
+		Name: name,
+		Tags: getQueries("source_tag", req),
+	}
+}
+
+// includeDisabled returns whether the request asked for disabled rules to be included in the results.
+func includeDisabled(req *rest.Request) bool {
+	include, err := strconv.ParseBool(req.URL.Query().Get("include_disabled"))
+	return err == nil && include
+}
+
 // handleManagerError interprets errors from the manager and outputs REST error messages.
 func handleManagerError(w rest.ResponseWriter, req *rest.Request, err error, args ...interface{}) {
 	switch e := err.(type) {
 	case *rules.InvalidRuleError:
-		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidRule, args)
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidRule,
+			map[string]interface{}{"Reason": e.Reason})
+	case *rules.RevisionNotFoundError:
+		i18n.RestError(w, req, http.StatusNotFound, i18n.ErrorRevisionNotFound,
+			map[string]interface{}{"Revision": e.Revision})
+	case *rules.NamespaceNotFoundError:
+		i18n.RestError(w, req, http.StatusNotFound, i18n.ErrorNamespaceNotFound,
+			map[string]interface{}{"Namespace": e.Namespace})
+	case *rules.RuleCountQuotaError:
+		i18n.RestError(w, req, http.StatusTooManyRequests, i18n.ErrorRuleQuotaExceeded,
+			map[string]interface{}{"Limit": e.Limit})
+	case *rules.RuleSizeQuotaError:
+		i18n.RestError(w, req, http.StatusRequestEntityTooLarge, i18n.ErrorRuleTooLarge,
+			map[string]interface{}{"Limit": e.Limit, "Size": e.Size})
 	case *rules.JSONMarshalError:
 		i18n.RestError(w, req, http.StatusInternalServerError, i18n.ErrorInternalServer, args)
 	default:
@@ -0,0 +1,142 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/amalgam8/amalgam8/controller/metrics"
+	"github.com/amalgam8/amalgam8/controller/rules"
+	"github.com/amalgam8/amalgam8/controller/util"
+	"github.com/amalgam8/amalgam8/pkg/auth"
+	"github.com/ant0ine/go-json-rest/rest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockValidator struct {
+	err error
+}
+
+func (v *mockValidator) Validate(rule rules.Rule) error {
+	return v.err
+}
+
+var _ = Describe("Rule API", func() {
+
+	var (
+		validator *mockValidator
+		manager   rules.Manager
+		handler   http.Handler
+	)
+
+	BeforeEach(func() {
+		validator = &mockValidator{}
+		manager = rules.NewMemoryManager(validator)
+
+		// Requests are made by an admin in the prod namespace
+		authenticate := rest.MiddlewareSimple(func(h rest.HandlerFunc) rest.HandlerFunc {
+			return func(w rest.ResponseWriter, req *rest.Request) {
+				req.Env[util.Namespace] = auth.Namespace("prod")
+				req.Env[util.Admin] = true
+				h(w, req)
+			}
+		})
+
+		router, err := rest.MakeRouter(NewRule(manager, nil, metrics.NewReporter()).Routes(authenticate)...)
+		Expect(err).NotTo(HaveOccurred())
+
+		a := rest.NewApi()
+		a.SetApp(router)
+		handler = a.MakeHandler()
+	})
+
+	Describe("adding an invalid rule", func() {
+
+		It("responds with the validation error", func() {
+			validator.err = errors.New("destination: String length must be greater than or equal to 1")
+
+			req := httptest.NewRequest("POST", "/v1/rules", strings.NewReader(`{"rules": [{"priority": 1}]}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(w.Body.String()).To(ContainSubstring("error_invalid_rule"))
+			Expect(w.Body.String()).To(ContainSubstring("String length must be greater than or equal to 1"))
+		})
+	})
+
+	Describe("promoting rules in replace mode", func() {
+
+		var (
+			promotedID string
+			code       int
+		)
+
+		BeforeEach(func() {
+			_, err := manager.AddRules("prod", []rules.Rule{
+				{Destination: "reviews", Priority: 1},
+				{Destination: "ratings", Priority: 1},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			staging, err := manager.AddRules("staging", []rules.Rule{
+				{Destination: "reviews", Priority: 2},
+				{Destination: "ratings", Priority: 2},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			promotedID = staging.IDs[0]
+		})
+
+		promote := func(query string) {
+			req := httptest.NewRequest("POST", "/v1/rules/promote?from=staging&mode=replace&"+query, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			code = w.Code
+		}
+
+		It("rejects rules selected only by ID", func() {
+			promote("id=" + promotedID)
+			Expect(code).To(Equal(http.StatusBadRequest))
+
+			prod, err := manager.GetRules("prod", rules.Filter{IncludeDisabled: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prod.Rules).To(HaveLen(2))
+			for _, rule := range prod.Rules {
+				Expect(rule.Priority).To(Equal(1))
+			}
+		})
+
+		It("replaces only the rules of the destination filter", func() {
+			promote("id=" + promotedID + "&destination=reviews")
+			Expect(code).To(Equal(http.StatusCreated))
+
+			prod, err := manager.GetRules("prod", rules.Filter{IncludeDisabled: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prod.Rules).To(HaveLen(2))
+			for _, rule := range prod.Rules {
+				if rule.Destination == "reviews" {
+					Expect(rule.Priority).To(Equal(2))
+				} else {
+					Expect(rule.Priority).To(Equal(1))
+				}
+			}
+		})
+	})
+})
@@ -15,6 +15,7 @@
 package api
 
 import (
+	"github.com/amalgam8/amalgam8/controller/util/i18n"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -25,3 +26,7 @@ func TestAPI(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "API Suite")
 }
+
+var _ = BeforeSuite(func() {
+	Expect(i18n.LoadLocales("../locales")).To(Succeed())
+})
@@ -0,0 +1,239 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/amalgam8/amalgam8/controller/metrics"
+	"github.com/amalgam8/amalgam8/controller/rules"
+	"github.com/amalgam8/amalgam8/controller/templates"
+	"github.com/amalgam8/amalgam8/controller/util/i18n"
+	"github.com/amalgam8/amalgam8/controller/webhooks"
+	"github.com/ant0ine/go-json-rest/rest"
+)
+
+// TemplateList is used to output the templates of a namespace.
+type TemplateList struct {
+	Templates []templates.Template `json:"templates"`
+}
+
+// TemplateInstantiation is the input of a template instantiation.
+type TemplateInstantiation struct {
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+// Template API.
+type Template struct {
+	store    templates.Store
+	manager  rules.Manager
+	notifier webhooks.Notifier
+	reporter metrics.Reporter
+}
+
+// NewTemplate constructs a new Template API. Rules instantiated from templates are added to the manager, and the
+// changes are published to the notifier, unless it is nil.
+func NewTemplate(s templates.Store, m rules.Manager, n webhooks.Notifier, r metrics.Reporter) *Template {
+	return &Template{
+		store:    s,
+		manager:  m,
+		notifier: n,
+		reporter: r,
+	}
+}
+
+// Routes returns this API's routes wrapped by the middlewares.
+func (t *Template) Routes(middlewares ...rest.Middleware) []*rest.Route {
+	routes := []*rest.Route{
+		rest.Post("/v1/templates", reportMetric(t.reporter, t.add, "add_template")),
+		rest.Get("/v1/templates", reportMetric(t.reporter, t.list, "get_templates")),
+		rest.Get("/v1/templates/#name", reportMetric(t.reporter, t.get, "get_template")),
+		rest.Put("/v1/templates/#name", reportMetric(t.reporter, t.update, "update_template")),
+		rest.Delete("/v1/templates/#name", reportMetric(t.reporter, t.remove, "delete_template")),
+		rest.Post("/v1/templates/#name/instantiate", reportMetric(t.reporter, t.instantiate, "instantiate_template")),
+	}
+
+	for _, route := range routes {
+		route.Func = rest.WrapMiddlewares(middlewares, route.Func)
+	}
+
+	return routes
+}
+
+func (t *Template) add(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+
+	template := templates.Template{}
+	if err := req.DecodeJsonPayload(&template); err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidJSON)
+		return err
+	}
+
+	if err := template.Validate(); err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	if err := t.store.AddTemplate(ns, template); err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteJson(&template)
+	return nil
+}
+
+func (t *Template) list(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+
+	list, err := t.store.ListTemplates(ns)
+	if err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.WriteJson(&TemplateList{Templates: list})
+	return nil
+}
+
+func (t *Template) get(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+
+	template, err := t.store.GetTemplate(ns, req.PathParam("name"))
+	if err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.WriteJson(&template)
+	return nil
+}
+
+// update replaces the template named in the path. The name may be omitted from the payload.
+func (t *Template) update(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+	name := req.PathParam("name")
+
+	template := templates.Template{}
+	if err := req.DecodeJsonPayload(&template); err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidJSON)
+		return err
+	}
+
+	if template.Name == "" {
+		template.Name = name
+	} else if template.Name != name {
+		err := &templates.InvalidTemplateError{Reason: "name does not match the path"}
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	if err := template.Validate(); err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	if err := t.store.SetTemplate(ns, template); err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.WriteJson(&template)
+	return nil
+}
+
+func (t *Template) remove(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+
+	if err := t.store.DeleteTemplate(ns, req.PathParam("name")); err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// instantiate adds the rules of the template, with the parameters substituted, to the namespace. The rules are
+// validated as any other rules, and tagged with the name of the template.
+func (t *Template) instantiate(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+
+	template, err := t.store.GetTemplate(ns, req.PathParam("name"))
+	if err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	input := TemplateInstantiation{}
+	if err := req.DecodeJsonPayload(&input); err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidJSON)
+		return err
+	}
+
+	instances, err := template.Instantiate(input.Parameters)
+	if err != nil {
+		handleTemplateError(w, req, err)
+		return err
+	}
+
+	var newRules rules.NewRules
+	err = changeRules(t.notifier, ns, req, func() (rules.Change, error) {
+		var err error
+		newRules, err = t.manager.AddRules(ns, instances)
+		return newRules.Change, err
+	})
+	if err != nil {
+		handleManagerError(w, req, err)
+		return err
+	}
+
+	resp := struct {
+		IDs []string `json:"ids"`
+	}{
+		IDs: newRules.IDs,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteJson(&resp)
+	return nil
+}
+
+// handleTemplateError interprets errors from templates and the template store and outputs REST error messages.
+func handleTemplateError(w rest.ResponseWriter, req *rest.Request, err error) {
+	switch e := err.(type) {
+	case *templates.InvalidTemplateError:
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidTemplate,
+			map[string]interface{}{"Reason": e.Reason})
+	case *templates.InvalidArgumentsError:
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidTemplateArguments,
+			map[string]interface{}{"Reason": e.Reason})
+	default:
+		switch err {
+		case templates.ErrNotFound:
+			i18n.RestError(w, req, http.StatusNotFound, i18n.ErrorTemplateNotFound)
+		case templates.ErrExists:
+			i18n.RestError(w, req, http.StatusConflict, i18n.ErrorTemplateExists)
+		default:
+			logrus.WithError(err).Error("Template store failed")
+			i18n.RestError(w, req, http.StatusInternalServerError, i18n.ErrorInternalServer)
+		}
+	}
+}
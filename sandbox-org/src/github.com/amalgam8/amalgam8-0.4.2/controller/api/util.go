@@ -33,6 +33,33 @@ func GetNamespace(req *rest.Request) string {
 	return ""
 }
 
+// GetPrincipal returns the authenticated namespace of a request, which is the admin namespace for admin principals.
+func GetPrincipal(req *rest.Request) string {
+	if principal, ok := req.Env[util.Principal].(string); ok {
+		return principal
+	}
+
+	return GetNamespace(req)
+}
+
+// IsAuthorized returns whether the principal of a request is authorized in the namespace. Admin principals are
+// authorized in all namespaces.
+func IsAuthorized(req *rest.Request, namespace string) bool {
+	if namespace == GetNamespace(req) {
+		return true
+	}
+
+	if admin, ok := req.Env[util.Admin].(bool); ok && admin {
+		return true
+	}
+
+	if source, ok := req.Env[util.SourceNamespace].(auth.Namespace); ok && source.String() == namespace {
+		return true
+	}
+
+	return false
+}
+
 func reportMetric(reporter metrics.Reporter, f func(rest.ResponseWriter, *rest.Request) error, name string) rest.HandlerFunc {
 	return func(w rest.ResponseWriter, req *rest.Request) {
 		startTime := time.Now()
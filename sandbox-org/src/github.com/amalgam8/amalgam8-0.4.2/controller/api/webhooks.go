@@ -0,0 +1,147 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/amalgam8/amalgam8/controller/metrics"
+	"github.com/amalgam8/amalgam8/controller/util/i18n"
+	"github.com/amalgam8/amalgam8/controller/webhooks"
+	"github.com/ant0ine/go-json-rest/rest"
+	"github.com/pborman/uuid"
+)
+
+// WebhookList is used to output the webhooks of a namespace.
+type WebhookList struct {
+	Webhooks []webhooks.Webhook `json:"webhooks"`
+}
+
+// Webhook API.
+type Webhook struct {
+	store    webhooks.Store
+	policy   webhooks.URLPolicy
+	reporter metrics.Reporter
+}
+
+// NewWebhook constructs a new Webhook API, accepting the webhook URLs allowed by the policy.
+func NewWebhook(s webhooks.Store, p webhooks.URLPolicy, r metrics.Reporter) *Webhook {
+	return &Webhook{
+		store:    s,
+		policy:   p,
+		reporter: r,
+	}
+}
+
+// Routes returns this API's routes wrapped by the middlewares.
+func (wh *Webhook) Routes(middlewares ...rest.Middleware) []*rest.Route {
+	routes := []*rest.Route{
+		rest.Post("/v1/webhooks", reportMetric(wh.reporter, wh.add, "add_webhook")),
+		rest.Get("/v1/webhooks", reportMetric(wh.reporter, wh.list, "get_webhooks")),
+		rest.Delete("/v1/webhooks/#id", reportMetric(wh.reporter, wh.remove, "delete_webhook")),
+	}
+
+	for _, route := range routes {
+		route.Func = rest.WrapMiddlewares(middlewares, route.Func)
+	}
+
+	return routes
+}
+
+// add subscribes a webhook to the namespace. A secret is generated for signing the events unless one is provided.
+// The secret is only returned in this response.
+func (wh *Webhook) add(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+
+	webhook := webhooks.Webhook{}
+	if err := req.DecodeJsonPayload(&webhook); err != nil {
+		i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidJSON)
+		return err
+	}
+
+	if err := wh.policy.Validate(webhook.URL); err != nil {
+		if err == webhooks.ErrInvalidURL {
+			i18n.RestError(w, req, http.StatusBadRequest, i18n.ErrorInvalidWebhookURL)
+			return err
+		}
+
+		logrus.WithError(err).WithField("namespace", ns).Warn("Rejected webhook URL")
+		i18n.RestError(w, req, http.StatusForbidden, i18n.ErrorForbiddenWebhookURL)
+		return err
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			i18n.RestError(w, req, http.StatusInternalServerError, i18n.ErrorInternalServer)
+			return err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+
+	webhook.ID = uuid.New()
+
+	if err := wh.store.AddWebhook(ns, webhook); err != nil {
+		logrus.WithError(err).WithField("namespace", ns).Error("Could not add webhook")
+		i18n.RestError(w, req, http.StatusInternalServerError, i18n.ErrorInternalServer)
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteJson(&webhook)
+	return nil
+}
+
+func (wh *Webhook) list(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+
+	list, err := wh.store.ListWebhooks(ns)
+	if err != nil {
+		logrus.WithError(err).WithField("namespace", ns).Error("Could not list webhooks")
+		i18n.RestError(w, req, http.StatusInternalServerError, i18n.ErrorInternalServer)
+		return err
+	}
+
+	// Never output the secrets
+	for i := range list {
+		list[i].Secret = ""
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.WriteJson(&WebhookList{Webhooks: list})
+	return nil
+}
+
+func (wh *Webhook) remove(w rest.ResponseWriter, req *rest.Request) error {
+	ns := GetNamespace(req)
+	id := req.PathParam("id")
+
+	if err := wh.store.DeleteWebhook(ns, id); err != nil {
+		if err == webhooks.ErrNotFound {
+			i18n.RestError(w, req, http.StatusNotFound, i18n.ErrorWebhookNotFound)
+			return err
+		}
+
+		logrus.WithError(err).WithField("namespace", ns).Error("Could not delete webhook")
+		i18n.RestError(w, req, http.StatusInternalServerError, i18n.ErrorInternalServer)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -104,6 +104,25 @@ func (c *client) GetRules(filter rules.Filter) (RuleResponse, error) {
 	for _, tag := range filter.Tags {
 		query.Add("tag", tag)
 	}
+
+	for _, destination := range filter.Destinations {
+		query.Add("destination", destination)
+	}
+
+	if !filter.Selector.Empty() {
+		query.Add("selector", filter.Selector.String())
+	}
+
+	if filter.IncludeDisabled {
+		query.Add("include_disabled", "true")
+	}
+
+	if filter.Source != nil {
+		query.Add("source", filter.Source.Name)
+		for _, tag := range filter.Source.Tags {
+			query.Add("source_tag", tag)
+		}
+	}
 	u.RawQuery = query.Encode()
 
 	req, err := http.NewRequest("GET", u.String(), nil)
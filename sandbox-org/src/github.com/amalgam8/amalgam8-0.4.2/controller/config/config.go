@@ -19,6 +19,7 @@ import (
 
 	"errors"
 	"net/url"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/amalgam8/amalgam8/controller/util"
@@ -34,14 +35,49 @@ type Database struct {
 	//URL string
 }
 
+// Webhooks config
+type Webhooks struct {
+	MaxAttempts    int
+	DeadLetterFile string
+	AllowedHosts   []string
+	AllowInternal  bool
+}
+
+// TLS config
+type TLS struct {
+	Cert               string
+	Key                string
+	ClientCA           string
+	ClientCertIdentity string
+}
+
+// Quota config, with the default limits of namespaces
+type Quota struct {
+	MaxRules    int
+	MaxRuleSize int
+	WriteRate   float64
+	WriteBurst  int
+	File        string
+}
+
 // Config for the controller
 type Config struct {
 	Database     Database
+	Webhooks     Webhooks
+	TLS          TLS
+	Quota        Quota
 	APIPort      int
 	SecretKey    string
 	LogLevel     logrus.Level
 	AuthModes    []string
 	JWTSecret    string
+	JWTKeySet    string
+	JWTRefresh   time.Duration
+	JWTIssuer    string
+	JWTAudience  string
+	JWTUnscoped  bool
+	TokenFile    string
+	TokenReload  time.Duration
 	RequireHTTPS bool
 }
 
@@ -65,11 +101,37 @@ func New(context *cli.Context) *Config {
 			Password: context.String(dbPasswordFlag),
 			Host:     context.String(dbHostFlag),
 		},
+		Webhooks: Webhooks{
+			MaxAttempts:    context.Int(webhookMaxAttemptsFlag),
+			DeadLetterFile: context.String(webhookDeadLetterFileFlag),
+			AllowedHosts:   context.StringSlice(webhookAllowedHostsFlag),
+			AllowInternal:  context.Bool(webhookAllowInternalFlag),
+		},
+		TLS: TLS{
+			Cert:               context.String(tlsCertFlag),
+			Key:                context.String(tlsKeyFlag),
+			ClientCA:           context.String(tlsClientCAFlag),
+			ClientCertIdentity: context.String(clientCertIdentityFlag),
+		},
+		Quota: Quota{
+			MaxRules:    context.Int(maxRulesFlag),
+			MaxRuleSize: context.Int(maxRuleSizeFlag),
+			WriteRate:   context.Float64(writeRateFlag),
+			WriteBurst:  context.Int(writeBurstFlag),
+			File:        context.String(quotaFileFlag),
+		},
 		APIPort:      context.Int(apiPortFlag),
 		SecretKey:    context.String(secretKeyFlag),
 		LogLevel:     loggingLevel,
 		AuthModes:    context.StringSlice(authModeFlag),
 		JWTSecret:    context.String(jwtSecretFlag),
+		JWTKeySet:    context.String(jwtKeySetFlag),
+		JWTRefresh:   context.Duration(jwtRefreshFlag),
+		JWTIssuer:    context.String(jwtIssuerFlag),
+		JWTAudience:  context.String(jwtAudienceFlag),
+		JWTUnscoped:  context.Bool(jwtUnscopedFlag),
+		TokenFile:    context.String(tokenFileFlag),
+		TokenReload:  context.Duration(tokenReloadFlag),
 		RequireHTTPS: context.Bool(requireHTTPSFlag),
 	}
 }
@@ -111,6 +173,19 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("Invalid database type %v", c.Database.Type)
 	}
 
+	if c.TLS.Cert != "" || c.TLS.Key != "" {
+		validators = append(validators,
+			util.IsNotEmpty("TLS certificate", c.TLS.Cert),
+			util.IsNotEmpty("TLS private key", c.TLS.Key),
+		)
+	} else if c.TLS.ClientCA != "" {
+		return errors.New("TLS client CA requires a TLS certificate and private key")
+	}
+
+	if c.Quota.MaxRules < 0 || c.Quota.MaxRuleSize < 0 || c.Quota.WriteRate < 0 || c.Quota.WriteBurst < 0 {
+		return errors.New("Quota limits must not be negative")
+	}
+
 	if len(c.SecretKey) != 16 {
 		return fmt.Errorf("Secret must have a length of 16 characters")
 	}
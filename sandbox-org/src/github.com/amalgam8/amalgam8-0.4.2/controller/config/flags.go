@@ -16,6 +16,7 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	"github.com/urfave/cli"
 )
@@ -30,7 +31,30 @@ const (
 	logLevelFlag     = "log_level"
 	authModeFlag     = "auth_mode"
 	jwtSecretFlag    = "jwt_secret"
+	jwtKeySetFlag    = "jwt_key_set"
+	jwtRefreshFlag   = "jwt_key_set_refresh"
+	jwtIssuerFlag    = "jwt_issuer"
+	jwtAudienceFlag  = "jwt_audience"
+	jwtUnscopedFlag  = "jwt_unscoped_read_only"
+	tokenFileFlag    = "token_file"
+	tokenReloadFlag  = "token_file_reload"
 	requireHTTPSFlag = "require_https"
+
+	tlsCertFlag            = "tls_cert"
+	tlsKeyFlag             = "tls_key"
+	tlsClientCAFlag        = "tls_client_ca"
+	clientCertIdentityFlag = "client_cert_identity"
+
+	maxRulesFlag    = "max_rules"
+	maxRuleSizeFlag = "max_rule_size"
+	writeRateFlag   = "write_rate_limit"
+	writeBurstFlag  = "write_rate_burst"
+	quotaFileFlag   = "quota_file"
+
+	webhookMaxAttemptsFlag    = "webhook_max_attempts"
+	webhookDeadLetterFileFlag = "webhook_dead_letter_file"
+	webhookAllowedHostsFlag   = "webhook_allowed_hosts"
+	webhookAllowInternalFlag  = "webhook_allow_internal"
 )
 
 const apiPort = 8080
@@ -58,10 +82,79 @@ var Flags = []cli.Flag{
 		Usage:  "Secret key for JWT authentication",
 	},
 
+	cli.StringFlag{
+		Name:   jwtKeySetFlag,
+		EnvVar: envVar(jwtKeySetFlag),
+		Usage:  "URL or file path of a JSON Web Key Set for JWT authentication",
+	},
+
+	cli.DurationFlag{
+		Name:   jwtRefreshFlag,
+		EnvVar: envVar(jwtRefreshFlag),
+		Value:  5 * time.Minute,
+		Usage:  "Interval for reloading the JSON Web Key Set",
+	},
+
+	cli.StringFlag{
+		Name:   jwtIssuerFlag,
+		EnvVar: envVar(jwtIssuerFlag),
+		Usage:  "Required issuer claim of JWT tokens",
+	},
+
+	cli.StringFlag{
+		Name:   jwtAudienceFlag,
+		EnvVar: envVar(jwtAudienceFlag),
+		Usage:  "Required audience claim of JWT tokens",
+	},
+
+	cli.BoolFlag{
+		Name:   jwtUnscopedFlag,
+		EnvVar: envVar(jwtUnscopedFlag),
+		Usage:  "Grant only read-only scopes to JWT tokens without a scope claim, which are otherwise granted all scopes",
+	},
+
+	cli.StringFlag{
+		Name:   tokenFileFlag,
+		EnvVar: envVar(tokenFileFlag),
+		Usage:  "Path of the static token file for token-file authentication",
+	},
+
+	cli.DurationFlag{
+		Name:   tokenReloadFlag,
+		EnvVar: envVar(tokenReloadFlag),
+		Value:  10 * time.Second,
+		Usage:  "Interval for checking the static token file for changes",
+	},
+
+	cli.StringFlag{
+		Name:   tlsCertFlag,
+		EnvVar: envVar(tlsCertFlag),
+		Usage:  "Path of the PEM encoded certificate for serving the API over TLS",
+	},
+
+	cli.StringFlag{
+		Name:   tlsKeyFlag,
+		EnvVar: envVar(tlsKeyFlag),
+		Usage:  "Path of the PEM encoded private key for serving the API over TLS",
+	},
+
+	cli.StringFlag{
+		Name:   tlsClientCAFlag,
+		EnvVar: envVar(tlsClientCAFlag),
+		Usage:  "Path of the PEM encoded CA certificates for verifying client certificates",
+	},
+
+	cli.StringFlag{
+		Name:   clientCertIdentityFlag,
+		EnvVar: envVar(clientCertIdentityFlag),
+		Value:  "subject",
+		Usage:  "Source of the namespace of client certificates. Supported values are: 'subject', 'uri'",
+	},
+
 	cli.StringSliceFlag{
 		Name:   authModeFlag,
 		EnvVar: envVar(authModeFlag),
-		Usage:  "Authentication modes. Supported values are: 'trusted', 'jwt'",
+		Usage:  "Authentication modes. Supported values are: 'trusted', 'jwt', 'token-file', 'client-cert'",
 	},
 
 	cli.BoolFlag{
@@ -70,6 +163,57 @@ var Flags = []cli.Flag{
 		Usage:  "Require clients to use HTTPS for API calls",
 	},
 
+	// Quotas
+	cli.IntFlag{
+		Name:   maxRulesFlag,
+		EnvVar: envVar(maxRulesFlag),
+		Usage:  "Maximal number of rules per namespace, or 0 for unlimited",
+	},
+	cli.IntFlag{
+		Name:   maxRuleSizeFlag,
+		EnvVar: envVar(maxRuleSizeFlag),
+		Usage:  "Maximal size of a rule in bytes, or 0 for unlimited",
+	},
+	cli.Float64Flag{
+		Name:   writeRateFlag,
+		EnvVar: envVar(writeRateFlag),
+		Usage:  "Maximal rate of write requests per second per namespace, or 0 for unlimited",
+	},
+	cli.IntFlag{
+		Name:   writeBurstFlag,
+		EnvVar: envVar(writeBurstFlag),
+		Value:  10,
+		Usage:  "Number of write requests per namespace allowed in a burst above the write rate limit",
+	},
+	cli.StringFlag{
+		Name:   quotaFileFlag,
+		EnvVar: envVar(quotaFileFlag),
+		Usage:  "YAML or JSON file of per namespace overrides of the quota limits",
+	},
+
+	// Webhooks
+	cli.IntFlag{
+		Name:   webhookMaxAttemptsFlag,
+		EnvVar: envVar(webhookMaxAttemptsFlag),
+		Value:  5,
+		Usage:  "Number of attempts to deliver an event to a webhook",
+	},
+	cli.StringFlag{
+		Name:   webhookDeadLetterFileFlag,
+		EnvVar: envVar(webhookDeadLetterFileFlag),
+		Usage:  "File to append the events that could not be delivered to webhooks. Logged if not set",
+	},
+	cli.StringSliceFlag{
+		Name:   webhookAllowedHostsFlag,
+		EnvVar: envVar(webhookAllowedHostsFlag),
+		Usage:  "Hosts webhooks may be subscribed with, a '*.' prefix matching any subdomain. Any host if not set",
+	},
+	cli.BoolFlag{
+		Name:   webhookAllowInternalFlag,
+		EnvVar: envVar(webhookAllowInternalFlag),
+		Usage:  "Allow webhooks with loopback, link-local, private network and metadata service addresses",
+	},
+
 	// Database
 	cli.StringFlag{
 		Name:   dbTypeFlag,
@@ -16,8 +16,11 @@ package controller
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/ant0ine/go-json-rest/rest"
@@ -28,8 +31,11 @@ import (
 	"github.com/amalgam8/amalgam8/controller/metrics"
 	"github.com/amalgam8/amalgam8/controller/middleware"
 	"github.com/amalgam8/amalgam8/controller/rules"
+	"github.com/amalgam8/amalgam8/controller/templates"
 	"github.com/amalgam8/amalgam8/controller/util/i18n"
+	"github.com/amalgam8/amalgam8/controller/webhooks"
 	"github.com/amalgam8/amalgam8/pkg/auth"
+	"github.com/amalgam8/amalgam8/pkg/health"
 	"github.com/amalgam8/amalgam8/pkg/version"
 )
 
@@ -41,6 +47,7 @@ func Main() {
 	app.Usage = "Amalgam8 Controller"
 	app.Version = version.Build.Version
 	app.Flags = config.Flags
+	app.Commands = []cli.Command{tokenCommand}
 	app.Action = func(context *cli.Context) error {
 		return Run(config.New(context))
 	}
@@ -68,8 +75,27 @@ func Run(conf *config.Config) error {
 		setupHandler.SetError(validationErr)
 	}
 
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%v", conf.APIPort),
+		Handler: setupHandler,
+	}
+
+	if validationErr == nil && conf.TLS.Cert != "" {
+		server.TLSConfig, validationErr = auth.NewServerTLSConfig(conf.TLS.Cert, conf.TLS.Key, conf.TLS.ClientCA)
+		if validationErr != nil {
+			logrus.WithError(validationErr).Error("TLS configuration failed")
+			return validationErr
+		}
+	}
+
 	go func() {
-		if err := http.ListenAndServe(fmt.Sprintf(":%v", conf.APIPort), setupHandler); err != nil {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil {
 			logrus.WithError(err).Error("Server init failed")
 		}
 	}()
@@ -100,7 +126,76 @@ func Run(conf *config.Config) error {
 	} else {
 		ruleManager = rules.NewMemoryManager(validator)
 	}
-	rulesAPI := api.NewRule(ruleManager, reporter)
+
+	if checker, ok := ruleManager.(health.Checker); ok {
+		health.Register("database", checker)
+	}
+
+	quotas, err := rules.LoadQuotas(conf.Quota.File, rules.Quota{
+		MaxRules:    conf.Quota.MaxRules,
+		MaxRuleSize: conf.Quota.MaxRuleSize,
+		WriteRate:   conf.Quota.WriteRate,
+		WriteBurst:  conf.Quota.WriteBurst,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Could not load quotas")
+		setupHandler.SetError(err)
+		return err
+	}
+	ruleManager = rules.NewQuotaManager(ruleManager, quotas)
+
+	var webhookStore webhooks.Store
+	if conf.Database.Type == "redis" {
+		webhookStore = webhooks.NewRedisStore(
+			conf.Database.Host,
+			conf.Database.Password,
+		)
+	} else {
+		webhookStore = webhooks.NewMemoryStore()
+	}
+
+	var templateStore templates.Store
+	if conf.Database.Type == "redis" {
+		templateStore = templates.NewRedisStore(
+			conf.Database.Host,
+			conf.Database.Password,
+		)
+	} else {
+		templateStore = templates.NewMemoryStore()
+	}
+
+	var deadLetter io.Writer
+	if conf.Webhooks.DeadLetterFile != "" {
+		deadLetter, err = os.OpenFile(conf.Webhooks.DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			logrus.WithError(err).Error("Could not open webhook dead letter file")
+			setupHandler.SetError(err)
+			return err
+		}
+	}
+
+	webhookPolicy := webhooks.URLPolicy{
+		AllowedHosts:  conf.Webhooks.AllowedHosts,
+		AllowInternal: conf.Webhooks.AllowInternal,
+	}
+
+	notifier := webhooks.NewNotifier(webhooks.Config{
+		Store:       webhookStore,
+		MaxAttempts: conf.Webhooks.MaxAttempts,
+		URLPolicy:   webhookPolicy,
+		DeadLetter:  deadLetter,
+	})
+
+	rulesAPI := api.NewRule(ruleManager, notifier, reporter)
+	webhooksAPI := api.NewWebhook(webhookStore, webhookPolicy, reporter)
+	templatesAPI := api.NewTemplate(templateStore, ruleManager, notifier, reporter)
+
+	limiter := middleware.NewRateLimiter(func(namespace string) (float64, int) {
+		quota := quotas.For(namespace)
+		return quota.WriteRate, quota.WriteBurst
+	})
+	quotaAPI := api.NewQuota(ruleManager, quotas, limiter, reporter)
+	namespacesAPI := api.NewNamespace(ruleManager, notifier, reporter)
 
 	a := rest.NewApi()
 	a.Use(
@@ -124,10 +219,34 @@ func Run(conf *config.Config) error {
 		setupHandler.SetError(err)
 		return err
 	}
+	if checker, ok := authenticator.(health.Checker); ok {
+		health.Register("authenticator", checker)
+	}
 
-	authMw := &middleware.AuthMiddleware{Authenticator: authenticator}
+	rulesAuthMw := &middleware.AuthMiddleware{
+		Authenticator: authenticator,
+		RequiredScope: middleware.ScopeByMethod(auth.ScopeRulesRead, auth.ScopeRulesWrite),
+		SourceScope:   auth.ScopeRulesRead,
+	}
+	webhooksAuthMw := &middleware.AuthMiddleware{
+		Authenticator: authenticator,
+		RequiredScope: middleware.ScopeByMethod(auth.ScopeRulesWrite, auth.ScopeRulesWrite),
+	}
 
-	routes := rulesAPI.Routes(authMw)
+	adminAuthMw := &middleware.AuthMiddleware{
+		Authenticator: authenticator,
+		RequiredScope: middleware.ScopeByMethod(auth.ScopeRulesRead, auth.ScopeRulesWrite),
+		AdminOnly:     true,
+	}
+	rateLimitMw := &middleware.RateLimitMiddleware{
+		Limiter: limiter,
+	}
+
+	routes := rulesAPI.Routes(rulesAuthMw, rateLimitMw)
+	routes = append(routes, webhooksAPI.Routes(webhooksAuthMw, rateLimitMw)...)
+	routes = append(routes, templatesAPI.Routes(rulesAuthMw, rateLimitMw)...)
+	routes = append(routes, quotaAPI.Routes(rulesAuthMw)...)
+	routes = append(routes, namespacesAPI.Routes(adminAuthMw)...)
 	routes = append(routes, healthAPI.Routes()...)
 	router, err := rest.MakeRouter(
 		routes...,
@@ -145,7 +264,18 @@ func Run(conf *config.Config) error {
 		"port": conf.APIPort,
 	}).Info("Server started")
 
-	select {}
+	// Serve until terminated, then stop the background reloading of the authenticator
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-sigChan
+	logrus.WithField("signal", sig).Info("Controller terminating")
+
+	if closer, ok := authenticator.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logrus.WithError(err).Warn("Failed to close the authenticator")
+		}
+	}
+	return nil
 }
 
 func setupAuthenticator(conf *config.Config) (authenticator auth.Authenticator, err error) {
@@ -156,11 +286,34 @@ func setupAuthenticator(conf *config.Config) (authenticator auth.Authenticator,
 			case "trusted":
 				auths[i] = auth.NewTrustedAuthenticator()
 			case "jwt":
-				jwtAuth, err := auth.NewJWTAuthenticator([]byte(conf.JWTSecret))
+				jwtAuth, err := auth.NewJWTAuthenticatorFromConfig(auth.JWTConfig{
+					Secret:        []byte(conf.JWTSecret),
+					KeySet:        conf.JWTKeySet,
+					KeySetRefresh: conf.JWTRefresh,
+					Issuer:        conf.JWTIssuer,
+					Audience:      conf.JWTAudience,
+
+					UnscopedReadOnly: conf.JWTUnscoped,
+				})
 				if err != nil {
 					return authenticator, fmt.Errorf("Failed to create the authentication module: %s", err)
 				}
 				auths[i] = jwtAuth
+			case "token-file":
+				tokenAuth, err := auth.NewTokenFileAuthenticator(conf.TokenFile, conf.TokenReload)
+				if err != nil {
+					return authenticator, fmt.Errorf("Failed to create the authentication module: %s", err)
+				}
+				auths[i] = tokenAuth
+			case "client-cert":
+				if conf.TLS.ClientCA == "" {
+					return authenticator, fmt.Errorf("Failed to create the authentication module: client-cert mode requires a TLS client CA")
+				}
+				certAuth, err := auth.NewClientCertAuthenticator(conf.TLS.ClientCertIdentity)
+				if err != nil {
+					return authenticator, fmt.Errorf("Failed to create the authentication module: %s", err)
+				}
+				auths[i] = certAuth
 			default:
 				return authenticator, fmt.Errorf("Failed to create the authentication module: unrecognized authentication mode '%s'", err)
 			}
@@ -30,8 +30,28 @@ const adminNamespace = "admin"
 
 // AuthMiddleware provides a generic authentication middleware
 // On failure, a 401 HTTP response is returned. On success, the wrapped middleware is called.
+// If RequiredScope is set, a 403 HTTP response is returned for tokens not granted the scope required by the request.
 type AuthMiddleware struct {
 	Authenticator auth.Authenticator
+	RequiredScope func(request *rest.Request) auth.Scope
+
+	// SourceScope, if set, is required of the token in the source authorization header.
+	SourceScope auth.Scope
+
+	// AdminOnly rejects principals other than the admin namespace with a 403 HTTP response. The namespace header is
+	// optional for admin only requests, which are not bound to a namespace.
+	AdminOnly bool
+}
+
+// ScopeByMethod returns a RequiredScope function requiring the read scope for GET and HEAD requests, and the write
+// scope for any other request.
+func ScopeByMethod(read, write auth.Scope) func(request *rest.Request) auth.Scope {
+	return func(request *rest.Request) auth.Scope {
+		if request.Method == http.MethodGet || request.Method == http.MethodHead {
+			return read
+		}
+		return write
+	}
 }
 
 // MiddlewareFunc returns a go-json-rest HTTP Handler function, wrapping calls to the provided HandlerFunc
@@ -44,46 +64,94 @@ func (mw *AuthMiddleware) MiddlewareFunc(handler rest.HandlerFunc) rest.HandlerF
 }
 
 func (mw *AuthMiddleware) handler(writer rest.ResponseWriter, request *rest.Request, h rest.HandlerFunc) {
-	authHeader := request.Header.Get(util.AuthHeader) // for Amalgam8 requests
+	baseCtx := request.Env[util.Context].(context.Context)
+
+	// The client certificate only identifies the caller, not the source namespace
+	ctx := baseCtx
+	if request.TLS != nil {
+		ctx = context.WithValue(ctx, auth.ContextTLSKey, request.TLS)
+	}
+
+	nsPtr, scopes, code, id := mw.authenticate(ctx, request.Header.Get(util.AuthHeader)) // for Amalgam8 requests
+	if nsPtr == nil {
+		i18n.RestError(writer, request, code, id)
+		return
+	}
+
+	if mw.RequiredScope != nil {
+		if scope := mw.RequiredScope(request); scope != "" && !scopes.Has(scope) {
+			i18n.RestError(writer, request, http.StatusForbidden, i18n.ErrorAuthorizationInsufficientScope,
+				map[string]interface{}{"Scope": scope})
+			return
+		}
+	}
+
+	principal := nsPtr.String()
+
+	// Recognize admin namespace and get the namespace from the header
+	admin := principal == adminNamespace
+	if mw.AdminOnly && !admin {
+		i18n.RestError(writer, request, http.StatusForbidden, i18n.ErrorAuthorizationAdminRequired)
+		return
+	}
+	if admin {
+		nsStr := request.Header.Get(util.NamespaceHeader)
+		if nsStr != "" {
+			ns := auth.Namespace(nsStr)
+			nsPtr = &ns
+		} else if !mw.AdminOnly {
+			i18n.RestError(writer, request, http.StatusBadRequest, "missing_namespace_header")
+			return
+		}
+	}
+
+	// Optionally authenticate a second namespace for operations spanning two namespaces
+	if sourceHeader := request.Header.Get(util.SourceAuthHeader); sourceHeader != "" {
+		sourcePtr, sourceScopes, code, id := mw.authenticate(baseCtx, sourceHeader)
+		if sourcePtr == nil {
+			i18n.RestError(writer, request, code, id)
+			return
+		}
+		if mw.SourceScope != "" && !sourceScopes.Has(mw.SourceScope) {
+			i18n.RestError(writer, request, http.StatusForbidden, i18n.ErrorAuthorizationInsufficientScope,
+				map[string]interface{}{"Scope": mw.SourceScope})
+			return
+		}
+		request.Env[util.SourceNamespace] = *sourcePtr
+	}
+
+	request.Env[util.Namespace] = *nsPtr
+	request.Env[util.Admin] = admin
+	request.Env[util.Principal] = principal
+	h(writer, request)
+}
+
+// authenticate resolves the bearer token in the authorization header into a namespace and its granted scopes.
+// On failure, the namespace is nil and the HTTP status code and message ID to respond with are returned.
+func (mw *AuthMiddleware) authenticate(ctx context.Context, authHeader string) (*auth.Namespace, auth.Scopes, int, string) {
 	token := ""
 
 	if authHeader != "" {
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "bearer") {
-			i18n.RestError(writer, request, http.StatusUnauthorized, i18n.ErrorAuthorizationMalformedHeader)
-			return
+			return nil, nil, http.StatusUnauthorized, i18n.ErrorAuthorizationMalformedHeader
 		}
 		token = parts[1]
 	}
 
-	ctx := request.Env[util.Context].(context.Context)
-
-	nsPtr, err := mw.Authenticator.Authenticate(ctx, token)
+	nsPtr, scopes, err := auth.AuthenticateScopes(ctx, mw.Authenticator, token)
 	if err != nil {
 		switch err {
 		case auth.ErrEmptyToken:
-			i18n.RestError(writer, request, http.StatusUnauthorized, i18n.ErrorAuthorizationMissingHeader)
+			return nil, nil, http.StatusUnauthorized, i18n.ErrorAuthorizationMissingHeader
 		case auth.ErrUnauthorized, auth.ErrUnrecognizedToken:
-			i18n.RestError(writer, request, http.StatusUnauthorized, i18n.ErrorAuthorizationNotAuthorized)
+			return nil, nil, http.StatusUnauthorized, i18n.ErrorAuthorizationNotAuthorized
 		case auth.ErrCommunicationError:
-			i18n.RestError(writer, request, http.StatusServiceUnavailable, i18n.ErrorAuthorizationTokenValidationFailed)
+			return nil, nil, http.StatusServiceUnavailable, i18n.ErrorAuthorizationTokenValidationFailed
 		default:
-			i18n.RestError(writer, request, http.StatusInternalServerError, i18n.ErrorInternalServer)
-		}
-		return
-	}
-
-	// Recognize admin namespace and get the namespace from the header
-	if nsPtr.String() == adminNamespace {
-		nsStr := request.Header.Get(util.NamespaceHeader)
-		if nsStr == "" {
-			i18n.RestError(writer, request, http.StatusBadRequest, "missing_namespace_header")
-			return
+			return nil, nil, http.StatusInternalServerError, i18n.ErrorInternalServer
 		}
-		ns := auth.Namespace(nsStr)
-		nsPtr = &ns
 	}
 
-	request.Env[util.Namespace] = *nsPtr
-	h(writer, request)
+	return nsPtr, scopes, http.StatusOK, ""
 }
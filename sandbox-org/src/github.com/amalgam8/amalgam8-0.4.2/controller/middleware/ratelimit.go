@@ -0,0 +1,151 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/amalgam8/amalgam8/controller/util"
+	"github.com/amalgam8/amalgam8/controller/util/i18n"
+	"github.com/amalgam8/amalgam8/pkg/auth"
+	"github.com/ant0ine/go-json-rest/rest"
+)
+
+// RateLimit returns the sustained rate per second and the burst of requests allowed in a namespace.
+// A non-positive rate is unlimited.
+type RateLimit func(namespace string) (rate float64, burst int)
+
+// pruneInterval is the minimal interval between evictions of idle buckets.
+const pruneInterval = time.Minute
+
+// RateLimiter limits the rate of requests per namespace using token buckets. Buckets that have refilled to their
+// capacity are evicted, as they are equivalent to new buckets.
+type RateLimiter struct {
+	limit   RateLimit
+	buckets map[string]*bucket
+	pruned  time.Time
+	now     func() time.Time
+	mutex   sync.Mutex
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // Time at which the bucket refills to its capacity
+}
+
+// NewRateLimiter creates a rate limiter with the given per namespace limits.
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+		pruned:  time.Now(),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the namespace. If the bucket is empty, the request is not allowed, and the
+// time until a token is available is returned.
+func (rl *RateLimiter) Allow(namespace string) (bool, time.Duration) {
+	rate, burst := rl.limit(namespace)
+	if rate <= 0 {
+		return true, 0
+	}
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	b := rl.refill(namespace, rate, burst)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+
+	b.tokens--
+	b.full = b.full.Add(time.Duration(float64(time.Second) / rate))
+	return true, 0
+}
+
+// Available returns the number of requests the namespace may currently make, or -1 if it is unlimited.
+func (rl *RateLimiter) Available(namespace string) int {
+	rate, burst := rl.limit(namespace)
+	if rate <= 0 {
+		return -1
+	}
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	return int(rl.refill(namespace, rate, burst).tokens)
+}
+
+// refill adds the tokens accumulated since the last request to the bucket of the namespace, up to its capacity.
+func (rl *RateLimiter) refill(namespace string, rate float64, burst int) *bucket {
+	capacity := math.Max(float64(burst), 1)
+	now := rl.now()
+
+	if now.Sub(rl.pruned) >= pruneInterval {
+		rl.prune(now)
+	}
+
+	b, exists := rl.buckets[namespace]
+	if !exists {
+		b = &bucket{tokens: capacity, last: now}
+		rl.buckets[namespace] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	b.full = now.Add(time.Duration((capacity - b.tokens) / rate * float64(time.Second)))
+	return b
+}
+
+// prune evicts the buckets that have refilled to their capacity.
+func (rl *RateLimiter) prune(now time.Time) {
+	for namespace, b := range rl.buckets {
+		if !now.Before(b.full) {
+			delete(rl.buckets, namespace)
+		}
+	}
+	rl.pruned = now
+}
+
+// RateLimitMiddleware rejects write requests with a 429 HTTP response when the namespace of the request exceeds its
+// rate limit. It must be wrapped by an AuthMiddleware, which identifies the namespace. Read requests are not limited.
+type RateLimitMiddleware struct {
+	Limiter *RateLimiter
+}
+
+// MiddlewareFunc returns a go-json-rest HTTP Handler function, wrapping calls to the provided HandlerFunc
+func (mw *RateLimitMiddleware) MiddlewareFunc(handler rest.HandlerFunc) rest.HandlerFunc {
+	return func(writer rest.ResponseWriter, request *rest.Request) {
+		if request.Method == http.MethodGet || request.Method == http.MethodHead {
+			handler(writer, request)
+			return
+		}
+
+		namespace, _ := request.Env[util.Namespace].(auth.Namespace)
+		if allowed, wait := mw.Limiter.Allow(namespace.String()); !allowed {
+			writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			i18n.RestError(writer, request, http.StatusTooManyRequests, i18n.ErrorRateLimitExceeded)
+			return
+		}
+
+		handler(writer, request)
+	}
+}
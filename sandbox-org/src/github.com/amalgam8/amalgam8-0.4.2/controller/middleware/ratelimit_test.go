@@ -0,0 +1,86 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestRateLimiter(rate float64, burst int) (*RateLimiter, *time.Time) {
+	now := time.Unix(0, 0)
+	rl := NewRateLimiter(func(namespace string) (float64, int) {
+		if namespace == "unlimited" {
+			return 0, 0
+		}
+		return rate, burst
+	})
+	rl.now = func() time.Time { return now }
+	rl.pruned = now
+	return rl, &now
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	rl, now := newTestRateLimiter(2, 3)
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := rl.Allow("ns"); !allowed {
+			t.Fatalf("expected request %v within the burst to be allowed", i)
+		}
+	}
+
+	allowed, wait := rl.Allow("ns")
+	if allowed {
+		t.Fatal("expected request exceeding the burst to be rejected")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("expected to wait 500ms, got %v", wait)
+	}
+
+	if available := rl.Available("other"); available != 3 {
+		t.Errorf("expected 3 requests available in another namespace, got %v", available)
+	}
+	if available := rl.Available("unlimited"); available != -1 {
+		t.Errorf("expected unlimited namespace, got %v", available)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if allowed, _ := rl.Allow("ns"); !allowed {
+		t.Error("expected request to be allowed after refill")
+	}
+	if available := rl.Available("ns"); available != 0 {
+		t.Errorf("expected no requests available, got %v", available)
+	}
+}
+
+func TestRateLimiterPrune(t *testing.T) {
+	rl, now := newTestRateLimiter(0.1, 10)
+
+	rl.Allow("idle")
+	for i := 0; i < 10; i++ {
+		rl.Allow("busy")
+	}
+
+	// The idle bucket refills after 10s, while the busy bucket needs 100s
+	*now = now.Add(pruneInterval)
+	rl.Allow("other")
+
+	if _, exists := rl.buckets["idle"]; exists {
+		t.Error("expected idle bucket to be evicted")
+	}
+	if _, exists := rl.buckets["busy"]; !exists {
+		t.Error("expected busy bucket to be kept")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff describes the differences between two sets of rules.
+type Diff struct {
+	// Added rules are only present in the second set.
+	Added []Rule `json:"added"`
+
+	// Removed rules are only present in the first set.
+	Removed []Rule `json:"removed"`
+
+	// Changed rules are present in both sets, but differ.
+	Changed []RuleDiff `json:"changed"`
+}
+
+// Empty returns whether the two sets of rules are identical.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// RuleDiff describes the differences between two versions of a rule.
+type RuleDiff struct {
+	From    Rule          `json:"from"`
+	To      Rule          `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a single difference between two versions of a rule. The path is a JSON pointer to the changed field
+// (e.g. "/route/backends/0/weight"). From is omitted when the field was added, and To is omitted when it was removed.
+type FieldChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// DiffRules compares two sets of rules. When byID is set, rules are paired by ID, which is only meaningful for rules of
+// the same namespace. Otherwise rules are paired by destination, type, priority and match, so that equivalent rules of
+// different namespaces are compared to each other.
+func DiffRules(from, to []Rule, byID bool) Diff {
+	key := ruleKey
+	if byID {
+		key = func(rule Rule) string { return rule.ID }
+	}
+
+	fromRules := indexRules(from, key)
+	toRules := indexRules(to, key)
+
+	diff := Diff{
+		Added:   []Rule{},
+		Removed: []Rule{},
+		Changed: []RuleDiff{},
+	}
+
+	for _, k := range sortedKeys(fromRules) {
+		fromRule := fromRules[k]
+		toRule, exists := toRules[k]
+		if !exists {
+			diff.Removed = append(diff.Removed, fromRule)
+			continue
+		}
+
+		changes := diffJSON("", ruleFields(fromRule), ruleFields(toRule))
+		if len(changes) > 0 {
+			diff.Changed = append(diff.Changed, RuleDiff{
+				From:    fromRule,
+				To:      toRule,
+				Changes: changes,
+			})
+		}
+	}
+
+	for _, k := range sortedKeys(toRules) {
+		if _, exists := fromRules[k]; !exists {
+			diff.Added = append(diff.Added, toRules[k])
+		}
+	}
+
+	return diff
+}
+
+// ruleKey identifies a rule independently of its ID.
+func ruleKey(rule Rule) string {
+	ruleType := "route"
+	if len(rule.Actions) > 0 {
+		ruleType = "actions"
+	}
+
+	return fmt.Sprintf("%v|%v|%v|%v", rule.Destination, ruleType, rule.Priority, canonicalJSON(rule.Match))
+}
+
+// indexRules maps each rule by its key. Rules sharing a key are disambiguated by their order.
+func indexRules(rules []Rule, key func(Rule) string) map[string]Rule {
+	sorted := make([]Rule, len(rules))
+	copy(sorted, rules)
+	sort.Sort(byID(sorted))
+
+	index := make(map[string]Rule, len(sorted))
+	for _, rule := range sorted {
+		k := key(rule)
+		for i := 2; ; i++ {
+			if _, exists := index[k]; !exists {
+				break
+			}
+			k = fmt.Sprintf("%v#%v", key(rule), i)
+		}
+		index[k] = rule
+	}
+
+	return index
+}
+
+// ruleFields returns the generic JSON representation of the rule, excluding its ID.
+func ruleFields(rule Rule) interface{} {
+	rule.ID = ""
+
+	data, _ := json.Marshal(&rule)
+
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	delete(fields, "id")
+
+	return fields
+}
+
+// diffJSON returns the changes between two generic JSON values.
+func diffJSON(path string, from, to interface{}) []FieldChange {
+	switch f := from.(type) {
+	case map[string]interface{}:
+		t, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make(map[string]bool)
+		for k := range f {
+			keys[k] = true
+		}
+		for k := range t {
+			keys[k] = true
+		}
+
+		var changes []FieldChange
+		for _, k := range sortedKeys(keys) {
+			fv, fexists := f[k]
+			tv, texists := t[k]
+			p := path + "/" + escapePointer(k)
+			switch {
+			case !fexists:
+				changes = append(changes, FieldChange{Path: p, To: tv})
+			case !texists:
+				changes = append(changes, FieldChange{Path: p, From: fv})
+			default:
+				changes = append(changes, diffJSON(p, fv, tv)...)
+			}
+		}
+		return changes
+	case []interface{}:
+		t, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+
+		var changes []FieldChange
+		for i := 0; i < len(f) || i < len(t); i++ {
+			p := fmt.Sprintf("%v/%v", path, i)
+			switch {
+			case i >= len(f):
+				changes = append(changes, FieldChange{Path: p, To: t[i]})
+			case i >= len(t):
+				changes = append(changes, FieldChange{Path: p, From: f[i]})
+			default:
+				changes = append(changes, diffJSON(p, f[i], t[i])...)
+			}
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(from, to) {
+		return nil
+	}
+	return []FieldChange{{Path: path, From: from, To: to}}
+}
+
+// canonicalJSON re-encodes the JSON so that equivalent documents have the same representation.
+func canonicalJSON(data json.RawMessage) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return string(data)
+	}
+
+	canonical, _ := json.Marshal(v)
+	return string(canonical)
+}
+
+// escapePointer escapes a JSON pointer reference token.
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type byID []Rule
+
+func (r byID) Len() int           { return len(r) }
+func (r byID) Less(i, j int) bool { return r[i].ID < r[j].ID }
+func (r byID) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
@@ -0,0 +1,108 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffRulesByID(t *testing.T) {
+	unchanged := Rule{
+		ID:          "id1",
+		Destination: "service1",
+		Route:       []byte(`{"backends":[{"tags":["v1"]}]}`),
+	}
+	removed := Rule{
+		ID:          "id2",
+		Destination: "service2",
+		Actions:     []byte(`[{"action":"delay","duration":7}]`),
+	}
+	added := Rule{
+		ID:          "id3",
+		Destination: "service3",
+		Route:       []byte(`{"backends":[{"tags":["v1"]}]}`),
+	}
+	before := Rule{
+		ID:          "id4",
+		Priority:    1,
+		Destination: "service4",
+		Route:       []byte(`{"backends":[{"tags":["v1"],"weight":0.5},{"tags":["v2"]}]}`),
+	}
+	after := Rule{
+		ID:          "id4",
+		Priority:    2,
+		Destination: "service4",
+		Route:       []byte(`{"backends":[{"tags":["v1"],"weight":0.9},{"tags":["v2"]},{"tags":["v3"]}]}`),
+		Disabled:    true,
+	}
+
+	diff := DiffRules([]Rule{unchanged, removed, before}, []Rule{after, added, unchanged}, true)
+
+	if !reflect.DeepEqual(diff.Added, []Rule{added}) {
+		t.Errorf("expected added %v, got %v", []Rule{added}, diff.Added)
+	}
+	if !reflect.DeepEqual(diff.Removed, []Rule{removed}) {
+		t.Errorf("expected removed %v, got %v", []Rule{removed}, diff.Removed)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("expected 1 changed rule, got %v", diff.Changed)
+	}
+
+	expected := []FieldChange{
+		{Path: "/disabled", To: true},
+		{Path: "/priority", From: float64(1), To: float64(2)},
+		{Path: "/route/backends/0/weight", From: 0.5, To: 0.9},
+		{Path: "/route/backends/2", To: map[string]interface{}{"tags": []interface{}{"v3"}}},
+	}
+	if !reflect.DeepEqual(diff.Changed[0].Changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, diff.Changed[0].Changes)
+	}
+}
+
+func TestDiffRulesAcrossNamespaces(t *testing.T) {
+	from := []Rule{
+		{
+			ID:          "staging1",
+			Destination: "service1",
+			Match:       []byte(`{"source":{"name":"client"}}`),
+			Route:       []byte(`{"backends":[{"tags":["v2"]}]}`),
+		},
+	}
+	to := []Rule{
+		{
+			ID:          "prod1",
+			Destination: "service1",
+			Match:       []byte(`{ "source": { "name": "client" } }`),
+			Route:       []byte(`{"backends":[{"tags":["v1"]}]}`),
+		},
+	}
+
+	diff := DiffRules(from, to, false)
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("expected rules to be paired, got %v", diff)
+	}
+
+	expected := []FieldChange{
+		{Path: "/route/backends/0/tags/0", From: "v2", To: "v1"},
+	}
+	if len(diff.Changed) != 1 || !reflect.DeepEqual(diff.Changed[0].Changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, diff.Changed)
+	}
+
+	if diff := DiffRules(from, from, false); !diff.Empty() {
+		t.Errorf("expected no differences, got %v", diff)
+	}
+}
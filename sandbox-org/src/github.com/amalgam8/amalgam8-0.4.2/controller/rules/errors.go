@@ -17,11 +17,13 @@ package rules
 import "fmt"
 
 // InvalidRuleError occurs when a rule is not valid
-type InvalidRuleError struct{}
+type InvalidRuleError struct {
+	Reason string
+}
 
 // Error description
 func (e *InvalidRuleError) Error() string {
-	return "Invalid Rule Error"
+	return fmt.Sprintf("Invalid Rule Error: %v", e.Reason)
 }
 
 // RedisInsertError occurs when there is an issue writing to Redis
@@ -41,3 +43,44 @@ type JSONMarshalError struct {
 func (e *JSONMarshalError) Error() string {
 	return fmt.Sprintf("Error marshaling JSON: %v", e.Message)
 }
+
+// RevisionNotFoundError occurs when a revision is not retained in the history of a namespace
+type RevisionNotFoundError struct {
+	Revision int64
+}
+
+// Error description
+func (e *RevisionNotFoundError) Error() string {
+	return fmt.Sprintf("Revision %v not found", e.Revision)
+}
+
+// NamespaceNotFoundError occurs when a namespace never had rules
+type NamespaceNotFoundError struct {
+	Namespace string
+}
+
+// Error description
+func (e *NamespaceNotFoundError) Error() string {
+	return fmt.Sprintf("Namespace %v not found", e.Namespace)
+}
+
+// RuleCountQuotaError occurs when a change would exceed the maximal number of rules of a namespace
+type RuleCountQuotaError struct {
+	Limit int
+}
+
+// Error description
+func (e *RuleCountQuotaError) Error() string {
+	return fmt.Sprintf("Rule quota of %v exceeded", e.Limit)
+}
+
+// RuleSizeQuotaError occurs when a rule is larger than the maximal rule size of a namespace
+type RuleSizeQuotaError struct {
+	Limit int
+	Size  int
+}
+
+// Error description
+func (e *RuleSizeQuotaError) Error() string {
+	return fmt.Sprintf("Rule size of %v bytes exceeds the limit of %v bytes", e.Size, e.Limit)
+}
@@ -16,6 +16,8 @@ package rules
 
 import (
 	"fmt"
+
+	"github.com/amalgam8/amalgam8/pkg/labels"
 )
 
 const (
@@ -43,14 +45,25 @@ type Filter struct {
 	// a member of this set. This field is ignored when len(Destinations) <= 0.
 	Destinations []string
 
+	// Selector is the set of requirements each rule's labels must satisfy. This field is ignored when the selector
+	// is empty.
+	Selector labels.Selector
+
 	// RuleType is the type of rule to filter by.
 	RuleType int
+
+	// IncludeDisabled determines whether disabled rules pass the filter. Disabled rules are filtered out when false.
+	IncludeDisabled bool
+
+	// Source is the caller each rule's match must be able to apply to. This field is ignored when nil.
+	Source *Source
 }
 
 // Empty returns whether the filter has any attributes that would cause rules to be filtered out. A filter is considered
 // empty if no rules would be filtered out from any set of rules.
 func (f Filter) Empty() bool {
-	return len(f.IDs) == 0 && len(f.Tags) == 0 && len(f.Destinations) == 0 && f.RuleType == RuleAny
+	return len(f.IDs) == 0 && len(f.Tags) == 0 && len(f.Destinations) == 0 && f.Selector.Empty() &&
+		f.RuleType == RuleAny && f.IncludeDisabled && f.Source == nil
 }
 
 // String representation of the filter
@@ -99,6 +112,11 @@ func FilterRules(f Filter, rules []Rule) []Rule {
 			}
 		}
 
+		// Filter out disabled rules unless requested
+		if rule.Disabled && !f.IncludeDisabled {
+			continue
+		}
+
 		// Filter by rule type
 		if (f.RuleType == RuleAction && len(rule.Actions) == 0) ||
 			(f.RuleType == RuleRoute && len(rule.Route) == 0) {
@@ -127,6 +145,16 @@ func FilterRules(f Filter, rules []Rule) []Rule {
 			}
 		}
 
+		// Ensure rule's labels satisfy the selector.
+		if !f.Selector.Matches(rule.Labels) {
+			continue
+		}
+
+		// Ensure the rule can apply to requests from the source.
+		if f.Source != nil && !rule.AppliesTo(*f.Source) {
+			continue
+		}
+
 		// The rule has passed all the filters, so we add it to the list of filtered rules.
 		res = append(res, rule)
 	}
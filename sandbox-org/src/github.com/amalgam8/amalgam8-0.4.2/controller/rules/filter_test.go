@@ -17,6 +17,8 @@ package rules
 import (
 	"reflect"
 	"testing"
+
+	"github.com/amalgam8/amalgam8/pkg/labels"
 )
 
 func TestFilterRules(t *testing.T) {
@@ -24,6 +26,7 @@ func TestFilterRules(t *testing.T) {
 		ID:          "id1",
 		Priority:    1,
 		Tags:        []string{"tag1", "tag2"},
+		Labels:      map[string]string{"owner": "team-a", "env": "prod"},
 		Destination: "service1",
 		Match:       []byte(`{}`),
 		Actions:     []byte(`{}`),
@@ -32,10 +35,17 @@ func TestFilterRules(t *testing.T) {
 		ID:          "id1",
 		Priority:    1,
 		Tags:        []string{},
+		Labels:      map[string]string{"owner": "team-b", "experiment": "canary"},
 		Destination: "service2",
 		Match:       []byte(`{}`),
 		Route:       []byte(`{}`),
 	}
+	disabled := Rule{
+		ID:          "id3",
+		Destination: "service1",
+		Route:       []byte(`{}`),
+		Disabled:    true,
+	}
 
 	cases := []struct {
 		In, Out []Rule
@@ -98,6 +108,57 @@ func TestFilterRules(t *testing.T) {
 				RuleType: RuleRoute,
 			},
 		},
+		{ // Disabled rules are filtered out by default
+			In: []Rule{
+				rule1,
+				disabled,
+			},
+			Out: []Rule{
+				rule1,
+			},
+			Filter: Filter{},
+		},
+		{ // Disabled rules are included on request
+			In: []Rule{
+				rule1,
+				disabled,
+			},
+			Out: []Rule{
+				rule1,
+				disabled,
+			},
+			Filter: Filter{
+				IncludeDisabled: true,
+			},
+		},
+		{ // Filter by label selector
+			In: []Rule{
+				rule1,
+				rule2,
+			},
+			Out: []Rule{
+				rule1,
+			},
+			Filter: Filter{
+				Selector: labels.Selector{
+					{Key: "owner", Operator: labels.In, Values: []string{"team-a", "team-c"}},
+					{Key: "experiment", Operator: labels.DoesNotExist},
+				},
+			},
+		},
+		{ // Label selector excludes all rules
+			In: []Rule{
+				rule1,
+				rule2,
+			},
+			Out: []Rule{},
+			Filter: Filter{
+				Selector: labels.Selector{
+					{Key: "env", Operator: labels.NotEquals, Values: []string{"prod"}},
+					{Key: "owner", Operator: labels.Equals, Values: []string{"team-a"}},
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		actual := FilterRules(c.Filter, c.In)
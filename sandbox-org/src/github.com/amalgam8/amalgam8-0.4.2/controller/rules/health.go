@@ -0,0 +1,104 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rules
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/amalgam8/amalgam8/pkg/health"
+)
+
+const (
+	// databaseProbeInterval is the interval between database connectivity probes
+	databaseProbeInterval = 5 * time.Second
+
+	// databaseMaxLatency is the maximal probe round-trip time of a healthy database
+	databaseMaxLatency = time.Second
+
+	// databaseProbeTimeout is the age after which the last successful probe is considered stale, i.e. when a probe hangs
+	databaseProbeTimeout = 3 * databaseProbeInterval
+)
+
+// databaseHealth is a health.Checker probing the connectivity and latency of the Redis database in the background,
+// so that health checks do not block on the network.
+type databaseHealth struct {
+	db *redisDB
+
+	latency     time.Duration
+	lastErr     error
+	lastSuccess time.Time
+	probed      bool
+	mutex       sync.Mutex
+}
+
+func newDatabaseHealth(db *redisDB) *databaseHealth {
+	dh := &databaseHealth{
+		db: db,
+	}
+	go dh.run()
+	return dh
+}
+
+func (dh *databaseHealth) run() {
+	dh.probe()
+	for range time.Tick(databaseProbeInterval) {
+		dh.probe()
+	}
+}
+
+func (dh *databaseHealth) probe() {
+	latency, err := dh.db.Ping()
+
+	dh.mutex.Lock()
+	defer dh.mutex.Unlock()
+
+	if err != nil && (dh.lastErr == nil || !dh.probed) {
+		logrus.WithError(err).Warn("Redis database is unreachable")
+	}
+
+	dh.probed = true
+	dh.lastErr = err
+	if err == nil {
+		dh.latency = latency
+		dh.lastSuccess = time.Now()
+	}
+}
+
+// Check reports the result of the latest database probe.
+func (dh *databaseHealth) Check() health.Status {
+	dh.mutex.Lock()
+	defer dh.mutex.Unlock()
+
+	switch {
+	case !dh.probed:
+		return health.StatusUnhealthy("database has not been probed yet", nil)
+	case dh.lastErr != nil:
+		return health.StatusUnhealthy("database is unreachable", dh.lastErr)
+	case time.Since(dh.lastSuccess) > databaseProbeTimeout:
+		return health.StatusUnhealthy("database probe timed out", errors.New("no response since "+dh.lastSuccess.String()))
+	case dh.latency > databaseMaxLatency:
+		return health.StatusUnhealthyWithProperties(map[string]interface{}{
+			"message":    "database latency is too high",
+			"latency_ms": dh.latency.Seconds() * 1000,
+		})
+	default:
+		return health.StatusHealthyWithProperties(map[string]interface{}{
+			"latency_ms": dh.latency.Seconds() * 1000,
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rules
+
+// ruleChange records the state of a rule before a revision modified it. The history of a namespace keeps the changes
+// of each recent revision rather than a copy of its rules, so that previous revisions are reconstructed by undoing
+// the changes of the revisions that followed them.
+type ruleChange struct {
+	ID string
+
+	// Before is the rule before the change, or nil if the change added the rule.
+	Before *Rule
+}
+
+// undoChanges reverts the rules, mapped by ID, to their state before the changes of a revision.
+func undoChanges(rules map[string]Rule, changes []ruleChange) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		if change.Before == nil {
+			delete(rules, change.ID)
+		} else {
+			rules[change.ID] = *change.Before
+		}
+	}
+}
+
+// changedIDs returns the IDs of the rules modified by the changes, in order of their first change.
+func changedIDs(changes []ruleChange) []string {
+	ids := make([]string, 0, len(changes))
+	seen := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		if !seen[change.ID] {
+			seen[change.ID] = true
+			ids = append(ids, change.ID)
+		}
+	}
+	return ids
+}
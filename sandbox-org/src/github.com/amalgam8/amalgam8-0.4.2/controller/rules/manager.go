@@ -14,6 +14,10 @@
 
 package rules
 
+// HistorySize is the number of most recent revisions of each namespace whose changes are retained by the managers.
+// Previous revisions are reconstructed by undoing these changes, rather than stored as copies of the rules.
+const HistorySize = 100
+
 // Manager is an interface for managing collections of rules mapped by namespace.
 type Manager interface {
 	// AddRules validates the rules and adds them to the collection for the namespace.
@@ -22,21 +26,62 @@ type Manager interface {
 	// GetRules returns a collection of filtered rules from the namespace.
 	GetRules(namespace string, filter Filter) (RetrievedRules, error)
 
+	// GetRevision returns all the rules of the namespace as they were at the given revision. Only revisions
+	// within the last HistorySize changes of each namespace can be reconstructed.
+	GetRevision(namespace string, revision int64) (RetrievedRules, error)
+
 	// UpdateRules updates rules by ID in the namespace.
-	UpdateRules(namespace string, rules []Rule) error
+	UpdateRules(namespace string, rules []Rule) (Change, error)
 
 	// DeleteRules deletes rules that match the filter in the namespace.
-	DeleteRules(namespace string, filter Filter) error
+	DeleteRules(namespace string, filter Filter) (Change, error)
+
+	// EnableRules clears the disabled flag of the rules that match the filter in the namespace. The filter is
+	// applied to both enabled and disabled rules.
+	EnableRules(namespace string, filter Filter) (Change, error)
+
+	// DisableRules sets the disabled flag of the rules that match the filter in the namespace. Disabled rules are
+	// kept, but are only returned by GetRules when the filter includes them.
+	DisableRules(namespace string, filter Filter) (Change, error)
 
 	// SetRules deletes the rules that match the filter and adds the new rules as a single
 	// atomic transaction.
 	SetRules(namespace string, filter Filter, rules []Rule) (NewRules, error)
+
+	// ListNamespaces returns a summary of each namespace that has rules.
+	ListNamespaces() ([]NamespaceInfo, error)
+
+	// GetNamespace returns a summary of the namespace, or a NamespaceNotFoundError if it never had rules.
+	GetNamespace(namespace string) (NamespaceInfo, error)
+
+	// DeleteNamespace deletes all the rules and the history of the namespace. The revision of the namespace is
+	// incremented rather than reset, so that clients polling the namespace observe the deletion.
+	DeleteNamespace(namespace string) (Change, error)
+}
+
+// Change describes a write to the rules of a namespace, as made by the write itself.
+type Change struct {
+	// IDs of the rules that were added, updated or deleted by the write.
+	IDs []string
+
+	// Revision of the namespace after the write. Each write that increments the revision increments it by one.
+	Revision int64
+}
+
+// NamespaceInfo summarizes the rules of a namespace.
+type NamespaceInfo struct {
+	Namespace string `json:"namespace"`
+	Revision  int64  `json:"revision"`
+	Rules     int    `json:"rules"`
 }
 
 // NewRules provides information about newly added rules.
 type NewRules struct {
 	// IDs of the added rules.
 	IDs []string
+
+	// Change made to the namespace, including rules deleted in favor of the added rules.
+	Change Change
 }
 
 // RetrievedRules are the results of a read from a manager.
@@ -104,7 +104,7 @@ var _ = Describe("Manager", func() {
 								Destination: NewDestination,
 							},
 						}
-						err = manager.UpdateRules(namespace, rules)
+						_, err = manager.UpdateRules(namespace, rules)
 					})
 
 					Context("the modified rule is valid", func() {
@@ -138,12 +138,118 @@ var _ = Describe("Manager", func() {
 					})
 				})
 
+				Describe("reading a previous revision", func() {
+					JustBeforeEach(func() {
+						_, err = manager.DeleteRules(namespace, Filter{})
+					})
+
+					It("returns the rules of that revision", func() {
+						retrievedRules, err := manager.GetRevision(namespace, 1)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(retrievedRules.Revision).To(Equal(int64(1)))
+						Expect(retrievedRules.Rules).To(HaveLen(1))
+						Expect(retrievedRules.Rules[0].ID).To(Equal(newRules.IDs[0]))
+					})
+
+					It("returns the current rules for the current revision", func() {
+						retrievedRules, err := manager.GetRevision(namespace, 2)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(retrievedRules.Rules).To(BeEmpty())
+					})
+
+					It("errors for unknown revisions", func() {
+						_, err := manager.GetRevision(namespace, 3)
+						Expect(err).To(BeAssignableToTypeOf(&RevisionNotFoundError{}))
+					})
+
+					Context("after further changes", func() {
+						JustBeforeEach(func() {
+							_, err = manager.AddRules(namespace, []Rule{{Destination: "DestinationZ"}})
+							Expect(err).ToNot(HaveOccurred())
+						})
+
+						It("undoes the changes of each later revision", func() {
+							retrievedRules, err := manager.GetRevision(namespace, 1)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(retrievedRules.Rules).To(HaveLen(1))
+							Expect(retrievedRules.Rules[0].ID).To(Equal(newRules.IDs[0]))
+							Expect(retrievedRules.Rules[0].Destination).To(Equal(Destination))
+
+							retrievedRules, err = manager.GetRevision(namespace, 2)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(retrievedRules.Rules).To(BeEmpty())
+
+							retrievedRules, err = manager.GetRevision(namespace, 3)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(retrievedRules.Rules).To(HaveLen(1))
+							Expect(retrievedRules.Rules[0].Destination).To(Equal("DestinationZ"))
+						})
+					})
+				})
+
+				Describe("disabling the rule", func() {
+					var change Change
+
+					JustBeforeEach(func() {
+						filter := Filter{
+							IDs: newRules.IDs,
+						}
+						change, err = manager.DisableRules(namespace, filter)
+					})
+
+					It("should not error", func() {
+						Expect(err).ToNot(HaveOccurred())
+					})
+
+					It("returns the changed rule and the new revision", func() {
+						Expect(change).To(Equal(Change{IDs: newRules.IDs, Revision: 2}))
+					})
+
+					It("excludes the rule by default", func() {
+						retrievedRules, err := manager.GetRules(namespace, Filter{})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(retrievedRules.Rules).To(BeEmpty())
+					})
+
+					It("keeps the rule", func() {
+						retrievedRules, err := manager.GetRules(namespace, Filter{IncludeDisabled: true})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(retrievedRules.Rules).To(HaveLen(1))
+						Expect(retrievedRules.Rules[0].ID).To(Equal(newRules.IDs[0]))
+						Expect(retrievedRules.Rules[0].Disabled).To(BeTrue())
+					})
+
+					It("updates the revision", func() {
+						retrievedRules, err := manager.GetRules(namespace, Filter{})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(retrievedRules.Revision).To(Equal(int64(2)))
+					})
+
+					Context("the rule is enabled again", func() {
+						JustBeforeEach(func() {
+							_, err = manager.EnableRules(namespace, Filter{IDs: newRules.IDs})
+						})
+
+						It("should not error", func() {
+							Expect(err).ToNot(HaveOccurred())
+						})
+
+						It("returns the rule by default", func() {
+							retrievedRules, err := manager.GetRules(namespace, Filter{})
+							Expect(err).ToNot(HaveOccurred())
+							Expect(retrievedRules.Rules).To(HaveLen(1))
+							Expect(retrievedRules.Rules[0].Disabled).To(BeFalse())
+							Expect(retrievedRules.Revision).To(Equal(int64(3)))
+						})
+					})
+				})
+
 				Describe("deleting the rule", func() {
 					JustBeforeEach(func() {
 						filter := Filter{
 							IDs: newRules.IDs,
 						}
-						err = manager.DeleteRules(namespace, filter)
+						_, err = manager.DeleteRules(namespace, filter)
 					})
 
 					It("should not error", func() {
@@ -183,6 +289,11 @@ var _ = Describe("Manager", func() {
 					Expect(err).To(HaveOccurred())
 				})
 
+				It("should return the validation error", func() {
+					Expect(err).To(BeAssignableToTypeOf(&InvalidRuleError{}))
+					Expect(err.(*InvalidRuleError).Reason).To(Equal("invalid rule"))
+				})
+
 				It("should not generate an ID", func() {
 					Expect(newRules.IDs).To(BeEmpty())
 				})
@@ -209,4 +320,60 @@ var _ = Describe("Manager", func() {
 			})
 		})
 	})
+
+	Describe("namespace administration", func() {
+		JustBeforeEach(func() {
+			_, err := manager.AddRules(namespace, []Rule{{Destination: "a"}, {Destination: "b"}})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manager.AddRules("other", []Rule{{Destination: "a"}})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should list the namespaces that have rules", func() {
+			infos, err := manager.ListNamespaces()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(infos).To(ConsistOf(
+				NamespaceInfo{Namespace: namespace, Revision: 1, Rules: 2},
+				NamespaceInfo{Namespace: "other", Revision: 1, Rules: 1},
+			))
+		})
+
+		It("should not find a namespace that never had rules", func() {
+			_, err := manager.GetNamespace("unknown")
+			Expect(err).To(BeAssignableToTypeOf(&NamespaceNotFoundError{}))
+
+			_, err = manager.DeleteNamespace("unknown")
+			Expect(err).To(BeAssignableToTypeOf(&NamespaceNotFoundError{}))
+		})
+
+		Context("a namespace is deleted", func() {
+			JustBeforeEach(func() {
+				change, err := manager.DeleteNamespace(namespace)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(change.IDs).To(HaveLen(2))
+				Expect(change.Revision).To(Equal(int64(2)))
+			})
+
+			It("should delete its rules and bump its revision", func() {
+				info, err := manager.GetNamespace(namespace)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info).To(Equal(NamespaceInfo{Namespace: namespace, Revision: 2, Rules: 0}))
+
+				retrieved, err := manager.GetRules(namespace, Filter{IncludeDisabled: true})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(retrieved.Rules).To(BeEmpty())
+			})
+
+			It("should delete its history", func() {
+				_, err := manager.GetRevision(namespace, 1)
+				Expect(err).To(BeAssignableToTypeOf(&RevisionNotFoundError{}))
+			})
+
+			It("should no longer be listed", func() {
+				infos, err := manager.ListNamespaces()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(infos).To(ConsistOf(NamespaceInfo{Namespace: "other", Revision: 1, Rules: 1}))
+			})
+		})
+	})
 })
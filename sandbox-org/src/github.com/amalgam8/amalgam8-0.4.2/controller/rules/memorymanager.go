@@ -27,6 +27,7 @@ func NewMemoryManager(validator Validator) Manager {
 	return &memory{
 		rules:     make(map[string]map[string]Rule),
 		revision:  make(map[string]int64),
+		history:   make(map[string]map[int64][]ruleChange),
 		validator: validator,
 		mutex:     &sync.Mutex{},
 	}
@@ -35,11 +36,16 @@ func NewMemoryManager(validator Validator) Manager {
 type memory struct {
 	rules     map[string]map[string]Rule
 	revision  map[string]int64
+	history   map[string]map[int64][]ruleChange
 	validator Validator
 	mutex     *sync.Mutex
 }
 
 func (m *memory) AddRules(namespace string, rules []Rule) (NewRules, error) {
+	return m.addRulesLimited(namespace, rules, 0)
+}
+
+func (m *memory) addRulesLimited(namespace string, rules []Rule, maxRules int) (NewRules, error) {
 	if len(rules) == 0 {
 		return NewRules{}, errors.New("rules: no rules provided")
 	}
@@ -54,8 +60,13 @@ func (m *memory) AddRules(namespace string, rules []Rule) (NewRules, error) {
 
 	// Add the rules
 	m.mutex.Lock()
-	m.addRules(namespace, rules)
-	m.mutex.Unlock()
+	defer m.mutex.Unlock()
+
+	if maxRules > 0 && len(m.rules[namespace])+len(rules) > maxRules {
+		return NewRules{}, &RuleCountQuotaError{Limit: maxRules}
+	}
+
+	change := m.bumpRevision(namespace, m.putRules(namespace, rules))
 
 	// Get the new IDs
 	ids := make([]string, len(rules))
@@ -64,23 +75,11 @@ func (m *memory) AddRules(namespace string, rules []Rule) (NewRules, error) {
 	}
 
 	return NewRules{
-		IDs: ids,
+		IDs:    ids,
+		Change: change,
 	}, nil
 }
 
-func (m *memory) addRules(namespace string, rules []Rule) {
-	_, exists := m.rules[namespace]
-	if !exists {
-		m.rules[namespace] = make(map[string]Rule)
-	}
-
-	for _, rule := range rules {
-		m.rules[namespace][rule.ID] = rule
-	}
-
-	m.revision[namespace]++
-}
-
 func (m *memory) GetRules(namespace string, filter Filter) (RetrievedRules, error) {
 	m.mutex.Lock()
 
@@ -127,14 +126,14 @@ func (m *memory) GetRules(namespace string, filter Filter) (RetrievedRules, erro
 	}, nil
 }
 
-func (m *memory) UpdateRules(namespace string, rules []Rule) error {
+func (m *memory) UpdateRules(namespace string, rules []Rule) (Change, error) {
 	if len(rules) == 0 {
-		return errors.New("rules: no rules provided")
+		return Change{}, errors.New("rules: no rules provided")
 	}
 
 	// Validate rules
 	if err := m.validateRules(rules); err != nil {
-		return err
+		return Change{}, err
 	}
 
 	m.mutex.Lock()
@@ -143,36 +142,70 @@ func (m *memory) UpdateRules(namespace string, rules []Rule) error {
 	// Make sure the IDs exist
 	_, exists := m.rules[namespace]
 	if !exists {
-		return errors.New("rules: ID not found")
+		return Change{}, errors.New("rules: ID not found")
 	}
 
 	for _, rule := range rules {
 		_, exists := m.rules[namespace][rule.ID]
 		if !exists {
-			return errors.New("rules: ID not found")
+			return Change{}, errors.New("rules: ID not found")
 		}
 	}
 
-	// Update the rules
-	for _, rule := range rules {
-		m.rules[namespace][rule.ID] = rule
-	}
+	// Update the rules and the revision
+	return m.bumpRevision(namespace, m.putRules(namespace, rules)), nil
+}
 
-	// Update the revision
-	m.revision[namespace]++
+func (m *memory) DeleteRules(namespace string, filter Filter) (Change, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
-	return nil
+	return m.bumpRevision(namespace, m.deleteRulesByFilter(namespace, filter)), nil
 }
 
-func (m *memory) DeleteRules(namespace string, filter Filter) error {
+func (m *memory) EnableRules(namespace string, filter Filter) (Change, error) {
+	return m.setDisabled(namespace, filter, false)
+}
+
+func (m *memory) DisableRules(namespace string, filter Filter) (Change, error) {
+	return m.setDisabled(namespace, filter, true)
+}
+
+func (m *memory) setDisabled(namespace string, filter Filter, disabled bool) (Change, error) {
+	filter.IncludeDisabled = true
+
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	m.revision[namespace]++
-	return m.deleteRulesByFilter(namespace, filter)
+	unchanged := Change{Revision: m.revision[namespace]}
+
+	ruleMap, exists := m.rules[namespace]
+	if !exists {
+		return unchanged, nil
+	}
+
+	rules := make([]Rule, 0, len(ruleMap))
+	for _, rule := range ruleMap {
+		rules = append(rules, rule)
+	}
+
+	rules = FilterRules(filter, rules)
+	if len(rules) == 0 {
+		return unchanged, nil
+	}
+
+	for i := range rules {
+		rules[i].Disabled = disabled
+	}
+
+	return m.bumpRevision(namespace, m.putRules(namespace, rules)), nil
 }
 
 func (m *memory) SetRules(namespace string, filter Filter, rules []Rule) (NewRules, error) {
+	return m.setRulesLimited(namespace, filter, rules, 0)
+}
+
+func (m *memory) setRulesLimited(namespace string, filter Filter, rules []Rule, maxRules int) (NewRules, error) {
 	// Validate rules
 	if err := m.validateRules(rules); err != nil {
 		return NewRules{}, err
@@ -184,11 +217,12 @@ func (m *memory) SetRules(namespace string, filter Filter, rules []Rule) (NewRul
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	if err := m.deleteRulesByFilter(namespace, filter); err != nil {
-		return NewRules{}, err
+	if maxRules > 0 && len(m.rules[namespace])-len(m.matchRules(namespace, filter))+len(rules) > maxRules {
+		return NewRules{}, &RuleCountQuotaError{Limit: maxRules}
 	}
 
-	m.addRules(namespace, rules)
+	changes := m.deleteRulesByFilter(namespace, filter)
+	change := m.bumpRevision(namespace, append(changes, m.putRules(namespace, rules)...))
 
 	// Get the new IDs
 	ids := make([]string, len(rules))
@@ -197,30 +231,170 @@ func (m *memory) SetRules(namespace string, filter Filter, rules []Rule) (NewRul
 	}
 
 	return NewRules{
-		IDs: ids,
+		IDs:    ids,
+		Change: change,
 	}, nil
 }
 
-func (m *memory) deleteRulesByFilter(namespace string, filter Filter) error {
-	ruleMap, exists := m.rules[namespace]
-	if !exists {
-		return nil
+func (m *memory) GetRevision(namespace string, revision int64) (RetrievedRules, error) {
+	if revision == 0 {
+		return RetrievedRules{
+			Rules:    []Rule{},
+			Revision: revision,
+		}, nil
 	}
 
-	rules := make([]Rule, len(m.rules[namespace]))
-	i := 0
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if revision > m.revision[namespace] {
+		return RetrievedRules{}, &RevisionNotFoundError{Revision: revision}
+	}
+
+	ruleMap := make(map[string]Rule, len(m.rules[namespace]))
+	for id, rule := range m.rules[namespace] {
+		ruleMap[id] = rule
+	}
+
+	// Undo the changes of the revisions since the requested revision, from the most recent one
+	for r := m.revision[namespace]; r > revision; r-- {
+		changes, exists := m.history[namespace][r]
+		if !exists {
+			return RetrievedRules{}, &RevisionNotFoundError{Revision: revision}
+		}
+		undoChanges(ruleMap, changes)
+	}
+
+	rules := make([]Rule, 0, len(ruleMap))
 	for _, rule := range ruleMap {
-		rules[i] = rule
-		i++
+		rules = append(rules, rule)
 	}
 
-	rules = FilterRules(filter, rules)
+	return RetrievedRules{
+		Rules:    rules,
+		Revision: revision,
+	}, nil
+}
+
+func (m *memory) ListNamespaces() ([]NamespaceInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	infos := make([]NamespaceInfo, 0, len(m.rules))
+	for namespace, ruleMap := range m.rules {
+		if len(ruleMap) == 0 {
+			continue
+		}
+		infos = append(infos, NamespaceInfo{
+			Namespace: namespace,
+			Revision:  m.revision[namespace],
+			Rules:     len(ruleMap),
+		})
+	}
+
+	return infos, nil
+}
+
+func (m *memory) GetNamespace(namespace string) (NamespaceInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	revision := m.revision[namespace]
+	if revision == 0 {
+		return NamespaceInfo{}, &NamespaceNotFoundError{Namespace: namespace}
+	}
+
+	return NamespaceInfo{
+		Namespace: namespace,
+		Revision:  revision,
+		Rules:     len(m.rules[namespace]),
+	}, nil
+}
+
+func (m *memory) DeleteNamespace(namespace string) (Change, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.revision[namespace] == 0 {
+		return Change{}, &NamespaceNotFoundError{Namespace: namespace}
+	}
+
+	changes := m.deleteRulesByFilter(namespace, Filter{IncludeDisabled: true})
+	delete(m.rules, namespace)
+	change := m.bumpRevision(namespace, changes)
+
+	// The deleted rules are not retained, so none of the previous revisions can be reconstructed
+	delete(m.history, namespace)
 
+	return change, nil
+}
+
+// bumpRevision increments the revision of the namespace and records the changes made by the new revision, so that it
+// can be undone. Returns the change made by the revision. Must be called with the mutex held.
+func (m *memory) bumpRevision(namespace string, changes []ruleChange) Change {
+	m.revision[namespace]++
+	revision := m.revision[namespace]
+
+	history, exists := m.history[namespace]
+	if !exists {
+		history = make(map[int64][]ruleChange)
+		m.history[namespace] = history
+	}
+
+	history[revision] = changes
+	delete(history, revision-HistorySize)
+
+	return Change{
+		IDs:      changedIDs(changes),
+		Revision: revision,
+	}
+}
+
+// putRules adds or replaces the rules of the namespace, returning the changes made. Must be called with the mutex held.
+func (m *memory) putRules(namespace string, rules []Rule) []ruleChange {
+	ruleMap, exists := m.rules[namespace]
+	if !exists {
+		ruleMap = make(map[string]Rule)
+		m.rules[namespace] = ruleMap
+	}
+
+	changes := make([]ruleChange, 0, len(rules))
 	for _, rule := range rules {
-		delete(m.rules[namespace], rule.ID)
+		change := ruleChange{ID: rule.ID}
+		if before, exists := ruleMap[rule.ID]; exists {
+			change.Before = &before
+		}
+		changes = append(changes, change)
+		ruleMap[rule.ID] = rule
 	}
 
-	return nil
+	return changes
+}
+
+// deleteRulesByFilter deletes the rules of the namespace that match the filter, returning the changes made. Must be
+// called with the mutex held.
+func (m *memory) deleteRulesByFilter(namespace string, filter Filter) []ruleChange {
+	rules := m.matchRules(namespace, filter)
+
+	changes := make([]ruleChange, 0, len(rules))
+	for i := range rules {
+		changes = append(changes, ruleChange{ID: rules[i].ID, Before: &rules[i]})
+		delete(m.rules[namespace], rules[i].ID)
+	}
+
+	return changes
+}
+
+// matchRules returns the rules of the namespace that match the filter. Must be called with the mutex held.
+func (m *memory) matchRules(namespace string, filter Filter) []Rule {
+	ruleMap := m.rules[namespace]
+
+	rules := make([]Rule, 0, len(ruleMap))
+	for _, rule := range ruleMap {
+		rules = append(rules, rule)
+	}
+
+	return FilterRules(filter, rules)
 }
 
 func (m *memory) generateRuleIDs(rules []Rule) {
@@ -232,7 +406,7 @@ func (m *memory) generateRuleIDs(rules []Rule) {
 func (m *memory) validateRules(rules []Rule) error {
 	for _, rule := range rules {
 		if err := m.validator.Validate(rule); err != nil {
-			return err
+			return &InvalidRuleError{Reason: err.Error()}
 		}
 	}
 	return nil
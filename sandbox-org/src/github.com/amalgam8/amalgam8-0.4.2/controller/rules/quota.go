@@ -0,0 +1,196 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Quota limits the rules of a namespace, and the rate of changes to them. Zero values are unlimited.
+type Quota struct {
+	// MaxRules is the maximal number of rules in the namespace, including disabled rules.
+	MaxRules int `json:"max_rules" yaml:"max_rules"`
+
+	// MaxRuleSize is the maximal size in bytes of the JSON encoding of a rule.
+	MaxRuleSize int `json:"max_rule_size" yaml:"max_rule_size"`
+
+	// WriteRate is the sustained number of write requests per second allowed in the namespace.
+	WriteRate float64 `json:"write_rate" yaml:"write_rate"`
+
+	// WriteBurst is the number of write requests allowed in the namespace above the sustained rate.
+	WriteBurst int `json:"write_burst" yaml:"write_burst"`
+}
+
+// Quotas holds the default quota and per namespace overrides.
+type Quotas struct {
+	Default    Quota
+	Namespaces map[string]Quota
+}
+
+// For returns the quota of the namespace.
+func (q *Quotas) For(namespace string) Quota {
+	if quota, exists := q.Namespaces[namespace]; exists {
+		return quota
+	}
+	return q.Default
+}
+
+// quotaOverride is a per namespace entry of a quota file. Limits that are not set are inherited from the default quota.
+type quotaOverride struct {
+	MaxRules    *int     `json:"max_rules" yaml:"max_rules"`
+	MaxRuleSize *int     `json:"max_rule_size" yaml:"max_rule_size"`
+	WriteRate   *float64 `json:"write_rate" yaml:"write_rate"`
+	WriteBurst  *int     `json:"write_burst" yaml:"write_burst"`
+}
+
+// ParseQuotas parses a YAML or JSON document mapping namespaces to their quota overrides, e.g.
+//
+//	ns1:
+//	  max_rules: 5000
+//	  write_rate: 10
+func ParseQuotas(data []byte, def Quota) (*Quotas, error) {
+	overrides := make(map[string]quotaOverride)
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("could not parse quotas: %v", err)
+	}
+
+	quotas := &Quotas{
+		Default:    def,
+		Namespaces: make(map[string]Quota, len(overrides)),
+	}
+	for namespace, override := range overrides {
+		quota := def
+		if override.MaxRules != nil {
+			quota.MaxRules = *override.MaxRules
+		}
+		if override.MaxRuleSize != nil {
+			quota.MaxRuleSize = *override.MaxRuleSize
+		}
+		if override.WriteRate != nil {
+			quota.WriteRate = *override.WriteRate
+		}
+		if override.WriteBurst != nil {
+			quota.WriteBurst = *override.WriteBurst
+		}
+		quotas.Namespaces[namespace] = quota
+	}
+
+	return quotas, nil
+}
+
+// LoadQuotas reads the quota overrides from a file. If no file is given, the default quota applies to all namespaces.
+func LoadQuotas(path string, def Quota) (*Quotas, error) {
+	if path == "" {
+		return &Quotas{Default: def}, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read quota file: %v", err)
+	}
+
+	return ParseQuotas(data, def)
+}
+
+// NewQuotaManager wraps a manager, rejecting changes that would exceed the rule count or rule size quota of the
+// namespace. The rule count is checked by the wrapped manager atomically with the change, so that concurrent requests,
+// possibly to other controllers sharing the same database, cannot exceed the quota together.
+func NewQuotaManager(m Manager, quotas *Quotas) Manager {
+	return &quotaManager{
+		Manager: m,
+		quotas:  quotas,
+	}
+}
+
+// countLimiter is implemented by managers that can add rules while enforcing a maximal number of rules in the
+// namespace, as a single atomic operation. A non-positive maxRules is unlimited.
+type countLimiter interface {
+	addRulesLimited(namespace string, rules []Rule, maxRules int) (NewRules, error)
+	setRulesLimited(namespace string, filter Filter, rules []Rule, maxRules int) (NewRules, error)
+}
+
+type quotaManager struct {
+	Manager
+	quotas *Quotas
+}
+
+func (q *quotaManager) AddRules(namespace string, rules []Rule) (NewRules, error) {
+	quota := q.quotas.For(namespace)
+	if err := checkRuleSizes(rules, quota.MaxRuleSize); err != nil {
+		return NewRules{}, err
+	}
+
+	limiter, err := q.limiter()
+	if err != nil {
+		return NewRules{}, err
+	}
+
+	return limiter.addRulesLimited(namespace, rules, quota.MaxRules)
+}
+
+func (q *quotaManager) UpdateRules(namespace string, rules []Rule) (Change, error) {
+	if err := checkRuleSizes(rules, q.quotas.For(namespace).MaxRuleSize); err != nil {
+		return Change{}, err
+	}
+
+	return q.Manager.UpdateRules(namespace, rules)
+}
+
+func (q *quotaManager) SetRules(namespace string, filter Filter, rules []Rule) (NewRules, error) {
+	quota := q.quotas.For(namespace)
+	if err := checkRuleSizes(rules, quota.MaxRuleSize); err != nil {
+		return NewRules{}, err
+	}
+
+	limiter, err := q.limiter()
+	if err != nil {
+		return NewRules{}, err
+	}
+
+	return limiter.setRulesLimited(namespace, filter, rules, quota.MaxRules)
+}
+
+// limiter returns the wrapped manager as a countLimiter, or an error if it cannot enforce the rule count quota.
+func (q *quotaManager) limiter() (countLimiter, error) {
+	limiter, ok := q.Manager.(countLimiter)
+	if !ok {
+		return nil, errors.New("rules: manager does not support rule count quotas")
+	}
+	return limiter, nil
+}
+
+// checkRuleSizes returns a RuleSizeQuotaError if the JSON encoding of a rule is larger than the limit.
+func checkRuleSizes(rules []Rule, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	for _, rule := range rules {
+		data, err := json.Marshal(rule)
+		if err != nil {
+			return &JSONMarshalError{Message: err.Error()}
+		}
+		if len(data) > limit {
+			return &RuleSizeQuotaError{Limit: limit, Size: len(data)}
+		}
+	}
+
+	return nil
+}
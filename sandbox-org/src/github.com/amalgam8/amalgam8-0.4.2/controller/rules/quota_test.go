@@ -0,0 +1,117 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rules
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseQuotas(t *testing.T) {
+	def := Quota{MaxRules: 100, MaxRuleSize: 1024, WriteRate: 1, WriteBurst: 10}
+
+	quotas, err := ParseQuotas([]byte("ns1:\n  max_rules: 5000\n  write_rate: 0\nns2: {}\n"), def)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Quota{MaxRules: 5000, MaxRuleSize: 1024, WriteRate: 0, WriteBurst: 10}
+	if quota := quotas.For("ns1"); quota != expected {
+		t.Errorf("expected quota %+v for ns1, got %+v", expected, quota)
+	}
+	if quota := quotas.For("ns2"); quota != def {
+		t.Errorf("expected default quota %+v for ns2, got %+v", def, quota)
+	}
+	if quota := quotas.For("ns3"); quota != def {
+		t.Errorf("expected default quota %+v for ns3, got %+v", def, quota)
+	}
+
+	if _, err := ParseQuotas([]byte("ns1: [1, 2]"), def); err == nil {
+		t.Error("expected error for malformed quotas")
+	}
+}
+
+func TestQuotaManager(t *testing.T) {
+	quotas := &Quotas{
+		Default: Quota{MaxRules: 2, MaxRuleSize: 256},
+		Namespaces: map[string]Quota{
+			"unlimited": {},
+		},
+	}
+	manager := NewQuotaManager(NewMemoryManager(&MockValidator{}), quotas)
+
+	rule := func(destination string) Rule {
+		return Rule{Destination: destination, Tags: []string{}}
+	}
+
+	if _, err := manager.AddRules("ns", []Rule{rule("a"), rule("b")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := manager.AddRules("ns", []Rule{rule("c")})
+	if quotaErr, ok := err.(*RuleCountQuotaError); !ok || quotaErr.Limit != 2 {
+		t.Errorf("expected rule count quota error, got %v", err)
+	}
+
+	// Replacing rules is allowed as long as the resulting count is within the quota
+	if _, err := manager.SetRules("ns", Filter{Destinations: []string{"a"}}, []Rule{rule("c")}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = manager.SetRules("ns", Filter{Destinations: []string{"b"}}, []Rule{rule("d"), rule("e")})
+	if _, ok := err.(*RuleCountQuotaError); !ok {
+		t.Errorf("expected rule count quota error, got %v", err)
+	}
+
+	large := rule(strings.Repeat("x", 256))
+	_, err = manager.AddRules("other", []Rule{large})
+	if sizeErr, ok := err.(*RuleSizeQuotaError); !ok || sizeErr.Limit != 256 || sizeErr.Size <= 256 {
+		t.Errorf("expected rule size quota error, got %v", err)
+	}
+
+	if _, err := manager.AddRules("unlimited", []Rule{rule("a"), rule("b"), rule("c"), large}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestQuotaManagerConcurrentAdds(t *testing.T) {
+	quotas := &Quotas{Default: Quota{MaxRules: 5}}
+	manager := NewQuotaManager(NewMemoryManager(&MockValidator{}), quotas)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := manager.AddRules("ns", []Rule{{Destination: "a", Tags: []string{}}})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	added := 0
+	for err := range errs {
+		if err == nil {
+			added++
+		} else if _, ok := err.(*RuleCountQuotaError); !ok {
+			t.Errorf("expected rule count quota error, got %v", err)
+		}
+	}
+	if added != 5 {
+		t.Errorf("expected 5 rules to be added, got %v", added)
+	}
+}
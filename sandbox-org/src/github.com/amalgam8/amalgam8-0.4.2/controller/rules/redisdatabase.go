@@ -22,12 +22,102 @@ import (
 	"encoding/base64"
 
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/amalgam8/amalgam8/controller/util/encryption"
 	"github.com/garyburd/redigo/redis"
 )
 
+// changeEntriesScript sets and deletes entries of the rules hash of a namespace, increments its revision and records
+// the previous value of each changed entry under the new revision in the changes hash, as a single atomic operation.
+// The change recorded for the revision that falls out of the history size is evicted. The arguments are the history
+// size, whether all the entries must already exist, the maximal number of entries (non-positive is unlimited), and
+// pairs of entry ID and value, where an empty value deletes the entry. Returns the new revision followed by the IDs of
+// the changed entries, or the quotaExceededReply error without any changes if there would be too many entries.
+var changeEntriesScript = redis.NewScript(3, `
+if ARGV[2] == "1" then
+	for i = 4, #ARGV, 2 do
+		if redis.call("HEXISTS", KEYS[1], ARGV[i]) == 0 then
+			return redis.error_reply("rules: id " .. ARGV[i] .. " does not exist")
+		end
+	end
+end
+local max = tonumber(ARGV[3])
+if max > 0 then
+	local count = redis.call("HLEN", KEYS[1])
+	for i = 4, #ARGV, 2 do
+		local exists = redis.call("HEXISTS", KEYS[1], ARGV[i]) == 1
+		if ARGV[i + 1] == "" then
+			if exists then
+				count = count - 1
+			end
+		elseif not exists then
+			count = count + 1
+		end
+	end
+	if count > max then
+		return redis.error_reply("`+quotaExceededReply+`")
+	end
+end
+local changes = {}
+for i = 4, #ARGV, 2 do
+	local before = redis.call("HGET", KEYS[1], ARGV[i])
+	if ARGV[i + 1] ~= "" then
+		redis.call("HSET", KEYS[1], ARGV[i], ARGV[i + 1])
+		table.insert(changes, {id = ARGV[i], before = before or nil})
+	elseif before then
+		redis.call("HDEL", KEYS[1], ARGV[i])
+		table.insert(changes, {id = ARGV[i], before = before})
+	end
+end
+local encoded = "[]"
+if #changes > 0 then
+	encoded = cjson.encode(changes)
+end
+local revision = redis.call("INCR", KEYS[2])
+redis.call("HSET", KEYS[3], revision, encoded)
+redis.call("HDEL", KEYS[3], revision - tonumber(ARGV[1]))
+local result = {revision}
+for _, change in ipairs(changes) do
+	table.insert(result, change.id)
+end
+return result
+`)
+
+// deleteNamespaceScript deletes the rules hash and the changes hash of a namespace, and bumps its revision, as a
+// single atomic operation. Returns the new revision followed by the IDs of the deleted entries, or 0 if the namespace
+// does not exist.
+var deleteNamespaceScript = redis.NewScript(3, `
+if redis.call("EXISTS", KEYS[2]) == 0 then
+	return {0}
+end
+local ids = redis.call("HKEYS", KEYS[1])
+redis.call("DEL", KEYS[1], KEYS[3])
+local result = {redis.call("INCR", KEYS[2])}
+for _, id in ipairs(ids) do
+	table.insert(result, id)
+end
+return result
+`)
+
+// quotaExceededReply is the error reply of changeEntriesScript when the changes would exceed the maximal number of
+// entries.
+const quotaExceededReply = "QUOTA maximal number of entries exceeded"
+
+// maxTransactionAttempts is the number of attempts made to apply changes computed from the entries of a namespace
+// before giving up due to concurrent modifications.
+const maxTransactionAttempts = 5
+
+// entryChange is the JSON representation of a change recorded by changeEntriesScript. Before is the encrypted
+// previous value of the entry, or nil if the change added the entry.
+type entryChange struct {
+	ID     string  `json:"id"`
+	Before *string `json:"before"`
+}
+
 // Entry is used to encapsulate a record with an IV for encryption and decryption.
 type Entry struct {
 	IV      string `json:"IV"`
@@ -71,6 +161,18 @@ func newRedisDB(address string, password string) *redisDB {
 	return db
 }
 
+// Ping checks the connectivity to the database, returning the round-trip time.
+func (rdb *redisDB) Ping() (time.Duration, error) {
+	conn := rdb.pool.Get()
+	defer conn.Close()
+
+	start := time.Now()
+	if _, err := conn.Do("PING"); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
 func (rdb *redisDB) ReadAllEntries(namespace string) ([]string, int64, error) {
 	conn := rdb.pool.Get()
 	defer conn.Close()
@@ -131,228 +233,383 @@ func (rdb *redisDB) ReadEntries(namespace string, ids []string) ([]string, int64
 	return entries, rev, nil
 }
 
-func (rdb *redisDB) InsertEntries(namespace string, entries map[string]string) error {
+// InsertEntries inserts entries, failing with a RuleCountQuotaError without any changes if the namespace would have more
+// than maxEntries entries. A non-positive maxEntries is unlimited.
+func (rdb *redisDB) InsertEntries(namespace string, entries map[string]string, maxEntries int) (Change, error) {
 	encrypted, err := rdb.encrypt(entries)
 	if err != nil {
-		return err
+		return Change{}, err
 	}
 
-	args := buildHMSetArgs(buildRulesKey(namespace), encrypted)
-
 	conn := rdb.pool.Get()
 	defer conn.Close()
 
-	logrus.Debug("HMSET ", args)
-	_, err = redis.String(conn.Do("HMSET", args...))
+	change, err := parseChange(changeEntriesScript.Do(conn, rdb.changeEntriesArgs(namespace, encrypted, false, maxEntries)...))
+	return change, quotaError(err, maxEntries)
+}
+
+// UpdateEntries replaces existing entries, failing without any changes if some entry does not exist.
+func (rdb *redisDB) UpdateEntries(namespace string, entries map[string]string) (Change, error) {
+	encrypted, err := rdb.encrypt(entries)
 	if err != nil {
-		return err
+		return Change{}, err
 	}
 
-	_, err = conn.Do("INCR", buildNamespaceKey(namespace, "revision")) // FIXME: pipeline
+	conn := rdb.pool.Get()
+	defer conn.Close()
 
-	return err
+	return parseChange(changeEntriesScript.Do(conn, rdb.changeEntriesArgs(namespace, encrypted, true, 0)...))
 }
 
-// 1. Get all existing IDs
-// 2. Ensure the new rules are a subset of the existing rules
-// 3. Update the rules
-func (rdb *redisDB) UpdateEntries(namespace string, entries map[string]string) error {
-	key := buildRulesKey(namespace)
+func (rdb *redisDB) DeleteEntries(namespace string, ids []string) (Change, error) {
+	deleted := make(map[string]string, len(ids))
+	for _, id := range ids {
+		deleted[id] = ""
+	}
 
 	conn := rdb.pool.Get()
 	defer conn.Close()
 
-	conn.Do("WATCH", key) // TODO: return codes?
-
-	existingIDs, err := redis.Strings(conn.Do("HKEYS", key))
-	if err != nil {
-		return err
-	}
+	return parseChange(changeEntriesScript.Do(conn, rdb.changeEntriesArgs(namespace, deleted, false, 0)...))
+}
 
-	existingIDSet := make(map[string]bool)
-	for _, id := range existingIDs {
-		existingIDSet[id] = true
-	}
+func (rdb *redisDB) DeleteAllEntries(namespace string) (Change, error) {
+	return rdb.SetByDestination(namespace, Filter{IncludeDisabled: true}, []Rule{}, 0)
+}
 
-	// TODO: build a list of all the IDs that are missing?
-	for id := range entries {
-		_, exists := existingIDSet[id]
-		if !exists {
-			return errors.New("rules: id " + id + " does not exist")
+// SetByDestination replaces the rules that match the filter with the given rules, failing with a RuleCountQuotaError
+// without any changes if the namespace would have more than maxEntries entries. A non-positive maxEntries is unlimited.
+func (rdb *redisDB) SetByDestination(namespace string, filter Filter, rules []Rule, maxEntries int) (Change, error) {
+	entries := make(map[string]string)
+	for _, rule := range rules {
+		entry, err := json.Marshal(&rule)
+		if err != nil {
+			return Change{}, err
 		}
+		entries[rule.ID] = string(entry)
 	}
 
-	entries, err = rdb.encrypt(entries)
-	if err != nil {
-		return err
-	}
+	return rdb.transact(namespace, maxEntries, func(existingRules []Rule) (map[string]string, error) {
+		rulesToDelete := FilterRules(filter, existingRules)
+		logrus.WithFields(logrus.Fields{
+			"pre_filtered": existingRules,
+			"filtered":     rulesToDelete,
+			"filter":       filter,
+		}).Debug("Filtering")
 
-	conn.Send("MULTI")
-	args := buildHMSetArgs(key, entries)
-	if err := conn.Send("HMSET", args...); err != nil {
-		return err
-	}
+		changes := make(map[string]string, len(rulesToDelete)+len(entries))
+		for _, rule := range rulesToDelete {
+			changes[rule.ID] = ""
+		}
+		for id, entry := range entries {
+			changes[id] = entry
+		}
+
+		return changes, nil
+	})
+}
 
-	// Execute transaction
-	_, err = redis.Values(conn.Do("EXEC"))
+// SetDisabled sets the disabled flag of the rules that match the filter as a single transaction, so that concurrent
+// changes to the rules are not overwritten. The revision is not incremented if no rules match the filter.
+func (rdb *redisDB) SetDisabled(namespace string, filter Filter, disabled bool) (Change, error) {
+	filter.IncludeDisabled = true
 
-	// Nil return indicates that the transaction failed
-	if err != nil {
-		if err == redis.ErrNil {
-			logrus.Error("Transaction failed due to conflict")
+	return rdb.transact(namespace, 0, func(existingRules []Rule) (map[string]string, error) {
+		matched := FilterRules(filter, existingRules)
+		if len(matched) == 0 {
+			return nil, nil
 		}
-		return err
-	}
 
-	_, err = conn.Do("INCR", buildNamespaceKey(namespace, "revision")) // FIXME: pipeline
+		changes := make(map[string]string, len(matched))
+		for _, rule := range matched {
+			rule.Disabled = disabled
+			entry, err := json.Marshal(&rule)
+			if err != nil {
+				return nil, &JSONMarshalError{Message: err.Error()}
+			}
+			changes[rule.ID] = string(entry)
+		}
 
-	return err
+		return changes, nil
+	})
 }
 
-func (rdb *redisDB) DeleteEntries(namespace string, ids []string) error {
+// transact computes changes to the entries of the namespace from its current rules, and applies them as a single
+// transaction. Entries with an empty value are deleted, and no transaction is made if the computed changes are nil.
+// The transaction is retried if the rules were modified concurrently, and fails with a RuleCountQuotaError if the
+// namespace would have more than maxEntries entries. A non-positive maxEntries is unlimited.
+func (rdb *redisDB) transact(namespace string, maxEntries int, compute func([]Rule) (map[string]string, error)) (Change, error) {
+	key := buildRulesKey(namespace)
+
 	conn := rdb.pool.Get()
-	defer conn.Close()
+	defer conn.Close() // Automatically calls DISCARD if necessary
 
-	args := make([]interface{}, len(ids)+1)
-	args[0] = buildRulesKey(namespace)
-	i := 1
-	for _, id := range ids {
-		args[i] = id
-		i++
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		if _, err := conn.Do("WATCH", key); err != nil {
+			return Change{}, err
+		}
+
+		existingEntryMap, err := redis.StringMap(conn.Do("HGETALL", key))
+		if err != nil {
+			return Change{}, err
+		}
+
+		existingRules, err := rdb.decodeRules(existingEntryMap)
+		if err != nil {
+			return Change{}, err
+		}
+
+		changes, err := compute(existingRules)
+		if err != nil {
+			return Change{}, err
+		}
+
+		// Nothing to change, so the revision is left as is
+		if changes == nil {
+			rev, err := redis.Int64(conn.Do("GET", buildNamespaceKey(namespace, "revision")))
+			if err == redis.ErrNil {
+				rev, err = 0, nil
+			}
+			return Change{Revision: rev}, err
+		}
+
+		changes, err = rdb.encrypt(changes)
+		if err != nil {
+			return Change{}, err
+		}
+
+		conn.Send("MULTI")
+		if err := changeEntriesScript.Send(conn, rdb.changeEntriesArgs(namespace, changes, false, maxEntries)...); err != nil {
+			return Change{}, err
+		}
+
+		// Execute transaction. Nil return indicates that the transaction failed due to a conflict.
+		values, err := redis.Values(conn.Do("EXEC"))
+		if err == nil {
+			change, err := parseChange(values[0], nil)
+			return change, quotaError(err, maxEntries)
+		} else if err != redis.ErrNil {
+			return Change{}, err
+		}
+
+		logrus.WithField("attempt", attempt).Warn("Transaction failed due to conflict")
 	}
 
-	logrus.Debug("HDEL ", args)
-	_, err := redis.Int(conn.Do("HDEL", args...))
-	// TODO: more error checking?
+	return Change{}, errors.New("rules: transaction failed due to concurrent changes")
+}
+
+// decodeRules decrypts and unmarshals the entries of a rules hash.
+func (rdb *redisDB) decodeRules(entryMap map[string]string) ([]Rule, error) {
+	entries := make([]string, 0, len(entryMap))
+	for _, entry := range entryMap {
+		entries = append(entries, entry)
+	}
+
+	entries, err := rdb.decrypt(entries)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = conn.Do("INCR", buildNamespaceKey(namespace, "revision")) // FIXME: pipeline
+	rules := make([]Rule, len(entries))
+	for i, entry := range entries {
+		if err := json.Unmarshal([]byte(entry), &rules[i]); err != nil {
+			return nil, err
+		}
+	}
 
-	return err
+	return rules, nil
 }
 
-func (rdb *redisDB) DeleteAllEntries(namespace string) error {
+// ReadRevision returns the entries of the namespace as they were at the given revision, by undoing the changes
+// recorded for the revisions that followed it.
+func (rdb *redisDB) ReadRevision(namespace string, revision int64) ([]string, error) {
 	conn := rdb.pool.Get()
 	defer conn.Close()
 
-	_, err := redis.Int(conn.Do("DEL", buildRulesKey(namespace)))
+	conn.Send("MULTI")
+	conn.Send("HGETALL", buildRulesKey(namespace))
+	conn.Send("GET", buildNamespaceKey(namespace, "revision"))
+	conn.Send("HGETALL", buildChangesKey(namespace))
+	values, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return []string{}, err
+	}
+
+	entryMap, err := redis.StringMap(values[0], nil)
 	if err != nil {
-		return err
+		return []string{}, err
 	}
 
-	_, err = conn.Do("INCR", buildNamespaceKey(namespace, "revision")) // FIXME: pipeline
+	current, err := redis.Int64(values[1], nil)
+	if err == redis.ErrNil {
+		current = 0
+	} else if err != nil {
+		return []string{}, err
+	}
 
-	return err
-}
+	changesMap, err := redis.StringMap(values[2], nil)
+	if err != nil {
+		return []string{}, err
+	}
 
-func (rdb *redisDB) SetByDestination(namespace string, filter Filter, rules []Rule) error {
-	var err error
+	if revision > current {
+		return []string{}, &RevisionNotFoundError{Revision: revision}
+	}
 
-	key := buildRulesKey(namespace)
+	for r := current; r > revision; r-- {
+		encoded, exists := changesMap[strconv.FormatInt(r, 10)]
+		if !exists {
+			return []string{}, &RevisionNotFoundError{Revision: revision}
+		}
 
-	entries := make(map[string]string)
-	for _, rule := range rules {
-		entry, err := json.Marshal(&rule)
-		if err != nil {
-			return err
+		var changes []entryChange
+		if err := json.Unmarshal([]byte(encoded), &changes); err != nil {
+			return []string{}, err
+		}
+
+		for i := len(changes) - 1; i >= 0; i-- {
+			if changes[i].Before == nil {
+				delete(entryMap, changes[i].ID)
+			} else {
+				entryMap[changes[i].ID] = *changes[i].Before
+			}
 		}
-		entries[rule.ID] = string(entry)
 	}
 
-	entries, err = rdb.encrypt(entries)
-	if err != nil {
-		return err
+	entries := make([]string, 0, len(entryMap))
+	for _, entry := range entryMap {
+		entries = append(entries, entry)
 	}
 
+	return rdb.decrypt(entries)
+}
+
+// ListNamespaces returns the namespaces that have rules, scanning the keys of the rules hashes incrementally so that
+// Redis is not blocked.
+func (rdb *redisDB) ListNamespaces() ([]string, error) {
 	conn := rdb.pool.Get()
-	defer conn.Close() // Automatically calls DISCARD if necessary
+	defer conn.Close()
 
-	conn.Do("WATCH", key)
+	namespaces := []string{}
+	cursor := 0
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", buildRulesKey("*"), "COUNT", 1000))
+		if err != nil {
+			return nil, err
+		}
 
-	// Get all rules
-	existingEntryMap, err := redis.StringMap(conn.Do("HGETALL", key))
-	if err != nil {
-		return err
-	}
+		var keys []string
+		if _, err := redis.Scan(values, &cursor, &keys); err != nil {
+			return nil, err
+		}
 
-	existingEntries := make([]string, len(existingEntryMap))
-	i := 0
-	for _, entry := range existingEntryMap {
-		existingEntries[i] = entry
-		i++
+		for _, key := range keys {
+			namespaces = append(namespaces, strings.TrimSuffix(strings.TrimPrefix(key, "controller:"), ":rules"))
+		}
+
+		if cursor == 0 {
+			return namespaces, nil
+		}
 	}
+}
+
+// CountEntries returns the number of entries and the revision of the namespace.
+func (rdb *redisDB) CountEntries(namespace string) (int, int64, error) {
+	conn := rdb.pool.Get()
+	defer conn.Close()
 
-	existingEntries, err = rdb.decrypt(existingEntries)
+	conn.Send("MULTI")
+	conn.Send("HLEN", buildRulesKey(namespace))
+	conn.Send("GET", buildNamespaceKey(namespace, "revision"))
+	values, err := redis.Values(conn.Do("EXEC"))
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
 
-	// Unmarshal
-	existingRules := make([]Rule, len(existingEntries))
-	for i, entry := range existingEntries {
-		rule := Rule{}
-		err := json.Unmarshal([]byte(entry), &rule)
-		if err != nil {
-			return err
-		}
+	count, err := redis.Int(values[0], nil)
+	if err != nil {
+		return 0, 0, err
+	}
 
-		existingRules[i] = rule
+	rev, err := redis.Int64(values[1], nil)
+	if err == redis.ErrNil {
+		rev = 0
+	} else if err != nil {
+		return 0, 0, err
 	}
 
-	rulesToDelete := FilterRules(filter, existingRules)
-	logrus.WithFields(logrus.Fields{
-		"pre_filtered": existingRules,
-		"filtered":     rulesToDelete,
-		"filter":       filter,
-	}).Debug("Filtering")
+	return count, rev, nil
+}
 
-	conn.Send("MULTI")
+// DeleteNamespace deletes the entries and changes of the namespace. The revision of the returned change is 0 if the
+// namespace does not exist.
+func (rdb *redisDB) DeleteNamespace(namespace string) (Change, error) {
+	conn := rdb.pool.Get()
+	defer conn.Close()
 
-	// Delete IDs
-	if len(rulesToDelete) > 0 {
-		args := make([]interface{}, len(rulesToDelete)+1)
-		args[0] = key
-		for i, rule := range rulesToDelete {
-			args[i+1] = rule.ID
-		}
-		logrus.Debug("HDEL ", args)
+	return parseChange(deleteNamespaceScript.Do(
+		conn,
+		buildRulesKey(namespace),
+		buildNamespaceKey(namespace, "revision"),
+		buildChangesKey(namespace),
+	))
+}
 
-		err = conn.Send("HDEL", args...)
-		if err != nil {
-			return err
-		}
+// parseChange parses the reply of a script returning the new revision of a namespace followed by the changed IDs.
+func parseChange(reply interface{}, err error) (Change, error) {
+	values, err := redis.Values(reply, err)
+	if err != nil {
+		return Change{}, err
 	}
-
-	// Add new rules
-	if len(entries) > 0 {
-		args := buildHMSetArgs(key, entries)
-
-		logrus.Debug("HMSET ", args)
-		err = conn.Send("HMSET", args...)
-		if err != nil {
-			return err
-		}
+	if len(values) == 0 {
+		return Change{}, errors.New("rules: unexpected empty reply")
 	}
 
-	// Execute transaction
-	_, err = redis.Values(conn.Do("EXEC"))
+	rev, err := redis.Int64(values[0], nil)
+	if err != nil {
+		return Change{}, err
+	}
 
+	ids, err := redis.Strings(values[1:], nil)
 	if err != nil {
-		// Nil return indicates that the transaction failed
-		if err == redis.ErrNil {
-			logrus.Error("Transaction failed due to conflict")
-		}
-		return err
+		return Change{}, err
 	}
 
-	_, err = redis.Int64(conn.Do("INCR", buildNamespaceKey(namespace, "revision"))) // FIXME: pipeline
+	return Change{
+		IDs:      ids,
+		Revision: rev,
+	}, nil
+}
 
+// quotaError converts the quotaExceededReply error of changeEntriesScript to a RuleCountQuotaError.
+func quotaError(err error, maxEntries int) error {
+	if redisErr, ok := err.(redis.Error); ok && strings.HasPrefix(string(redisErr), quotaExceededReply) {
+		return &RuleCountQuotaError{Limit: maxEntries}
+	}
 	return err
 }
 
-// encrypt
+// changeEntriesArgs returns the keys and arguments of changeEntriesScript for the changes to the namespace. Every key
+// the script accesses is passed explicitly.
+func (rdb *redisDB) changeEntriesArgs(namespace string, changes map[string]string, mustExist bool, maxEntries int) []interface{} {
+	args := make([]interface{}, 0, 6+len(changes)*2)
+	args = append(args,
+		buildRulesKey(namespace),
+		buildNamespaceKey(namespace, "revision"),
+		buildChangesKey(namespace),
+		HistorySize,
+		mustExist,
+		maxEntries,
+	)
+
+	for id, entry := range changes {
+		args = append(args, id, entry)
+	}
+
+	return args
+}
+
+// encrypt the entries. Empty entries, which denote deleted entries, are left empty.
 func (rdb *redisDB) encrypt(entries map[string]string) (map[string]string, error) {
 	// Short-circuit without encryption
 	if rdb.encryption == nil {
@@ -361,6 +618,11 @@ func (rdb *redisDB) encrypt(entries map[string]string) (map[string]string, error
 
 	encryptedMap := make(map[string]string)
 	for id, entry := range entries {
+		if entry == "" {
+			encryptedMap[id] = entry
+			continue
+		}
+
 		iv := rdb.encryption.NewIV()
 		payload, err := rdb.encryption.Encrypt(iv, []byte(entry))
 		if err != nil {
@@ -424,24 +686,15 @@ func (rdb *redisDB) decrypt(entries []string) ([]string, error) {
 	return decryptedEntries, nil
 }
 
-func buildHMSetArgs(key string, fieldMap map[string]string) []interface{} {
-	args := make([]interface{}, len(fieldMap)*2+1)
-	args[0] = key
-
-	i := 1
-	for id, entry := range fieldMap {
-		args[i] = id
-		args[i+1] = entry
-		i += 2
-	}
-
-	return args
-}
-
 func buildNamespaceKey(namespace, key string) string {
 	return fmt.Sprintf("controller:%v:%v", namespace, key)
 }
 
+// buildChangesKey returns the key of the hash mapping each recent revision of the namespace to its changes.
+func buildChangesKey(namespace string) string {
+	return buildNamespaceKey(namespace, "changes")
+}
+
 func buildRulesKey(namespace string) string {
 	return fmt.Sprintf("controller:%v:rules", namespace)
 }
@@ -20,23 +20,37 @@ import (
 	"encoding/json"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/amalgam8/amalgam8/pkg/health"
 	"github.com/pborman/uuid"
 )
 
 // NewRedisManager creates a Redis backed manager implementation.
+// The manager implements health.Checker, reporting the connectivity and latency of the database.
 func NewRedisManager(host, pass string, v Validator) Manager {
+	db := newRedisDB(host, pass)
 	return &redisManager{
 		validator: v,
-		db:        newRedisDB(host, pass),
+		db:        db,
+		health:    newDatabaseHealth(db),
 	}
 }
 
 type redisManager struct {
 	validator Validator
 	db        *redisDB
+	health    *databaseHealth
+}
+
+// Check reports the health of the database.
+func (r *redisManager) Check() health.Status {
+	return r.health.Check()
 }
 
 func (r *redisManager) AddRules(namespace string, rules []Rule) (NewRules, error) {
+	return r.addRulesLimited(namespace, rules, 0)
+}
+
+func (r *redisManager) addRulesLimited(namespace string, rules []Rule, maxRules int) (NewRules, error) {
 	if len(rules) == 0 {
 		return NewRules{}, errors.New("rules: no rules provided")
 	}
@@ -44,7 +58,7 @@ func (r *redisManager) AddRules(namespace string, rules []Rule) (NewRules, error
 	// Validate rules
 	for _, rule := range rules {
 		if err := r.validator.Validate(rule); err != nil {
-			return NewRules{}, &InvalidRuleError{}
+			return NewRules{}, &InvalidRuleError{Reason: err.Error()}
 		}
 	}
 
@@ -60,7 +74,10 @@ func (r *redisManager) AddRules(namespace string, rules []Rule) (NewRules, error
 		entries[id] = string(data)
 	}
 
-	if err := r.db.InsertEntries(namespace, entries); err != nil {
+	change, err := r.db.InsertEntries(namespace, entries, maxRules)
+	if _, ok := err.(*RuleCountQuotaError); ok {
+		return NewRules{}, err
+	} else if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"namespace": namespace,
 		}).Error("Error inserting entries in Redis")
@@ -74,7 +91,8 @@ func (r *redisManager) AddRules(namespace string, rules []Rule) (NewRules, error
 	}
 
 	return NewRules{
-		IDs: ids,
+		IDs:    ids,
+		Change: change,
 	}, nil
 }
 
@@ -125,7 +143,49 @@ func (r *redisManager) GetRules(namespace string, filter Filter) (RetrievedRules
 	}, nil
 }
 
+func (r *redisManager) GetRevision(namespace string, revision int64) (RetrievedRules, error) {
+	if revision == 0 {
+		return RetrievedRules{
+			Rules:    []Rule{},
+			Revision: revision,
+		}, nil
+	}
+
+	entries, err := r.db.ReadRevision(namespace, revision)
+	if err != nil {
+		if _, ok := err.(*RevisionNotFoundError); !ok {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"namespace": namespace,
+				"revision":  revision,
+			}).Error("Could not read revision from Redis")
+		}
+		return RetrievedRules{}, err
+	}
+
+	results := make([]Rule, len(entries))
+	for index, entry := range entries {
+		rule := Rule{}
+		if err = json.Unmarshal([]byte(entry), &rule); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"namespace": namespace,
+				"entry":     entry,
+			}).Error("Could not unmarshal object returned from Redis")
+			return RetrievedRules{}, &JSONMarshalError{Message: err.Error()}
+		}
+		results[index] = rule
+	}
+
+	return RetrievedRules{
+		Rules:    results,
+		Revision: revision,
+	}, nil
+}
+
 func (r *redisManager) SetRules(namespace string, filter Filter, rules []Rule) (NewRules, error) {
+	return r.setRulesLimited(namespace, filter, rules, 0)
+}
+
+func (r *redisManager) setRulesLimited(namespace string, filter Filter, rules []Rule, maxRules int) (NewRules, error) {
 	for i := range rules {
 		rules[i].ID = uuid.New()
 	}
@@ -133,11 +193,12 @@ func (r *redisManager) SetRules(namespace string, filter Filter, rules []Rule) (
 	// Validate rules
 	for _, rule := range rules {
 		if err := r.validator.Validate(rule); err != nil {
-			return NewRules{}, &InvalidRuleError{}
+			return NewRules{}, &InvalidRuleError{Reason: err.Error()}
 		}
 	}
 
-	if err := r.db.SetByDestination(namespace, filter, rules); err != nil {
+	change, err := r.db.SetByDestination(namespace, filter, rules, maxRules)
+	if err != nil {
 		return NewRules{}, err
 	}
 
@@ -148,19 +209,20 @@ func (r *redisManager) SetRules(namespace string, filter Filter, rules []Rule) (
 	}
 
 	return NewRules{
-		IDs: ids,
+		IDs:    ids,
+		Change: change,
 	}, nil
 }
 
-func (r *redisManager) UpdateRules(namespace string, rules []Rule) error {
+func (r *redisManager) UpdateRules(namespace string, rules []Rule) (Change, error) {
 	if len(rules) == 0 {
-		return errors.New("rules: no rules provided")
+		return Change{}, errors.New("rules: no rules provided")
 	}
 
 	// Validate rules
 	for _, rule := range rules {
 		if err := r.validator.Validate(rule); err != nil {
-			return &InvalidRuleError{}
+			return Change{}, &InvalidRuleError{Reason: err.Error()}
 		}
 	}
 
@@ -168,22 +230,106 @@ func (r *redisManager) UpdateRules(namespace string, rules []Rule) error {
 	for _, rule := range rules {
 		data, err := json.Marshal(&rule)
 		if err != nil {
-			return err
+			return Change{}, err
 		}
 
 		entries[rule.ID] = string(data)
 	}
 
-	if err := r.db.UpdateEntries(namespace, entries); err != nil {
+	change, err := r.db.UpdateEntries(namespace, entries)
+	if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"namespace": namespace,
 		}).Error("Error updating entries in Redis")
-		return err
+		return Change{}, err
 	}
 
-	return nil
+	return change, nil
+}
+
+func (r *redisManager) EnableRules(namespace string, filter Filter) (Change, error) {
+	return r.setDisabled(namespace, filter, false)
 }
 
-func (r *redisManager) DeleteRules(namespace string, filter Filter) error {
-	return r.db.SetByDestination(namespace, filter, []Rule{})
+func (r *redisManager) DisableRules(namespace string, filter Filter) (Change, error) {
+	return r.setDisabled(namespace, filter, true)
+}
+
+func (r *redisManager) setDisabled(namespace string, filter Filter, disabled bool) (Change, error) {
+	change, err := r.db.SetDisabled(namespace, filter, disabled)
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"namespace": namespace,
+			"disabled":  disabled,
+		}).Error("Error updating entries in Redis")
+		return Change{}, err
+	}
+
+	return change, nil
+}
+
+func (r *redisManager) DeleteRules(namespace string, filter Filter) (Change, error) {
+	return r.db.SetByDestination(namespace, filter, []Rule{}, 0)
+}
+
+func (r *redisManager) ListNamespaces() ([]NamespaceInfo, error) {
+	namespaces, err := r.db.ListNamespaces()
+	if err != nil {
+		logrus.WithError(err).Error("Could not scan namespaces in Redis")
+		return nil, err
+	}
+
+	infos := make([]NamespaceInfo, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		count, rev, err := r.db.CountEntries(namespace)
+		if err != nil {
+			logrus.WithError(err).WithField("namespace", namespace).Error("Could not count entries in Redis")
+			return nil, err
+		}
+
+		// The namespace may have been deleted since it was scanned
+		if count == 0 {
+			continue
+		}
+
+		infos = append(infos, NamespaceInfo{
+			Namespace: namespace,
+			Revision:  rev,
+			Rules:     count,
+		})
+	}
+
+	return infos, nil
+}
+
+func (r *redisManager) GetNamespace(namespace string) (NamespaceInfo, error) {
+	count, rev, err := r.db.CountEntries(namespace)
+	if err != nil {
+		logrus.WithError(err).WithField("namespace", namespace).Error("Could not count entries in Redis")
+		return NamespaceInfo{}, err
+	}
+
+	if rev == 0 {
+		return NamespaceInfo{}, &NamespaceNotFoundError{Namespace: namespace}
+	}
+
+	return NamespaceInfo{
+		Namespace: namespace,
+		Revision:  rev,
+		Rules:     count,
+	}, nil
+}
+
+func (r *redisManager) DeleteNamespace(namespace string) (Change, error) {
+	change, err := r.db.DeleteNamespace(namespace)
+	if err != nil {
+		logrus.WithError(err).WithField("namespace", namespace).Error("Could not delete namespace in Redis")
+		return Change{}, err
+	}
+
+	if change.Revision == 0 {
+		return Change{}, &NamespaceNotFoundError{Namespace: namespace}
+	}
+
+	return change, nil
 }
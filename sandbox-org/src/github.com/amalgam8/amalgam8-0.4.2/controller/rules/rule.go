@@ -18,11 +18,13 @@ import "encoding/json"
 
 // Rule represents an individual rule.
 type Rule struct {
-	ID          string          `json:"id"`
-	Priority    int             `json:"priority"`
-	Tags        []string        `json:"tags,omitempty"`
-	Destination string          `json:"destination"`
-	Match       json.RawMessage `json:"match,omitempty"`
-	Route       json.RawMessage `json:"route,omitempty"`
-	Actions     json.RawMessage `json:"actions,omitempty"`
+	ID          string            `json:"id"`
+	Priority    int               `json:"priority"`
+	Tags        []string          `json:"tags,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Destination string            `json:"destination"`
+	Match       json.RawMessage   `json:"match,omitempty"`
+	Route       json.RawMessage   `json:"route,omitempty"`
+	Actions     json.RawMessage   `json:"actions,omitempty"`
+	Disabled    bool              `json:"disabled,omitempty"`
 }
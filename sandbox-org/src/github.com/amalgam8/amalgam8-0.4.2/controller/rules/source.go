@@ -0,0 +1,120 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rules
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Source identifies the service instance that sends requests through a sidecar, i.e. the caller the rules of the
+// sidecar apply to.
+type Source struct {
+	Name string
+	Tags []string
+}
+
+// matchSource is the source condition of a match block.
+type matchSource struct {
+	Name *string  `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// matchBlock is a single condition of a match.
+type matchBlock struct {
+	Source *matchSource `json:"source"`
+}
+
+// ruleMatch is the match field of a rule, reduced to the parts that depend on the source.
+type ruleMatch struct {
+	Source  *matchSource    `json:"source"`
+	Headers json.RawMessage `json:"headers"`
+	All     []matchBlock    `json:"all"`
+	Any     []matchBlock    `json:"any"`
+	None    []matchBlock    `json:"none"`
+}
+
+// AppliesTo returns whether the match of the rule can apply to requests from the source. This mirrors the evaluation of
+// source conditions by the sidecar, which remains the authority on whether a rule applies: rules whose match cannot be
+// decoded are assumed to apply.
+func (r Rule) AppliesTo(source Source) bool {
+	if len(r.Match) == 0 || string(r.Match) == "null" {
+		return true
+	}
+
+	var match ruleMatch
+	if err := json.Unmarshal(r.Match, &match); err != nil {
+		return true
+	}
+
+	// A top level source or header condition is an additional condition that must hold
+	if match.Source != nil || len(match.Headers) > 0 {
+		if match.All == nil {
+			match.All = []matchBlock{}
+		}
+		match.All = append(match.All, matchBlock{Source: match.Source})
+	}
+
+	// The sidecar compares the source tags to its comma separated tags by substring
+	tags := strings.Join(source.Tags, ",")
+
+	allRes := matchBlocks(match.All, source.Name, tags, true)
+	anyRes := matchBlocks(match.Any, source.Name, tags, false)
+	noneRes := matchBlocks(match.None, source.Name, tags, false)
+
+	return ((allRes && match.All != nil) || (anyRes && match.Any != nil)) && !(noneRes && match.None != nil)
+}
+
+// matchBlocks evaluates the source conditions of the blocks of a match. When all is set, every block with a source
+// condition must match. Otherwise some block with a source condition must match: as in the sidecar, blocks without a
+// source condition do not count as a match.
+func matchBlocks(blocks []matchBlock, name, tags string, all bool) bool {
+	if blocks == nil {
+		return false
+	}
+
+	matched := false
+	for _, block := range blocks {
+		if block.Source == nil {
+			continue
+		}
+
+		found := block.Source.matches(name, tags)
+		if !found && all {
+			return false
+		}
+		matched = matched || found
+	}
+
+	return all || matched
+}
+
+// matches returns whether the source condition holds for the named service with the comma separated tags. A condition
+// naming a service without tags matches any instance of the service. A condition with tags requires the source to
+// have all the tags.
+func (s *matchSource) matches(name, tags string) bool {
+	tagsMatch := tags != ""
+	for _, tag := range s.Tags {
+		if !strings.Contains(tags, tag) {
+			tagsMatch = false
+			break
+		}
+	}
+
+	if s.Name != nil {
+		return *s.Name == name && (s.Tags == nil || tagsMatch)
+	}
+	return tagsMatch
+}
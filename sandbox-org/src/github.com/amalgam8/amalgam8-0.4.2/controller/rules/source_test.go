@@ -0,0 +1,84 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rules
+
+import "testing"
+
+func TestRuleAppliesTo(t *testing.T) {
+	reviews := Source{Name: "reviews", Tags: []string{"v1", "prod"}}
+	untagged := Source{Name: "reviews"}
+
+	cases := []struct {
+		match    string
+		source   Source
+		expected bool
+	}{
+		// Rules without a match apply to every source
+		{``, reviews, true},
+		{`null`, reviews, true},
+
+		// Top level source
+		{`{"source": {"name": "reviews"}}`, reviews, true},
+		{`{"source": {"name": "ratings"}}`, reviews, false},
+		{`{"source": {"name": "reviews", "tags": ["v1"]}}`, reviews, true},
+		{`{"source": {"name": "reviews", "tags": ["v2"]}}`, reviews, false},
+		{`{"source": {"name": "reviews", "tags": ["v1"]}}`, untagged, false},
+		{`{"source": {"tags": ["prod"]}}`, reviews, true},
+		{`{"source": {"tags": ["prod"]}}`, untagged, false},
+
+		// Header conditions do not depend on the source
+		{`{"headers": {"Cookie": "user=jason"}}`, reviews, true},
+
+		// Blocks of all must match, blocks of any may match, blocks of none must not match
+		{`{"all": [{"source": {"name": "reviews"}}, {"source": {"tags": ["v1"]}}]}`, reviews, true},
+		{`{"all": [{"source": {"name": "reviews"}}, {"source": {"tags": ["v2"]}}]}`, reviews, false},
+		{`{"any": [{"source": {"name": "ratings"}}, {"source": {"name": "reviews"}}]}`, reviews, true},
+		{`{"any": [{"source": {"name": "ratings"}}]}`, reviews, false},
+		{`{"any": [{"headers": {"Cookie": "user=jason"}}]}`, reviews, false},
+		{`{"source": {"name": "reviews"}, "none": [{"source": {"tags": ["prod"]}}]}`, reviews, false},
+		{`{"source": {"name": "reviews"}, "none": [{"source": {"tags": ["canary"]}}]}`, reviews, true},
+
+		// Blocks without a source do not count as a match, so header conditions of none do not exclude the source
+		{`{"source": {"name": "reviews"}, "none": [{"headers": {"Cookie": "user=jason"}}]}`, reviews, true},
+		{`{"source": {"name": "ratings"}, "none": [{"headers": {"Cookie": "user=jason"}}]}`, reviews, false},
+
+		// Undecodable matches are left to the sidecar
+		{`"invalid"`, reviews, true},
+	}
+
+	for _, c := range cases {
+		rule := Rule{Destination: "details", Match: []byte(c.match)}
+		if actual := rule.AppliesTo(c.source); actual != c.expected {
+			t.Errorf("match %s with source %+v: expected %v, got %v", c.match, c.source, c.expected, actual)
+		}
+	}
+}
+
+func TestFilterRulesBySource(t *testing.T) {
+	rules := []Rule{
+		{ID: "any", Destination: "details"},
+		{ID: "reviews", Destination: "details", Match: []byte(`{"source": {"name": "reviews"}}`)},
+		{ID: "ratings", Destination: "details", Match: []byte(`{"source": {"name": "ratings"}}`)},
+	}
+
+	filtered := FilterRules(Filter{Source: &Source{Name: "reviews"}}, rules)
+	if len(filtered) != 2 || filtered[0].ID != "any" || filtered[1].ID != "reviews" {
+		t.Errorf("expected rules any and reviews, got %+v", filtered)
+	}
+
+	if filtered := FilterRules(Filter{}, rules); len(filtered) != 3 {
+		t.Errorf("expected all rules without a source, got %+v", filtered)
+	}
+}
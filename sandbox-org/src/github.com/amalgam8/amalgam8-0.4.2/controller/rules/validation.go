@@ -15,10 +15,11 @@
 package rules
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/amalgam8/amalgam8/pkg/labels"
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -65,7 +66,12 @@ func (v *validator) Validate(rule Rule) error {
 			"descriptions": descriptions,
 		}).Warn("Invalid rule")
 
-		return errors.New("invalid rule")
+		return fmt.Errorf("invalid rule: %v", strings.Join(descriptions, "; "))
+	}
+
+	if err := labels.Validate(rule.Labels); err != nil {
+		logrus.WithError(err).Warn("Invalid rule labels")
+		return err
 	}
 
 	return nil
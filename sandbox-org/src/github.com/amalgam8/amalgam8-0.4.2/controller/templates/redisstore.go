@@ -0,0 +1,134 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// NewRedisStore creates a Redis backed store.
+func NewRedisStore(address, password string) Store {
+	pool := redis.NewPool(func() (redis.Conn, error) {
+		return redis.DialURL(
+			address,
+			redis.DialPassword(password),
+		)
+	}, 10)
+
+	return &redisStore{
+		pool: pool,
+	}
+}
+
+type redisStore struct {
+	pool *redis.Pool
+}
+
+func (r *redisStore) AddTemplate(namespace string, template Template) error {
+	data, err := json.Marshal(&template)
+	if err != nil {
+		return err
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	added, err := redis.Int(conn.Do("HSETNX", buildTemplatesKey(namespace), template.Name, string(data)))
+	if err != nil {
+		return err
+	}
+
+	if added == 0 {
+		return ErrExists
+	}
+
+	return nil
+}
+
+func (r *redisStore) SetTemplate(namespace string, template Template) error {
+	data, err := json.Marshal(&template)
+	if err != nil {
+		return err
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("HSET", buildTemplatesKey(namespace), template.Name, string(data))
+	return err
+}
+
+func (r *redisStore) GetTemplate(namespace, name string) (Template, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	entry, err := redis.String(conn.Do("HGET", buildTemplatesKey(namespace), name))
+	if err == redis.ErrNil {
+		return Template{}, ErrNotFound
+	} else if err != nil {
+		return Template{}, err
+	}
+
+	template := Template{}
+	if err := json.Unmarshal([]byte(entry), &template); err != nil {
+		return Template{}, err
+	}
+
+	return template, nil
+}
+
+func (r *redisStore) ListTemplates(namespace string) ([]Template, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	entries, err := redis.StringMap(conn.Do("HGETALL", buildTemplatesKey(namespace)))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]Template, 0, len(entries))
+	for _, entry := range entries {
+		template := Template{}
+		if err := json.Unmarshal([]byte(entry), &template); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+func (r *redisStore) DeleteTemplate(namespace, name string) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	deleted, err := redis.Int(conn.Do("HDEL", buildTemplatesKey(namespace), name))
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func buildTemplatesKey(namespace string) string {
+	return fmt.Sprintf("controller:%v:templates", namespace)
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package templates
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrNotFound is returned when a template does not exist in the namespace.
+	ErrNotFound = errors.New("templates: template not found")
+
+	// ErrExists is returned when adding a template whose name is already used in the namespace.
+	ErrExists = errors.New("templates: template already exists")
+)
+
+// Store is an interface for managing the templates of each namespace.
+type Store interface {
+	// AddTemplate adds the template to the namespace, unless a template with the same name exists.
+	AddTemplate(namespace string, template Template) error
+
+	// SetTemplate adds the template to the namespace, replacing any template with the same name.
+	SetTemplate(namespace string, template Template) error
+
+	// GetTemplate returns a template by name from the namespace.
+	GetTemplate(namespace, name string) (Template, error)
+
+	// ListTemplates returns the templates of the namespace.
+	ListTemplates(namespace string) ([]Template, error)
+
+	// DeleteTemplate deletes a template by name from the namespace.
+	DeleteTemplate(namespace, name string) error
+}
+
+// NewMemoryStore creates an in memory store.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		templates: make(map[string]map[string]Template),
+	}
+}
+
+type memoryStore struct {
+	templates map[string]map[string]Template
+	mutex     sync.Mutex
+}
+
+func (m *memoryStore) AddTemplate(namespace string, template Template) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.templates[namespace][template.Name]; exists {
+		return ErrExists
+	}
+	m.setTemplate(namespace, template)
+
+	return nil
+}
+
+func (m *memoryStore) SetTemplate(namespace string, template Template) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.setTemplate(namespace, template)
+
+	return nil
+}
+
+func (m *memoryStore) setTemplate(namespace string, template Template) {
+	if _, exists := m.templates[namespace]; !exists {
+		m.templates[namespace] = make(map[string]Template)
+	}
+	m.templates[namespace][template.Name] = template
+}
+
+func (m *memoryStore) GetTemplate(namespace, name string) (Template, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	template, exists := m.templates[namespace][name]
+	if !exists {
+		return Template{}, ErrNotFound
+	}
+
+	return template, nil
+}
+
+func (m *memoryStore) ListTemplates(namespace string) ([]Template, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	templates := make([]Template, 0, len(m.templates[namespace]))
+	for _, template := range m.templates[namespace] {
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+func (m *memoryStore) DeleteTemplate(namespace, name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.templates[namespace][name]; !exists {
+		return ErrNotFound
+	}
+	delete(m.templates[namespace], name)
+
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package templates manages reusable rule templates, which are instantiated into concrete rules by substituting
+// typed parameters.
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/amalgam8/amalgam8/controller/rules"
+)
+
+// Parameter types
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Template is a named list of rules containing parameter placeholders of the form "{{name}}". A string consisting of
+// a single placeholder is replaced by the value of the parameter, keeping its type. Placeholders within a longer
+// string are replaced by the text of the value.
+type Template struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  []Parameter     `json:"parameters"`
+	Rules       json.RawMessage `json:"rules"`
+}
+
+// Parameter is a typed parameter of a template. Parameters without a default value are required.
+type Parameter struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+// InvalidTemplateError occurs when a template is not valid
+type InvalidTemplateError struct {
+	Reason string
+}
+
+// Error description
+func (e *InvalidTemplateError) Error() string {
+	return fmt.Sprintf("Invalid template: %v", e.Reason)
+}
+
+// InvalidArgumentsError occurs when the arguments of a template instantiation do not match its parameters
+type InvalidArgumentsError struct {
+	Reason string
+}
+
+// Error description
+func (e *InvalidArgumentsError) Error() string {
+	return fmt.Sprintf("Invalid template arguments: %v", e.Reason)
+}
+
+var (
+	namePattern        = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+	paramNamePattern   = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	placeholderPattern = regexp.MustCompile(`{{\s*([A-Za-z0-9_]+)\s*}}`)
+)
+
+// Validate checks that the template is well formed: its parameters are uniquely named and typed, their defaults
+// are of their type, and its rules are a non-empty list that only refers to declared parameters. The rules
+// themselves are validated when the template is instantiated.
+func (t *Template) Validate() error {
+	if !namePattern.MatchString(t.Name) {
+		return &InvalidTemplateError{Reason: fmt.Sprintf("invalid name '%v'", t.Name)}
+	}
+
+	params := make(map[string]bool, len(t.Parameters))
+	for _, param := range t.Parameters {
+		if !paramNamePattern.MatchString(param.Name) {
+			return &InvalidTemplateError{Reason: fmt.Sprintf("invalid parameter name '%v'", param.Name)}
+		}
+		if params[param.Name] {
+			return &InvalidTemplateError{Reason: fmt.Sprintf("duplicate parameter '%v'", param.Name)}
+		}
+		params[param.Name] = true
+
+		switch param.Type {
+		case TypeString, TypeInteger, TypeNumber, TypeBoolean:
+		default:
+			return &InvalidTemplateError{Reason: fmt.Sprintf("parameter '%v' has unknown type '%v'", param.Name, param.Type)}
+		}
+
+		if param.Default != nil {
+			if _, err := param.convert(param.Default); err != nil {
+				return &InvalidTemplateError{Reason: fmt.Sprintf("default of parameter '%v' %v", param.Name, err)}
+			}
+		}
+	}
+
+	var body []interface{}
+	if err := json.Unmarshal(t.Rules, &body); err != nil || len(body) == 0 {
+		return &InvalidTemplateError{Reason: "rules must be a non-empty list"}
+	}
+
+	for _, name := range placeholders(body, nil) {
+		if !params[name] {
+			return &InvalidTemplateError{Reason: fmt.Sprintf("undeclared parameter '%v'", name)}
+		}
+	}
+
+	return nil
+}
+
+// Instantiate substitutes the arguments for the parameters of the template, and returns the resulting rules tagged
+// with the name of the template.
+func (t *Template) Instantiate(args map[string]interface{}) ([]rules.Rule, error) {
+	values := make(map[string]interface{}, len(t.Parameters))
+	for _, param := range t.Parameters {
+		arg, exists := args[param.Name]
+		if !exists || arg == nil {
+			if param.Default == nil {
+				return nil, &InvalidArgumentsError{Reason: fmt.Sprintf("missing parameter '%v'", param.Name)}
+			}
+			arg = param.Default
+		}
+
+		value, err := param.convert(arg)
+		if err != nil {
+			return nil, &InvalidArgumentsError{Reason: fmt.Sprintf("parameter '%v' %v", param.Name, err)}
+		}
+		values[param.Name] = value
+	}
+
+	for name := range args {
+		if _, exists := values[name]; !exists {
+			return nil, &InvalidArgumentsError{Reason: fmt.Sprintf("unknown parameter '%v'", name)}
+		}
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(t.Rules, &body); err != nil {
+		return nil, &InvalidTemplateError{Reason: "rules must be a non-empty list"}
+	}
+
+	data, err := json.Marshal(substitute(body, values))
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []rules.Rule
+	if err := json.Unmarshal(data, &instances); err != nil {
+		return nil, &InvalidArgumentsError{Reason: fmt.Sprintf("instantiated rules are malformed: %v", err)}
+	}
+
+	for i := range instances {
+		instances[i].ID = ""
+		instances[i].Tags = addTag(instances[i].Tags, t.Name)
+	}
+
+	return instances, nil
+}
+
+// convert checks that the value, as decoded from JSON, is of the type of the parameter.
+func (p *Parameter) convert(value interface{}) (interface{}, error) {
+	switch p.Type {
+	case TypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case TypeInteger:
+		if n, ok := value.(float64); ok && n == math.Trunc(n) {
+			return int64(n), nil
+		}
+	case TypeNumber:
+		if n, ok := value.(float64); ok {
+			return n, nil
+		}
+	case TypeBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("must be of type %v", p.Type)
+}
+
+// substitute replaces the placeholders in the strings of the decoded JSON value.
+func substitute(value interface{}, values map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if match := placeholderPattern.FindStringSubmatch(v); match != nil && match[0] == v {
+			return values[match[1]]
+		}
+		return placeholderPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			return format(values[placeholderPattern.FindStringSubmatch(placeholder)[1]])
+		})
+	case []interface{}:
+		for i := range v {
+			v[i] = substitute(v[i], values)
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = substitute(v[key], values)
+		}
+	}
+	return value
+}
+
+// placeholders appends the names of the parameters referred to in the strings of the decoded JSON value.
+func placeholders(value interface{}, names []string) []string {
+	switch v := value.(type) {
+	case string:
+		for _, match := range placeholderPattern.FindAllStringSubmatch(v, -1) {
+			names = append(names, match[1])
+		}
+	case []interface{}:
+		for _, elem := range v {
+			names = placeholders(elem, names)
+		}
+	case map[string]interface{}:
+		for _, elem := range v {
+			names = placeholders(elem, names)
+		}
+	}
+	return names
+}
+
+// format returns the text of a parameter value.
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func addTag(tags []string, tag string) []string {
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
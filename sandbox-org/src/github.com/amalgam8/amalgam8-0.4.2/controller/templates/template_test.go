@@ -0,0 +1,122 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package templates
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func delayTemplate() Template {
+	return Template{
+		Name: "delay",
+		Parameters: []Parameter{
+			{Name: "destination", Type: TypeString},
+			{Name: "user", Type: TypeString},
+			{Name: "duration", Type: TypeNumber, Default: 7.0},
+			{Name: "priority", Type: TypeInteger, Default: 10.0},
+		},
+		Rules: json.RawMessage(`[{
+			"destination": "{{destination}}",
+			"priority": "{{priority}}",
+			"match": {"headers": {"Cookie": ".*?user={{ user }}"}},
+			"actions": [{"action": "delay", "duration": "{{duration}}", "probability": 1}]
+		}]`),
+	}
+}
+
+func TestValidate(t *testing.T) {
+	template := delayTemplate()
+	if err := template.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := []func(*Template){
+		func(t *Template) { t.Name = "" },
+		func(t *Template) { t.Name = "a/b" },
+		func(t *Template) { t.Parameters[0].Type = "list" },
+		func(t *Template) { t.Parameters[1].Name = "destination" },
+		func(t *Template) { t.Parameters[2].Default = "7s" },
+		func(t *Template) { t.Parameters = t.Parameters[1:] },
+		func(t *Template) { t.Rules = json.RawMessage(`[]`) },
+		func(t *Template) { t.Rules = json.RawMessage(`{}`) },
+	}
+
+	for i, mutate := range invalid {
+		template := delayTemplate()
+		mutate(&template)
+		if _, ok := template.Validate().(*InvalidTemplateError); !ok {
+			t.Errorf("case %v: expected invalid template error", i)
+		}
+	}
+}
+
+func TestInstantiate(t *testing.T) {
+	template := delayTemplate()
+
+	instances, err := template.Instantiate(map[string]interface{}{
+		"destination": "ratings",
+		"user":        "jason",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(instances) != 1 {
+		t.Fatalf("expected a single rule, got %v", len(instances))
+	}
+
+	rule := instances[0]
+	if rule.Destination != "ratings" || rule.Priority != 10 {
+		t.Errorf("unexpected destination or priority: %+v", rule)
+	}
+	if !reflect.DeepEqual(rule.Tags, []string{"delay"}) {
+		t.Errorf("expected the rule to be tagged with the template name, got %v", rule.Tags)
+	}
+
+	var match, actions interface{}
+	json.Unmarshal(rule.Match, &match)
+	json.Unmarshal(rule.Actions, &actions)
+	expectedMatch := map[string]interface{}{"headers": map[string]interface{}{"Cookie": ".*?user=jason"}}
+	if !reflect.DeepEqual(match, expectedMatch) {
+		t.Errorf("expected match %v, got %v", expectedMatch, match)
+	}
+	expectedActions := []interface{}{
+		map[string]interface{}{"action": "delay", "duration": 7.0, "probability": 1.0},
+	}
+	if !reflect.DeepEqual(actions, expectedActions) {
+		t.Errorf("expected actions %v, got %v", expectedActions, actions)
+	}
+
+	// The template itself is not modified by instantiation
+	if _, err := template.Instantiate(map[string]interface{}{"destination": "reviews", "user": "x"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := []map[string]interface{}{
+		{"destination": "ratings"},
+		{"destination": "ratings", "user": 5.0},
+		{"destination": "ratings", "user": "jason", "priority": 1.5},
+		{"destination": "ratings", "user": "jason", "other": "x"},
+	}
+	for i, args := range invalid {
+		if _, err := template.Instantiate(args); err == nil {
+			t.Errorf("case %v: expected invalid arguments error", i)
+		} else if _, ok := err.(*InvalidArgumentsError); !ok {
+			t.Errorf("case %v: expected invalid arguments error, got %v", i, err)
+		}
+	}
+}
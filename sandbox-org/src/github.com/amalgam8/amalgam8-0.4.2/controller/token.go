@@ -0,0 +1,235 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/urfave/cli"
+
+	"github.com/amalgam8/amalgam8/pkg/auth"
+)
+
+const (
+	tokenNamespaceFlag  = "namespace"
+	tokenSecretFlag     = "jwt_secret"
+	tokenPrivateKeyFlag = "private_key"
+	tokenKeyIDFlag      = "kid"
+	tokenExpiryFlag     = "expiry"
+	tokenScopeFlag      = "scope"
+	tokenClaimFlag      = "claim"
+	tokenIssuerFlag     = "jwt_issuer"
+	tokenAudienceFlag   = "jwt_audience"
+	tokenKeySetFlag     = "jwt_key_set"
+)
+
+var tokenCommand = cli.Command{
+	Name:  "token",
+	Usage: "Mint and inspect JWT authentication tokens",
+	Subcommands: []cli.Command{
+		{
+			Name:   "mint",
+			Usage:  "Mint a token for a namespace, signed with a secret or a private key",
+			Action: mintToken,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  tokenNamespaceFlag,
+					Usage: "Namespace of the token",
+				},
+				cli.StringFlag{
+					Name:   tokenSecretFlag,
+					EnvVar: "A8_JWT_SECRET",
+					Usage:  "Secret key for signing the token with HS256",
+				},
+				cli.StringFlag{
+					Name:  tokenPrivateKeyFlag,
+					Usage: "Path of a PEM encoded RSA or EC private key for signing the token with RS256 or ES256",
+				},
+				cli.StringFlag{
+					Name:  tokenKeyIDFlag,
+					Usage: "Key ID of the private key in the key set",
+				},
+				cli.DurationFlag{
+					Name:  tokenExpiryFlag,
+					Value: 24 * time.Hour,
+					Usage: "Validity period of the token, or 0 for a token that does not expire",
+				},
+				cli.StringSliceFlag{
+					Name:  tokenScopeFlag,
+					Usage: "Scope granted to the token. If not specified, the token is granted all scopes explicitly",
+				},
+				cli.StringSliceFlag{
+					Name:  tokenClaimFlag,
+					Usage: "Additional claim of the token, in the form name=value. JSON values are decoded",
+				},
+				cli.StringFlag{
+					Name:   tokenIssuerFlag,
+					EnvVar: "A8_JWT_ISSUER",
+					Usage:  "Issuer claim of the token",
+				},
+				cli.StringFlag{
+					Name:   tokenAudienceFlag,
+					EnvVar: "A8_JWT_AUDIENCE",
+					Usage:  "Audience claim of the token",
+				},
+			},
+		},
+		{
+			Name:      "inspect",
+			Usage:     "Decode a token and verify it as the controller would",
+			ArgsUsage: "TOKEN",
+			Action:    inspectToken,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   tokenSecretFlag,
+					EnvVar: "A8_JWT_SECRET",
+					Usage:  "Secret key for JWT authentication",
+				},
+				cli.StringFlag{
+					Name:   tokenKeySetFlag,
+					EnvVar: "A8_JWT_KEY_SET",
+					Usage:  "URL or file path of a JSON Web Key Set for JWT authentication",
+				},
+				cli.StringFlag{
+					Name:   tokenIssuerFlag,
+					EnvVar: "A8_JWT_ISSUER",
+					Usage:  "Required issuer claim of JWT tokens",
+				},
+				cli.StringFlag{
+					Name:   tokenAudienceFlag,
+					EnvVar: "A8_JWT_AUDIENCE",
+					Usage:  "Required audience claim of JWT tokens",
+				},
+			},
+		},
+	},
+}
+
+func mintToken(context *cli.Context) error {
+	namespace := context.String(tokenNamespaceFlag)
+	if namespace == "" {
+		return cli.NewExitError("A namespace is required", 1)
+	}
+
+	var key interface{}
+	switch {
+	case context.String(tokenPrivateKeyFlag) != "":
+		data, err := ioutil.ReadFile(context.String(tokenPrivateKeyFlag))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Could not read private key: %v", err), 1)
+		}
+		if key, err = auth.ParsePrivateKeyPEM(data); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Could not parse private key: %v", err), 1)
+		}
+	case context.String(tokenSecretFlag) != "":
+		key = []byte(context.String(tokenSecretFlag))
+	default:
+		return cli.NewExitError("A secret key or a private key is required", 1)
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iat": now.Unix(),
+	}
+
+	for _, claim := range context.StringSlice(tokenClaimFlag) {
+		parts := strings.SplitN(claim, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return cli.NewExitError(fmt.Sprintf("Invalid claim '%s', expected name=value", claim), 1)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			value = parts[1]
+		}
+		claims[parts[0]] = value
+	}
+
+	claims[auth.NamespaceClaim] = namespace
+	if expiry := context.Duration(tokenExpiryFlag); expiry > 0 {
+		claims["exp"] = now.Add(expiry).Unix()
+	}
+	// Grant all scopes explicitly, so that the token keeps them where unscoped tokens are read-only
+	scopes := context.StringSlice(tokenScopeFlag)
+	if len(scopes) == 0 {
+		for _, scope := range auth.AllScopes {
+			scopes = append(scopes, string(scope))
+		}
+	}
+	claims[auth.ScopeClaim] = strings.Join(scopes, " ")
+	if issuer := context.String(tokenIssuerFlag); issuer != "" {
+		claims["iss"] = issuer
+	}
+	if audience := context.String(tokenAudienceFlag); audience != "" {
+		claims["aud"] = audience
+	}
+
+	token, err := auth.SignJWT(key, context.String(tokenKeyIDFlag), claims)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("Could not sign token: %v", err), 1)
+	}
+
+	fmt.Fprintln(context.App.Writer, token)
+	return nil
+}
+
+func inspectToken(context *cli.Context) error {
+	if context.NArg() != 1 {
+		return cli.NewExitError("A single token argument is required", 1)
+	}
+	token := context.Args().First()
+
+	// Decode the token without verifying it, to show its content even if it is rejected
+	decoded, err := jwt.Parse(token, nil)
+	if decoded == nil {
+		return cli.NewExitError(fmt.Sprintf("Token is malformed: %v", err), 1)
+	}
+
+	content, err := json.MarshalIndent(map[string]interface{}{
+		"header": decoded.Header,
+		"claims": decoded.Claims,
+	}, "", "  ")
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("Could not encode token: %v", err), 1)
+	}
+	fmt.Fprintln(context.App.Writer, string(content))
+
+	if exp, ok := decoded.Claims["exp"].(float64); ok {
+		fmt.Fprintf(context.App.Writer, "Expires: %v\n", time.Unix(int64(exp), 0))
+	}
+
+	conf := auth.JWTConfig{
+		Secret:   []byte(context.String(tokenSecretFlag)),
+		KeySet:   context.String(tokenKeySetFlag),
+		Issuer:   context.String(tokenIssuerFlag),
+		Audience: context.String(tokenAudienceFlag),
+	}
+	if len(conf.Secret) == 0 && conf.KeySet == "" {
+		fmt.Fprintln(context.App.Writer, "Token was not verified: no secret key or key set is configured")
+		return nil
+	}
+
+	if _, err := auth.VerifyJWT(conf, token); err != nil {
+		return cli.NewExitError(fmt.Sprintf("Token is rejected: %v", err), 1)
+	}
+
+	fmt.Fprintf(context.App.Writer, "Token is valid for namespace %v\n", decoded.Claims[auth.NamespaceClaim])
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+// runToken runs the token command with the arguments, returning its output, error output and exit code
+func runToken(args ...string) (string, string, int) {
+	var out, errOut bytes.Buffer
+	exitCode := 0
+
+	osExiter, errWriter := cli.OsExiter, cli.ErrWriter
+	cli.OsExiter = func(code int) { exitCode = code }
+	cli.ErrWriter = &errOut
+	defer func() {
+		cli.OsExiter, cli.ErrWriter = osExiter, errWriter
+	}()
+
+	app := cli.NewApp()
+	app.Writer = &out
+	app.Commands = []cli.Command{tokenCommand}
+	app.Run(append([]string{"controller", "token"}, args...))
+
+	return strings.TrimSpace(out.String()), errOut.String(), exitCode
+}
+
+// writeECKey writes a PEM encoded private key and a key set with its public key to the directory
+func writeECKey(t *testing.T, dir, kid string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, kid+".pem")
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	keySet, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "EC",
+			"kid": kid,
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+	keySetPath := filepath.Join(dir, kid+".json")
+	require.NoError(t, ioutil.WriteFile(keySetPath, keySet, 0600))
+
+	return keyPath, keySetPath
+}
+
+func TestTokenMintInspectSecret(t *testing.T) {
+	token, _, code := runToken("mint", "--namespace", "ns1", "--jwt_secret", "secret",
+		"--scope", "rules:read", "--claim", "team=blue", "--jwt_issuer", "issuer1")
+	require.Equal(t, 0, code)
+	require.NotEmpty(t, token)
+
+	out, _, code := runToken("inspect", "--jwt_secret", "secret", "--jwt_issuer", "issuer1", token)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, `"namespace": "ns1"`)
+	assert.Contains(t, out, `"scope": "rules:read"`)
+	assert.Contains(t, out, `"team": "blue"`)
+	assert.Contains(t, out, "Expires:")
+	assert.Contains(t, out, "Token is valid for namespace ns1")
+
+	// Tokens are granted all scopes explicitly unless restricted
+	token, _, code = runToken("mint", "--namespace", "ns1", "--jwt_secret", "secret")
+	require.Equal(t, 0, code)
+	out, _, code = runToken("inspect", "--jwt_secret", "secret", token)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, `"scope": "rules:read rules:write registry:register registry:discover"`)
+
+	// Not verified without a key
+	out, _, code = runToken("inspect", token)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "Token was not verified")
+}
+
+func TestTokenMintInspectPrivateKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyPath, keySetPath := writeECKey(t, dir, "key1")
+
+	token, _, code := runToken("mint", "--namespace", "ns1", "--private_key", keyPath, "--kid", "key1")
+	require.Equal(t, 0, code)
+
+	out, _, code := runToken("inspect", "--jwt_key_set", keySetPath, token)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, `"alg": "ES256"`)
+	assert.Contains(t, out, `"kid": "key1"`)
+	assert.Contains(t, out, "Token is valid for namespace ns1")
+}
+
+func TestTokenBadKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyPath, keySetPath := writeECKey(t, dir, "key1")
+	_, otherKeySetPath := writeECKey(t, dir, "key2")
+
+	invalidKeyPath := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, ioutil.WriteFile(invalidKeyPath, []byte("not a key"), 0600))
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"mint", "--jwt_secret", "secret"}, "A namespace is required"},
+		{[]string{"mint", "--namespace", "ns1"}, "A secret key or a private key is required"},
+		{[]string{"mint", "--namespace", "ns1", "--private_key", filepath.Join(dir, "missing.pem")}, "Could not read private key"},
+		{[]string{"mint", "--namespace", "ns1", "--private_key", invalidKeyPath}, "Could not parse private key"},
+		{[]string{"mint", "--namespace", "ns1", "--jwt_secret", "secret", "--claim", "team"}, "Invalid claim 'team'"},
+		{[]string{"inspect"}, "A single token argument is required"},
+		{[]string{"inspect", "not-a-jwt"}, "Token is malformed"},
+	}
+
+	for _, tc := range cases {
+		_, errOut, code := runToken(tc.args...)
+		assert.Equal(t, 1, code, "%v", tc.args)
+		assert.Contains(t, errOut, tc.expected, "%v", tc.args)
+	}
+
+	// Tokens verified with the wrong keys
+	token, _, code := runToken("mint", "--namespace", "ns1", "--jwt_secret", "secret")
+	require.Equal(t, 0, code)
+	_, errOut, code := runToken("inspect", "--jwt_secret", "other", token)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "Token is rejected")
+
+	token, _, code = runToken("mint", "--namespace", "ns1", "--private_key", keyPath, "--kid", "key1")
+	require.Equal(t, 0, code)
+	_, errOut, code = runToken("inspect", "--jwt_key_set", otherKeySetPath, token)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "unknown key ID 'key1'")
+
+	_, errOut, code = runToken("inspect", "--jwt_key_set", filepath.Join(dir, "missing.json"), token)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "Token is rejected")
+
+	// A token signed with a private key is not verified with a secret
+	_, errOut, code = runToken("inspect", "--jwt_secret", "secret", token)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "Token is rejected")
+
+	_, _, code = runToken("inspect", "--jwt_key_set", keySetPath, token)
+	assert.Equal(t, 0, code)
+}
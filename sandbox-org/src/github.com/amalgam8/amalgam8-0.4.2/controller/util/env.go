@@ -16,6 +16,9 @@ package util
 
 // Keys of rest.Request.Env used by rest.Middleware.
 const (
-	Namespace = "NAMESPACE"
-	Context   = "CONTEXT"
+	Namespace       = "NAMESPACE"
+	SourceNamespace = "SOURCE_NAMESPACE"
+	Admin           = "ADMIN"
+	Principal       = "PRINCIPAL"
+	Context         = "CONTEXT"
 )
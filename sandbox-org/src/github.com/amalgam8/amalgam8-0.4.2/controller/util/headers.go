@@ -16,7 +16,8 @@ package util
 
 // Header keys
 const (
-	AuthHeader      = "Authorization"
-	NamespaceHeader = "A8-Namespace"
-	RequestIDHeader = "A8-Request-Id"
+	AuthHeader       = "Authorization"
+	SourceAuthHeader = "A8-Source-Authorization"
+	NamespaceHeader  = "A8-Namespace"
+	RequestIDHeader  = "A8-Request-Id"
 )
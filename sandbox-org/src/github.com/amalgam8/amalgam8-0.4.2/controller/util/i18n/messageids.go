@@ -19,11 +19,39 @@ const (
 	ErrorInvalidJSON     = "error_invalid_json"
 	ErrorInvalidRule     = "error_invalid_rule"
 	ErrorNoRulesProvided = "error_no_rules_provided"
+	ErrorInvalidSelector = "error_invalid_selector"
+
+	ErrorMissingSourceNamespace = "error_missing_source_namespace"
+	ErrorSameSourceNamespace    = "error_same_source_namespace"
+	ErrorInvalidPromotionMode   = "error_invalid_promotion_mode"
+	ErrorReplaceFilterRequired  = "error_replace_filter_required"
+
+	ErrorMissingRevision  = "error_missing_revision"
+	ErrorInvalidRevision  = "error_invalid_revision"
+	ErrorRevisionNotFound = "error_revision_not_found"
+
+	ErrorRuleQuotaExceeded = "error_rule_quota_exceeded"
+	ErrorRuleTooLarge      = "error_rule_too_large"
+	ErrorRateLimitExceeded = "error_rate_limit_exceeded"
+
+	ErrorNamespaceNotFound = "error_namespace_not_found"
+
+	ErrorInvalidTemplate          = "error_invalid_template"
+	ErrorInvalidTemplateArguments = "error_invalid_template_arguments"
+	ErrorTemplateNotFound         = "error_template_not_found"
+	ErrorTemplateExists           = "error_template_exists"
+
+	ErrorInvalidWebhookURL   = "error_invalid_webhook_url"
+	ErrorForbiddenWebhookURL = "error_forbidden_webhook_url"
+	ErrorWebhookNotFound     = "error_webhook_not_found"
 
 	ErrorAuthorizationMissingHeader         = "error_auth_header_missing"
 	ErrorAuthorizationMalformedHeader       = "error_auth_header_malformed"
 	ErrorAuthorizationTokenValidationFailed = "error_auth_failed_validation"
 	ErrorAuthorizationNotAuthorized         = "error_auth_not_authorized"
+	ErrorAuthorizationInsufficientScope     = "error_auth_insufficient_scope"
+	ErrorNamespaceNotAuthorized             = "error_namespace_not_authorized"
+	ErrorAuthorizationAdminRequired         = "error_auth_admin_required"
 
 	ErrorInternalServer = "error_internal"
 )
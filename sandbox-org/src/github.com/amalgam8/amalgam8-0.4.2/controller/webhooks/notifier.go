@@ -0,0 +1,229 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultBackoff     = time.Second
+	defaultTimeout     = 10 * time.Second
+)
+
+// Notifier delivers events to the webhooks subscribed to their namespace.
+type Notifier interface {
+	// Notify asynchronously delivers the event to every webhook of the event's namespace.
+	Notify(event Event)
+}
+
+// Config stores the configurable attributes of the notifier.
+type Config struct {
+	// Store of the webhook subscriptions.
+	Store Store
+
+	// MaxAttempts is the number of delivery attempts made to a webhook before the event is dead-lettered.
+	// If left zero, a default is used.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry. The delay doubles with each subsequent retry.
+	// If left zero, a default is used.
+	Backoff time.Duration
+
+	// URLPolicy restricts the hosts the events are posted to, when using the default HTTP client.
+	URLPolicy URLPolicy
+
+	// HTTPClient is used to post the events.
+	// If left nil, a default HTTP client enforcing the URL policy will be used.
+	HTTPClient *http.Client
+
+	// DeadLetter receives a JSON line for each event that could not be delivered to a webhook.
+	// If left nil, undelivered events are logged.
+	DeadLetter io.Writer
+}
+
+// DeadLetter records an event that could not be delivered to a webhook.
+type DeadLetter struct {
+	WebhookID string    `json:"webhook_id"`
+	URL       string    `json:"url"`
+	Event     Event     `json:"event"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewNotifier creates a notifier.
+func NewNotifier(conf Config) Notifier {
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = defaultMaxAttempts
+	}
+
+	if conf.Backoff <= 0 {
+		conf.Backoff = defaultBackoff
+	}
+
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Dial:                conf.URLPolicy.Dial,
+				TLSHandshakeTimeout: defaultTimeout,
+			},
+			CheckRedirect: conf.URLPolicy.CheckRedirect,
+			Timeout:       defaultTimeout,
+		}
+	}
+
+	return &notifier{
+		store:       conf.Store,
+		maxAttempts: conf.MaxAttempts,
+		backoff:     conf.Backoff,
+		httpClient:  conf.HTTPClient,
+		deadLetter:  conf.DeadLetter,
+	}
+}
+
+type notifier struct {
+	store       Store
+	maxAttempts int
+	backoff     time.Duration
+	httpClient  *http.Client
+
+	deadLetter      io.Writer
+	deadLetterMutex sync.Mutex
+}
+
+func (n *notifier) Notify(event Event) {
+	go n.dispatch(event)
+}
+
+func (n *notifier) dispatch(event Event) {
+	webhooks, err := n.store.ListWebhooks(event.Namespace)
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"namespace": event.Namespace,
+			"event_id":  event.ID,
+		}).Error("Could not list webhooks")
+		return
+	}
+
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(&event)
+	if err != nil {
+		logrus.WithError(err).WithField("event_id", event.ID).Error("Could not marshal event")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		go n.deliver(webhook, event, payload)
+	}
+}
+
+// deliver posts the payload to the webhook, retrying with exponential backoff until it is accepted or the attempts
+// are exhausted.
+func (n *notifier) deliver(webhook Webhook, event Event, payload []byte) {
+	var err error
+
+	backoff := n.backoff
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		if err = n.post(webhook, payload); err == nil {
+			logrus.WithFields(logrus.Fields{
+				"webhook_id": webhook.ID,
+				"event_id":   event.ID,
+				"attempt":    attempt,
+			}).Debug("Delivered event to webhook")
+			return
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"webhook_id": webhook.ID,
+			"event_id":   event.ID,
+			"attempt":    attempt,
+		}).Warn("Failed to deliver event to webhook")
+
+		if attempt < n.maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	n.reportDeadLetter(DeadLetter{
+		WebhookID: webhook.ID,
+		URL:       webhook.URL,
+		Event:     event,
+		Attempts:  n.maxAttempts,
+		Error:     err.Error(),
+		Timestamp: time.Now(),
+	})
+}
+
+func (n *notifier) post(webhook Webhook, payload []byte) error {
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, payload))
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhooks: received unexpected response code %v", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (n *notifier) reportDeadLetter(deadLetter DeadLetter) {
+	if n.deadLetter == nil {
+		logrus.WithFields(logrus.Fields{
+			"webhook_id": deadLetter.WebhookID,
+			"url":        deadLetter.URL,
+			"event":      deadLetter.Event,
+			"attempts":   deadLetter.Attempts,
+			"error":      deadLetter.Error,
+		}).Error("Giving up delivering event to webhook")
+		return
+	}
+
+	data, err := json.Marshal(&deadLetter)
+	if err != nil {
+		logrus.WithError(err).Error("Could not marshal dead letter")
+		return
+	}
+
+	n.deadLetterMutex.Lock()
+	defer n.deadLetterMutex.Unlock()
+
+	if _, err := n.deadLetter.Write(append(data, '\n')); err != nil {
+		logrus.WithError(err).Error("Could not write dead letter")
+	}
+}
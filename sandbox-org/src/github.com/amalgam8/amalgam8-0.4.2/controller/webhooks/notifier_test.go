@@ -0,0 +1,148 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	buf   bytes.Buffer
+	mutex sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Bytes()
+}
+
+func TestNotifyDeliversSignedEvent(t *testing.T) {
+	received := make(chan Event, 1)
+	failures := 2
+
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign("secret", body) {
+			t.Errorf("unexpected signature %v", r.Header.Get(SignatureHeader))
+		}
+
+		event := Event{}
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("could not unmarshal event: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	store.AddWebhook("ns1", Webhook{ID: "hook1", URL: server.URL, Secret: "secret"})
+
+	n := NewNotifier(Config{
+		Store:       store,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		URLPolicy:   URLPolicy{AllowInternal: true},
+	})
+
+	n.Notify(Event{
+		ID:          "event1",
+		Type:        RulesChanged,
+		Namespace:   "ns1",
+		OldRevision: 1,
+		NewRevision: 2,
+		RuleIDs:     []string{"rule1"},
+		Principal:   "ns1",
+	})
+
+	select {
+	case event := <-received:
+		if event.ID != "event1" || event.NewRevision != 2 || len(event.RuleIDs) != 1 {
+			t.Errorf("unexpected event %v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+}
+
+func TestNotifyDeadLetter(t *testing.T) {
+	var mutex sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		attempts++
+		mutex.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	store.AddWebhook("ns1", Webhook{ID: "hook1", URL: server.URL})
+	store.AddWebhook("ns2", Webhook{ID: "hook2", URL: server.URL})
+
+	deadLetters := &syncBuffer{}
+	n := NewNotifier(Config{
+		Store:       store,
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+		DeadLetter:  deadLetters,
+		URLPolicy:   URLPolicy{AllowInternal: true},
+	})
+
+	n.Notify(Event{ID: "event1", Namespace: "ns1"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(deadLetters.Bytes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	deadLetter := DeadLetter{}
+	if err := json.Unmarshal(deadLetters.Bytes(), &deadLetter); err != nil {
+		t.Fatalf("could not unmarshal dead letter: %v", err)
+	}
+
+	if deadLetter.WebhookID != "hook1" || deadLetter.Event.ID != "event1" || deadLetter.Attempts != 2 {
+		t.Errorf("unexpected dead letter %v", deadLetter)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %v", attempts)
+	}
+}
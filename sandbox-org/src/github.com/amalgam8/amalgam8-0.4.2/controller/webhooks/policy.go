@@ -0,0 +1,197 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrInvalidURL is returned for webhook URLs that are not absolute HTTP or HTTPS URLs.
+	ErrInvalidURL = errors.New("webhooks: invalid URL")
+
+	// ErrForbiddenURL is returned for webhook URLs whose host is not allowed by the URL policy.
+	ErrForbiddenURL = errors.New("webhooks: forbidden URL")
+)
+
+// metadataHosts are the names of cloud metadata services
+var metadataHosts = []string{
+	"metadata",
+	"metadata.google.internal",
+}
+
+// metadataIPs are the addresses of cloud metadata services that are not link-local
+var metadataIPs = []net.IP{
+	net.ParseIP("fd00:ec2::254"),
+	net.ParseIP("100.100.100.200"),
+}
+
+// privateNetworks are the private (RFC 1918), shared (RFC 6598) and unique local (RFC 4193) address ranges
+var privateNetworks = parseCIDRs(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+	"fc00::/7",
+)
+
+// URLPolicy restricts the URLs webhooks may be subscribed with, so that the controller cannot be used to reach
+// endpoints that are otherwise inaccessible, such as services on its loopback interface or the cloud metadata service.
+type URLPolicy struct {
+	// AllowedHosts are the hosts webhooks may be subscribed with. A "*." prefix matches any subdomain.
+	// If empty, any host is allowed.
+	AllowedHosts []string
+
+	// AllowInternal allows hosts with loopback, link-local, unspecified, private network or metadata service
+	// addresses, which are forbidden otherwise.
+	AllowInternal bool
+}
+
+// Validate checks that the URL is an absolute HTTP or HTTPS URL, whose host is allowed.
+// A host name is checked against the addresses it currently resolves to, if any.
+func (p URLPolicy) Validate(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+
+	host := hostname(u)
+	if err := p.checkHost(host); err != nil {
+		return err
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		// Unresolvable hosts are checked again when the events are delivered
+		return nil
+	}
+	return p.checkIPs(host, ips)
+}
+
+// Dial connects to the address if it resolves only to allowed addresses. It is used for delivering the events,
+// so that a host name cannot be resolved to a forbidden address after the webhook was validated.
+func (p URLPolicy) Dial(network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkIPs(host, ips); err != nil {
+		return nil, err
+	}
+
+	// Connect to the checked address rather than resolving the host again
+	dialer := &net.Dialer{Timeout: defaultTimeout}
+	return dialer.Dial(network, net.JoinHostPort(ips[0].String(), port))
+}
+
+// CheckRedirect checks the host redirected to, for use as the CheckRedirect function of an HTTP client.
+func (p URLPolicy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return p.checkHost(hostname(req.URL))
+}
+
+func (p URLPolicy) checkHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if len(p.AllowedHosts) > 0 && !p.allowedHost(host) {
+		return fmt.Errorf("%v: host %s is not allowed", ErrForbiddenURL, host)
+	}
+
+	if !p.AllowInternal {
+		for _, metadataHost := range metadataHosts {
+			if host == metadataHost {
+				return fmt.Errorf("%v: host %s is a metadata service", ErrForbiddenURL, host)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p URLPolicy) allowedHost(host string) bool {
+	for _, allowed := range p.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p URLPolicy) checkIPs(host string, ips []net.IP) error {
+	if p.AllowInternal {
+		return nil
+	}
+
+	for _, ip := range ips {
+		if internalIP(ip) {
+			return fmt.Errorf("%v: host %s resolves to internal address %s", ErrForbiddenURL, host, ip)
+		}
+	}
+	return nil
+}
+
+// internalIP returns whether the address is a loopback, link-local, unspecified, private network or metadata service
+// address
+func internalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	for _, metadataIP := range metadataIPs {
+		if ip.Equal(metadataIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostname returns the host of the URL without its port, and without brackets for IPv6 addresses
+func hostname(u *url.URL) string {
+	if host, _, err := net.SplitHostPort(u.Host); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(u.Host, "["), "]")
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
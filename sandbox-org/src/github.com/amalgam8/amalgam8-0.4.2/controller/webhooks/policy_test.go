@@ -0,0 +1,101 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestURLPolicyValidate(t *testing.T) {
+	cases := []struct {
+		policy    URLPolicy
+		url       string
+		invalid   bool
+		forbidden bool
+	}{
+		{URLPolicy{}, "http://203.0.113.1:8080/hook", false, false},
+		{URLPolicy{}, "https://203.0.113.1/hook", false, false},
+		{URLPolicy{}, "http://[2001:db8::1]:8080/hook", false, false},
+		{URLPolicy{}, "ftp://203.0.113.1/hook", true, false},
+		{URLPolicy{}, "file:///etc/passwd", true, false},
+		{URLPolicy{}, "/hook", true, false},
+		{URLPolicy{}, "http://127.0.0.1:6379/", false, true},
+		{URLPolicy{}, "http://[::1]/", false, true},
+		{URLPolicy{}, "http://[::1]:8080/", false, true},
+		{URLPolicy{}, "http://10.0.0.1:8080/hook", false, true},
+		{URLPolicy{}, "http://172.16.5.4/hook", false, true},
+		{URLPolicy{}, "http://172.31.255.255/hook", false, true},
+		{URLPolicy{}, "http://172.32.0.1/hook", false, false},
+		{URLPolicy{}, "http://192.168.1.1/hook", false, true},
+		{URLPolicy{}, "http://100.64.0.1/hook", false, true},
+		{URLPolicy{}, "http://[fd12:3456::1]/hook", false, true},
+		{URLPolicy{}, "http://0.0.0.0/", false, true},
+		{URLPolicy{}, "http://169.254.169.254/latest/meta-data/", false, true},
+		{URLPolicy{}, "http://[fe80::1]/", false, true},
+		{URLPolicy{}, "http://metadata.google.internal/", false, true},
+		{URLPolicy{AllowInternal: true}, "http://127.0.0.1:6379/", false, false},
+		{URLPolicy{AllowInternal: true}, "http://10.0.0.1:8080/hook", false, false},
+		{URLPolicy{AllowedHosts: []string{"203.0.113.1", "*.example.org"}}, "http://203.0.113.1/hook", false, false},
+		{URLPolicy{AllowedHosts: []string{"203.0.113.1", "*.example.org"}}, "http://203.0.113.2/hook", false, true},
+		{URLPolicy{AllowedHosts: []string{"203.0.113.1", "*.example.org"}}, "http://example.org.evil.com/", false, true},
+		{URLPolicy{AllowedHosts: []string{"10.0.0.1"}}, "http://10.0.0.1/", false, true},
+		{URLPolicy{AllowedHosts: []string{"10.0.0.1"}, AllowInternal: true}, "http://10.0.0.1/", false, false},
+		{URLPolicy{AllowedHosts: []string{"127.0.0.1"}}, "http://127.0.0.1/", false, true},
+	}
+
+	for _, tc := range cases {
+		err := tc.policy.Validate(tc.url)
+		switch {
+		case tc.invalid:
+			if err != ErrInvalidURL {
+				t.Errorf("Expected %s to be invalid, got %v", tc.url, err)
+			}
+		case tc.forbidden:
+			if err == nil || !strings.HasPrefix(err.Error(), ErrForbiddenURL.Error()) {
+				t.Errorf("Expected %s to be forbidden, got %v", tc.url, err)
+			}
+		default:
+			if err != nil {
+				t.Errorf("Expected %s to be allowed, got %v", tc.url, err)
+			}
+		}
+	}
+}
+
+func TestNotifierRejectsInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Event delivered to a loopback address")
+	}))
+	defer server.Close()
+
+	n := NewNotifier(Config{}).(*notifier)
+	err := n.post(Webhook{URL: server.URL}, []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), ErrForbiddenURL.Error()) {
+		t.Errorf("Expected delivery to a loopback address to be forbidden, got %v", err)
+	}
+
+	n = NewNotifier(Config{URLPolicy: URLPolicy{AllowInternal: true}}).(*notifier)
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusFound))
+	defer redirect.Close()
+
+	n.httpClient.CheckRedirect = URLPolicy{AllowedHosts: []string{"localhost"}}.CheckRedirect
+	err = n.post(Webhook{URL: redirect.URL}, []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), ErrForbiddenURL.Error()) {
+		t.Errorf("Expected redirection to a host that is not allowed to be forbidden, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// NewRedisStore creates a Redis backed store.
+func NewRedisStore(address, password string) Store {
+	pool := redis.NewPool(func() (redis.Conn, error) {
+		return redis.DialURL(
+			address,
+			redis.DialPassword(password),
+		)
+	}, 10)
+
+	return &redisStore{
+		pool: pool,
+	}
+}
+
+type redisStore struct {
+	pool *redis.Pool
+}
+
+func (r *redisStore) AddWebhook(namespace string, webhook Webhook) error {
+	data, err := json.Marshal(&webhook)
+	if err != nil {
+		return err
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("HSET", buildWebhooksKey(namespace), webhook.ID, string(data))
+	return err
+}
+
+func (r *redisStore) ListWebhooks(namespace string) ([]Webhook, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	entries, err := redis.StringMap(conn.Do("HGETALL", buildWebhooksKey(namespace)))
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]Webhook, 0, len(entries))
+	for _, entry := range entries {
+		webhook := Webhook{}
+		if err := json.Unmarshal([]byte(entry), &webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (r *redisStore) DeleteWebhook(namespace, id string) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	deleted, err := redis.Int(conn.Do("HDEL", buildWebhooksKey(namespace), id))
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func buildWebhooksKey(namespace string) string {
+	return fmt.Sprintf("controller:%v:webhooks", namespace)
+}
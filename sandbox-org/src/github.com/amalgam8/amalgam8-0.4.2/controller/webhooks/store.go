@@ -0,0 +1,83 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package webhooks
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned when a webhook does not exist in the namespace.
+var ErrNotFound = errors.New("webhooks: webhook not found")
+
+// Store is an interface for managing the webhooks of each namespace.
+type Store interface {
+	// AddWebhook adds the webhook to the namespace. The webhook must have an ID.
+	AddWebhook(namespace string, webhook Webhook) error
+
+	// ListWebhooks returns the webhooks of the namespace.
+	ListWebhooks(namespace string) ([]Webhook, error)
+
+	// DeleteWebhook deletes a webhook by ID from the namespace.
+	DeleteWebhook(namespace, id string) error
+}
+
+// NewMemoryStore creates an in memory store.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		webhooks: make(map[string]map[string]Webhook),
+	}
+}
+
+type memoryStore struct {
+	webhooks map[string]map[string]Webhook
+	mutex    sync.Mutex
+}
+
+func (m *memoryStore) AddWebhook(namespace string, webhook Webhook) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.webhooks[namespace]; !exists {
+		m.webhooks[namespace] = make(map[string]Webhook)
+	}
+	m.webhooks[namespace][webhook.ID] = webhook
+
+	return nil
+}
+
+func (m *memoryStore) ListWebhooks(namespace string) ([]Webhook, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	webhooks := make([]Webhook, 0, len(m.webhooks[namespace]))
+	for _, webhook := range m.webhooks[namespace] {
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (m *memoryStore) DeleteWebhook(namespace, id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.webhooks[namespace][id]; !exists {
+		return ErrNotFound
+	}
+	delete(m.webhooks[namespace], id)
+
+	return nil
+}
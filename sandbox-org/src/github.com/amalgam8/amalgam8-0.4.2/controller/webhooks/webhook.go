@@ -0,0 +1,57 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package webhooks notifies subscribed HTTP endpoints of changes to the rules of a namespace.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Event types
+const (
+	RulesChanged = "rules_changed"
+)
+
+// SignatureHeader is the header carrying the HMAC-SHA256 signature of the event payload, keyed by the webhook secret.
+const SignatureHeader = "A8-Signature"
+
+// Webhook is a subscription of an HTTP endpoint to the events of a namespace.
+type Webhook struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// Event is the payload posted to webhooks.
+type Event struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Namespace   string    `json:"namespace"`
+	OldRevision int64     `json:"old_revision"`
+	NewRevision int64     `json:"new_revision"`
+	RuleIDs     []string  `json:"rule_ids"`
+	Principal   string    `json:"principal"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Sign returns the signature of the payload for the secret, as sent in the SignatureHeader.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
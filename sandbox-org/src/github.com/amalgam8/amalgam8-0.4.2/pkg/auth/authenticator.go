@@ -22,6 +22,9 @@ const (
 
 	// ContextHeadersKey is the key in the context for the headers passed to the Authenticators from the auth middleware
 	ContextHeadersKey = "headers"
+
+	// ContextTLSKey is the key in the context for the *tls.ConnectionState of the request, if it was received over TLS
+	ContextTLSKey = "tls"
 )
 
 // Authenticator is an interface for token authentication
@@ -16,8 +16,11 @@ package auth
 
 import (
 	"fmt"
+	"io"
 
 	"context"
+
+	"github.com/amalgam8/amalgam8/pkg/health"
 )
 
 // NewChainAuthenticator creates and initializes a new chain authenticator, wrapping the given authenticators.
@@ -42,17 +45,57 @@ type chainAuthenticator struct {
 	authenticators []Authenticator
 }
 
+// Check reports the first unhealthy status of the registered authenticators which report their health, if any.
+func (r *chainAuthenticator) Check() health.Status {
+	for _, a := range r.authenticators {
+		if checker, ok := a.(health.Checker); ok {
+			if status := checker.Check(); !status.Healthy {
+				return status
+			}
+		}
+	}
+	return health.Healthy
+}
+
+// Close closes the registered authenticators which hold resources, such as periodically reloaded keys.
+func (r *chainAuthenticator) Close() error {
+	var result error
+	for _, a := range r.authenticators {
+		if closer, ok := a.(io.Closer); ok {
+			if err := closer.Close(); err != nil && result == nil {
+				result = err
+			}
+		}
+	}
+	return result
+}
+
 // Authenticate verifies the specified token with the registered authenticators.
 // The function returns the Namespace of this token or an error if the token is not valid
 func (r *chainAuthenticator) Authenticate(ctx context.Context, token string) (*Namespace, error) {
+	namespace, _, err := r.AuthenticateScopes(ctx, token)
+	return namespace, err
+}
+
+// AuthenticateScopes verifies the specified token with the registered authenticators.
+// The function returns the Namespace and granted Scopes of this token or an error if the token is not valid.
+// Authenticators that do not recognize the token, or that require a token when none is specified, are skipped, so that
+// the request may be authenticated by other means, such as a client certificate.
+func (r *chainAuthenticator) AuthenticateScopes(ctx context.Context, token string) (*Namespace, Scopes, error) {
+	result := ErrUnauthorized
+
 	// Scan the list of authenticators in order
 	for _, a := range r.authenticators {
-		namespace, err := a.Authenticate(ctx, token)
-		if err == ErrUnrecognizedToken {
+		namespace, scopes, err := AuthenticateScopes(ctx, a, token)
+		switch err {
+		case ErrUnrecognizedToken:
+			continue
+		case ErrEmptyToken:
+			result = ErrEmptyToken
 			continue
 		}
-		return namespace, err
+		return namespace, scopes, err
 	}
 
-	return nil, ErrUnauthorized
+	return nil, nil, result
 }
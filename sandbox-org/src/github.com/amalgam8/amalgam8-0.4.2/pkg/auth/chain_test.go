@@ -98,6 +98,20 @@ func TestMultipleAuthenticatorChain(t *testing.T) {
 	ns, err = ca.Authenticate(ctx, "token")
 	assert.NoError(t, err)
 	assert.Equal(t, namespace2, *ns)
+
+	// Case 4 - first authenticator requires a token, the second authorizes without one
+	ma1.err = ErrEmptyToken
+	ma2.err = nil
+	ns, err = ca.Authenticate(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, namespace2, *ns)
+
+	// Case 5 - first authenticator requires a token, the second does not recognize the request
+	ma1.err = ErrEmptyToken
+	ma2.err = ErrUnrecognizedToken
+	ns, err = ca.Authenticate(ctx, "")
+	assert.Equal(t, ErrEmptyToken, err)
+	assert.Nil(t, ns)
 }
 
 type mockAuthenticator struct {
@@ -0,0 +1,165 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+// Sources of the namespace of a client certificate
+const (
+	// IdentityFromSubject takes the namespace from the subject's organization.
+	IdentityFromSubject = "subject"
+
+	// IdentityFromURI takes the namespace from a SAN URI with a path of the form "/ns/<namespace>",
+	// e.g. "spiffe://cluster.local/ns/ns1/sa/reviews".
+	IdentityFromURI = "uri"
+)
+
+// oidSubjectAltName is the object identifier of the subject alternative name extension.
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// sanURITag is the context-specific tag of the uniformResourceIdentifier choice of a GeneralName.
+const sanURITag = 6
+
+// CertificateNamespace derives the namespace of a client certificate from the given source.
+func CertificateNamespace(cert *x509.Certificate, source string) (Namespace, error) {
+	switch source {
+	case IdentityFromSubject:
+		if len(cert.Subject.Organization) == 0 || cert.Subject.Organization[0] == "" {
+			return "", fmt.Errorf("certificate subject has no organization")
+		}
+		return Namespace(cert.Subject.Organization[0]), nil
+	case IdentityFromURI:
+		uris, err := certificateURIs(cert)
+		if err != nil {
+			return "", err
+		}
+		for _, uri := range uris {
+			segments := strings.Split(strings.Trim(uri.Path, "/"), "/")
+			if len(segments) >= 2 && segments[0] == "ns" && segments[1] != "" {
+				return Namespace(segments[1]), nil
+			}
+		}
+		return "", fmt.Errorf("certificate has no SAN URI identifying a namespace")
+	default:
+		return "", fmt.Errorf("unrecognized client certificate identity source '%s'", source)
+	}
+}
+
+// certificateURIs parses the URIs of the certificate's subject alternative name extension.
+// The x509 package parses them only as of Go 1.10.
+func certificateURIs(cert *x509.Certificate) ([]*url.URL, error) {
+	var uris []*url.URL
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+
+		var seq asn1.RawValue
+		if rest, err := asn1.Unmarshal(ext.Value, &seq); err != nil || len(rest) != 0 {
+			return nil, fmt.Errorf("malformed subject alternative name extension")
+		}
+		if !seq.IsCompound || seq.Tag != asn1.TagSequence || seq.Class != asn1.ClassUniversal {
+			return nil, fmt.Errorf("malformed subject alternative name extension")
+		}
+
+		rest := seq.Bytes
+		for len(rest) > 0 {
+			var name asn1.RawValue
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &name); err != nil {
+				return nil, fmt.Errorf("malformed subject alternative name extension")
+			}
+			if name.Class != asn1.ClassContextSpecific || name.Tag != sanURITag {
+				continue
+			}
+
+			uri, err := url.Parse(string(name.Bytes))
+			if err != nil {
+				return nil, fmt.Errorf("malformed SAN URI '%s'", name.Bytes)
+			}
+			uris = append(uris, uri)
+		}
+	}
+	return uris, nil
+}
+
+type clientCertAuthenticator struct {
+	source string
+}
+
+// NewClientCertAuthenticator creates an authenticator deriving the namespace from the verified client certificate of
+// the request's TLS connection, which is passed in the context by the auth middleware.
+// Requests without a verified client certificate are unrecognized, so that other authenticators in a chain may
+// verify their token.
+func NewClientCertAuthenticator(source string) (Authenticator, error) {
+	if source != IdentityFromSubject && source != IdentityFromURI {
+		return nil, fmt.Errorf("unrecognized client certificate identity source '%s'", source)
+	}
+	return &clientCertAuthenticator{source: source}, nil
+}
+
+func (aut *clientCertAuthenticator) Authenticate(ctx context.Context, token string) (*Namespace, error) {
+	state, ok := ctx.Value(ContextTLSKey).(*tls.ConnectionState)
+	if !ok || state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, ErrUnrecognizedToken
+	}
+
+	namespace, err := CertificateNamespace(state.VerifiedChains[0][0], aut.source)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	return &namespace, nil
+}
+
+// NewServerTLSConfig creates the TLS configuration of an API server from PEM encoded files.
+// If a client CA file is given, client certificates are requested and verified against it.
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		data, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in client CA file '%s'", clientCAFile)
+		}
+
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return conf, nil
+}
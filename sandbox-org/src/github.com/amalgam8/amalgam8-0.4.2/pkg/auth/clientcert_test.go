@@ -0,0 +1,222 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) writePEM(t *testing.T, dir, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600))
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
+}
+
+// sanExtension encodes a subject alternative name extension with the URIs and the DNS names
+func sanExtension(t *testing.T, uris []string, dnsNames []string) pkix.Extension {
+	var names []asn1.RawValue
+	for _, name := range dnsNames {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, Bytes: []byte(name)})
+	}
+	for _, uri := range uris {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte(uri)})
+	}
+	value, err := asn1.Marshal(names)
+	require.NoError(t, err)
+	return pkix.Extension{Id: oidSubjectAltName, Value: value}
+}
+
+func TestCertificateNamespace(t *testing.T) {
+	cert := newTestCert(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "reviews", Organization: []string{"ns2"}},
+		ExtraExtensions: []pkix.Extension{sanExtension(t,
+			[]string{"https://example.com/", "spiffe://cluster.local/ns/ns1/sa/reviews"}, []string{"reviews.local"})},
+	}, nil).cert
+
+	namespace, err := CertificateNamespace(cert, IdentityFromSubject)
+	assert.NoError(t, err)
+	assert.Equal(t, Namespace("ns2"), namespace)
+
+	namespace, err = CertificateNamespace(cert, IdentityFromURI)
+	assert.NoError(t, err)
+	assert.Equal(t, Namespace("ns1"), namespace)
+
+	other := newTestCert(t, &x509.Certificate{
+		ExtraExtensions: []pkix.Extension{sanExtension(t, []string{"https://example.com/"}, nil)},
+	}, nil).cert
+	_, err = CertificateNamespace(other, IdentityFromURI)
+	assert.Error(t, err)
+
+	_, err = CertificateNamespace(&x509.Certificate{Subject: pkix.Name{CommonName: "reviews"}}, IdentityFromSubject)
+	assert.Error(t, err)
+
+	_, err = CertificateNamespace(&x509.Certificate{}, IdentityFromURI)
+	assert.Error(t, err)
+
+	_, err = NewClientCertAuthenticator("unknown")
+	assert.Error(t, err)
+}
+
+func TestClientCertAuthenticator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	server := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	client := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "reviews", Organization: []string{"ns1"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	caFile, _ := ca.writePEM(t, dir, "ca")
+	serverCert, serverKey := server.writePEM(t, dir, "server")
+
+	tlsConfig, err := NewServerTLSConfig(serverCert, serverKey, caFile)
+	require.NoError(t, err)
+
+	certAuth, err := NewClientCertAuthenticator(IdentityFromSubject)
+	require.NoError(t, err)
+	aut, err := NewChainAuthenticator([]Authenticator{certAuth, NewTrustedAuthenticator()})
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(context.Background(), ContextTLSKey, r.TLS)
+		namespace, err := aut.Authenticate(ctx, r.Header.Get("Authorization"))
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(namespace.String()))
+	}))
+	ts.TLS = tlsConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	request := func(certs []tls.Certificate, token string) (int, string) {
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		}}}
+		req, _ := http.NewRequest("GET", ts.URL, nil)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	clientCert := tls.Certificate{Certificate: [][]byte{client.der}, PrivateKey: client.key}
+
+	// The namespace is taken from the client certificate
+	code, body := request([]tls.Certificate{clientCert}, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ns1", body)
+
+	// Without a client certificate, the next authenticator of the chain verifies the token
+	code, body = request(nil, "ns2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ns2", body)
+
+	code, _ = request(nil, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// An authenticator requiring a token is skipped when there is none, even if it comes first in the chain
+	jwtAuth, err := NewJWTAuthenticator([]byte("secret"))
+	require.NoError(t, err)
+	aut, err = NewChainAuthenticator([]Authenticator{jwtAuth, certAuth})
+	require.NoError(t, err)
+
+	code, body = request([]tls.Certificate{clientCert}, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ns1", body)
+
+	token := signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), map[string]interface{}{NamespaceClaim: "ns2"})
+	code, body = request(nil, token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ns2", body)
+
+	code, _ = request(nil, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,350 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/amalgam8/amalgam8/pkg/health"
+)
+
+const (
+	defaultKeySetTimeout = 10 * time.Second
+
+	// minKeySetReload is the minimal delay between on-demand reloads triggered by tokens signed with an unknown key.
+	minKeySetReload = 30 * time.Second
+
+	// staleKeySetRefreshes is the number of refresh intervals after which keys that failed to reload are stale.
+	staleKeySetRefreshes = 3
+)
+
+// PublicKey is a verification key of a key set.
+type PublicKey struct {
+	// ID of the key, matched against the "kid" header of the tokens.
+	ID string
+
+	// Algorithm the key is restricted to. Empty if the key may be used with any algorithm of its type.
+	Algorithm string
+
+	// Key is either an *rsa.PublicKey or an *ecdsa.PublicKey.
+	Key interface{}
+}
+
+// jsonWebKey is the JSON representation of a key, as defined by RFC 7517.
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+
+	// RSA parameters
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC parameters
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+// ParseKeySet parses a JSON Web Key Set document. Keys which are not signature verification keys, or whose type is
+// not supported, are ignored.
+func ParseKeySet(data []byte) ([]PublicKey, error) {
+	doc := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid key set: %v", err)
+	}
+
+	keys := make([]PublicKey, 0, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var key interface{}
+		var err error
+		switch jwk.KeyType {
+		case "RSA":
+			key, err = parseRSAKey(jwk)
+		case "EC":
+			key, err = parseECKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s': %v", jwk.KeyID, err)
+		}
+
+		keys = append(keys, PublicKey{ID: jwk.KeyID, Algorithm: jwk.Algorithm, Key: key})
+	}
+
+	return keys, nil
+}
+
+func parseRSAKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+	if e.BitLen() > 31 || e.Int64() < 3 {
+		return nil, fmt.Errorf("invalid RSA exponent")
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func parseECKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve '%s'", jwk.Curve)
+	}
+
+	x, err := decodeBigInt(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(jwk.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point is not on curve '%s'", jwk.Curve)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("missing key parameter")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// KeySet is a set of verification keys loaded from a JSON Web Key Set document.
+// The document is reloaded periodically, so keys can be rotated by publishing the new key alongside the old one
+// until all tokens signed by the old key have expired.
+type KeySet struct {
+	source     string
+	httpClient *http.Client
+
+	keys     []PublicKey
+	loaded   time.Time
+	lastErr  error
+	interval time.Duration
+	mutex    sync.RWMutex
+
+	// lastAttempt is the time of the last on-demand reload, which is pending until its reloading channel is closed
+	lastAttempt time.Time
+	reloading   chan struct{}
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewKeySet creates a key set from the document at the given source, which is either an HTTP(S) URL or a file path.
+// If refresh is positive, the document is reloaded at that interval until the key set is closed. The initial load
+// must succeed, while failures of subsequent reloads are logged and the previous keys are retained.
+func NewKeySet(source string, refresh time.Duration) (*KeySet, error) {
+	ks := &KeySet{
+		source: source,
+		httpClient: &http.Client{
+			Timeout: defaultKeySetTimeout,
+		},
+		interval:    refresh,
+		stop:        make(chan struct{}),
+		lastAttempt: time.Now(),
+	}
+
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+
+	if refresh > 0 {
+		go ks.refresh(refresh)
+	}
+
+	return ks, nil
+}
+
+// Close stops the periodic reloading of the key set.
+func (ks *KeySet) Close() {
+	ks.closeOnce.Do(func() {
+		close(ks.stop)
+	})
+}
+
+// Reload loads the key set document from its source, replacing the current keys.
+func (ks *KeySet) Reload() error {
+	keys, err := ks.load()
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	ks.lastErr = err
+	if err != nil {
+		return err
+	}
+	ks.keys = keys
+	ks.loaded = time.Now()
+	return nil
+}
+
+// Check reports the age of the keys and the failure of the last reload, if any. The key set is unhealthy once keys
+// which failed to reload become stale, as tokens signed by newly rotated keys are then rejected.
+func (ks *KeySet) Check() health.Status {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	age := time.Since(ks.loaded)
+	properties := map[string]interface{}{
+		"key_set_age_s": int64(age.Seconds()),
+	}
+	if ks.lastErr == nil {
+		return health.StatusHealthyWithProperties(properties)
+	}
+
+	properties["cause"] = ks.lastErr.Error()
+	if ks.interval > 0 && age > staleKeySetRefreshes*ks.interval {
+		properties["message"] = "JWT key set is stale"
+		return health.StatusUnhealthyWithProperties(properties)
+	}
+	return health.StatusHealthyWithProperties(properties)
+}
+
+func (ks *KeySet) load() ([]PublicKey, error) {
+	data, err := ks.read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key set from '%s': %v", ks.source, err)
+	}
+
+	return ParseKeySet(data)
+}
+
+// Keys returns the keys of the set which match the key ID.
+// If the key ID is empty, all keys of the set are returned.
+func (ks *KeySet) Keys(kid string) []PublicKey {
+	keys := ks.lookup(kid)
+	if len(keys) == 0 && kid != "" {
+		// The token may be signed by a newly published key
+		ks.reloadOnDemand()
+		keys = ks.lookup(kid)
+	}
+	return keys
+}
+
+func (ks *KeySet) lookup(kid string) []PublicKey {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	keys := make([]PublicKey, 0, 1)
+	for _, key := range ks.keys {
+		if kid == "" || key.ID == kid {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// reloadOnDemand reloads the key set, unless it was attempted less than minKeySetReload ago, whether it failed or not.
+// Callers during a pending reload wait for it rather than reloading again.
+func (ks *KeySet) reloadOnDemand() {
+	ks.mutex.Lock()
+	if reloading := ks.reloading; reloading != nil {
+		ks.mutex.Unlock()
+		<-reloading
+		return
+	}
+	if time.Since(ks.lastAttempt) < minKeySetReload {
+		ks.mutex.Unlock()
+		return
+	}
+	reloading := make(chan struct{})
+	ks.reloading = reloading
+	ks.lastAttempt = time.Now()
+	ks.mutex.Unlock()
+
+	if err := ks.Reload(); err != nil {
+		logrus.WithError(err).Warn("Failed to reload JWT key set")
+	}
+
+	ks.mutex.Lock()
+	ks.reloading = nil
+	ks.mutex.Unlock()
+	close(reloading)
+}
+
+func (ks *KeySet) refresh(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := ks.Reload(); err != nil {
+				logrus.WithError(err).Warn("Failed to refresh JWT key set")
+			}
+		case <-ks.stop:
+			return
+		}
+	}
+}
+
+func (ks *KeySet) read() ([]byte, error) {
+	if !strings.HasPrefix(ks.source, "http://") && !strings.HasPrefix(ks.source, "https://") {
+		return ioutil.ReadFile(ks.source)
+	}
+
+	resp, err := ks.httpClient.Get(ks.source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received unexpected response code %v", resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
@@ -16,52 +16,184 @@ package auth
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
+
+	"github.com/amalgam8/amalgam8/pkg/health"
 )
 
 // JWT related constants
 const (
 	SigningAlgorithm = "HS256"
 	NamespaceClaim   = "namespace"
+	ScopeClaim       = "scope"
 )
 
+// JWTConfig stores the configurable attributes of the JWT authenticator.
+type JWTConfig struct {
+	// Secret is the key of HS256 signed tokens. If left empty, HS256 signed tokens are rejected.
+	Secret []byte
+
+	// KeySet is an HTTP(S) URL or a file path of a JSON Web Key Set with the public keys of RS256 and ES256
+	// signed tokens. If left empty, asymmetrically signed tokens are rejected.
+	KeySet string
+
+	// KeySetRefresh is the interval at which the key set is reloaded. If left zero, the key set is loaded once.
+	KeySetRefresh time.Duration
+
+	// Issuer, if set, must match the "iss" claim of the tokens.
+	Issuer string
+
+	// Audience, if set, must be contained in the "aud" claim of the tokens.
+	Audience string
+
+	// UnscopedReadOnly grants only the ReadOnlyScopes to tokens without a scope claim. If false, such tokens,
+	// including those issued before scopes were supported, are granted all scopes.
+	UnscopedReadOnly bool
+}
+
 type jwtAuthenticator struct {
-	key []byte
+	key      []byte
+	keySet   *KeySet
+	issuer   string
+	audience string
+	unscoped Scopes
 }
 
 // NewJWTAuthenticator creates a new Json-Web-Token authenticator based on the provided configuration options.
 // Returns a valid Authenticator interface on success or an error on failure
 func NewJWTAuthenticator(key []byte) (Authenticator, error) {
-	if key == nil || len(key) == 0 {
-		return nil, errors.New("Secret key is required")
+	return NewJWTAuthenticatorFromConfig(JWTConfig{Secret: key})
+}
+
+// NewJWTAuthenticatorFromConfig creates a new Json-Web-Token authenticator verifying tokens signed either by the
+// secret or by a key of the key set.
+func NewJWTAuthenticatorFromConfig(conf JWTConfig) (Authenticator, error) {
+	if len(conf.Secret) == 0 && conf.KeySet == "" {
+		return nil, errors.New("Secret key or key set is required")
+	}
+
+	aut := &jwtAuthenticator{
+		key:      conf.Secret,
+		issuer:   conf.Issuer,
+		audience: conf.Audience,
+		unscoped: AllScopes,
+	}
+	if conf.UnscopedReadOnly {
+		aut.unscoped = ReadOnlyScopes
+	}
+
+	if conf.KeySet != "" {
+		keySet, err := NewKeySet(conf.KeySet, conf.KeySetRefresh)
+		if err != nil {
+			return nil, err
+		}
+		aut.keySet = keySet
+	}
+
+	return aut, nil
+}
+
+// Check reports the health of the key set, if any.
+func (aut *jwtAuthenticator) Check() health.Status {
+	if aut.keySet == nil {
+		return health.Healthy
+	}
+	return aut.keySet.Check()
+}
+
+// Close stops the periodic reloading of the key set, if any.
+func (aut *jwtAuthenticator) Close() error {
+	if aut.keySet != nil {
+		aut.keySet.Close()
 	}
-	return &jwtAuthenticator{key: key}, nil
+	return nil
 }
 
 func (aut *jwtAuthenticator) Authenticate(ctx context.Context, token string) (*Namespace, error) {
+	namespace, _, err := aut.AuthenticateScopes(ctx, token)
+	return namespace, err
+}
+
+// AuthenticateScopes verifies the token, returning its namespace and the scopes of its scope claim.
+// Tokens without a scope claim are granted all scopes, or the read-only scopes if so configured.
+func (aut *jwtAuthenticator) AuthenticateScopes(ctx context.Context, token string) (*Namespace, Scopes, error) {
 	if token == "" {
-		return nil, ErrEmptyToken
+		return nil, nil, ErrEmptyToken
 	}
 
-	t, err := aut.parseToken(token)
+	namespace, scopes, _, err := aut.verify(token)
 	if err != nil {
 		if ve, ok := err.(*jwt.ValidationError); ok {
 			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
-				return nil, ErrUnrecognizedToken
+				return nil, nil, ErrUnrecognizedToken
 			}
 		}
-		return nil, ErrUnauthorized
+		return nil, nil, ErrUnauthorized
+	}
+
+	return namespace, scopes, nil
+}
+
+// verify parses and validates the token, returning its namespace, scopes and claims.
+// On failure, the returned error describes why the token is rejected.
+func (aut *jwtAuthenticator) verify(token string) (*Namespace, Scopes, map[string]interface{}, error) {
+	t, err := aut.parseToken(token)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := aut.checkClaims(t.Claims); err != nil {
+		return nil, nil, t.Claims, err
+	}
+
+	claim, ok := t.Claims[NamespaceClaim].(string)
+	if !ok || claim == "" {
+		return nil, nil, t.Claims, fmt.Errorf("missing %s claim", NamespaceClaim)
+	}
+
+	scopes := aut.unscoped
+	if scopeClaim, exists := t.Claims[ScopeClaim]; exists {
+		if scopes, ok = ParseScopes(scopeClaim); !ok {
+			return nil, nil, t.Claims, fmt.Errorf("malformed %s claim", ScopeClaim)
+		}
+	}
+
+	namespace := Namespace(claim)
+	return &namespace, scopes, t.Claims, nil
+}
+
+// checkClaims checks the issuer and audience claims. The expiration and not-before claims are verified by the parser.
+func (aut *jwtAuthenticator) checkClaims(claims map[string]interface{}) error {
+	if aut.issuer != "" {
+		if iss, ok := claims["iss"].(string); !ok || iss != aut.issuer {
+			return fmt.Errorf("issuer claim does not match '%s'", aut.issuer)
+		}
 	}
 
-	claim, exists := t.Claims[NamespaceClaim]
-	if !exists || claim.(string) == "" {
-		return nil, ErrUnauthorized
+	if aut.audience != "" {
+		found := false
+		switch aud := claims["aud"].(type) {
+		case string:
+			found = aud == aut.audience
+		case []interface{}:
+			for _, a := range aud {
+				if a == aut.audience {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("audience claim does not contain '%s'", aut.audience)
+		}
 	}
 
-	namespace := Namespace(claim.(string))
-	return &namespace, nil
+	return nil
 }
 
 func (aut *jwtAuthenticator) parseToken(token string) (*jwt.Token, error) {
@@ -70,9 +202,130 @@ func (aut *jwtAuthenticator) parseToken(token string) (*jwt.Token, error) {
 	}
 
 	return jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if jwt.GetSigningMethod(SigningAlgorithm) != token.Method {
-			return nil, ErrUnauthorized
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if jwt.GetSigningMethod(SigningAlgorithm) != token.Method {
+				return nil, fmt.Errorf("unsupported signing algorithm '%s'", token.Method.Alg())
+			}
+			if len(aut.key) == 0 {
+				return nil, fmt.Errorf("no secret key is configured for %s signed tokens", SigningAlgorithm)
+			}
+			return aut.key, nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+			return aut.publicKey(token)
+		default:
+			return nil, fmt.Errorf("unsupported signing algorithm '%s'", token.Method.Alg())
 		}
-		return aut.key, nil
 	})
 }
+
+// publicKey selects the key set key for verifying the token, by the token's "kid" header.
+// A token without a key ID is accepted only if the set has a single key usable with the token's algorithm.
+func (aut *jwtAuthenticator) publicKey(token *jwt.Token) (interface{}, error) {
+	if aut.keySet == nil {
+		return nil, fmt.Errorf("no key set is configured for %s signed tokens", token.Method.Alg())
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	alg := token.Method.Alg()
+
+	keys := aut.keySet.Keys(kid)
+	var candidates []interface{}
+	for _, key := range keys {
+		if key.Algorithm != "" && key.Algorithm != alg {
+			continue
+		}
+
+		switch key.Key.(type) {
+		case *rsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+				candidates = append(candidates, key.Key)
+			}
+		case *ecdsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+				candidates = append(candidates, key.Key)
+			}
+		}
+	}
+
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) > 1 && kid == "":
+		return nil, fmt.Errorf("token has no key ID and the key set has several %s keys", alg)
+	case len(candidates) > 1:
+		return nil, fmt.Errorf("the key set has several %s keys with ID '%s'", alg, kid)
+	case kid == "":
+		return nil, fmt.Errorf("no %s key in the key set", alg)
+	case len(keys) == 0:
+		return nil, fmt.Errorf("unknown key ID '%s'", kid)
+	default:
+		return nil, fmt.Errorf("key '%s' is not usable with %s", kid, alg)
+	}
+}
+
+// VerifyJWT verifies the token as the authenticator created from the configuration would, returning its claims.
+// On failure, the returned error describes why the token is rejected, and the claims are returned if the token
+// could be parsed.
+func VerifyJWT(conf JWTConfig, token string) (map[string]interface{}, error) {
+	// The key set is loaded once for verifying a single token
+	conf.KeySetRefresh = 0
+	aut, err := NewJWTAuthenticatorFromConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == "" {
+		return nil, ErrEmptyToken
+	}
+
+	_, _, claims, err := aut.(*jwtAuthenticator).verify(token)
+	return claims, err
+}
+
+// SignJWT creates a token with the given claims, signed by the key. The signing algorithm is HS256 for a []byte
+// secret, RS256 for an *rsa.PrivateKey, and ES256, ES384 or ES512 for an *ecdsa.PrivateKey according to its curve.
+// If kid is not empty, it is set as the token's key ID header.
+func SignJWT(key interface{}, kid string, claims map[string]interface{}) (string, error) {
+	var method jwt.SigningMethod
+	switch k := key.(type) {
+	case []byte:
+		method = jwt.GetSigningMethod(SigningAlgorithm)
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch k.Curve.Params().BitSize {
+		case 256:
+			method = jwt.SigningMethodES256
+		case 384:
+			method = jwt.SigningMethodES384
+		case 521:
+			method = jwt.SigningMethodES512
+		default:
+			return "", fmt.Errorf("unsupported elliptic curve %s", k.Curve.Params().Name)
+		}
+	default:
+		return "", fmt.Errorf("unsupported signing key type %T", key)
+	}
+
+	token := jwt.New(method)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	for name, value := range claims {
+		token.Claims[name] = value
+	}
+
+	return token.SignedString(key)
+}
+
+// ParsePrivateKeyPEM parses a PEM encoded RSA or EC private key for signing tokens.
+func ParsePrivateKeyPEM(data []byte) (interface{}, error) {
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("not a PEM encoded RSA or EC private key")
+}
@@ -0,0 +1,435 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amalgam8/amalgam8/pkg/health"
+)
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"n":   encodeBigInt(key.N),
+		"e":   encodeBigInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   encodeBigInt(key.X),
+		"y":   encodeBigInt(key.Y),
+	}
+}
+
+func keySetDocument(t *testing.T, keys ...map[string]string) []byte {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	return data
+}
+
+func writeKeySet(t *testing.T, keys ...map[string]string) string {
+	f, err := ioutil.TempFile("", "jwks")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write(keySetDocument(t, keys...))
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims map[string]interface{}) string {
+	token := jwt.New(method)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	for k, v := range claims {
+		token.Claims[k] = v
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWTAuthenticatorRequiresKey(t *testing.T) {
+	_, err := NewJWTAuthenticator(nil)
+	assert.Error(t, err)
+
+	_, err = NewJWTAuthenticatorFromConfig(JWTConfig{})
+	assert.Error(t, err)
+
+	_, err = NewJWTAuthenticatorFromConfig(JWTConfig{KeySet: "/does/not/exist"})
+	assert.Error(t, err)
+}
+
+func TestJWTAuthenticatorHMAC(t *testing.T) {
+	aut, err := NewJWTAuthenticator([]byte("secret"))
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	token := signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), map[string]interface{}{NamespaceClaim: "ns1"})
+	namespace, err := aut.Authenticate(ctx, token)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ns1", *namespace)
+
+	token = signToken(t, jwt.SigningMethodHS256, "", []byte("other"), map[string]interface{}{NamespaceClaim: "ns1"})
+	_, err = aut.Authenticate(ctx, token)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	_, err = aut.Authenticate(ctx, "not-a-jwt")
+	assert.Equal(t, ErrUnrecognizedToken, err)
+
+	_, err = aut.Authenticate(ctx, "")
+	assert.Equal(t, ErrEmptyToken, err)
+}
+
+func TestJWTAuthenticatorRSAKeyFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := writeKeySet(t, rsaJWK("key1", &key.PublicKey))
+	defer os.Remove(path)
+
+	aut, err := NewJWTAuthenticatorFromConfig(JWTConfig{KeySet: path})
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	token := signToken(t, jwt.SigningMethodRS256, "key1", key, map[string]interface{}{NamespaceClaim: "ns1"})
+	namespace, err := aut.Authenticate(ctx, token)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ns1", *namespace)
+
+	// A single key is selected even without a key ID
+	token = signToken(t, jwt.SigningMethodRS256, "", key, map[string]interface{}{NamespaceClaim: "ns1"})
+	_, err = aut.Authenticate(ctx, token)
+	assert.NoError(t, err)
+
+	// Unknown key ID
+	token = signToken(t, jwt.SigningMethodRS256, "key2", key, map[string]interface{}{NamespaceClaim: "ns1"})
+	_, err = aut.Authenticate(ctx, token)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = VerifyJWT(JWTConfig{KeySet: path}, token)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown key ID 'key2'")
+
+	// HS256 tokens are rejected without a secret, even when signed with the public key
+	pub, err := json.Marshal(rsaJWK("key1", &key.PublicKey))
+	require.NoError(t, err)
+	token = signToken(t, jwt.SigningMethodHS256, "key1", pub, map[string]interface{}{NamespaceClaim: "ns1"})
+	_, err = aut.Authenticate(ctx, token)
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestJWTAuthenticatorKeyRotation(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	document := keySetDocument(t, ecJWK("old", &oldKey.PublicKey), ecJWK("new", &newKey.PublicKey))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(document)
+	}))
+	defer server.Close()
+
+	aut, err := NewJWTAuthenticatorFromConfig(JWTConfig{KeySet: server.URL})
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	// Both keys are valid during rotation
+	for kid, key := range map[string]*ecdsa.PrivateKey{"old": oldKey, "new": newKey} {
+		token := signToken(t, jwt.SigningMethodES256, kid, key, map[string]interface{}{NamespaceClaim: "ns1"})
+		namespace, err := aut.Authenticate(ctx, token)
+		assert.NoError(t, err)
+		assert.EqualValues(t, "ns1", *namespace)
+	}
+
+	// Tokens signed by one key are not verified by the other
+	token := signToken(t, jwt.SigningMethodES256, "old", newKey, map[string]interface{}{NamespaceClaim: "ns1"})
+	_, err = aut.Authenticate(ctx, token)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Ambiguous key selection
+	token = signToken(t, jwt.SigningMethodES256, "", newKey, map[string]interface{}{NamespaceClaim: "ns1"})
+	_, err = aut.Authenticate(ctx, token)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = VerifyJWT(JWTConfig{KeySet: server.URL}, token)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token has no key ID")
+}
+
+func TestKeySetRefresh(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var mutex sync.Mutex
+	loads := 0
+	document := keySetDocument(t, ecJWK("key1", &key.PublicKey))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		loads++
+		mutex.Unlock()
+		w.Write(document)
+	}))
+	defer server.Close()
+
+	count := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return loads
+	}
+
+	ks, err := NewKeySet(server.URL, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, count() > 1, "key set not refreshed")
+
+	// No reloads once closed
+	ks.Close()
+	time.Sleep(20 * time.Millisecond)
+	closed := count()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, closed, count())
+	ks.Close()
+}
+
+func TestKeySetReloadOnDemand(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var mutex sync.Mutex
+	loads := 0
+	document := keySetDocument(t, ecJWK("key1", &key.PublicKey))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		loads++
+		failed := loads > 1
+		mutex.Unlock()
+
+		if failed {
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(document)
+	}))
+	defer server.Close()
+
+	ks, err := NewKeySet(server.URL, 0)
+	require.NoError(t, err)
+	ks.lastAttempt = time.Time{}
+
+	// Concurrent lookups of unknown keys share a single reload, and failed reloads are throttled as well
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Empty(t, ks.Keys(fmt.Sprintf("unknown%d", i)))
+		}(i)
+	}
+	wg.Wait()
+	assert.Empty(t, ks.Keys("unknown"))
+	assert.Len(t, ks.Keys("key1"), 1)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 2, loads)
+}
+
+func TestKeySetCheck(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var mutex sync.Mutex
+	failing := false
+	document := keySetDocument(t, ecJWK("key1", &key.PublicKey))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(document)
+	}))
+	defer server.Close()
+
+	aut, err := NewJWTAuthenticatorFromConfig(JWTConfig{KeySet: server.URL, KeySetRefresh: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer aut.(io.Closer).Close()
+
+	checker := aut.(health.Checker)
+	assert.True(t, checker.Check().Healthy)
+
+	// Keys become stale once they fail to reload for several refresh intervals
+	mutex.Lock()
+	failing = true
+	mutex.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	status := checker.Check()
+	assert.False(t, status.Healthy)
+	assert.Contains(t, status.Properties["cause"], "503")
+
+	mutex.Lock()
+	failing = false
+	mutex.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, checker.Check().Healthy)
+}
+
+func TestJWTAuthenticatorClaims(t *testing.T) {
+	aut, err := NewJWTAuthenticatorFromConfig(JWTConfig{
+		Secret:   []byte("secret"),
+		Issuer:   "issuer1",
+		Audience: "amalgam8",
+	})
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	now := time.Now().Unix()
+	cases := []struct {
+		claims map[string]interface{}
+		valid  bool
+	}{
+		{claims: map[string]interface{}{"iss": "issuer1", "aud": "amalgam8"}, valid: true},
+		{claims: map[string]interface{}{"iss": "issuer1", "aud": []string{"other", "amalgam8"}}, valid: true},
+		{claims: map[string]interface{}{"iss": "issuer1", "aud": "amalgam8", "exp": now + 60, "nbf": now - 60}, valid: true},
+		{claims: map[string]interface{}{"iss": "issuer2", "aud": "amalgam8"}, valid: false},
+		{claims: map[string]interface{}{"aud": "amalgam8"}, valid: false},
+		{claims: map[string]interface{}{"iss": "issuer1", "aud": "other"}, valid: false},
+		{claims: map[string]interface{}{"iss": "issuer1"}, valid: false},
+		{claims: map[string]interface{}{"iss": "issuer1", "aud": "amalgam8", "exp": now - 60}, valid: false},
+		{claims: map[string]interface{}{"iss": "issuer1", "aud": "amalgam8", "nbf": now + 60}, valid: false},
+	}
+
+	for _, c := range cases {
+		c.claims[NamespaceClaim] = "ns1"
+		token := signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), c.claims)
+		_, err := aut.Authenticate(ctx, token)
+		if c.valid {
+			assert.NoError(t, err, "claims %v", c.claims)
+		} else {
+			assert.Equal(t, ErrUnauthorized, err, "claims %v", c.claims)
+		}
+	}
+}
+
+func TestParseKeySet(t *testing.T) {
+	data := []byte(`{"keys": [
+		{"kty": "oct", "kid": "sym", "k": "c2VjcmV0"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"}
+	]}`)
+	keys, err := ParseKeySet(data)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = ParseKeySet([]byte(`{"keys": [{"kty": "EC", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`))
+	assert.Error(t, err)
+
+	_, err = ParseKeySet([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestSignAndVerifyJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := writeKeySet(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey))
+	defer os.Remove(path)
+
+	conf := JWTConfig{Secret: []byte("secret"), KeySet: path}
+	claims := map[string]interface{}{NamespaceClaim: "ns1", "custom": "value"}
+
+	for kid, key := range map[string]interface{}{"": []byte("secret"), "rsa": rsaKey, "ec": ecKey} {
+		token, err := SignJWT(key, kid, claims)
+		require.NoError(t, err)
+
+		verified, err := VerifyJWT(conf, token)
+		assert.NoError(t, err, "key %v", kid)
+		assert.Equal(t, "value", verified["custom"])
+	}
+
+	_, err = SignJWT("secret", "", claims)
+	assert.Error(t, err)
+}
+
+func TestVerifyJWTReasons(t *testing.T) {
+	conf := JWTConfig{Secret: []byte("secret"), Issuer: "issuer1"}
+	now := time.Now().Unix()
+
+	cases := []struct {
+		key    interface{}
+		claims map[string]interface{}
+		reason string
+	}{
+		{key: []byte("other"), claims: map[string]interface{}{NamespaceClaim: "ns1", "iss": "issuer1"}, reason: "signature is invalid"},
+		{key: []byte("secret"), claims: map[string]interface{}{NamespaceClaim: "ns1", "iss": "issuer1", "exp": now - 60}, reason: "token is expired"},
+		{key: []byte("secret"), claims: map[string]interface{}{NamespaceClaim: "ns1"}, reason: "issuer claim does not match 'issuer1'"},
+		{key: []byte("secret"), claims: map[string]interface{}{"iss": "issuer1"}, reason: "missing namespace claim"},
+	}
+
+	for _, c := range cases {
+		token, err := SignJWT(c.key, "", c.claims)
+		require.NoError(t, err)
+
+		_, err = VerifyJWT(conf, token)
+		if assert.Error(t, err) {
+			assert.Equal(t, c.reason, err.Error())
+		}
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	token, err := SignJWT(ecKey, "ec", map[string]interface{}{NamespaceClaim: "ns1"})
+	require.NoError(t, err)
+	_, err = VerifyJWT(conf, token)
+	if assert.Error(t, err) {
+		assert.Equal(t, "no key set is configured for ES256 signed tokens", err.Error())
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// Scope is an operation a token is authorized to perform within its namespace.
+type Scope string
+
+// Supported scopes
+const (
+	ScopeRulesRead        Scope = "rules:read"
+	ScopeRulesWrite       Scope = "rules:write"
+	ScopeRegistryRegister Scope = "registry:register"
+	ScopeRegistryDiscover Scope = "registry:discover"
+)
+
+// Scopes is a set of scopes granted to a token.
+type Scopes []Scope
+
+// AllScopes is granted to tokens which do not restrict their scopes.
+var AllScopes = Scopes{ScopeRulesRead, ScopeRulesWrite, ScopeRegistryRegister, ScopeRegistryDiscover}
+
+// ReadOnlyScopes is granted to JWT tokens without a scope claim, if they are configured to be read-only.
+var ReadOnlyScopes = Scopes{ScopeRulesRead, ScopeRegistryDiscover}
+
+// Has returns whether the scope is granted. Granting rules:write implies rules:read.
+func (s Scopes) Has(scope Scope) bool {
+	for _, granted := range s {
+		if granted == scope || (granted == ScopeRulesWrite && scope == ScopeRulesRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes parses a scope claim, which is either a space delimited string or a list of strings.
+// The second return value is false if the claim is malformed.
+func ParseScopes(claim interface{}) (Scopes, bool) {
+	var scopes Scopes
+	switch c := claim.(type) {
+	case string:
+		for _, s := range strings.Fields(c) {
+			scopes = append(scopes, Scope(s))
+		}
+	case []interface{}:
+		for _, s := range c {
+			str, ok := s.(string)
+			if !ok {
+				return nil, false
+			}
+			scopes = append(scopes, Scope(str))
+		}
+	default:
+		return nil, false
+	}
+	return scopes, true
+}
+
+// ScopedAuthenticator is implemented by authenticators whose tokens may be restricted to a set of scopes.
+type ScopedAuthenticator interface {
+	Authenticator
+
+	// AuthenticateScopes verifies the token, returning its namespace and granted scopes.
+	AuthenticateScopes(ctx context.Context, token string) (*Namespace, Scopes, error)
+}
+
+// AuthenticateScopes verifies the token using the given authenticator, returning its namespace and granted scopes.
+// Tokens verified by authenticators not implementing ScopedAuthenticator are granted all scopes.
+func AuthenticateScopes(ctx context.Context, a Authenticator, token string) (*Namespace, Scopes, error) {
+	if sa, ok := a.(ScopedAuthenticator); ok {
+		return sa.AuthenticateScopes(ctx, token)
+	}
+
+	namespace, err := a.Authenticate(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	return namespace, AllScopes, nil
+}
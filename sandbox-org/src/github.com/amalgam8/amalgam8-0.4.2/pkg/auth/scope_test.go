@@ -0,0 +1,121 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopesHas(t *testing.T) {
+	sidecar := Scopes{ScopeRulesRead, ScopeRegistryRegister, ScopeRegistryDiscover}
+	assert.True(t, sidecar.Has(ScopeRulesRead))
+	assert.True(t, sidecar.Has(ScopeRegistryRegister))
+	assert.False(t, sidecar.Has(ScopeRulesWrite))
+
+	operator := Scopes{ScopeRulesWrite}
+	assert.True(t, operator.Has(ScopeRulesWrite))
+	assert.True(t, operator.Has(ScopeRulesRead))
+	assert.False(t, operator.Has(ScopeRegistryDiscover))
+
+	for _, scope := range AllScopes {
+		assert.True(t, AllScopes.Has(scope))
+	}
+	assert.False(t, Scopes(nil).Has(ScopeRulesRead))
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, ok := ParseScopes("rules:read  registry:register")
+	assert.True(t, ok)
+	assert.Equal(t, Scopes{ScopeRulesRead, ScopeRegistryRegister}, scopes)
+
+	scopes, ok = ParseScopes([]interface{}{"rules:write"})
+	assert.True(t, ok)
+	assert.Equal(t, Scopes{ScopeRulesWrite}, scopes)
+
+	scopes, ok = ParseScopes("")
+	assert.True(t, ok)
+	assert.Empty(t, scopes)
+
+	_, ok = ParseScopes([]interface{}{1})
+	assert.False(t, ok)
+
+	_, ok = ParseScopes(42)
+	assert.False(t, ok)
+}
+
+func TestAuthenticateScopesUnscoped(t *testing.T) {
+	namespace, scopes, err := AuthenticateScopes(context.TODO(), NewTrustedAuthenticator(), "ns1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ns1", *namespace)
+	assert.Equal(t, AllScopes, scopes)
+
+	_, _, err = AuthenticateScopes(context.TODO(), NewTrustedAuthenticator(), "")
+	assert.Error(t, err)
+}
+
+func TestChainAuthenticateScopes(t *testing.T) {
+	jwtAuth, err := NewJWTAuthenticator([]byte("secret"))
+	assert.NoError(t, err)
+	ca, err := NewChainAuthenticator([]Authenticator{jwtAuth, NewTrustedAuthenticator()})
+	assert.NoError(t, err)
+
+	token := signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), map[string]interface{}{
+		NamespaceClaim: "ns1",
+		ScopeClaim:     "rules:read registry:register",
+	})
+	namespace, scopes, err := AuthenticateScopes(context.TODO(), ca, token)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ns1", *namespace)
+	assert.Equal(t, Scopes{ScopeRulesRead, ScopeRegistryRegister}, scopes)
+
+	// Malformed scope claim
+	token = signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), map[string]interface{}{
+		NamespaceClaim: "ns1",
+		ScopeClaim:     42,
+	})
+	_, _, err = AuthenticateScopes(context.TODO(), ca, token)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Unrecognized by the JWT authenticator, falls back to the trusted authenticator
+	namespace, scopes, err = AuthenticateScopes(context.TODO(), ca, "ns2")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ns2", *namespace)
+	assert.Equal(t, AllScopes, scopes)
+}
+
+func TestJWTUnscopedToken(t *testing.T) {
+	token := signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), map[string]interface{}{NamespaceClaim: "ns1"})
+
+	// All scopes by default, for compatibility with tokens issued before scopes were supported
+	aut, err := NewJWTAuthenticator([]byte("secret"))
+	assert.NoError(t, err)
+	_, scopes, err := AuthenticateScopes(context.TODO(), aut, token)
+	assert.NoError(t, err)
+	assert.Equal(t, AllScopes, scopes)
+
+	aut, err = NewJWTAuthenticatorFromConfig(JWTConfig{Secret: []byte("secret"), UnscopedReadOnly: true})
+	assert.NoError(t, err)
+	_, scopes, err = AuthenticateScopes(context.TODO(), aut, token)
+	assert.NoError(t, err)
+	assert.Equal(t, ReadOnlyScopes, scopes)
+	assert.True(t, scopes.Has(ScopeRulesRead))
+	assert.True(t, scopes.Has(ScopeRegistryDiscover))
+	assert.False(t, scopes.Has(ScopeRulesWrite))
+	assert.False(t, scopes.Has(ScopeRegistryRegister))
+}
@@ -0,0 +1,196 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/amalgam8/amalgam8/pkg/health"
+)
+
+// TokenHashPrefix is the optional prefix of the token hashes in a token file.
+const TokenHashPrefix = "sha256:"
+
+// TokenEntry describes the namespace and scopes of a token in a token file.
+type TokenEntry struct {
+	Namespace string  `yaml:"namespace" json:"namespace"`
+	Scopes    []Scope `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+}
+
+// HashToken returns the hash under which a token is stored in a token file.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return TokenHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// ParseTokenFile parses a token file, which is a YAML or JSON map from token hashes to their entries.
+// Tokens without scopes are granted all scopes.
+func ParseTokenFile(data []byte) (map[string]TokenEntry, error) {
+	entries := make(map[string]TokenEntry)
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid token file: %v", err)
+	}
+
+	tokens := make(map[string]TokenEntry, len(entries))
+	for hash, entry := range entries {
+		if entry.Namespace == "" {
+			return nil, fmt.Errorf("invalid token file: missing namespace of token '%s'", hash)
+		}
+
+		digest := strings.ToLower(strings.TrimPrefix(hash, TokenHashPrefix))
+		if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid token file: '%s' is not a SHA-256 hash", hash)
+		}
+
+		if entry.Scopes == nil {
+			entry.Scopes = AllScopes
+		}
+		tokens[TokenHashPrefix+digest] = entry
+	}
+
+	return tokens, nil
+}
+
+type tokenFileAuthenticator struct {
+	path string
+
+	tokens  map[string]TokenEntry
+	modTime time.Time
+	size    int64
+	lastErr error
+	mutex   sync.RWMutex
+}
+
+// NewTokenFileAuthenticator creates an authenticator for the static tokens of the token file at the given path.
+// If reload is positive, the file is checked for changes at that interval. The initial load must succeed, while
+// failures of subsequent reloads are logged and the previous tokens are retained.
+func NewTokenFileAuthenticator(path string, reload time.Duration) (Authenticator, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Token file path is required")
+	}
+
+	aut := &tokenFileAuthenticator{path: path}
+	if err := aut.load(); err != nil {
+		return nil, err
+	}
+
+	if reload > 0 {
+		go aut.watch(reload)
+	}
+
+	return aut, nil
+}
+
+func (aut *tokenFileAuthenticator) Authenticate(ctx context.Context, token string) (*Namespace, error) {
+	namespace, _, err := aut.AuthenticateScopes(ctx, token)
+	return namespace, err
+}
+
+// AuthenticateScopes looks up the token's hash in the token file. Tokens which are not in the file are
+// unrecognized, so that other authenticators in a chain may verify them.
+func (aut *tokenFileAuthenticator) AuthenticateScopes(ctx context.Context, token string) (*Namespace, Scopes, error) {
+	if token == "" {
+		return nil, nil, ErrEmptyToken
+	}
+
+	aut.mutex.RLock()
+	entry, exists := aut.tokens[HashToken(token)]
+	aut.mutex.RUnlock()
+
+	if !exists {
+		return nil, nil, ErrUnrecognizedToken
+	}
+
+	namespace := Namespace(entry.Namespace)
+	return &namespace, entry.Scopes, nil
+}
+
+func (aut *tokenFileAuthenticator) load() error {
+	info, err := os.Stat(aut.path)
+	if err != nil {
+		return fmt.Errorf("failed to read token file: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(aut.path)
+	if err != nil {
+		return fmt.Errorf("failed to read token file: %v", err)
+	}
+
+	tokens, err := ParseTokenFile(data)
+	if err != nil {
+		return err
+	}
+
+	aut.mutex.Lock()
+	defer aut.mutex.Unlock()
+	aut.tokens = tokens
+	aut.modTime = info.ModTime()
+	aut.size = info.Size()
+	return nil
+}
+
+// changed returns whether the token file was modified since it was last loaded.
+func (aut *tokenFileAuthenticator) changed() bool {
+	info, err := os.Stat(aut.path)
+	if err != nil {
+		logrus.WithError(err).WithField("path", aut.path).Warn("Failed to check token file")
+		return false
+	}
+
+	aut.mutex.RLock()
+	defer aut.mutex.RUnlock()
+	return !info.ModTime().Equal(aut.modTime) || info.Size() != aut.size
+}
+
+func (aut *tokenFileAuthenticator) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		if !aut.changed() {
+			continue
+		}
+
+		err := aut.load()
+		aut.mutex.Lock()
+		aut.lastErr = err
+		aut.mutex.Unlock()
+
+		if err != nil {
+			logrus.WithError(err).WithField("path", aut.path).Warn("Failed to reload token file")
+			continue
+		}
+		logrus.WithField("path", aut.path).Info("Reloaded token file")
+	}
+}
+
+// Check reports the token file unhealthy if its last reload failed, as tokens removed from it are still accepted.
+func (aut *tokenFileAuthenticator) Check() health.Status {
+	aut.mutex.RLock()
+	defer aut.mutex.RUnlock()
+
+	if aut.lastErr != nil {
+		return health.StatusUnhealthy("token file failed to reload", aut.lastErr)
+	}
+	return health.Healthy
+}
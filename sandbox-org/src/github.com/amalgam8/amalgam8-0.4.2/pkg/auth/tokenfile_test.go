@@ -0,0 +1,136 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amalgam8/amalgam8/pkg/health"
+)
+
+func writeTokenFile(t *testing.T, path, content string) string {
+	if path == "" {
+		f, err := ioutil.TempFile("", "tokens")
+		require.NoError(t, err)
+		f.Close()
+		path = f.Name()
+	}
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestTokenFileAuthenticator(t *testing.T) {
+	path := writeTokenFile(t, "", fmt.Sprintf(`
+%s:
+  namespace: ns1
+  scopes:
+  - rules:read
+  - registry:register
+%s:
+  namespace: ns2
+`, HashToken("sidecar-token"), HashToken("operator-token")))
+	defer os.Remove(path)
+
+	aut, err := NewTokenFileAuthenticator(path, 0)
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	namespace, scopes, err := AuthenticateScopes(ctx, aut, "sidecar-token")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ns1", *namespace)
+	assert.Equal(t, Scopes{ScopeRulesRead, ScopeRegistryRegister}, scopes)
+
+	namespace, scopes, err = AuthenticateScopes(ctx, aut, "operator-token")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ns2", *namespace)
+	assert.Equal(t, AllScopes, scopes)
+
+	_, err = aut.Authenticate(ctx, "unknown-token")
+	assert.Equal(t, ErrUnrecognizedToken, err)
+
+	_, err = aut.Authenticate(ctx, "")
+	assert.Equal(t, ErrEmptyToken, err)
+}
+
+func TestTokenFileJSON(t *testing.T) {
+	tokens, err := ParseTokenFile([]byte(fmt.Sprintf(`{"%s": {"namespace": "ns1", "scopes": ["rules:write"]}}`,
+		HashToken("token"))))
+	assert.NoError(t, err)
+	assert.Equal(t, TokenEntry{Namespace: "ns1", Scopes: []Scope{ScopeRulesWrite}}, tokens[HashToken("token")])
+}
+
+func TestTokenFileInvalid(t *testing.T) {
+	cases := []string{
+		`not: [valid`,
+		`sha256:1234: {namespace: ns1}`,
+		fmt.Sprintf(`%s: {scopes: ["rules:read"]}`, HashToken("token")),
+	}
+
+	for _, c := range cases {
+		_, err := ParseTokenFile([]byte(c))
+		assert.Error(t, err, c)
+	}
+
+	_, err := NewTokenFileAuthenticator("", 0)
+	assert.Error(t, err)
+
+	_, err = NewTokenFileAuthenticator("/does/not/exist", 0)
+	assert.Error(t, err)
+}
+
+func TestTokenFileReload(t *testing.T) {
+	path := writeTokenFile(t, "", fmt.Sprintf("%s: {namespace: ns1}\n", HashToken("token1")))
+	defer os.Remove(path)
+
+	aut, err := NewTokenFileAuthenticator(path, 10*time.Millisecond)
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	_, err = aut.Authenticate(ctx, "token1")
+	assert.NoError(t, err)
+
+	writeTokenFile(t, path, fmt.Sprintf("%s: {namespace: ns2}\n", HashToken("token2")))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err = aut.Authenticate(ctx, "token2"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(t, err)
+
+	_, err = aut.Authenticate(ctx, "token1")
+	assert.Equal(t, ErrUnrecognizedToken, err)
+
+	checker := aut.(health.Checker)
+	assert.True(t, checker.Check().Healthy)
+
+	// Invalid files are ignored, and reported as unhealthy
+	writeTokenFile(t, path, "not: [valid")
+	time.Sleep(50 * time.Millisecond)
+	namespace, err := aut.Authenticate(ctx, "token2")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ns2", *namespace)
+	assert.False(t, checker.Check().Healthy)
+}
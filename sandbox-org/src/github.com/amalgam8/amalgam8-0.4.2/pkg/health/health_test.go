@@ -23,7 +23,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/amalgam8/amalgam8/registry/utils/health"
+	"github.com/amalgam8/amalgam8/pkg/health"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -0,0 +1,268 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package labels provides key/value labels and set-based label selectors.
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Operator is the relation between a label key and a set of values in a selector requirement.
+type Operator string
+
+// Supported selector operators
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+var (
+	keyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	valuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+	setPattern   = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+// Requirement is a single condition of a selector.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Matches returns whether the labels satisfy the requirement.
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, exists := labels[r.Key]
+
+	switch r.Operator {
+	case Equals, In:
+		return exists && contains(r.Values, value)
+	case NotEquals, NotIn:
+		return !exists || !contains(r.Values, value)
+	case Exists:
+		return exists
+	case DoesNotExist:
+		return !exists
+	}
+
+	return false
+}
+
+// String representation of the requirement, in the same syntax accepted by Parse.
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Equals, NotEquals:
+		return fmt.Sprintf("%v%v%v", r.Key, r.Operator, strings.Join(r.Values, ""))
+	case In, NotIn:
+		return fmt.Sprintf("%v %v (%v)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	case DoesNotExist:
+		return "!" + r.Key
+	}
+
+	return r.Key
+}
+
+// Selector is a set of requirements. Labels match the selector only if they satisfy every requirement.
+// An empty selector matches any set of labels.
+type Selector []Requirement
+
+// Empty returns whether the selector has no requirements.
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+// Matches returns whether the labels satisfy all the requirements of the selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String representation of the selector, in the same syntax accepted by Parse.
+func (s Selector) String() string {
+	terms := make([]string, len(s))
+	for i, r := range s {
+		terms[i] = r.String()
+	}
+	return strings.Join(terms, ",")
+}
+
+// Parse converts a comma separated list of requirements into a selector. Supported requirements are
+// "key=value", "key==value", "key!=value", "key in (v1,v2)", "key notin (v1,v2)", "key" and "!key".
+func Parse(s string) (Selector, error) {
+	terms, err := splitTerms(s)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := make(Selector, 0, len(terms))
+	for _, term := range terms {
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		selector = append(selector, r)
+	}
+
+	return selector, nil
+}
+
+// ParseAll parses each of the selectors and combines them into a single selector requiring all of them.
+func ParseAll(selectors []string) (Selector, error) {
+	var res Selector
+	for _, s := range selectors {
+		selector, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, selector...)
+	}
+	return res, nil
+}
+
+// Validate checks that all label keys and values are well formed.
+func Validate(labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := validateKey(key); err != nil {
+			return err
+		}
+		if err := validateValue(labels[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTerms splits the selector on the commas that are not enclosed by parentheses.
+func splitTerms(s string) ([]string, error) {
+	var terms []string
+
+	depth := 0
+	start := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("labels: unbalanced parentheses in selector '%v'", s)
+			}
+		case ',':
+			if depth == 0 {
+				terms = append(terms, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("labels: unbalanced parentheses in selector '%v'", s)
+	}
+	terms = append(terms, s[start:])
+
+	res := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			if len(terms) == 1 {
+				break
+			}
+			return nil, fmt.Errorf("labels: empty requirement in selector '%v'", s)
+		}
+		res = append(res, term)
+	}
+
+	return res, nil
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	var r Requirement
+
+	if m := setPattern.FindStringSubmatch(term); m != nil {
+		r.Key = m[1]
+		r.Operator = Operator(m[2])
+		for _, v := range strings.Split(m[3], ",") {
+			v = strings.TrimSpace(v)
+			if err := validateValue(v); err != nil {
+				return r, err
+			}
+			r.Values = append(r.Values, v)
+		}
+	} else if strings.HasPrefix(term, "!") && !strings.Contains(term, "=") {
+		r.Key = strings.TrimSpace(term[1:])
+		r.Operator = DoesNotExist
+	} else if i := strings.Index(term, "!="); i >= 0 {
+		r.Key = strings.TrimSpace(term[:i])
+		r.Operator = NotEquals
+		r.Values = []string{strings.TrimSpace(term[i+2:])}
+	} else if i := strings.Index(term, "="); i >= 0 {
+		value := strings.TrimPrefix(term[i+1:], "=")
+		r.Key = strings.TrimSpace(term[:i])
+		r.Operator = Equals
+		r.Values = []string{strings.TrimSpace(value)}
+	} else {
+		r.Key = term
+		r.Operator = Exists
+	}
+
+	if err := validateKey(r.Key); err != nil {
+		return r, err
+	}
+	for _, v := range r.Values {
+		if err := validateValue(v); err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+func validateKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("labels: invalid label key '%v'", key)
+	}
+	return nil
+}
+
+func validateValue(value string) error {
+	if !valuePattern.MatchString(value) {
+		return fmt.Errorf("labels: invalid label value '%v'", value)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		In       string
+		Selector Selector
+	}{
+		{"", Selector{}},
+		{"owner=team-a", Selector{{"owner", Equals, []string{"team-a"}}}},
+		{"owner==team-a", Selector{{"owner", Equals, []string{"team-a"}}}},
+		{"owner != team-a", Selector{{"owner", NotEquals, []string{"team-a"}}}},
+		{"env in (prod, staging)", Selector{{"env", In, []string{"prod", "staging"}}}},
+		{"env notin (dev)", Selector{{"env", NotIn, []string{"dev"}}}},
+		{"experiment", Selector{{"experiment", Exists, nil}}},
+		{"!experiment", Selector{{"experiment", DoesNotExist, nil}}},
+		{
+			"owner=team-a,env in (prod,staging),!experiment",
+			Selector{
+				{"owner", Equals, []string{"team-a"}},
+				{"env", In, []string{"prod", "staging"}},
+				{"experiment", DoesNotExist, nil},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		s, err := Parse(c.In)
+		assert.NoError(t, err, c.In)
+		assert.Equal(t, c.Selector, s, c.In)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []string{
+		"owner=team-a,",
+		"env in (prod",
+		"env in prod)",
+		"=value",
+		"owner=team a",
+		"!",
+		"env in (prod,,dev)x",
+	}
+
+	for _, c := range cases {
+		_, err := Parse(c)
+		assert.Error(t, err, c)
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{
+		"owner": "team-a",
+		"env":   "prod",
+	}
+
+	cases := []struct {
+		Selector string
+		Match    bool
+	}{
+		{"", true},
+		{"owner=team-a", true},
+		{"owner=team-b", false},
+		{"owner!=team-b", true},
+		{"zone!=eu-1", true},
+		{"env in (prod,staging)", true},
+		{"env in (dev,staging)", false},
+		{"env notin (prod)", false},
+		{"zone notin (eu-1)", true},
+		{"owner", true},
+		{"zone", false},
+		{"!zone", true},
+		{"!owner", false},
+		{"owner=team-a,env in (prod,staging),!experiment", true},
+		{"owner=team-a,env in (prod,staging),experiment", false},
+	}
+
+	for _, c := range cases {
+		s, err := Parse(c.Selector)
+		assert.NoError(t, err, c.Selector)
+		assert.Equal(t, c.Match, s.Matches(labels), c.Selector)
+	}
+}
+
+func TestSelectorString(t *testing.T) {
+	in := "owner=team-a,env in (prod,staging),!experiment,zone!=eu-1,canary"
+	s, err := Parse(in)
+	assert.NoError(t, err)
+	assert.Equal(t, in, s.String())
+
+	s2, err := Parse(s.String())
+	assert.NoError(t, err)
+	assert.Equal(t, s, s2)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(nil))
+	assert.NoError(t, Validate(map[string]string{"owner": "team-a", "example.com/env": ""}))
+	assert.Error(t, Validate(map[string]string{"": "team-a"}))
+	assert.Error(t, Validate(map[string]string{"owner": "team a"}))
+}
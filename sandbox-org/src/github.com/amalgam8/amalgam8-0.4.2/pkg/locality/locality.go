@@ -0,0 +1,117 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package locality provides the region and zone of service instances, and locality-aware instance selection.
+package locality
+
+// Labels holding the locality of service instances
+const (
+	RegionLabel = "region"
+	ZoneLabel   = "zone"
+)
+
+// Locality is the region and zone in which a service instance, or a caller, runs.
+type Locality struct {
+	Region string
+	Zone   string
+}
+
+// FromLabels returns the locality described by the labels of a service instance.
+func FromLabels(labels map[string]string) Locality {
+	return Locality{Region: labels[RegionLabel], Zone: labels[ZoneLabel]}
+}
+
+// Empty returns whether neither the region nor the zone are known.
+func (l Locality) Empty() bool {
+	return l.Region == "" && l.Zone == ""
+}
+
+// Labels returns the labels describing the locality. Unknown attributes are omitted.
+func (l Locality) Labels() map[string]string {
+	labels := make(map[string]string, 2)
+	if l.Region != "" {
+		labels[RegionLabel] = l.Region
+	}
+	if l.Zone != "" {
+		labels[ZoneLabel] = l.Zone
+	}
+	return labels
+}
+
+// Proximity is the relation between two localities.
+type Proximity int
+
+// Proximities, from the farthest to the nearest
+const (
+	Remote Proximity = iota
+	SameRegion
+	SameZone
+)
+
+// ProximityTo returns the proximity of the other locality to this one.
+// Zones are only considered the same if they are in the same region.
+func (l Locality) ProximityTo(other Locality) Proximity {
+	if l.Zone != "" && l.Zone == other.Zone && l.Region == other.Region {
+		return SameZone
+	}
+	if l.Region != "" && l.Region == other.Region {
+		return SameRegion
+	}
+	return Remote
+}
+
+// Preference selects service instances by their proximity to the locality of the caller.
+type Preference struct {
+	// Locality of the caller. When empty, all instances are selected in their original order.
+	Locality Locality
+
+	// MinHealthy is the minimum number of healthy instances to select. Instances in the same zone are selected first,
+	// falling back to instances in the same region, and then to remote instances, until at least MinHealthy healthy
+	// instances are selected. When zero, all instances are selected, ordered by their proximity.
+	MinHealthy int
+}
+
+// Select returns the indexes of the selected instances out of n instances, nearest first.
+// Instances with the same proximity are kept in their original order.
+func (p Preference) Select(n int, locality func(i int) Locality, healthy func(i int) bool) []int {
+	selected := make([]int, 0, n)
+	if p.Locality.Empty() {
+		for i := 0; i < n; i++ {
+			selected = append(selected, i)
+		}
+		return selected
+	}
+
+	var byProximity [SameZone + 1][]int
+	for i := 0; i < n; i++ {
+		proximity := p.Locality.ProximityTo(locality(i))
+		byProximity[proximity] = append(byProximity[proximity], i)
+	}
+
+	healthyCount := 0
+	for proximity := SameZone; proximity >= Remote; proximity-- {
+		for _, i := range byProximity[proximity] {
+			selected = append(selected, i)
+			if healthy(i) {
+				healthyCount++
+			}
+		}
+
+		if p.MinHealthy > 0 && healthyCount >= p.MinHealthy {
+			break
+		}
+	}
+
+	return selected
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package locality
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProximity(t *testing.T) {
+	caller := Locality{Region: "eu", Zone: "eu-1"}
+
+	cases := []struct {
+		Other     Locality
+		Proximity Proximity
+	}{
+		{Locality{Region: "eu", Zone: "eu-1"}, SameZone},
+		{Locality{Region: "eu", Zone: "eu-2"}, SameRegion},
+		{Locality{Region: "eu"}, SameRegion},
+		{Locality{Region: "us", Zone: "eu-1"}, Remote},
+		{Locality{Zone: "eu-1"}, Remote},
+		{Locality{}, Remote},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.Proximity, caller.ProximityTo(c.Other), "%+v", c.Other)
+	}
+
+	assert.Equal(t, SameZone, Locality{Zone: "eu-1"}.ProximityTo(Locality{Zone: "eu-1"}))
+	assert.Equal(t, Remote, Locality{}.ProximityTo(Locality{}))
+}
+
+func TestLabels(t *testing.T) {
+	l := Locality{Region: "eu", Zone: "eu-1"}
+	assert.Equal(t, map[string]string{RegionLabel: "eu", ZoneLabel: "eu-1"}, l.Labels())
+	assert.Equal(t, l, FromLabels(l.Labels()))
+
+	assert.Equal(t, map[string]string{RegionLabel: "eu"}, Locality{Region: "eu"}.Labels())
+	assert.True(t, FromLabels(nil).Empty())
+}
+
+func TestSelect(t *testing.T) {
+	localities := []Locality{
+		{Region: "us", Zone: "us-1"},
+		{Region: "eu", Zone: "eu-2"},
+		{Region: "eu", Zone: "eu-1"},
+		{Region: "eu", Zone: "eu-1"},
+		{},
+	}
+	healthy := []bool{true, true, true, false, true}
+
+	cases := []struct {
+		Preference Preference
+		Selected   []int
+	}{
+		{Preference{}, []int{0, 1, 2, 3, 4}},
+		{Preference{MinHealthy: 1}, []int{0, 1, 2, 3, 4}},
+		{Preference{Locality: Locality{Region: "eu", Zone: "eu-1"}}, []int{2, 3, 1, 0, 4}},
+		{Preference{Locality: Locality{Region: "eu", Zone: "eu-1"}, MinHealthy: 1}, []int{2, 3}},
+		{Preference{Locality: Locality{Region: "eu", Zone: "eu-1"}, MinHealthy: 2}, []int{2, 3, 1}},
+		{Preference{Locality: Locality{Region: "eu", Zone: "eu-1"}, MinHealthy: 10}, []int{2, 3, 1, 0, 4}},
+		{Preference{Locality: Locality{Region: "eu", Zone: "eu-2"}, MinHealthy: 1}, []int{1}},
+		{Preference{Locality: Locality{Region: "ap", Zone: "ap-1"}, MinHealthy: 1}, []int{0, 1, 2, 3, 4}},
+	}
+
+	for _, c := range cases {
+		selected := c.Preference.Select(len(localities),
+			func(i int) Locality { return localities[i] },
+			func(i int) bool { return healthy[i] })
+		assert.Equal(t, c.Selected, selected, "%+v", c.Preference)
+	}
+}
@@ -17,11 +17,16 @@ package api
 // Consider adding a REST client class to abstract away some of the http details?
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -63,7 +68,7 @@ func TestInstancesMethodInvalid(t *testing.T) {
 	assert.Nil(t, err)
 	for _, url := range urls {
 		for _, method := range methods {
-			if method == "DELETE" && url == serverURL+amalgam8.InstanceURL(fake) {
+			if (method == "DELETE" || method == "PATCH") && url == serverURL+amalgam8.InstanceURL(fake) {
 				continue // this is a valid combination
 			}
 			recorder := httptest.NewRecorder()
@@ -249,6 +254,178 @@ func TestInstanceHeartbeat(t *testing.T) {
 	}
 }
 
+// instances:batch
+func TestInstancesBatch(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	send := func(method, url string, body []byte) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest(method, serverURL+url, bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// Malformed, oversized and unknown operation requests fail as a whole
+	assert.Equal(t, http.StatusBadRequest, send("POST", amalgam8.InstancesBatchURL(), []byte("{")).Code)
+	b, err := json.Marshal(&amalgam8.InstanceRegistrationBatch{Instances: make([]*amalgam8.InstanceRegistration, amalgam8.BatchMaxSize+1)})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, send("POST", amalgam8.InstancesBatchURL(), b).Code)
+	assert.Equal(t, http.StatusNotFound, send("POST", amalgam8.InstancesURL()+":bogus", []byte("{}")).Code)
+
+	b, err = json.Marshal(&amalgam8.InstanceRegistrationBatch{
+		Instances: []*amalgam8.InstanceRegistration{
+			{ServiceName: "http", Endpoint: &amalgam8.InstanceAddress{Value: "192.168.0.1:80", Type: "http"}},
+			{ServiceName: "http"},
+			nil,
+			{ServiceName: "http", Endpoint: &amalgam8.InstanceAddress{Value: "192.168.0.2:80", Type: "http"}, TTL: 60},
+		},
+	})
+	assert.NoError(t, err)
+	recorder := send("POST", amalgam8.InstancesBatchURL(), b)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	results := amalgam8.InstanceRegistrationResults{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &results))
+	if assert.Len(t, results.Instances, 4) {
+		assert.Equal(t, http.StatusCreated, results.Instances[0].Code)
+		assert.NotEmpty(t, results.Instances[0].ID)
+		assert.NotNil(t, results.Instances[0].Links)
+		assert.Equal(t, http.StatusBadRequest, results.Instances[1].Code)
+		assert.NotEmpty(t, results.Instances[1].Error)
+		assert.Empty(t, results.Instances[1].ID)
+		assert.Equal(t, http.StatusBadRequest, results.Instances[2].Code)
+		assert.Equal(t, http.StatusCreated, results.Instances[3].Code)
+		assert.EqualValues(t, 60, results.Instances[3].TTL)
+	}
+
+	recorder = send("GET", amalgam8.InstancesURL(), nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	list := amalgam8.InstancesList{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+	assert.Len(t, list.Instances, 2)
+}
+
+// heartbeats
+func TestHeartbeats(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap.(*mockCatalog).prepopulateInstances(instances)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	send := func(body []byte) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", serverURL+amalgam8.HeartbeatsURL(), bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send([]byte("{")).Code)
+	b, err := json.Marshal(&amalgam8.InstanceHeartbeatBatch{IDs: make([]string, amalgam8.BatchMaxSize+1)})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, send(b).Code)
+
+	b, err = json.Marshal(&amalgam8.InstanceHeartbeatBatch{IDs: []string{"http-1", "http-3", "", "http-2"}})
+	assert.NoError(t, err)
+	recorder := send(b)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	results := amalgam8.InstanceHeartbeatResults{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &results))
+	if assert.Len(t, results.Heartbeats, 4) {
+		assert.Equal(t, http.StatusOK, results.Heartbeats[0].Code)
+		assert.Equal(t, "http-1", results.Heartbeats[0].ID)
+		assert.Equal(t, http.StatusGone, results.Heartbeats[1].Code) // unknown instance id should fail
+		assert.NotEmpty(t, results.Heartbeats[1].Error)
+		assert.Equal(t, http.StatusBadRequest, results.Heartbeats[2].Code)
+		assert.Equal(t, http.StatusOK, results.Heartbeats[3].Code)
+	}
+}
+
+// instance:status
+func TestInstanceSetStatus(t *testing.T) {
+	cases := []struct {
+		iid      string // input service identifier
+		status   string // input status
+		expected int    // expected result
+	}{
+		{"http-1", "OUT_OF_SERVICE", http.StatusOK},
+		{"http-1", "up", http.StatusOK},
+		{"http-2", "STARTING", http.StatusOK},
+		{"http-2", "draining", http.StatusOK},
+		{"http-2", "DOWN", http.StatusBadRequest}, // unsupported status should fail
+		{"http-2", "", http.StatusBadRequest},     // missing status should fail
+		{"http-3", "UP", http.StatusGone},         // unknown instance id should fail
+	}
+
+	c := defaultServerConfig()
+	c.CatalogMap.(*mockCatalog).prepopulateInstances(instances)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	for _, tc := range cases {
+		b, err := json.Marshal(&amalgam8.InstanceStatus{Status: tc.status})
+		assert.Nil(t, err)
+
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", serverURL+amalgam8.InstanceStatusURL(tc.iid), bytes.NewReader(b))
+		assert.Nil(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.expected, recorder.Code, tc.iid+":"+tc.status)
+
+		if tc.expected == http.StatusOK {
+			inst := amalgam8.ServiceInstance{}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &inst))
+			assert.Equal(t, strings.ToUpper(tc.status), inst.Status)
+		}
+	}
+}
+
+// instance:update
+func TestInstanceUpdate(t *testing.T) {
+	cases := []struct {
+		iid      string // input service identifier
+		body     string // input update
+		expected int    // expected result
+	}{
+		{"http-1", `{"tags": ["v2"]}`, http.StatusOK},
+		{"http-1", `{"metadata": {"version": "v2"}}`, http.StatusOK},
+		{"http-2", `{"tags": ["v1"], "metadata": null}`, http.StatusOK},
+		{"http-2", `{"tags": "v1"}`, http.StatusBadRequest}, // malformed update should fail
+		{"http-3", `{"tags": ["v1"]}`, http.StatusGone},     // unknown instance id should fail
+	}
+
+	c := defaultServerConfig()
+	c.CatalogMap.(*mockCatalog).prepopulateInstances(instances)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("PATCH", serverURL+amalgam8.InstanceURL(tc.iid), strings.NewReader(tc.body))
+		assert.Nil(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.expected, recorder.Code, tc.iid+":"+tc.body)
+	}
+
+	// Fields not included in an update are left unchanged
+	inst := c.CatalogMap.(*mockCatalog).instances["http-1"]
+	assert.Equal(t, []string{"v2"}, inst.Tags)
+	assert.JSONEq(t, `{"version": "v2"}`, string(inst.Metadata))
+
+	inst = c.CatalogMap.(*mockCatalog).instances["http-2"]
+	assert.Equal(t, []string{"v1"}, inst.Tags)
+	assert.Empty(t, inst.Metadata)
+}
+
 //----------
 // services
 //----------
@@ -534,6 +711,380 @@ func TestServiceInstances(t *testing.T) {
 	}
 }
 
+func TestInstancesDrainingExcluded(t *testing.T) {
+	cases := []struct {
+		query    string // input query
+		expected []string
+	}{
+		{"", []string{"http-1"}},
+		{"?service_name=http", []string{"http-1"}},
+		{"?service_name=http&status=draining", []string{"http-2"}},
+		{"?service_name=http&status=ALL", []string{"http-1", "http-2"}},
+	}
+
+	c := defaultServerConfig()
+	draining := instances[1]
+	draining.data.Status = store.Draining
+	c.CatalogMap.(*mockCatalog).prepopulateServices([]mockService{{data: store.Service{ServiceName: "http"}}})
+	c.CatalogMap.(*mockCatalog).prepopulateInstances([]mockInstance{instances[0], draining})
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", serverURL+amalgam8.InstancesURL()+tc.query, nil)
+		assert.Nil(t, err)
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, tc.query)
+
+		list := amalgam8.InstancesList{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+		ids := make([]string, len(list.Instances))
+		for i, inst := range list.Instances {
+			ids[i] = inst.ID
+		}
+		sort.Strings(ids)
+		assert.Equal(t, tc.expected, ids, tc.query)
+	}
+
+	// The instances of a service are filtered by status the same way
+	serviceCases := []struct {
+		query    string // input query
+		expected []string
+	}{
+		{"", []string{"http-1"}},
+		{"?status=draining", []string{"http-2"}},
+		{"?status=ALL", []string{"http-1", "http-2"}},
+	}
+
+	for _, tc := range serviceCases {
+		query := tc.query
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", serverURL+amalgam8.ServiceInstancesURL("http")+query, nil)
+		assert.Nil(t, err)
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, query)
+
+		svc := amalgam8.InstanceList{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &svc))
+		ids := make([]string, len(svc.Instances))
+		for i, inst := range svc.Instances {
+			ids[i] = inst.ID
+		}
+		sort.Strings(ids)
+		assert.Equal(t, tc.expected, ids, query)
+	}
+}
+
+func TestServiceInstancesAllDraining(t *testing.T) {
+	c := defaultServerConfig()
+	draining := instances[1]
+	draining.data.Status = store.Draining
+	c.CatalogMap.(*mockCatalog).prepopulateServices([]mockService{{data: store.Service{ServiceName: "http"}}})
+	c.CatalogMap.(*mockCatalog).prepopulateInstances([]mockInstance{draining})
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	// A service whose instances are all draining is not found, unless draining instances are requested
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", serverURL+amalgam8.ServiceInstancesURL("http"), nil)
+	assert.Nil(t, err)
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", serverURL+amalgam8.ServiceInstancesURL("http")+"?status=DRAINING", nil)
+	assert.Nil(t, err)
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	svc := amalgam8.InstanceList{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &svc))
+	assert.Len(t, svc.Instances, 1)
+	assert.Equal(t, "http-2", svc.Instances[0].ID)
+}
+
+func TestInstancesFilteringIndexedCatalog(t *testing.T) {
+	cases := []struct {
+		query    string // input query
+		expected []string
+	}{
+		{"", []string{"192.168.0.1:80", "192.168.0.2:80"}},
+		{"?tags=DB", []string{"192.168.0.1:80", "192.168.0.2:80", "192.168.0.3:80"}},
+		{"?tags=DB,NoSQL", []string{"192.168.0.2:80", "192.168.0.3:80"}},
+		{"?tags=", []string{}},
+		{"?status=up&tags=NoSQL", []string{"192.168.0.2:80"}},
+		{"?status=STARTING", []string{"192.168.0.3:80"}},
+		{"?status=ALL", []string{"192.168.0.1:80", "192.168.0.2:80", "192.168.0.3:80", "192.168.0.4:80"}},
+		{"?status=", []string{}},
+		{"?service_name=http&tags=NoSQL", []string{"192.168.0.2:80", "192.168.0.3:80"}},
+		{"?service_name=https", []string{}},
+		{"?selector=version=v2", []string{"192.168.0.2:80", "192.168.0.3:80"}},
+		{"?selector=" + url.QueryEscape("version in (v1,v2),zone!=eu-1"), []string{"192.168.0.2:80", "192.168.0.3:80"}},
+		{"?selector=version&selector=!zone", []string{"192.168.0.3:80"}},
+		{"?selector=version=v2&tags=NoSQL&status=UP", []string{"192.168.0.2:80"}},
+	}
+
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	registrations := []amalgam8.InstanceRegistration{
+		{Endpoint: &amalgam8.InstanceAddress{Value: "192.168.0.1:80"}, Tags: []string{"DB"},
+			Labels: map[string]string{"version": "v1", "zone": "eu-1"}},
+		{Endpoint: &amalgam8.InstanceAddress{Value: "192.168.0.2:80"}, Tags: []string{"DB", "NoSQL"},
+			Labels: map[string]string{"version": "v2", "zone": "us-1"}},
+		{Endpoint: &amalgam8.InstanceAddress{Value: "192.168.0.3:80"}, Tags: []string{"NoSQL", "DB"}, Status: "STARTING",
+			Labels: map[string]string{"version": "v2"}},
+		{Endpoint: &amalgam8.InstanceAddress{Value: "192.168.0.4:80"}, Status: "OUT_OF_SERVICE"},
+	}
+	for _, registration := range registrations {
+		registration.ServiceName = "http"
+		registration.Endpoint.Type = "tcp"
+		b, err := json.Marshal(&registration)
+		assert.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", serverURL+amalgam8.InstanceCreateURL(), bytes.NewReader(b))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+	}
+
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", serverURL+amalgam8.InstancesURL()+tc.query, nil)
+		assert.Nil(t, err)
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, tc.query)
+
+		list := amalgam8.InstancesList{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+		endpoints := make([]string, len(list.Instances))
+		for i, inst := range list.Instances {
+			endpoints[i] = inst.Endpoint.Value
+		}
+		sort.Strings(endpoints)
+		assert.Equal(t, tc.expected, endpoints, tc.query)
+	}
+}
+
+func TestInstanceLabels(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	register := func(instanceLabels map[string]string) *httptest.ResponseRecorder {
+		b, err := json.Marshal(&amalgam8.InstanceRegistration{
+			ServiceName: "http",
+			Endpoint:    &amalgam8.InstanceAddress{Value: "192.168.0.1:80", Type: "tcp"},
+			Labels:      instanceLabels,
+		})
+		assert.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", serverURL+amalgam8.InstanceCreateURL(), bytes.NewReader(b))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusBadRequest, register(map[string]string{"version": "v 2"}).Code)
+	assert.Equal(t, http.StatusBadRequest, register(map[string]string{"version": strings.Repeat("v", store.LabelsMaxLength)}).Code)
+
+	recorder := register(map[string]string{"version": "v2"})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// Labels are returned, and may be projected
+	for _, query := range []string{"", "?fields=labels"} {
+		recorder = httptest.NewRecorder()
+		req, err := http.NewRequest("GET", serverURL+amalgam8.InstancesURL()+query, nil)
+		assert.NoError(t, err)
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, query)
+
+		list := amalgam8.InstancesList{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+		if assert.Len(t, list.Instances, 1, query) {
+			assert.Equal(t, map[string]string{"version": "v2"}, list.Instances[0].Labels, query)
+		}
+	}
+
+	// Malformed selectors are rejected
+	for _, query := range []string{"?selector=" + url.QueryEscape("version in (v1"), "?selector=" + url.QueryEscape("version=v 2")} {
+		recorder = httptest.NewRecorder()
+		req, err := http.NewRequest("GET", serverURL+amalgam8.InstancesURL()+query, nil)
+		assert.NoError(t, err)
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+	}
+}
+
+func TestInstanceWeight(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	register := func(endpoint string, weight int) *httptest.ResponseRecorder {
+		b, err := json.Marshal(&amalgam8.InstanceRegistration{
+			ServiceName: "http",
+			Endpoint:    &amalgam8.InstanceAddress{Value: endpoint, Type: "tcp"},
+			Weight:      weight,
+		})
+		assert.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", serverURL+amalgam8.InstanceCreateURL(), bytes.NewReader(b))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusBadRequest, register("192.168.0.1:80", -1).Code)
+	assert.Equal(t, http.StatusBadRequest, register("192.168.0.1:80", store.WeightMaxValue+1).Code)
+	assert.Equal(t, http.StatusCreated, register("192.168.0.1:80", 0).Code)
+	assert.Equal(t, http.StatusCreated, register("192.168.0.2:80", 4).Code)
+
+	// Weights are returned, defaulting to 1, and may be projected
+	for _, query := range []string{"", "?fields=weight"} {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", serverURL+amalgam8.InstancesURL()+query, nil)
+		assert.NoError(t, err)
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, query)
+
+		list := amalgam8.InstancesList{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+		weights := make(map[string]int)
+		for _, si := range list.Instances {
+			weights[si.Endpoint.Value] = si.Weight
+		}
+		assert.Equal(t, map[string]int{"192.168.0.1:80": 1, "192.168.0.2:80": 4}, weights, query)
+	}
+}
+
+func TestInstanceEndpoints(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	register := func(endpoints map[string]*amalgam8.InstanceAddress) *httptest.ResponseRecorder {
+		b, err := json.Marshal(&amalgam8.InstanceRegistration{
+			ServiceName: "http",
+			Endpoint:    &amalgam8.InstanceAddress{Value: "192.168.0.1:80", Type: "http"},
+			Endpoints:   endpoints,
+		})
+		assert.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", serverURL+amalgam8.InstanceCreateURL(), bytes.NewReader(b))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	endpoints := map[string]*amalgam8.InstanceAddress{
+		"admin": {Value: "192.168.0.1:9090", Type: "http"},
+		"grpc":  {Value: "192.168.0.1:5000", Type: "tcp"},
+	}
+
+	assert.Equal(t, http.StatusBadRequest, register(map[string]*amalgam8.InstanceAddress{"Admin": endpoints["admin"]}).Code)
+	assert.Equal(t, http.StatusBadRequest, register(map[string]*amalgam8.InstanceAddress{"admin": {Value: "192.168.0.1:9090"}}).Code)
+	assert.Equal(t, http.StatusBadRequest, register(map[string]*amalgam8.InstanceAddress{"admin": {Value: "192.168.0.1:9090", Type: "ftp"}}).Code)
+	assert.Equal(t, http.StatusCreated, register(endpoints).Code)
+
+	// Named endpoints are returned alongside the primary endpoint, and may be projected
+	for _, query := range []string{"", "?fields=endpoints"} {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", serverURL+amalgam8.InstancesURL()+query, nil)
+		assert.NoError(t, err)
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, query)
+
+		list := amalgam8.InstancesList{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+		if assert.Len(t, list.Instances, 1, query) {
+			assert.Equal(t, "192.168.0.1:80", list.Instances[0].Endpoint.Value, query)
+			assert.Equal(t, endpoints, list.Instances[0].Endpoints, query)
+		}
+	}
+}
+
+func TestInstancesLocality(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	for i, zone := range []string{"us-south/dal12", "us-south/dal10", "eu-de/fra02"} {
+		locality := strings.Split(zone, "/")
+		b, err := json.Marshal(&amalgam8.InstanceRegistration{
+			ServiceName: "http",
+			Endpoint:    &amalgam8.InstanceAddress{Value: "192.168.0." + strconv.Itoa(i+1) + ":80", Type: "tcp"},
+			Status:      "UP",
+			Labels:      map[string]string{"region": locality[0], "zone": locality[1]},
+		})
+		assert.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", serverURL+amalgam8.InstanceCreateURL(), bytes.NewReader(b))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+	}
+
+	cases := []struct {
+		query     string
+		expected  int
+		endpoints []string
+	}{
+		{"", http.StatusOK, []string{"192.168.0.1:80", "192.168.0.2:80", "192.168.0.3:80"}},
+		{"?region=us-south&zone=dal10", http.StatusOK, []string{"192.168.0.2:80", "192.168.0.1:80", "192.168.0.3:80"}},
+		{"?region=us-south&zone=dal10&min_healthy=1", http.StatusOK, []string{"192.168.0.2:80"}},
+		{"?region=us-south&zone=dal10&min_healthy=2", http.StatusOK, []string{"192.168.0.2:80", "192.168.0.1:80"}},
+		{"?region=ap-north", http.StatusOK, []string{"192.168.0.1:80", "192.168.0.2:80", "192.168.0.3:80"}},
+		{"?region=us-south&min_healthy=two", http.StatusBadRequest, nil},
+		{"?region=us-south&min_healthy=-1", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range cases {
+		for _, path := range []string{amalgam8.InstancesURL(), amalgam8.ServiceInstancesURL("http")} {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", serverURL+path+tc.query, nil)
+			assert.NoError(t, err)
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expected, recorder.Code, path+tc.query)
+			if recorder.Code != http.StatusOK {
+				continue
+			}
+
+			list := amalgam8.InstanceList{}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+			endpoints := make([]string, len(list.Instances))
+			for i, si := range list.Instances {
+				endpoints[i] = si.Endpoint.Value
+			}
+			// Only the nearest instance is known to come first, as instances of the same proximity are unordered
+			if len(endpoints) > 0 && tc.query != "" && tc.query != "?region=ap-north" {
+				assert.Equal(t, tc.endpoints[0], endpoints[0], path+tc.query)
+			}
+			expected := append([]string(nil), tc.endpoints...)
+			sort.Strings(expected)
+			sort.Strings(endpoints)
+			assert.Equal(t, expected, endpoints, path+tc.query)
+		}
+	}
+}
+
 // services/<name>:methods
 func TestServicesListMethods(t *testing.T) {
 	var methods = []string{"CONNECT", "HEAD", "OPTIONS", "PATCH", "POST", "PUT", "TRACE"}
@@ -590,6 +1141,116 @@ func TestServicesList(t *testing.T) {
 	}
 }
 
+//-----------------
+// blocking queries
+//-----------------
+func registerTestInstance(t *testing.T, handler http.Handler, endpoint string) {
+	b, err := json.Marshal(&amalgam8.InstanceRegistration{
+		ServiceName: "http",
+		Endpoint:    &amalgam8.InstanceAddress{Value: endpoint, Type: "tcp"},
+	})
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", serverURL+amalgam8.InstanceCreateURL(), bytes.NewReader(b))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+}
+
+func TestInstancesBlockingQuery(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	registerTestInstance(t, handler, "192.168.0.1:80")
+
+	list := func(query string) (*httptest.ResponseRecorder, amalgam8.InstancesList) {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", serverURL+amalgam8.InstancesURL()+query, nil)
+		assert.NoError(t, err)
+		handler.ServeHTTP(recorder, req)
+
+		list := amalgam8.InstancesList{}
+		if recorder.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+		}
+		return recorder, list
+	}
+
+	recorder, instances := list("")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Len(t, instances.Instances, 1)
+	index := recorder.Header().Get(amalgam8.IndexHeader)
+	assert.NotEmpty(t, index)
+
+	// Without changes, a blocking query returns the same index once the wait duration elapses
+	start := time.Now()
+	recorder, instances = list("?index=" + index + "&wait=100ms")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.Equal(t, index, recorder.Header().Get(amalgam8.IndexHeader))
+	assert.Len(t, instances.Instances, 1)
+
+	// A blocking query returns as soon as the catalog changes
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		registerTestInstance(t, handler, "192.168.0.2:80")
+	}()
+	start = time.Now()
+	recorder, instances = list("?index=" + index + "&wait=10s")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.NotEqual(t, index, recorder.Header().Get(amalgam8.IndexHeader))
+	assert.Len(t, instances.Instances, 2)
+
+	// Invalid blocking query parameters
+	for _, query := range []string{"?index=abc", "?index=1&wait=abc", "?wait=-1s"} {
+		recorder, _ = list(query)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + amalgam8.EventsURL())
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	registerTestInstance(t, handler, "192.168.0.1:80")
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		if err != nil {
+			return
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	assert.True(t, strings.HasPrefix(lines[0], "id: "))
+	assert.Equal(t, "event: register", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "data: "))
+
+	inst := amalgam8.ServiceInstance{}
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &inst))
+	assert.Equal(t, "http", inst.ServiceName)
+	assert.Equal(t, "192.168.0.1:80", inst.Endpoint.Value)
+}
+
 //---------------
 // secure access
 //---------------
@@ -15,6 +15,8 @@
 package api
 
 import (
+	"crypto/tls"
+
 	"github.com/ant0ine/go-json-rest/rest"
 
 	"github.com/amalgam8/amalgam8/pkg/auth"
@@ -28,4 +30,7 @@ type Config struct {
 	Authenticator   auth.Authenticator
 	Middlewares     []rest.Middleware
 	RequireHTTPS    bool
+
+	// TLSConfig, if set, is used for serving the REST API over TLS
+	TLSConfig *tls.Config
 }
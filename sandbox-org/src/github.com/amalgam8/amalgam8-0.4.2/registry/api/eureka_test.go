@@ -25,6 +25,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/amalgam8/amalgam8/registry/api/protocol/amalgam8"
 	"github.com/amalgam8/amalgam8/registry/api/protocol/eureka"
 	"github.com/amalgam8/amalgam8/registry/store"
 )
@@ -107,6 +108,42 @@ func TestEurekaInstancesCreate(t *testing.T) {
 	}
 }
 
+func TestEurekaInstanceEndpoints(t *testing.T) {
+	c := defaultServerConfig()
+	c.CatalogMap = store.New(nil)
+	handler, err := setupServer(c)
+	assert.Nil(t, err)
+
+	tc := newCreateEurekaTestCase("localhost", "http", "192.168.1.1", "http-vip", "8080", metadata, http.StatusNoContent)
+	tc.instance.SecPort = &eureka.Port{Enabled: "true", Value: "8443"}
+	b, err := json.Marshal(&eureka.InstanceWrapper{Inst: &tc.instance})
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", serverURL+eureka.ApplicationURL("", "http"), bytes.NewReader(b))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, tc.expected, recorder.Code, recorder.Body.String())
+
+	// Both ports are exposed as named endpoints, while the secure port remains the primary endpoint
+	recorder = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", serverURL+amalgam8.InstancesURL(), nil)
+	assert.NoError(t, err)
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	list := amalgam8.InstancesList{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+	if assert.Len(t, list.Instances, 1) {
+		assert.Equal(t, &amalgam8.InstanceAddress{Type: "https", Value: "localhost:8443"}, list.Instances[0].Endpoint)
+		assert.Equal(t, map[string]*amalgam8.InstanceAddress{
+			"http":  {Type: "http", Value: "localhost:8080"},
+			"https": {Type: "https", Value: "localhost:8443"},
+		}, list.Instances[0].Endpoints)
+	}
+}
+
 // instance:delete
 func TestEurekaInstanceDelete(t *testing.T) {
 	cases := []struct {
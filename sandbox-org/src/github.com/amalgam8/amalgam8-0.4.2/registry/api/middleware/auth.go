@@ -28,9 +28,11 @@ import (
 
 // AuthMiddleware provides a generic authentication middleware
 // On failure, a 401 HTTP response is returned. On success, the wrapped middleware is called.
+// If RequiredScope is set, a 403 HTTP response is returned for tokens not granted the scope required by the request.
 type AuthMiddleware struct {
 	TokenRouteParam string
 	Authenticator   auth.Authenticator
+	RequiredScope   func(request *rest.Request) auth.Scope
 }
 
 // MiddlewareFunc returns a go-json-rest HTTP Handler function, wrapping calls to the provided HandlerFunc
@@ -65,8 +67,11 @@ func (mw *AuthMiddleware) handler(writer rest.ResponseWriter, request *rest.Requ
 	} else {
 		ctx = context.WithValue(ctxFromEnv.(context.Context), auth.ContextHeadersKey, request.Header)
 	}
+	if request.TLS != nil {
+		ctx = context.WithValue(ctx, auth.ContextTLSKey, request.TLS)
+	}
 
-	nsPtr, err := mw.Authenticator.Authenticate(ctx, token)
+	nsPtr, scopes, err := auth.AuthenticateScopes(ctx, mw.Authenticator, token)
 	if err != nil {
 		switch err {
 		case auth.ErrEmptyToken:
@@ -81,6 +86,14 @@ func (mw *AuthMiddleware) handler(writer rest.ResponseWriter, request *rest.Requ
 		return
 	}
 
+	if mw.RequiredScope != nil {
+		if scope := mw.RequiredScope(request); scope != "" && !scopes.Has(scope) {
+			i18n.Error(request, writer, http.StatusForbidden, i18n.ErrorAuthorizationInsufficientScope,
+				map[string]interface{}{"Scope": scope})
+			return
+		}
+	}
+
 	request.Env[env.Namespace] = *nsPtr
 	h(writer, request)
 }
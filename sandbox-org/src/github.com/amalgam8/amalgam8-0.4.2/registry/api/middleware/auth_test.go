@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/amalgam8/amalgam8/pkg/auth"
+	"github.com/ant0ine/go-json-rest/rest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -159,3 +160,62 @@ func TestCommunicationError(t *testing.T) {
 
 	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
 }
+
+type mockScopedAuthenticator struct {
+	mockAuthenticator
+	scopes auth.Scopes
+}
+
+func (ma *mockScopedAuthenticator) AuthenticateScopes(ctx context.Context, token string) (*auth.Namespace, auth.Scopes, error) {
+	namespace := auth.NamespaceFrom(token)
+	return &namespace, ma.scopes, nil
+}
+
+func TestRequiredScope(t *testing.T) {
+	cases := []struct {
+		scopes auth.Scopes
+		code   int
+	}{
+		{scopes: auth.Scopes{auth.ScopeRegistryDiscover}, code: http.StatusOK},
+		{scopes: auth.Scopes{auth.ScopeRegistryRegister, auth.ScopeRegistryDiscover}, code: http.StatusOK},
+		{scopes: auth.Scopes{auth.ScopeRegistryRegister}, code: http.StatusForbidden},
+		{scopes: auth.Scopes{}, code: http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		ma := &mockScopedAuthenticator{scopes: c.scopes}
+		authMw := &AuthMiddleware{
+			Authenticator: ma,
+			RequiredScope: func(r *rest.Request) auth.Scope { return auth.ScopeRegistryDiscover },
+		}
+
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "http://example.com/", nil)
+		req.Header.Set("Authorization", "Bearer "+validToken)
+
+		jrestServer(authMw, "/").ServeHTTP(res, req)
+
+		assert.Equal(t, c.code, res.Code, "scopes %v", c.scopes)
+	}
+}
+
+func TestUnscopedAuthenticatorGrantsAllScopes(t *testing.T) {
+	ma := &mockAuthenticator{
+		authFunc: func(ctx context.Context, token string) (*auth.Namespace, error) {
+			namespace := auth.NamespaceFrom(token)
+			return &namespace, nil
+		},
+	}
+	authMw := &AuthMiddleware{
+		Authenticator: ma,
+		RequiredScope: func(r *rest.Request) auth.Scope { return auth.ScopeRegistryRegister },
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("Authorization", "Bearer "+validToken)
+
+	jrestServer(authMw, "/").ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+}
@@ -0,0 +1,207 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package amalgam8
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ant0ine/go-json-rest/rest"
+
+	"github.com/amalgam8/amalgam8/registry/api/env"
+	"github.com/amalgam8/amalgam8/registry/store"
+	"github.com/amalgam8/amalgam8/registry/utils/i18n"
+)
+
+func (routes *Routes) registerInstances(w rest.ResponseWriter, r *rest.Request) {
+	// The operation placeholder of the route matches any suffix of the instances path
+	if r.PathParam(RouteParamOperation) != batchOperation {
+		rest.NotFound(w, r)
+		return
+	}
+
+	var req InstanceRegistrationBatch
+	if err := r.DecodeJsonPayload(&req); err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warn("Failed to register instances")
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorBatchMalformed)
+		return
+	}
+
+	if len(req.Instances) > BatchMaxSize {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "batch too large",
+		}).Warnf("Failed to register %d instances", len(req.Instances))
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorBatchTooLarge, BatchMaxSize)
+		return
+	}
+
+	catalog := routes.catalog(w, r)
+	if catalog == nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "catalog is nil",
+		}).Errorf("Failed to register %d instances", len(req.Instances))
+		// error response set by routes.catalog()
+		return
+	}
+
+	results := make([]*InstanceRegistrationResult, len(req.Instances))
+	instances := make([]*store.ServiceInstance, 0, len(req.Instances))
+	positions := make([]int, 0, len(req.Instances))
+	for i, reg := range req.Instances {
+		if reg == nil {
+			results[i] = &InstanceRegistrationResult{
+				Code:  http.StatusBadRequest,
+				Error: i18n.Message(r, i18n.ErrorInstanceRegistrationFailed),
+			}
+			continue
+		}
+
+		if id, args, err := validateInstanceRegistration(reg); err != nil {
+			routes.logger.WithFields(log.Fields{
+				"namespace": r.Env[env.Namespace],
+				"error":     err,
+			}).Warnf("Failed to register instance %+v", reg)
+
+			results[i] = &InstanceRegistrationResult{Code: http.StatusBadRequest, Error: i18n.Message(r, id, args...)}
+			continue
+		}
+
+		instances = append(instances, newStoreInstance(reg))
+		positions = append(positions, i)
+	}
+
+	linksURL := baseLinksURL(r)
+	registered := 0
+	for k, result := range store.RegisterBatch(catalog, instances) {
+		i := positions[k]
+		if result.Err != nil {
+			routes.logger.WithFields(log.Fields{
+				"namespace": r.Env[env.Namespace],
+				"error":     result.Err,
+			}).Warnf("Failed to register instance %+v", req.Instances[i])
+
+			id, args := registrationErrorMessage(result.Err)
+			results[i] = &InstanceRegistrationResult{Code: statusCodeFromError(result.Err), Error: i18n.Message(r, id, args...)}
+			continue
+		}
+
+		sir := result.Instance
+		results[i] = &InstanceRegistrationResult{
+			Code:  http.StatusCreated,
+			ID:    sir.ID,
+			TTL:   uint32(sir.TTL / time.Second),
+			Links: BuildLinks(linksURL, sir.ID),
+		}
+		registered++
+	}
+
+	routes.logger.WithFields(log.Fields{
+		"namespace": r.Env[env.Namespace],
+	}).Infof("%d of %d instances registered", registered, len(req.Instances))
+
+	if err := w.WriteJson(&InstanceRegistrationResults{Instances: results}); err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warn("Failed to write batch registration response")
+
+		i18n.Error(r, w, http.StatusInternalServerError, i18n.ErrorEncoding)
+	}
+}
+
+func (routes *Routes) renewInstances(w rest.ResponseWriter, r *rest.Request) {
+	var req InstanceHeartbeatBatch
+	if err := r.DecodeJsonPayload(&req); err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warn("Failed to renew instances")
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorBatchMalformed)
+		return
+	}
+
+	if len(req.IDs) > BatchMaxSize {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "batch too large",
+		}).Warnf("Failed to renew %d instances", len(req.IDs))
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorBatchTooLarge, BatchMaxSize)
+		return
+	}
+
+	catalog := routes.catalog(w, r)
+	if catalog == nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "catalog is nil",
+		}).Errorf("Failed to renew %d instances", len(req.IDs))
+		// error response set by routes.catalog()
+		return
+	}
+
+	results := make([]*InstanceHeartbeatResult, len(req.IDs))
+	ids := make([]string, 0, len(req.IDs))
+	positions := make([]int, 0, len(req.IDs))
+	for i, iid := range req.IDs {
+		if iid == "" {
+			results[i] = &InstanceHeartbeatResult{
+				Code:  http.StatusBadRequest,
+				Error: i18n.Message(r, i18n.ErrorInstanceIdentifierMissing),
+			}
+			continue
+		}
+
+		ids = append(ids, iid)
+		positions = append(positions, i)
+	}
+
+	for k, result := range store.RenewBatch(catalog, ids) {
+		i := positions[k]
+		if result.Err != nil {
+			routes.logger.WithFields(log.Fields{
+				"namespace": r.Env[env.Namespace],
+				"error":     result.Err,
+			}).Warnf("Failed to renew instance %s", ids[k])
+
+			results[i] = &InstanceHeartbeatResult{
+				ID:    ids[k],
+				Code:  statusCodeFromError(result.Err),
+				Error: i18n.Message(r, i18n.ErrorInstanceHeartbeatFailed),
+			}
+			continue
+		}
+
+		results[i] = &InstanceHeartbeatResult{ID: ids[k], Code: http.StatusOK}
+	}
+
+	if err := w.WriteJson(&InstanceHeartbeatResults{Heartbeats: results}); err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warn("Failed to write batch heartbeat response")
+
+		i18n.Error(r, w, http.StatusInternalServerError, i18n.ErrorEncoding)
+	}
+}
@@ -23,6 +23,17 @@ func InstanceCreateURL() string {
 	return instancesPath
 }
 
+// InstancesBatchURL returns URL path used for registering multiple instances at once
+func InstancesBatchURL() string {
+	return instancesPath + batchOperation
+}
+
+// instancesOperationTemplateURL returns the router's (server side) URL path used for custom operations on instances.
+// Note that the router only supports custom operations as a placeholder, which the handler must match.
+func instancesOperationTemplateURL() string {
+	return instancesOperationTemplate
+}
+
 // InstancesURL returns URL path used for querying instances
 func InstancesURL() string {
 	return instancesPath
@@ -48,6 +59,21 @@ func instanceHeartbeatTemplateURL() string {
 	return instanceHeartbeatTemplate
 }
 
+// HeartbeatsURL returns (client side) URL path used for renewing registration of multiple instances at once
+func HeartbeatsURL() string {
+	return heartbeatsPath
+}
+
+// InstanceStatusURL returns (client side) URL path used for setting the status of the identified instance
+func InstanceStatusURL(id string) string {
+	return strings.Join([]string{instancesPath, "/", id, status}, "")
+}
+
+// instanceStatusTemplateURL returns router (server side) URL template for instance status
+func instanceStatusTemplateURL() string {
+	return instanceStatusTemplate
+}
+
 // ServiceNamesURL returns (client side) URL path used for querying service names
 func ServiceNamesURL() string {
 	return servicesPath
@@ -63,19 +89,56 @@ func serviceInstancesTemplateURL() string {
 	return serviceInstanceTemplate
 }
 
+// EventsURL returns URL path used for streaming catalog events
+func EventsURL() string {
+	return eventsPath
+}
+
 // API parameter names
 const (
 	RouteParamServiceName = "sname"
 	RouteParamInstanceID  = "iid"
+	RouteParamOperation   = "op"
+
+	// QueryParamSelector is the label selector of instance queries, e.g. "version in (v1,v2),!canary"
+	QueryParamSelector = "selector"
+
+	// QueryParamRegion and QueryParamZone are the locality of the caller of instance queries,
+	// which prefer instances labeled with the same zone, or else the same region
+	QueryParamRegion = "region"
+	QueryParamZone   = "zone"
+
+	// QueryParamMinHealthy is the minimum number of healthy instances returned by instance queries that specify a locality,
+	// below which farther instances are returned as well
+	QueryParamMinHealthy = "min_healthy"
+)
+
+// Query parameters and headers of blocking queries and event streams
+const (
+	// QueryParamIndex is the catalog modification index a blocking query waits to change from,
+	// or an event stream resumes after
+	QueryParamIndex = "index"
+
+	// QueryParamWait is the maximum duration of a blocking query, e.g. "30s"
+	QueryParamWait = "wait"
+
+	// IndexHeader is the response header holding the catalog modification index
+	IndexHeader = "SD-Index"
 )
 
 const ( // API related constants
-	apiPath                   = "/api"
-	apiVer                    = "/v1"
-	heartbeat                 = "/heartbeat"
-	instancesPath             = apiPath + apiVer + "/instances"
-	servicesPath              = apiPath + apiVer + "/services"
-	instanceTemplate          = instancesPath + "/#" + RouteParamInstanceID
-	instanceHeartbeatTemplate = instanceTemplate + heartbeat
-	serviceInstanceTemplate   = servicesPath + "/#" + RouteParamServiceName
+	apiPath                    = "/api"
+	apiVer                     = "/v1"
+	heartbeat                  = "/heartbeat"
+	status                     = "/status"
+	instancesPath              = apiPath + apiVer + "/instances"
+	servicesPath               = apiPath + apiVer + "/services"
+	eventsPath                 = apiPath + apiVer + "/events"
+	heartbeatsPath             = apiPath + apiVer + "/heartbeats"
+	batchOperation             = ":batch"
+	instancesOperationTemplate = instancesPath + ":" + RouteParamOperation
+	instanceTemplate           = instancesPath + "/#" + RouteParamInstanceID
+	instanceHeartbeatTemplate  = instanceTemplate + heartbeat
+	instanceStatusTemplate     = instanceTemplate + status
+	serviceInstanceTemplate    = servicesPath + "/#" + RouteParamServiceName
 )
@@ -24,12 +24,15 @@ import (
 
 // InstanceRegistration encapsulates information needed for a service instance registration request
 type InstanceRegistration struct {
-	ServiceName string           `json:"service_name,omitempty"`
-	Endpoint    *InstanceAddress `json:"endpoint,omitempty"`
-	TTL         uint32           `json:"ttl,omitempty"`
-	Status      string           `json:"status,omitempty"`
-	Metadata    json.RawMessage  `json:"metadata,omitempty"`
-	Tags        []string         `json:"tags,omitempty"`
+	ServiceName string                      `json:"service_name,omitempty"`
+	Endpoint    *InstanceAddress            `json:"endpoint,omitempty"`
+	Endpoints   map[string]*InstanceAddress `json:"endpoints,omitempty"`
+	TTL         uint32                      `json:"ttl,omitempty"`
+	Status      string                      `json:"status,omitempty"`
+	Metadata    json.RawMessage             `json:"metadata,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Labels      map[string]string           `json:"labels,omitempty"`
+	Weight      int                         `json:"weight,omitempty"`
 }
 
 // String output the structure
@@ -38,21 +41,37 @@ func (ir *InstanceRegistration) String() string {
 	if ir.Metadata != nil {
 		mtlen = len(ir.Metadata)
 	}
-	return fmt.Sprintf("service_name: %s, endpoint: %s, ttl: %d, status: %s, metadata: %d",
-		ir.ServiceName, ir.Endpoint, ir.TTL, ir.Status, mtlen)
+	return fmt.Sprintf("service_name: %s, endpoint: %s, endpoints: %v, ttl: %d, status: %s, metadata: %d, weight: %d",
+		ir.ServiceName, ir.Endpoint, ir.Endpoints, ir.TTL, ir.Status, mtlen, ir.Weight)
+}
+
+// InstanceStatus encapsulates information needed for an instance status change request
+type InstanceStatus struct {
+	Status string `json:"status"`
+}
+
+// InstanceUpdate encapsulates information needed for an instance update request.
+// Omitted fields are left unchanged, while a null metadata clears the metadata of the instance.
+type InstanceUpdate struct {
+	Metadata json.RawMessage   `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
 }
 
 // ServiceInstance defines the response of a successful instance registration request
 type ServiceInstance struct {
-	ID            string           `json:"id,omitempty"`
-	ServiceName   string           `json:"service_name,omitempty"`
-	Endpoint      *InstanceAddress `json:"endpoint,omitempty"`
-	TTL           uint32           `json:"ttl,omitempty"`
-	Status        string           `json:"status,omitempty"`
-	Metadata      json.RawMessage  `json:"metadata,omitempty"`
-	LastHeartbeat *time.Time       `json:"last_heartbeat,omitempty"`
-	Links         *InstanceLinks   `json:"links,omitempty"`
-	Tags          []string         `json:"tags,omitempty"`
+	ID            string                      `json:"id,omitempty"`
+	ServiceName   string                      `json:"service_name,omitempty"`
+	Endpoint      *InstanceAddress            `json:"endpoint,omitempty"`
+	Endpoints     map[string]*InstanceAddress `json:"endpoints,omitempty"`
+	TTL           uint32                      `json:"ttl,omitempty"`
+	Status        string                      `json:"status,omitempty"`
+	Metadata      json.RawMessage             `json:"metadata,omitempty"`
+	LastHeartbeat *time.Time                  `json:"last_heartbeat,omitempty"`
+	Links         *InstanceLinks              `json:"links,omitempty"`
+	Tags          []string                    `json:"tags,omitempty"`
+	Labels        map[string]string           `json:"labels,omitempty"`
+	Weight        int                         `json:"weight,omitempty"`
 }
 
 // String output the structure
@@ -61,8 +80,8 @@ func (si *ServiceInstance) String() string {
 	if si.Metadata != nil {
 		mtlen = len(si.Metadata)
 	}
-	return fmt.Sprintf("id: %s, serviceName: %s, endpoint: %s, ttl: %d, status: %s, lastHeartbeat: %v, metadata: %d, links: %s, tags:%s",
-		si.ID, si.ServiceName, si.Endpoint, si.TTL, si.Status, si.LastHeartbeat, mtlen, si.Links, si.Tags)
+	return fmt.Sprintf("id: %s, serviceName: %s, endpoint: %s, endpoints: %v, ttl: %d, status: %s, lastHeartbeat: %v, metadata: %d, links: %s, tags:%s, labels:%v, weight:%d",
+		si.ID, si.ServiceName, si.Endpoint, si.Endpoints, si.TTL, si.Status, si.LastHeartbeat, mtlen, si.Links, si.Tags, si.Labels, si.Weight)
 }
 
 // GetJSONToFieldsMap returns a map from JSON fields to struct field names
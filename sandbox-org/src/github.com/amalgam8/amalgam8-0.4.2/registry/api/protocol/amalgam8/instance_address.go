@@ -16,6 +16,8 @@ package amalgam8
 
 import (
 	"fmt"
+
+	"github.com/amalgam8/amalgam8/registry/store"
 )
 
 const (
@@ -42,3 +44,27 @@ type InstanceAddress struct {
 func (a *InstanceAddress) String() string {
 	return fmt.Sprintf("%s:%s", a.Type, a.Value)
 }
+
+// toStoreEndpoints converts named instance addresses to the named endpoints of a stored service instance
+func toStoreEndpoints(addresses map[string]*InstanceAddress) map[string]*store.Endpoint {
+	if len(addresses) == 0 {
+		return nil
+	}
+	endpoints := make(map[string]*store.Endpoint, len(addresses))
+	for name, address := range addresses {
+		endpoints[name] = &store.Endpoint{Type: address.Type, Value: address.Value}
+	}
+	return endpoints
+}
+
+// fromStoreEndpoints converts the named endpoints of a stored service instance to named instance addresses
+func fromStoreEndpoints(endpoints map[string]*store.Endpoint) map[string]*InstanceAddress {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	addresses := make(map[string]*InstanceAddress, len(endpoints))
+	for name, endpoint := range endpoints {
+		addresses[name] = &InstanceAddress{Type: endpoint.Type, Value: endpoint.Value}
+	}
+	return addresses
+}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -57,40 +58,16 @@ func (routes *Routes) registerInstance(w rest.ResponseWriter, r *rest.Request) {
 		return
 	}
 
-	si := &store.ServiceInstance{
-		ServiceName: req.ServiceName,
-		Endpoint:    &store.Endpoint{Type: req.Endpoint.Type, Value: req.Endpoint.Value},
-		Status:      strings.ToUpper(req.Status),
-		TTL:         time.Duration(req.TTL) * time.Second,
-		Metadata:    req.Metadata,
-		Tags:        req.Tags}
 	var sir *store.ServiceInstance
 
-	if sir, err = catalog.Register(si); err != nil {
+	if sir, err = catalog.Register(newStoreInstance(req)); err != nil {
 		routes.logger.WithFields(log.Fields{
 			"namespace": r.Env[env.Namespace],
 			"error":     err,
 		}).Warnf("Failed to register instance %+v", req)
 
-		if regerr, ok := err.(*store.Error); ok {
-			switch regerr.Code {
-			case store.ErrorNoInstanceServiceName:
-				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorNoServiceName)
-			case store.ErrorInstanceServiceNameTooLong:
-				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorServiceNameTooLong, store.ServiceNameMaxLength)
-			case store.ErrorInstanceEndpointValueTooLong:
-				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorEndpointValueTooLong, store.EndpointValueMaxLength)
-			case store.ErrorInstanceStatusLengthTooLong:
-				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorStatusLengthTooLong, store.StatusMaxLength)
-			case store.ErrorInstanceMetaDataTooLong:
-				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorMetaDataTooLong, store.MetadataMaxLength)
-			default:
-				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorInstanceRegistrationFailed)
-			}
-		} else {
-			i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorInstanceRegistrationFailed)
-		}
-
+		id, args := registrationErrorMessage(err)
+		i18n.Error(r, w, statusCodeFromError(err), id, args...)
 		return
 	} else if sir == nil {
 		routes.logger.WithFields(log.Fields{
@@ -114,18 +91,58 @@ func (routes *Routes) registerInstance(w rest.ResponseWriter, r *rest.Request) {
 	routes.sendRegistrationResponse(w, r, sir)
 }
 
+// newStoreInstance returns the service instance to be registered in the catalog for the registration request
+func newStoreInstance(req *InstanceRegistration) *store.ServiceInstance {
+	return &store.ServiceInstance{
+		ServiceName: req.ServiceName,
+		Endpoint:    &store.Endpoint{Type: req.Endpoint.Type, Value: req.Endpoint.Value},
+		Endpoints:   toStoreEndpoints(req.Endpoints),
+		Status:      strings.ToUpper(req.Status),
+		TTL:         time.Duration(req.TTL) * time.Second,
+		Metadata:    req.Metadata,
+		Tags:        req.Tags,
+		Labels:      req.Labels,
+		Weight:      req.Weight}
+}
+
+// registrationErrorMessage returns the identifier and arguments of the error message for the client of a failed registration
+func registrationErrorMessage(err error) (string, []interface{}) {
+	regerr, ok := err.(*store.Error)
+	if !ok {
+		return i18n.ErrorInstanceRegistrationFailed, nil
+	}
+
+	switch regerr.Code {
+	case store.ErrorNoInstanceServiceName:
+		return i18n.ErrorNoServiceName, nil
+	case store.ErrorInstanceServiceNameTooLong:
+		return i18n.ErrorServiceNameTooLong, []interface{}{store.ServiceNameMaxLength}
+	case store.ErrorInstanceEndpointValueTooLong:
+		return i18n.ErrorEndpointValueTooLong, []interface{}{store.EndpointValueMaxLength}
+	case store.ErrorInstanceEndpointNameInvalid:
+		return i18n.ErrorInstanceEndpointNameInvalid, nil
+	case store.ErrorInstanceStatusLengthTooLong:
+		return i18n.ErrorStatusLengthTooLong, []interface{}{store.StatusMaxLength}
+	case store.ErrorInstanceMetaDataTooLong:
+		return i18n.ErrorMetaDataTooLong, []interface{}{store.MetadataMaxLength}
+	case store.ErrorInstanceLabelsTooLong:
+		return i18n.ErrorLabelsTooLong, []interface{}{store.LabelsMaxLength}
+	case store.ErrorInstanceLabelsInvalid:
+		return i18n.ErrorInstanceLabelsInvalid, nil
+	case store.ErrorInstanceWeightOutOfRange:
+		return i18n.ErrorInstanceWeightOutOfRange, []interface{}{store.WeightMaxValue}
+	default:
+		return i18n.ErrorInstanceRegistrationFailed, nil
+	}
+}
+
 func (routes *Routes) sendRegistrationResponse(w rest.ResponseWriter, r *rest.Request, sir *store.ServiceInstance) {
 	routes.logger.WithFields(log.Fields{
 		"namespace": r.Env[env.Namespace],
 	}).Infof("Instance %s registered", sir)
 
 	ttl := uint32(sir.TTL / time.Second)
-	linksURL := r.BaseUrl()
-	if middleware.IsUsingSecureConnection(r) { // request came in over a secure connection, continue using it
-		linksURL.Scheme = "https"
-	}
-
-	links := BuildLinks(linksURL.String(), sir.ID)
+	links := BuildLinks(baseLinksURL(r), sir.ID)
 	instance := &ServiceInstance{
 		ID:    sir.ID,
 		TTL:   ttl,
@@ -145,6 +162,15 @@ func (routes *Routes) sendRegistrationResponse(w rest.ResponseWriter, r *rest.Re
 	}
 }
 
+// baseLinksURL returns the base URL of the links to registered instances
+func baseLinksURL(r *rest.Request) string {
+	linksURL := r.BaseUrl()
+	if middleware.IsUsingSecureConnection(r) { // request came in over a secure connection, continue using it
+		linksURL.Scheme = "https"
+	}
+	return linksURL.String()
+}
+
 func (routes *Routes) parseInstanceRegistrationRequest(w rest.ResponseWriter, r *rest.Request) (*InstanceRegistration, error) {
 	var req InstanceRegistration
 	var err error
@@ -160,66 +186,42 @@ func (routes *Routes) parseInstanceRegistrationRequest(w rest.ResponseWriter, r
 		return nil, err
 	}
 
-	if req.ServiceName == "" {
+	if id, args, err := validateInstanceRegistration(&req); err != nil {
 		routes.logger.WithFields(log.Fields{
 			"namespace": r.Env[env.Namespace],
-			"error":     "service name is required",
+			"error":     err,
 		}).Warnf("Failed to register instance %+v", req)
 
-		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorServiceNameMissing)
-		return nil, errors.New("Service name is required")
+		i18n.Error(r, w, http.StatusBadRequest, id, args...)
+		return nil, err
 	}
 
-	if req.Endpoint == nil {
-		routes.logger.WithFields(log.Fields{
-			"namespace": r.Env[env.Namespace],
-			"error":     "Endpoint is required",
-		}).Warnf("Failed to register instance %+v", req)
+	return &req, nil
+}
 
-		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceEndpointMissing)
-		return nil, errors.New("Endpoint is required")
+// validateInstanceRegistration validates the registration request, setting its status to UP if it is not passed in.
+// It returns the identifier and arguments of the error message for the client along with the error.
+func validateInstanceRegistration(req *InstanceRegistration) (string, []interface{}, error) {
+	if req.ServiceName == "" {
+		return i18n.ErrorServiceNameMissing, nil, errors.New("Service name is required")
 	}
 
-	if req.Endpoint.Type == "" || req.Endpoint.Value == "" {
-		routes.logger.WithFields(log.Fields{
-			"namespace": r.Env[env.Namespace],
-			"error":     "Endpoint type or value are missing or mismatched",
-		}).Warnf("Failed to register instance %+v", req)
-
-		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceEndpontMalformed)
-		return nil, errors.New("Endpoint type or value are missing or mismatched")
+	if req.Endpoint == nil {
+		return i18n.ErrorInstanceEndpointMissing, nil, errors.New("Endpoint is required")
 	}
 
-	switch req.Endpoint.Type {
-	case EndpointTypeHTTP:
-	case EndpointTypeHTTPS:
-	case EndpointTypeUDP:
-	case EndpointTypeTCP:
-	case EndpointTypeUser:
-	default:
-		routes.logger.WithFields(log.Fields{
-			"namespace": r.Env[env.Namespace],
-			"error":     "Endpoint type is of invalid value",
-		}).Warnf("Failed to register instance %+v", req)
-
-		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceEndpointInvalidType)
-		return nil, errors.New("Endpoint type is of invalid value")
+	endpoints := []*InstanceAddress{req.Endpoint}
+	for _, endpoint := range req.Endpoints {
+		endpoints = append(endpoints, endpoint)
 	}
-
-	metadataValid := true
-
-	if req.Metadata != nil {
-		metadataValid = validateJSON(req.Metadata)
+	for _, endpoint := range endpoints {
+		if id, err := validateEndpoint(endpoint); err != nil {
+			return id, nil, err
+		}
 	}
 
-	if !metadataValid {
-		routes.logger.WithFields(log.Fields{
-			"namespace": r.Env[env.Namespace],
-			"error":     "Metadata is invalid",
-		}).Warnf("Failed to register instance %+v", req)
-
-		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceMetadataInvalid)
-		return nil, errors.New("Metadata is invalid")
+	if req.Metadata != nil && !validateJSON(req.Metadata) {
+		return i18n.ErrorInstanceMetadataInvalid, nil, errors.New("Metadata is invalid")
 	}
 
 	// If the status is not passed in, set it to UP
@@ -231,19 +233,15 @@ func (routes *Routes) parseInstanceRegistrationRequest(w rest.ResponseWriter, r
 	switch strings.ToUpper(req.Status) {
 	case store.Up:
 	case store.Starting:
+	case store.Draining:
 	case store.OutOfService:
 	default:
-		routes.logger.WithFields(log.Fields{
-			"namespace": r.Env[env.Namespace],
-			"error":     "Status field is not a valid value",
-		}).Warnf("Failed to register instance %+v", req)
-
-		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceStatusInvalid,
-			map[string]interface{}{"Status": fmt.Sprintf("%s, %s, %s", store.Up, store.Starting, store.OutOfService)})
-		return nil, errors.New("Status field is not a valid value")
+		return i18n.ErrorInstanceStatusInvalid,
+			[]interface{}{map[string]interface{}{"Status": fmt.Sprintf("%s, %s, %s, %s", store.Up, store.Starting, store.Draining, store.OutOfService)}},
+			errors.New("Status field is not a valid value")
 	}
 
-	return &req, nil
+	return "", nil, nil
 }
 
 func validateJSON(jsonString json.RawMessage) bool {
@@ -329,6 +327,175 @@ func (routes *Routes) renewInstance(w rest.ResponseWriter, r *rest.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (routes *Routes) setInstanceStatus(w rest.ResponseWriter, r *rest.Request) {
+	iid := r.PathParam(RouteParamInstanceID)
+	if iid == "" {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "instance id is required",
+		}).Warn("Failed to set instance status")
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceIdentifierMissing)
+		return
+	}
+
+	var req InstanceStatus
+	if err := r.DecodeJsonPayload(&req); err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warnf("Failed to set instance %s status", iid)
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceStatusUpdateFailed)
+		return
+	}
+
+	status := strings.ToUpper(req.Status)
+	switch status {
+	case store.Up:
+	case store.Starting:
+	case store.Draining:
+	case store.OutOfService:
+	default:
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "Status field is not a valid value",
+		}).Warnf("Failed to set instance %s status %s", iid, req.Status)
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceStatusInvalid,
+			map[string]interface{}{"Status": fmt.Sprintf("%s, %s, %s, %s", store.Up, store.Starting, store.Draining, store.OutOfService)})
+		return
+	}
+
+	catalog := routes.catalog(w, r)
+	if catalog == nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "catalog is nil",
+		}).Errorf("Failed to set instance %s status", iid)
+		// error response set by routes.catalog()
+		return
+	}
+
+	si, err := catalog.SetStatus(iid, status)
+	if err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warnf("Failed to set instance %s status", iid)
+
+		i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorInstanceStatusUpdateFailed)
+		return
+	}
+
+	routes.logger.WithFields(log.Fields{
+		"namespace": r.Env[env.Namespace],
+	}).Infof("Instance id %s status set to %s", iid, status)
+
+	r.Env[env.ServiceInstance] = si
+	routes.sendInstanceResponse(w, r, si)
+}
+
+func (routes *Routes) updateInstance(w rest.ResponseWriter, r *rest.Request) {
+	iid := r.PathParam(RouteParamInstanceID)
+	if iid == "" {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "instance id is required",
+		}).Warn("Failed to update instance")
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceIdentifierMissing)
+		return
+	}
+
+	var req InstanceUpdate
+	if err := r.DecodeJsonPayload(&req); err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warnf("Failed to update instance %s", iid)
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceUpdateFailed)
+		return
+	}
+
+	update := &store.InstanceUpdate{Tags: req.Tags, Labels: req.Labels}
+	if req.Metadata != nil {
+		if !validateJSON(req.Metadata) {
+			routes.logger.WithFields(log.Fields{
+				"namespace": r.Env[env.Namespace],
+				"error":     "Metadata is invalid",
+			}).Warnf("Failed to update instance %s", iid)
+
+			i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorInstanceMetadataInvalid)
+			return
+		}
+
+		if string(req.Metadata) == "null" {
+			update.Metadata = []byte{}
+		} else {
+			update.Metadata = req.Metadata
+		}
+	}
+
+	catalog := routes.catalog(w, r)
+	if catalog == nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "catalog is nil",
+		}).Errorf("Failed to update instance %s", iid)
+		// error response set by routes.catalog()
+		return
+	}
+
+	si, err := catalog.Update(iid, update)
+	if err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warnf("Failed to update instance %s", iid)
+
+		if regerr, ok := err.(*store.Error); ok {
+			switch regerr.Code {
+			case store.ErrorInstanceMetaDataTooLong:
+				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorMetaDataTooLong, store.MetadataMaxLength)
+			case store.ErrorInstanceLabelsTooLong:
+				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorLabelsTooLong, store.LabelsMaxLength)
+			case store.ErrorInstanceLabelsInvalid:
+				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorInstanceLabelsInvalid)
+			default:
+				i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorInstanceUpdateFailed)
+			}
+		} else {
+			i18n.Error(r, w, statusCodeFromError(err), i18n.ErrorInstanceUpdateFailed)
+		}
+		return
+	}
+
+	routes.logger.WithFields(log.Fields{
+		"namespace": r.Env[env.Namespace],
+	}).Infof("Instance id %s updated", iid)
+
+	r.Env[env.ServiceInstance] = si
+	routes.sendInstanceResponse(w, r, si)
+}
+
+func (routes *Routes) sendInstanceResponse(w rest.ResponseWriter, r *rest.Request, si *store.ServiceInstance) {
+	inst, err := copyInstanceWithFilter(si.ServiceName, si, nil)
+	if err == nil {
+		err = w.WriteJson(inst)
+	}
+
+	if err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warnf("Failed to write response for instance %s", si)
+
+		i18n.Error(r, w, http.StatusInternalServerError, i18n.ErrorEncoding)
+	}
+}
+
 func (routes *Routes) listInstances(w rest.ResponseWriter, r *rest.Request) {
 	var fields []string
 
@@ -354,6 +521,28 @@ func (routes *Routes) listInstances(w rest.ResponseWriter, r *rest.Request) {
 		return
 	}
 
+	waitIndex, wait, err := extractWatch(r)
+	if err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warn("Failed to list instances")
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorWatchParameters)
+		return
+	}
+
+	preference, err := extractLocality(r)
+	if err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warn("Failed to list instances")
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorLocalityParameters)
+		return
+	}
+
 	catalog := routes.catalog(w, r)
 	if catalog == nil {
 		routes.logger.WithFields(log.Fields{
@@ -364,6 +553,8 @@ func (routes *Routes) listInstances(w rest.ResponseWriter, r *rest.Request) {
 		return
 	}
 
+	index := waitForChange(r, catalog, waitIndex, wait)
+
 	services := catalog.ListServices(nil)
 	if services == nil {
 		routes.logger.WithFields(log.Fields{
@@ -376,8 +567,13 @@ func (routes *Routes) listInstances(w rest.ResponseWriter, r *rest.Request) {
 	}
 
 	var insts = []*ServiceInstance{}
+	selector := sc.selector()
 	for _, svc := range services {
-		instances, err := catalog.List(svc.ServiceName, sc.instanceFilter)
+		if !sc.selectsService(svc.ServiceName) {
+			continue
+		}
+
+		instances, err := store.Select(catalog, svc.ServiceName, selector)
 		if err != nil {
 			routes.logger.WithFields(log.Fields{
 				"namespace": r.Env[env.Namespace],
@@ -388,7 +584,7 @@ func (routes *Routes) listInstances(w rest.ResponseWriter, r *rest.Request) {
 			return
 		}
 
-		for _, si := range instances {
+		for _, si := range preferLocality(instances, preference) {
 			inst, err := copyInstanceWithFilter(svc.ServiceName, si, fields)
 			if err != nil {
 				routes.logger.WithFields(log.Fields{
@@ -403,6 +599,10 @@ func (routes *Routes) listInstances(w rest.ResponseWriter, r *rest.Request) {
 		}
 	}
 
+	if index > 0 {
+		w.Header().Set(IndexHeader, strconv.FormatUint(index, 10))
+	}
+
 	if err = w.WriteJson(&InstancesList{Instances: insts}); err != nil {
 		routes.logger.WithFields(log.Fields{
 			"namespace": r.Env[env.Namespace],
@@ -418,6 +618,26 @@ func (routes *Routes) listInstances(w rest.ResponseWriter, r *rest.Request) {
 	}).Infof("Lookup instances (%d)", len(insts))
 }
 
+// validateEndpoint checks that the endpoint has a value and a valid type.
+// It returns the identifier of the error message for the client along with the error.
+func validateEndpoint(endpoint *InstanceAddress) (string, error) {
+	if endpoint == nil || endpoint.Type == "" || endpoint.Value == "" {
+		return i18n.ErrorInstanceEndpontMalformed, errors.New("Endpoint type or value are missing or mismatched")
+	}
+
+	switch endpoint.Type {
+	case EndpointTypeHTTP:
+	case EndpointTypeHTTPS:
+	case EndpointTypeUDP:
+	case EndpointTypeTCP:
+	case EndpointTypeUser:
+	default:
+		return i18n.ErrorInstanceEndpointInvalidType, errors.New("Endpoint type is of invalid value")
+	}
+
+	return "", nil
+}
+
 func statusCodeFromError(err error) int {
 	if regerr, ok := err.(*store.Error); ok {
 		switch regerr.Code {
@@ -441,6 +661,14 @@ func statusCodeFromError(err error) int {
 			return http.StatusBadRequest
 		case store.ErrorInstanceMetaDataTooLong:
 			return http.StatusBadRequest
+		case store.ErrorInstanceLabelsTooLong:
+			return http.StatusBadRequest
+		case store.ErrorInstanceLabelsInvalid:
+			return http.StatusBadRequest
+		case store.ErrorInstanceWeightOutOfRange:
+			return http.StatusBadRequest
+		case store.ErrorInstanceEndpointNameInvalid:
+			return http.StatusBadRequest
 		default:
 			return http.StatusInternalServerError
 		}
@@ -481,8 +709,11 @@ func copyInstanceWithFilter(sname string, si *store.ServiceInstance, fields []st
 			Type:  si.Endpoint.Type,
 			Value: si.Endpoint.Value,
 		},
+		Endpoints:     fromStoreEndpoints(si.Endpoints),
 		Status:        si.Status,
 		Tags:          si.Tags,
+		Labels:        si.Labels,
+		Weight:        weight(si),
 		TTL:           uint32(si.TTL / time.Second),
 		Metadata:      si.Metadata,
 		LastHeartbeat: &si.LastRenewal,
@@ -502,3 +733,11 @@ func copyInstanceWithFilter(sname string, si *store.ServiceInstance, fields []st
 
 	return inst, nil
 }
+
+// weight returns the weight of the instance, or the default weight for instances of catalogs that do not set it
+func weight(si *store.ServiceInstance) int {
+	if si.Weight <= 0 {
+		return store.DefaultWeight
+	}
+	return si.Weight
+}
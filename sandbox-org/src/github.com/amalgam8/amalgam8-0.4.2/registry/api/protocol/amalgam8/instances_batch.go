@@ -0,0 +1,59 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package amalgam8
+
+// BatchMaxSize is the maximum number of instances of a batch registration or heartbeat request
+const BatchMaxSize = 1000
+
+// InstanceRegistrationBatch encapsulates information needed for registering multiple service instances at once
+type InstanceRegistrationBatch struct {
+	Instances []*InstanceRegistration `json:"instances"`
+}
+
+// InstanceRegistrationResults type is returned in response to a batch registration request.
+// It holds a result for each instance of the request, in the same order.
+type InstanceRegistrationResults struct {
+	Instances []*InstanceRegistrationResult `json:"instances"`
+}
+
+// InstanceRegistrationResult is the result of registering a single instance of a batch.
+// The code is the HTTP status code of registering the instance on its own,
+// and either the registered instance or the error message is set.
+type InstanceRegistrationResult struct {
+	Code  int            `json:"code"`
+	Error string         `json:"error,omitempty"`
+	ID    string         `json:"id,omitempty"`
+	TTL   uint32         `json:"ttl,omitempty"`
+	Links *InstanceLinks `json:"links,omitempty"`
+}
+
+// InstanceHeartbeatBatch encapsulates information needed for renewing the registration of multiple instances at once
+type InstanceHeartbeatBatch struct {
+	IDs []string `json:"ids"`
+}
+
+// InstanceHeartbeatResults type is returned in response to a batch heartbeat request.
+// It holds a result for each instance of the request, in the same order.
+type InstanceHeartbeatResults struct {
+	Heartbeats []*InstanceHeartbeatResult `json:"heartbeats"`
+}
+
+// InstanceHeartbeatResult is the result of renewing the registration of a single instance of a batch.
+// The code is the HTTP status code of renewing the instance on its own.
+type InstanceHeartbeatResult struct {
+	ID    string `json:"id"`
+	Code  int    `json:"code"`
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package amalgam8
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ant0ine/go-json-rest/rest"
+
+	"github.com/amalgam8/amalgam8/pkg/locality"
+	"github.com/amalgam8/amalgam8/registry/store"
+)
+
+// extractLocality extracts and validates the locality query parameters of an instance query.
+// An empty locality indicates that instances are not selected by their locality.
+func extractLocality(r *rest.Request) (locality.Preference, error) {
+	query := r.URL.Query()
+	preference := locality.Preference{
+		Locality: locality.Locality{
+			Region: query.Get(QueryParamRegion),
+			Zone:   query.Get(QueryParamZone),
+		},
+	}
+
+	if value := query.Get(QueryParamMinHealthy); value != "" {
+		minHealthy, err := strconv.Atoi(value)
+		if err != nil || minHealthy < 0 {
+			return preference, fmt.Errorf("Minimum healthy instances %s is not a valid number", value)
+		}
+		preference.MinHealthy = minHealthy
+	}
+
+	return preference, nil
+}
+
+// preferLocality returns the instances of a service selected by the locality preference, nearest first
+func preferLocality(instances []*store.ServiceInstance, preference locality.Preference) []*store.ServiceInstance {
+	indexes := preference.Select(len(instances),
+		func(i int) locality.Locality { return locality.FromLabels(instances[i].Labels) },
+		func(i int) bool { return healthy(instances[i]) })
+
+	selected := make([]*store.ServiceInstance, len(indexes))
+	for i, index := range indexes {
+		selected[i] = instances[index]
+	}
+	return selected
+}
+
+// healthy returns whether the instance is ready to receive new traffic. User defined statuses are treated as UP.
+func healthy(si *store.ServiceInstance) bool {
+	switch si.Status {
+	case store.Starting, store.Draining, store.OutOfService:
+		return false
+	}
+	return true
+}
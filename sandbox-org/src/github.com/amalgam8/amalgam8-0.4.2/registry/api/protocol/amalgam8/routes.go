@@ -67,6 +67,13 @@ func (routes *Routes) RouteHandlers(middlewares ...rest.Middleware) []*rest.Rout
 			Operation: protocol.RegisterInstance,
 			Handler:   routes.registerInstance,
 		},
+		{
+			Path:      instancesOperationTemplateURL(),
+			Method:    "POST",
+			Protocol:  protocol.Amalgam8,
+			Operation: protocol.RegisterInstances,
+			Handler:   routes.registerInstances,
+		},
 		{
 			Path:      InstancesURL(),
 			Method:    "GET",
@@ -88,6 +95,34 @@ func (routes *Routes) RouteHandlers(middlewares ...rest.Middleware) []*rest.Rout
 			Operation: protocol.RenewInstance,
 			Handler:   routes.renewInstance,
 		},
+		{
+			Path:      HeartbeatsURL(),
+			Method:    "PUT",
+			Protocol:  protocol.Amalgam8,
+			Operation: protocol.RenewInstances,
+			Handler:   routes.renewInstances,
+		},
+		{
+			Path:      instanceStatusTemplateURL(),
+			Method:    "PUT",
+			Protocol:  protocol.Amalgam8,
+			Operation: protocol.SetInstanceStatus,
+			Handler:   routes.setInstanceStatus,
+		},
+		{
+			Path:      instanceTemplateURL(),
+			Method:    "PATCH",
+			Protocol:  protocol.Amalgam8,
+			Operation: protocol.UpdateInstance,
+			Handler:   routes.updateInstance,
+		},
+		{
+			Path:      EventsURL(),
+			Method:    "GET",
+			Protocol:  protocol.Amalgam8,
+			Operation: protocol.WatchEvents,
+			Handler:   routes.watchEvents,
+		},
 	}
 
 	rts := make([]*rest.Route, 0, len(descriptors))
@@ -21,29 +21,59 @@ import (
 
 	"github.com/ant0ine/go-json-rest/rest"
 
+	"github.com/amalgam8/amalgam8/pkg/labels"
 	"github.com/amalgam8/amalgam8/registry/store"
-	"github.com/amalgam8/amalgam8/registry/utils/reflection"
 )
 
+// statusQueryParam is the query param of the requested status of instances
+const statusQueryParam = "status"
+
+// selectCriteria is the selection filter of an instance query, compiled once per request.
+// The tags and the explicitly requested status are matched using the secondary indexes of the catalog,
+// while the remaining criteria are evaluated per instance.
 type selectCriteria struct {
-	criteria     map[string]interface{}
+	// serviceName is the requested service name, if hasServiceName is set
+	serviceName    string
+	hasServiceName bool
+
+	id    string
+	hasID bool
+
+	tags []string
+
+	// labels is the label selector. Multiple selector params are combined, requiring all of them.
+	labels labels.Selector
+
+	// status is the requested status, if hasStatus is set. The ALL status is not a criterion.
+	status    string
+	hasStatus bool
+
 	filterStatus string
+
+	// otherKey is set if any criterion other than the status is specified
+	otherKey bool
 }
 
 // Parses the request's query params and returns the wanted fields and their expected values
 // This method is used to apply a selection filter on instances
 func newSelectCriteria(r *rest.Request) (*selectCriteria, error) {
-	if len(r.URL.Query()) == 0 {
-		return &selectCriteria{}, nil
-	}
-
-	c := make(map[string]interface{})
-
-	var filter string
+	sc := &selectCriteria{}
 
 	for param := range r.URL.Query() {
-		// fields is used for projection-type filtering
-		if param == "fields" {
+		// fields is used for projection-type filtering, index and wait for blocking queries,
+		// and region, zone and min_healthy for locality-aware queries
+		if param == "fields" || param == QueryParamIndex || param == QueryParamWait ||
+			param == QueryParamRegion || param == QueryParamZone || param == QueryParamMinHealthy {
+			continue
+		}
+
+		if param == QueryParamSelector {
+			selector, err := labels.ParseAll(r.URL.Query()[param])
+			if err != nil {
+				return nil, err
+			}
+			sc.labels = selector
+			sc.otherKey = true
 			continue
 		}
 
@@ -57,62 +87,107 @@ func newSelectCriteria(r *rest.Request) (*selectCriteria, error) {
 		// convert param field's name to its actual value in the struct definition
 		fieldName := instanceQueryValuesToFieldNames[param]
 
-		// The status is made upper case on registration so ignore the query param case
-		if fieldName == "Status" {
-			// If the status is user defined, leave the case alone
-			if strings.EqualFold(requestedValue, store.Up) ||
-				strings.EqualFold(requestedValue, store.Starting) ||
-				strings.EqualFold(requestedValue, store.OutOfService) ||
-				strings.EqualFold(requestedValue, store.All) {
-				requestedValue = strings.ToUpper(requestedValue)
-			}
-			filter = requestedValue
-		}
-
-		// if it's a string array, split by commas
+		// ensure field type is string or []string
 		tmpServiceInstance := ServiceInstance{}
 		fieldType := reflect.Indirect(reflect.ValueOf(tmpServiceInstance)).FieldByName(fieldName).Type().String()
-
-		// ensure field type is string or []string
 		if fieldType != "[]string" && fieldType != "string" {
 			return nil, fmt.Errorf("Field %s is not a string or a []string but is of type %s", fieldName, fieldType)
 		}
 
-		if fieldType == "[]string" {
-			c[fieldName] = strings.Split(requestedValue, ",")
-		} else {
-			c[fieldName] = requestedValue
+		switch fieldName {
+		case "Status":
+			sc.setStatus(requestedValue)
+		case "ServiceName":
+			sc.serviceName = requestedValue
+			sc.hasServiceName = true
+			sc.otherKey = true
+		case "ID":
+			sc.id = requestedValue
+			sc.hasID = true
+			sc.otherKey = true
+		case "Tags":
+			// if it's a string array, split by commas
+			sc.tags = strings.Split(requestedValue, ",")
+			sc.otherKey = true
+		default:
+			return nil, fmt.Errorf("Field %s is not supported by the selection criteria", fieldName)
 		}
 	}
 
-	return &selectCriteria{criteria: c, filterStatus: filter}, nil
+	return sc, nil
 }
 
-func (sc *selectCriteria) instanceFilter(si *store.ServiceInstance) bool {
-	var otherkey bool
-
-	if sc.criteria != nil {
-		// iterate over selection criteria
-		for key, val := range sc.criteria {
-			// If the query string param is Status need to support ALL or filter appropriately
-			if key == "Status" {
-				if sc.filterStatus == store.All {
-					continue
-				}
-			} else {
-				// If we're filtering on another key, include any statuses
-				otherkey = true
-			}
-			fits, err := reflection.StructFieldMatchesValue(si, key, val)
-			if err != nil || !fits {
-				return false
-			}
-		}
+// newServiceSelectCriteria returns the selection filter of the instances of a service. Only the status query param
+// applies, so that draining instances are returned only when explicitly requested, as they are by instance queries.
+func newServiceSelectCriteria(r *rest.Request, serviceName string) *selectCriteria {
+	sc := &selectCriteria{serviceName: serviceName, hasServiceName: true, otherKey: true}
+	if _, exists := r.URL.Query()[statusQueryParam]; exists {
+		sc.setStatus(r.URL.Query().Get(statusQueryParam))
+	}
+	return sc
+}
+
+// setStatus sets the requested status
+func (sc *selectCriteria) setStatus(requestedValue string) {
+	// The status is made upper case on registration so ignore the query param case
+	// If the status is user defined, leave the case alone
+	if strings.EqualFold(requestedValue, store.Up) ||
+		strings.EqualFold(requestedValue, store.Starting) ||
+		strings.EqualFold(requestedValue, store.Draining) ||
+		strings.EqualFold(requestedValue, store.OutOfService) ||
+		strings.EqualFold(requestedValue, store.All) {
+		requestedValue = strings.ToUpper(requestedValue)
+	}
+	sc.filterStatus = requestedValue
+	if requestedValue != store.All {
+		sc.status = requestedValue
+		sc.hasStatus = true
+	}
+}
+
+// selectsService returns whether instances of the given service may be selected
+func (sc *selectCriteria) selectsService(serviceName string) bool {
+	return !sc.hasServiceName || sc.serviceName == serviceName
+}
+
+// selector returns the catalog selector equivalent to the selection criteria
+func (sc *selectCriteria) selector() *store.Selector {
+	selector := &store.Selector{
+		Tags:      sc.tags,
+		Labels:    sc.labels,
+		Predicate: sc.matches,
+	}
+
+	// An explicitly empty status matches instances with no status, while an empty selector status matches any
+	if sc.hasStatus && sc.status != "" {
+		selector.Status = sc.status
+	}
+
+	return selector
+}
+
+// matches evaluates the criteria that are not matched by the catalog selector
+func (sc *selectCriteria) matches(si *store.ServiceInstance) bool {
+	if sc.hasServiceName && si.ServiceName != sc.serviceName {
+		return false
+	}
+
+	if sc.hasID && si.ID != sc.id {
+		return false
+	}
+
+	if sc.hasStatus && si.Status != sc.status {
+		return false
+	}
+
+	// Draining instances do not receive new traffic, so they are only returned when explicitly requested
+	if sc.filterStatus == "" && si.Status == store.Draining {
+		return false
 	}
 
 	// Filter out those instances that are not UP if the status query string param was not set
 	// unless there is another param specified
-	if sc.filterStatus == "" && !otherkey {
+	if sc.filterStatus == "" && !sc.otherKey {
 		// For now, handle the case where there are user defined statuses as we want to treat them as UP
 		switch si.Status {
 		case store.Starting:
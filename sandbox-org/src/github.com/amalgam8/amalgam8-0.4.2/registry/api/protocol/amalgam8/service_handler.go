@@ -16,6 +16,7 @@ package amalgam8
 
 import (
 	"net/http"
+	"strconv"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/ant0ine/go-json-rest/rest"
@@ -36,6 +37,28 @@ func (routes *Routes) getServiceInstances(w rest.ResponseWriter, r *rest.Request
 		return
 	}
 
+	waitIndex, wait, err := extractWatch(r)
+	if err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warnf("Failed to lookup service %s", sname)
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorWatchParameters)
+		return
+	}
+
+	preference, err := extractLocality(r)
+	if err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warnf("Failed to lookup service %s", sname)
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorLocalityParameters)
+		return
+	}
+
 	catalog := routes.catalog(w, r)
 	if catalog == nil {
 		routes.logger.WithFields(log.Fields{
@@ -46,7 +69,10 @@ func (routes *Routes) getServiceInstances(w rest.ResponseWriter, r *rest.Request
 		return
 	}
 
-	if instances, err := catalog.List(sname, nil); err != nil {
+	index := waitForChange(r, catalog, waitIndex, wait)
+
+	sc := newServiceSelectCriteria(r, sname)
+	if instances, err := catalog.List(sname, sc.matches); err != nil {
 		routes.logger.WithFields(log.Fields{
 			"namespace": r.Env[env.Namespace],
 			"error":     err,
@@ -63,6 +89,7 @@ func (routes *Routes) getServiceInstances(w rest.ResponseWriter, r *rest.Request
 		i18n.Error(r, w, http.StatusNotFound, i18n.ErrorServiceNotFound)
 		return
 	} else {
+		instances = preferLocality(instances, preference)
 		insts := make([]*ServiceInstance, len(instances))
 		for index, si := range instances {
 			inst, err := copyInstanceWithFilter(sname, si, nil)
@@ -78,6 +105,10 @@ func (routes *Routes) getServiceInstances(w rest.ResponseWriter, r *rest.Request
 			insts[index] = inst
 		}
 
+		if index > 0 {
+			w.Header().Set(IndexHeader, strconv.FormatUint(index, 10))
+		}
+
 		if err := w.WriteJson(&InstanceList{ServiceName: sname, Instances: insts}); err != nil {
 			routes.logger.WithFields(log.Fields{
 				"namespace": r.Env[env.Namespace],
@@ -0,0 +1,199 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package amalgam8
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ant0ine/go-json-rest/rest"
+
+	"github.com/amalgam8/amalgam8/registry/api/env"
+	"github.com/amalgam8/amalgam8/registry/store"
+	"github.com/amalgam8/amalgam8/registry/utils/i18n"
+)
+
+const (
+	// defaultWait is the duration of a blocking query that does not specify one
+	defaultWait = 30 * time.Second
+
+	// maxWait is the maximum duration of a blocking query
+	maxWait = 5 * time.Minute
+
+	// keepAliveInterval is the interval of comments sent on idle event streams to detect closed connections
+	keepAliveInterval = 30 * time.Second
+
+	// eventReset is sent on an event stream that cannot be resumed, in which case the catalog should be listed again
+	eventReset = "reset"
+)
+
+// extractWatch extracts and validates the index and wait query parameters of a blocking query.
+// An index of 0 indicates a regular, non-blocking, query.
+func extractWatch(r *rest.Request) (uint64, time.Duration, error) {
+	var index uint64
+	wait := defaultWait
+
+	query := r.URL.Query()
+	if value := query.Get(QueryParamIndex); value != "" {
+		var err error
+		if index, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("Index %s is not a valid index", value)
+		}
+	}
+
+	if value := query.Get(QueryParamWait); value != "" {
+		var err error
+		if wait, err = time.ParseDuration(value); err != nil || wait < 0 {
+			return 0, 0, fmt.Errorf("Wait %s is not a valid duration", value)
+		}
+		if wait > maxWait {
+			wait = maxWait
+		}
+	}
+
+	return index, wait, nil
+}
+
+// waitForChange blocks until the modification index of the catalog differs from the given index, the wait duration
+// elapses or the request is cancelled. It returns the current modification index of the catalog, which should be read
+// before listing the catalog, or 0 if the catalog does not track its modifications.
+func waitForChange(r *rest.Request, catalog store.Catalog, index uint64, wait time.Duration) uint64 {
+	watchable, ok := catalog.(store.Watchable)
+	if !ok {
+		return 0
+	}
+
+	if index > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-watchable.Changed(index):
+		case <-timer.C:
+		case <-r.Context().Done():
+		}
+	}
+
+	return watchable.Index()
+}
+
+func (routes *Routes) watchEvents(w rest.ResponseWriter, r *rest.Request) {
+	index, _, err := extractWatch(r)
+	if err != nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     err,
+		}).Warn("Failed to watch events")
+
+		i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorWatchParameters)
+		return
+	}
+
+	// Reconnecting event sources resume after the last event they received
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		if index, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			routes.logger.WithFields(log.Fields{
+				"namespace": r.Env[env.Namespace],
+				"error":     err,
+			}).Warn("Failed to watch events")
+
+			i18n.Error(r, w, http.StatusBadRequest, i18n.ErrorWatchParameters)
+			return
+		}
+	}
+
+	catalog := routes.catalog(w, r)
+	if catalog == nil {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "catalog is nil",
+		}).Error("Failed to watch events")
+		// error response set by routes.catalog()
+		return
+	}
+
+	watchable, ok := catalog.(store.Watchable)
+	flusher, canFlush := w.(http.Flusher)
+	writer, canWrite := w.(http.ResponseWriter)
+	if !ok || !canFlush || !canWrite {
+		routes.logger.WithFields(log.Fields{
+			"namespace": r.Env[env.Namespace],
+			"error":     "streaming unsupported",
+		}).Error("Failed to watch events")
+
+		i18n.Error(r, w, http.StatusNotImplemented, i18n.ErrorWatchUnsupported)
+		return
+	}
+
+	sname := r.URL.Query().Get("service_name")
+
+	subscription := watchable.Subscribe(index)
+	defer subscription.Cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set(IndexHeader, strconv.FormatUint(subscription.Index, 10))
+	w.WriteHeader(http.StatusOK)
+
+	if index > 0 && !subscription.Complete {
+		fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: {}\n\n", subscription.Index, eventReset)
+	}
+	flusher.Flush()
+
+	routes.logger.WithFields(log.Fields{
+		"namespace": r.Env[env.Namespace],
+	}).Infof("Watch events from index %d", subscription.Index)
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// The subscriber fell behind, and should reconnect to resume
+				return
+			}
+			if sname != "" && event.Instance.ServiceName != sname {
+				continue
+			}
+
+			inst, err := copyInstanceWithFilter(event.Instance.ServiceName, event.Instance, nil)
+			if err != nil {
+				continue
+			}
+			data, err := json.Marshal(inst)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Index, event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
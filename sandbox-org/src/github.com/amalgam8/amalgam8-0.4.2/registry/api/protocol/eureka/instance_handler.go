@@ -566,10 +566,25 @@ func Translate(inst *Instance) (*store.ServiceInstance, error) {
 		endpoint = &store.Endpoint{Type: "tcp", Value: fmt.Sprintf("%s:%v", inst.HostName, inst.Port.Value)}
 	}
 
+	// Both ports are also exposed as named endpoints, so neither is lost when both are enabled.
+	var endpoints map[string]*store.Endpoint
+	if inst.Port != nil && inst.Port.Enabled == "true" {
+		endpoints = map[string]*store.Endpoint{
+			"http": {Type: "http", Value: fmt.Sprintf("%s:%v", inst.HostName, inst.Port.Value)},
+		}
+	}
+	if inst.SecPort != nil && inst.SecPort.Enabled == "true" {
+		if endpoints == nil {
+			endpoints = make(map[string]*store.Endpoint, 1)
+		}
+		endpoints["https"] = &store.Endpoint{Type: "https", Value: fmt.Sprintf("%s:%v", inst.HostName, inst.SecPort.Value)}
+	}
+
 	si := &store.ServiceInstance{
 		ID:          uid,
 		ServiceName: inst.Application,
 		Endpoint:    endpoint,
+		Endpoints:   endpoints,
 		Status:      inst.Status,
 		Tags:        tags,
 		TTL:         time.Duration(ttl) * time.Second,
@@ -636,5 +651,29 @@ func buildDefaultInstance(si *store.ServiceInstance) *Instance {
 		inst.IPAddr = inst.HostName
 	}
 
+	if inst.Port == nil {
+		if port := endpointPort(si.Endpoints["http"]); port != "" {
+			inst.Port = &Port{Enabled: "true", Value: port}
+		}
+	}
+	if inst.SecPort == nil {
+		if port := endpointPort(si.Endpoints["https"]); port != "" {
+			inst.SecPort = &Port{Enabled: "true", Value: port}
+		}
+	}
+
 	return inst
 }
+
+// endpointPort returns the port of the given endpoint, or an empty string if it has none
+func endpointPort(endpoint *store.Endpoint) string {
+	if endpoint == nil {
+		return ""
+	}
+	if u, err := url.Parse(endpoint.Value); err == nil && u.Host != "" {
+		_, port, _ := net.SplitHostPort(u.Host)
+		return port
+	}
+	_, port, _ := net.SplitHostPort(endpoint.Value)
+	return port
+}
@@ -17,6 +17,7 @@ package protocol
 import (
 	"github.com/ant0ine/go-json-rest/rest"
 
+	"github.com/amalgam8/amalgam8/pkg/auth"
 	"github.com/amalgam8/amalgam8/registry/api/env"
 )
 
@@ -26,7 +27,7 @@ type Operation string
 // The following are the current API operations exposed by Service Discovery.
 //
 // While most operations have implementations in both API protocols (Amalgam8 / Eureka),
-// some are unique to a certain protocol - e.g., SetInstanceStatus is currently unique to Eureka).
+// some are unique to a certain protocol - e.g., UpdateInstance is currently unique to Amalgam8).
 //
 // Also, several sub-operations may be mapped to the same Operation value, e.g. Amalgam8's ListInstances
 // as well as Eureka's ListVips are both mapped to ListInstances.
@@ -34,11 +35,15 @@ const (
 	RegisterInstance     Operation = "Register"
 	DeregisterInstance             = "Deregister"
 	RenewInstance                  = "Renew"
+	RegisterInstances              = "RegisterBatch"
+	RenewInstances                 = "RenewBatch"
 	ListServices                   = "ListServices"
 	ListServiceInstances           = "ListServiceInstances"
 	ListInstances                  = "ListInstances"
 	SetInstanceStatus              = "SetStatus"
+	UpdateInstance                 = "Update"
 	GetInstance                    = "GetInfo"
+	WatchEvents                    = "WatchEvents"
 )
 
 // String returns a string representation of this Operation value.
@@ -46,6 +51,26 @@ func (op Operation) String() string {
 	return string(op)
 }
 
+// Scope returns the authorization scope required for this Operation.
+// Operations modifying registrations require the register scope, while the others require the discover scope.
+func (op Operation) Scope() auth.Scope {
+	switch op {
+	case RegisterInstance, DeregisterInstance, RenewInstance, RegisterInstances, RenewInstances, SetInstanceStatus, UpdateInstance:
+		return auth.ScopeRegistryRegister
+	default:
+		return auth.ScopeRegistryDiscover
+	}
+}
+
+// RequiredScope returns the authorization scope required for the operation of the HTTP request.
+// An empty scope is returned if the request's operation is not known.
+func RequiredScope(r *rest.Request) auth.Scope {
+	if op, ok := r.Env[env.APIOperation].(Operation); ok {
+		return op.Scope()
+	}
+	return ""
+}
+
 // APIHandler returns a wrapper HandlerFunc that injects API information into the HTTP request's context (r.Env),
 // before calling the provided HandlerFunc.
 // The given protocol is injected as the env.APIProtocol, and the given operation as the env.APIOperation.
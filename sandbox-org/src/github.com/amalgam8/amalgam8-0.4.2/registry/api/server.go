@@ -15,6 +15,7 @@
 package api
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
@@ -23,6 +24,7 @@ import (
 	"github.com/ant0ine/go-json-rest/rest"
 
 	"github.com/amalgam8/amalgam8/registry/api/middleware"
+	"github.com/amalgam8/amalgam8/registry/api/protocol"
 	"github.com/amalgam8/amalgam8/registry/api/protocol/amalgam8"
 	"github.com/amalgam8/amalgam8/registry/api/protocol/eureka"
 	"github.com/amalgam8/amalgam8/registry/api/uptime"
@@ -124,7 +126,11 @@ func (s *server) setup() (http.Handler, error) {
 
 	amalgam8Routes := amalgam8.New(s.config.CatalogMap)
 	eurekaRoutes := eureka.New(s.config.CatalogMap)
-	authMw := &middleware.AuthMiddleware{TokenRouteParam: eureka.RouteParamToken, Authenticator: s.config.Authenticator}
+	authMw := &middleware.AuthMiddleware{
+		TokenRouteParam: eureka.RouteParamToken,
+		Authenticator:   s.config.Authenticator,
+		RequiredScope:   protocol.RequiredScope,
+	}
 
 	routes = append(routes, amalgam8Routes.RouteHandlers(secureMw, authMw)...)
 	routes = append(routes, eurekaRoutes.RouteHandlers(secureMw, authMw)...)
@@ -149,6 +155,10 @@ func (s *server) serve(h http.Handler) error {
 		return err
 	}
 
+	if s.config.TLSConfig != nil {
+		listener = tls.NewListener(listener, s.config.TLSConfig)
+	}
+
 	s.listener = listener
 	if err := http.Serve(listener, h); err != nil {
 		s.logger.WithFields(log.Fields{
@@ -30,10 +30,10 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/amalgam8/amalgam8/pkg/auth"
+	"github.com/amalgam8/amalgam8/pkg/health"
 	"github.com/amalgam8/amalgam8/registry/api/protocol/amalgam8"
 	"github.com/amalgam8/amalgam8/registry/api/uptime"
 	"github.com/amalgam8/amalgam8/registry/store"
-	"github.com/amalgam8/amalgam8/registry/utils/health"
 )
 
 const (
@@ -136,6 +136,20 @@ func (mc *mockCatalog) SetStatus(iid, status string) (*store.ServiceInstance, er
 	return nil, store.NewError(store.ErrorNoSuchServiceInstance, "unable to locate instance", iid)
 }
 
+func (mc *mockCatalog) Update(iid string, update *store.InstanceUpdate) (*store.ServiceInstance, error) {
+	inst, ok := mc.instances[iid]
+	if ok {
+		if update.Tags != nil {
+			inst.Tags = update.Tags
+		}
+		if update.Metadata != nil {
+			inst.Metadata = update.Metadata
+		}
+		return inst, nil
+	}
+	return nil, store.NewError(store.ErrorNoSuchServiceInstance, "unable to locate instance", iid)
+}
+
 func (mc *mockCatalog) List(sn string, predicate store.Predicate) ([]*store.ServiceInstance, error) {
 	if sn == "" {
 		return nil, store.NewError(store.ErrorBadRequest, "null service name", "")
@@ -22,9 +22,11 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/amalgam8/amalgam8/pkg/locality"
 	"github.com/amalgam8/amalgam8/registry/api/protocol/amalgam8"
 )
 
@@ -43,6 +45,25 @@ type Registry interface {
 
 	// Renew sends a heartbeat for the service instance identified by the given ID.
 	Renew(id string) error
+
+	// SetStatus sets the status of the service instance identified by the given ID.
+	// Valid values are "STARTING", "UP", "DRAINING", or "OUT_OF_SERVICE".
+	SetStatus(id string, status string) error
+
+	// Update changes the tags and/or metadata of the service instance identified by the given ID,
+	// as described by the given InstanceUpdate structure.
+	Update(id string, update InstanceUpdate) error
+
+	// RegisterBatch adds multiple service instances to the registry using a single request.
+	// The returned ServiceInstance and error slices correspond to the given instances by position:
+	// for each given instance, either the registered instance or the error registering it is set.
+	// A non-nil error is returned if the request as a whole has failed.
+	RegisterBatch(instances []*ServiceInstance) ([]*ServiceInstance, []error, error)
+
+	// RenewBatch sends a heartbeat for multiple service instances, identified by the given IDs, using a single request.
+	// The returned error slice corresponds to the given IDs by position, with a nil error for each renewed instance.
+	// A non-nil error is returned if the request as a whole has failed.
+	RenewBatch(ids []string) ([]error, error)
 }
 
 // Discovery defines the interface used by clients for discovering service instances from the registry.
@@ -56,8 +77,14 @@ type Discovery interface {
 	ListInstances(filter InstanceFilter) ([]*ServiceInstance, error)
 
 	// ListServiceInstances queries the registry for the list of service instances with status 'UP' currently
-	// registered for the given service.
+	// registered for the given service, preferring instances near the locality of the caller if it is configured.
 	ListServiceInstances(serviceName string) ([]*ServiceInstance, error)
+
+	// Watch queries the registry for the list of service instances, similarly to ListInstances, once the registry
+	// catalog has changed since the given index or the given wait duration has elapsed.
+	// The current index of the catalog is returned along with the instances, to be used in the following call.
+	// An index of 0 returns immediately. A returned index of 0 indicates that the registry does not track changes.
+	Watch(filter InstanceFilter, index uint64, wait time.Duration) ([]*ServiceInstance, uint64, error)
 }
 
 // Client unifies the Registry and Discovery interfaces under a single interface.
@@ -80,6 +107,11 @@ type Config struct {
 	// such as enabling TLS, setting timeouts, etc.
 	// If left nil, a default HTTP client will be used.
 	HTTPClient *http.Client
+
+	// Locality is the locality of the caller, and the preference for service instances near it,
+	// applied to ListServiceInstances calls.
+	// If left empty, service instances are returned regardless of their locality.
+	Locality locality.Preference
 }
 
 // client implements the Client interface using Amalgam8 Service Registry REST API.
@@ -144,6 +176,97 @@ func (client *client) Renew(id string) error {
 	return err
 }
 
+func (client *client) SetStatus(id string, status string) error {
+	body := struct {
+		Status string `json:"status"`
+	}{Status: status}
+	_, err := client.doRequest("PUT", amalgam8.InstanceStatusURL(id), &body, http.StatusOK)
+	return err
+}
+
+func (client *client) Update(id string, update InstanceUpdate) error {
+	_, err := client.doRequest("PATCH", amalgam8.InstanceURL(id), &update, http.StatusOK)
+	return err
+}
+
+func (client *client) RegisterBatch(instances []*ServiceInstance) ([]*ServiceInstance, []error, error) {
+	// Record a pessimistic last heartbeat time - Better safe than sorry!
+	lastHeartbeat := time.Now()
+
+	req := struct {
+		Instances []*ServiceInstance `json:"instances"`
+	}{Instances: instances}
+	body, headers, err := client.send(client.httpClient, "POST", amalgam8.InstancesBatchURL(), &req, http.StatusOK)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp amalgam8.InstanceRegistrationResults
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, nil, newError(ErrorCodeInternalClientError, "error unmarshaling HTTP response body", err, "")
+	}
+	if len(resp.Instances) != len(instances) {
+		return nil, nil, newError(ErrorCodeInternalClientError, "unexpected number of results in HTTP response body", nil, "")
+	}
+
+	requestID := headers.Get("Sd-Request-Id")
+	registeredInstances := make([]*ServiceInstance, len(instances))
+	errs := make([]error, len(instances))
+	for i, result := range resp.Instances {
+		if result == nil || result.Code != http.StatusCreated {
+			errs[i] = batchResultError(result, requestID)
+			continue
+		}
+
+		registeredInstance := *instances[i]
+		registeredInstance.ID = result.ID
+		registeredInstance.TTL = int(result.TTL)
+		registeredInstance.LastHeartbeat = lastHeartbeat
+		registeredInstances[i] = &registeredInstance
+	}
+	return registeredInstances, errs, nil
+}
+
+func (client *client) RenewBatch(ids []string) ([]error, error) {
+	req := amalgam8.InstanceHeartbeatBatch{IDs: ids}
+	body, headers, err := client.send(client.httpClient, "PUT", amalgam8.HeartbeatsURL(), &req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp amalgam8.InstanceHeartbeatResults
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, newError(ErrorCodeInternalClientError, "error unmarshaling HTTP response body", err, "")
+	}
+	if len(resp.Heartbeats) != len(ids) {
+		return nil, newError(ErrorCodeInternalClientError, "unexpected number of results in HTTP response body", nil, "")
+	}
+
+	requestID := headers.Get("Sd-Request-Id")
+	errs := make([]error, len(ids))
+	for i, result := range resp.Heartbeats {
+		if result == nil || result.Code != http.StatusOK {
+			var code int
+			var message string
+			if result != nil {
+				code, message = result.Code, result.Error
+			}
+			errs[i] = errorFromStatus(code, message, requestID)
+		}
+	}
+	return errs, nil
+}
+
+// batchResultError returns the error of a failed registration result of a batch
+func batchResultError(result *amalgam8.InstanceRegistrationResult, requestID string) error {
+	if result == nil {
+		return newError(ErrorCodeInternalClientError, "missing result in HTTP response body", nil, requestID)
+	}
+	return errorFromStatus(result.Code, result.Error, requestID)
+}
+
 func (client *client) ListServices() ([]string, error) {
 	body, err := client.doRequest("GET", amalgam8.ServiceNamesURL(), nil, http.StatusOK)
 	if err != nil {
@@ -163,6 +286,7 @@ func (client *client) ListServices() ([]string, error) {
 func (client *client) ListServiceInstances(serviceName string) ([]*ServiceInstance, error) {
 	return client.ListInstances(InstanceFilter{
 		ServiceName: serviceName,
+		Locality:    client.config.Locality,
 	})
 }
 
@@ -188,12 +312,61 @@ func (client *client) ListInstances(filter InstanceFilter) ([]*ServiceInstance,
 	return s.Instances, nil
 }
 
+func (client *client) Watch(filter InstanceFilter, index uint64, wait time.Duration) ([]*ServiceInstance, uint64, error) {
+	queryParams := filter.asQueryParams()
+	if index > 0 {
+		queryParams.Add(amalgam8.QueryParamIndex, strconv.FormatUint(index, 10))
+		queryParams.Add(amalgam8.QueryParamWait, wait.String())
+	}
+	path := amalgam8.InstancesURL()
+	if len(queryParams) > 0 {
+		path = fmt.Sprintf("%s?%s", path, queryParams.Encode())
+	}
+
+	// The request is expected to block for up to the wait duration
+	httpClient := client.httpClient
+	if httpClient.Timeout > 0 && index > 0 {
+		extended := *httpClient
+		extended.Timeout += wait
+		httpClient = &extended
+	}
+
+	body, header, err := client.send(httpClient, "GET", path, nil, http.StatusOK)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s := struct {
+		Instances []*ServiceInstance `json:"instances"`
+	}{}
+	err = json.Unmarshal(body, &s)
+	if err != nil {
+		return nil, 0, newError(ErrorCodeInternalClientError, "error unmarshaling HTTP response body", err, "")
+	}
+
+	var newIndex uint64
+	if value := header.Get(amalgam8.IndexHeader); value != "" {
+		newIndex, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, 0, newError(ErrorCodeInternalClientError, "error parsing catalog index", err, "")
+		}
+	}
+	return s.Instances, newIndex, nil
+}
+
 func (client *client) doRequest(method string, path string, body interface{}, status int) ([]byte, error) {
+	b, _, err := client.send(client.httpClient, method, path, body, status)
+	return b, err
+}
+
+// send performs the HTTP request using the given HTTP client,
+// and returns the body and headers of the response if it has the expected status.
+func (client *client) send(httpClient *http.Client, method string, path string, body interface{}, status int) ([]byte, http.Header, error) {
 	var reader io.Reader
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
-			return nil, newError(ErrorCodeInternalClientError, "error marshaling HTTP request body", err, "")
+			return nil, nil, newError(ErrorCodeInternalClientError, "error marshaling HTTP request body", err, "")
 		}
 		reader = bytes.NewBuffer(b)
 	}
@@ -201,7 +374,7 @@ func (client *client) doRequest(method string, path string, body interface{}, st
 	uri := client.config.URL + path
 	req, err := http.NewRequest(method, uri, reader)
 	if err != nil {
-		return nil, newError(ErrorCodeInternalClientError, "error creating HTTP request", err, "")
+		return nil, nil, newError(ErrorCodeInternalClientError, "error creating HTTP request", err, "")
 	}
 
 	// Add authorization header
@@ -217,19 +390,19 @@ func (client *client) doRequest(method string, path string, body interface{}, st
 		req.Header.Set("Content-Length", "0")
 	}
 
-	resp, err := client.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, newError(ErrorCodeConnectionFailure, "error performing HTTP request", err, "")
+		return nil, nil, newError(ErrorCodeConnectionFailure, "error performing HTTP request", err, "")
 	}
 
 	defer resp.Body.Close()
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, newError(ErrorCodeConnectionFailure, "error read HTTP response body", err, "")
+		return nil, nil, newError(ErrorCodeConnectionFailure, "error read HTTP response body", err, "")
 	}
 
 	if resp.StatusCode == status {
-		return b, nil
+		return b, resp.Header, nil
 	}
 
 	requestID := resp.Header.Get("Sd-Request-Id")
@@ -243,22 +416,27 @@ func (client *client) doRequest(method string, path string, body interface{}, st
 			message = s.Error
 		}
 	}
-	switch resp.StatusCode {
+	return nil, nil, errorFromStatus(resp.StatusCode, message, requestID)
+}
+
+// errorFromStatus returns the error corresponding to the given unexpected HTTP status code
+func errorFromStatus(status int, message string, requestID string) error {
+	switch status {
 	case http.StatusGone:
-		return nil, newError(ErrorCodeUnknownInstance, message, nil, requestID)
+		return newError(ErrorCodeUnknownInstance, message, nil, requestID)
 	case http.StatusNotFound:
 		if requestID != "" {
-			return nil, newError(ErrorCodeInternalClientError, message, nil, requestID)
+			return newError(ErrorCodeInternalClientError, message, nil, requestID)
 		}
-		return nil, newError(ErrorCodeServiceUnavailable, message, nil, requestID)
+		return newError(ErrorCodeServiceUnavailable, message, nil, requestID)
 	case http.StatusBadGateway:
-		return nil, newError(ErrorCodeServiceUnavailable, message, nil, requestID)
+		return newError(ErrorCodeServiceUnavailable, message, nil, requestID)
 	case http.StatusUnauthorized:
-		return nil, newError(ErrorCodeUnauthorized, message, nil, requestID)
+		return newError(ErrorCodeUnauthorized, message, nil, requestID)
 	case http.StatusInternalServerError:
-		return nil, newError(ErrorCodeInternalServerError, message, nil, requestID)
+		return newError(ErrorCodeInternalServerError, message, nil, requestID)
 	default:
-		return nil, newError(ErrorCodeInternalClientError, message, nil, requestID)
+		return newError(ErrorCodeInternalClientError, message, nil, requestID)
 	}
 }
 
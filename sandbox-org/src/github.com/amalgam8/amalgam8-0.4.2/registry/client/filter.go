@@ -16,7 +16,10 @@ package client
 
 import (
 	"net/url"
+	"strconv"
 	"strings"
+
+	"github.com/amalgam8/amalgam8/pkg/locality"
 )
 
 // InstanceFilter is used to filter service instances returned from lookup calls.
@@ -33,7 +36,8 @@ type InstanceFilter struct {
 	// Status is used to filter service instances based on their status.
 	// When set to a non-empty string, registered service instances will be returned
 	// only if their status matches the specified status.
-	// When left empty, only instances with status "UP" will be returned.
+	// When left empty, only instances with status "UP" will be returned,
+	// and instances with status "DRAINING" are excluded even when filtering by other fields.
 	// When set to "ALL", all instances will be returned, regardless of their status.
 	Status string
 
@@ -42,6 +46,18 @@ type InstanceFilter struct {
 	// only if they are tagged with each of the specified tags.
 	Tags []string
 
+	// Selector is used to filter service instances based on their labels.
+	// When set to a non-empty string, registered service instances will be returned
+	// only if their labels match the specified label selector, e.g. "version=v2,zone in (eu-1,eu-2),!canary".
+	// Supported requirements are "key=value", "key!=value", "key in (v1,v2)", "key notin (v1,v2)", "key" and "!key".
+	Selector string
+
+	// Locality is used to prefer service instances near the caller, based on their "region" and "zone" labels.
+	// When the locality of the caller is set, service instances in the same zone are returned first, followed by
+	// instances in the same region and then by remote instances. Farther instances are left out as long as
+	// at least Locality.MinHealthy healthy instances are nearer.
+	Locality locality.Preference
+
 	// Fields is used to filter the fields returned for each service instance.
 	// When set to a non-empty array, returned service instances will have their corresponding fields set,
 	// while other fields will remain at their zero-value.
@@ -54,8 +70,11 @@ const (
 	FieldID            = "id"
 	FieldServiceName   = "service_name"
 	FieldEndpoint      = "endpoint"
+	FieldEndpoints     = "endpoints"
 	FieldStatus        = "status"
 	FieldTags          = "tags"
+	FieldLabels        = "labels"
+	FieldWeight        = "weight"
 	FieldMetadata      = "metadata"
 	FieldTTL           = "ttl"
 	FieldLastHeartbeat = "last_heartbeat"
@@ -77,6 +96,22 @@ func (filter InstanceFilter) asQueryParams() url.Values {
 		queryParams.Add("tags", strings.Join(filter.Tags, ","))
 	}
 
+	if filter.Selector != "" {
+		queryParams.Add("selector", filter.Selector)
+	}
+
+	if filter.Locality.Locality.Region != "" {
+		queryParams.Add("region", filter.Locality.Locality.Region)
+	}
+
+	if filter.Locality.Locality.Zone != "" {
+		queryParams.Add("zone", filter.Locality.Locality.Zone)
+	}
+
+	if filter.Locality.MinHealthy > 0 {
+		queryParams.Add("min_healthy", strconv.Itoa(filter.Locality.MinHealthy))
+	}
+
 	if len(filter.Fields) > 0 {
 		queryParams.Add("fields", strings.Join(filter.Fields, ","))
 	}
@@ -17,6 +17,24 @@ package client
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/amalgam8/amalgam8/pkg/locality"
+)
+
+// Service instance statuses
+const (
+	// StatusStarting indicates that the service instance is not ready to receive traffic yet.
+	StatusStarting = "STARTING"
+
+	// StatusUp indicates that the service instance is ready to receive traffic.
+	StatusUp = "UP"
+
+	// StatusDraining indicates that the service instance is shutting down.
+	// It should only receive traffic of existing sessions, and is not discovered by default.
+	StatusDraining = "DRAINING"
+
+	// StatusOutOfService indicates that the service instance should not receive traffic.
+	StatusOutOfService = "OUT_OF_SERVICE"
 )
 
 // ServiceInstance holds information about a service instance registered with Amalgam8 Service Registry.
@@ -37,8 +55,12 @@ type ServiceInstance struct {
 	// This field is mandatory both for registration and discovery.
 	Endpoint ServiceEndpoint `json:"endpoint"`
 
+	// Endpoints is a set of additional network endpoints of this service instance, keyed by name (e.g. "admin" or "grpc").
+	// This field is optional both for registration and discovery.
+	Endpoints map[string]ServiceEndpoint `json:"endpoints,omitempty"`
+
 	// Status is a string representing the status of the service instance.
-	// Valid values are "STARTING", "UP", or "OUT_OF_SERVICE".
+	// Valid values are "STARTING", "UP", "DRAINING", or "OUT_OF_SERVICE".
 	// This field is optional both for registration and discovery.
 	Status string `json:"status,omitempty"`
 
@@ -46,6 +68,14 @@ type ServiceInstance struct {
 	// This field is optional both for registration and discovery.
 	Tags []string `json:"tags,omitempty"`
 
+	// Labels is a set of key/value pairs attached to this service instance, used for selecting instances.
+	// This field is optional both for registration and discovery.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Weight is the relative share of traffic the service instance should receive. It defaults to 1.
+	// This field is optional for registration, and is mandatory for discovery.
+	Weight int `json:"weight,omitempty"`
+
 	// Metadata is a marshaled JSON value associated with this service instance, in encoded-form.
 	// Any arbitrary JSON value is valid, including numbers, strings, arrays and objects.
 	// This field is optional both for registration and discovery.
@@ -59,3 +89,47 @@ type ServiceInstance struct {
 	// This field is ignored for registration, and is mandatory for discovery.
 	LastHeartbeat time.Time `json:"last_heartbeat,omitempty"`
 }
+
+// Healthy returns whether the service instance is ready to receive new traffic.
+// Instances with user defined statuses are considered healthy.
+func (instance *ServiceInstance) Healthy() bool {
+	switch instance.Status {
+	case StatusStarting, StatusDraining, StatusOutOfService:
+		return false
+	}
+	return true
+}
+
+// Locality returns the locality of the service instance, as described by its labels.
+func (instance *ServiceInstance) Locality() locality.Locality {
+	return locality.FromLabels(instance.Labels)
+}
+
+// PreferLocality returns the service instances selected by the given locality preference, nearest first.
+func PreferLocality(instances []*ServiceInstance, preference locality.Preference) []*ServiceInstance {
+	indexes := preference.Select(len(instances),
+		func(i int) locality.Locality { return instances[i].Locality() },
+		func(i int) bool { return instances[i].Healthy() })
+
+	selected := make([]*ServiceInstance, len(indexes))
+	for i, index := range indexes {
+		selected[i] = instances[index]
+	}
+	return selected
+}
+
+// InstanceUpdate describes changes to the tags, labels and metadata of a registered service instance.
+type InstanceUpdate struct {
+
+	// Tags replaces the set of tags attached to the service instance.
+	// When nil, the tags are left unchanged. When set to an empty array, the tags are removed.
+	Tags []string `json:"tags"`
+
+	// Labels replaces the set of labels attached to the service instance.
+	// When nil, the labels are left unchanged. When set to an empty map, the labels are removed.
+	Labels map[string]string `json:"labels"`
+
+	// Metadata replaces the metadata associated with the service instance.
+	// When nil, the metadata is left unchanged. When set to the JSON null value, the metadata is removed.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
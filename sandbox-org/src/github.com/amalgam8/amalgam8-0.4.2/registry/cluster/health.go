@@ -21,7 +21,7 @@ import (
 	"sync"
 
 	"github.com/Sirupsen/logrus"
-	"github.com/amalgam8/amalgam8/registry/utils/health"
+	"github.com/amalgam8/amalgam8/pkg/health"
 	"github.com/amalgam8/amalgam8/registry/utils/logging"
 )
 
@@ -27,8 +27,20 @@ type Values struct {
 
 	AuthModes    []string
 	JWTSecret    string
+	JWTKeySet    string
+	JWTRefresh   time.Duration
+	JWTIssuer    string
+	JWTAudience  string
+	JWTUnscoped  bool
+	TokenFile    string
+	TokenReload  time.Duration
 	RequireHTTPS bool
 
+	TLSCert            string
+	TLSKey             string
+	TLSClientCA        string
+	ClientCertIdentity string
+
 	APIPort         uint16
 	ReplicationPort uint16
 
@@ -53,6 +65,7 @@ type Values struct {
 	Store         string
 	StoreAddr     string
 	StorePassword string
+	StoreNotify   bool
 }
 
 // NewValuesFromContext creates a Config instance from the given CLI context
@@ -63,8 +76,20 @@ func NewValuesFromContext(context *cli.Context) *Values {
 
 		AuthModes:    context.StringSlice(AuthModeFlag),
 		JWTSecret:    context.String(JWTSecretFlag),
+		JWTKeySet:    context.String(JWTKeySetFlag),
+		JWTRefresh:   context.Duration(JWTRefreshFlag),
+		JWTIssuer:    context.String(JWTIssuerFlag),
+		JWTAudience:  context.String(JWTAudienceFlag),
+		JWTUnscoped:  context.Bool(JWTUnscopedFlag),
+		TokenFile:    context.String(TokenFileFlag),
+		TokenReload:  context.Duration(TokenReloadFlag),
 		RequireHTTPS: context.Bool(RequireHTTPSFlag),
 
+		TLSCert:            context.String(TLSCertFlag),
+		TLSKey:             context.String(TLSKeyFlag),
+		TLSClientCA:        context.String(TLSClientCAFlag),
+		ClientCertIdentity: context.String(ClientCertIdentityFlag),
+
 		APIPort:         uint16(context.Int(RestAPIPortFlag)),
 		ReplicationPort: uint16(context.Int(ReplicationPortFlag)),
 
@@ -89,5 +114,6 @@ func NewValuesFromContext(context *cli.Context) *Values {
 		Store:         context.String(StoreFlag),
 		StoreAddr:     context.String(StoreAddrFlag),
 		StorePassword: context.String(StorePasswordFlag),
+		StoreNotify:   context.Bool(StoreNotifyFlag),
 	}
 }
@@ -29,8 +29,20 @@ const (
 
 	AuthModeFlag     = "auth_mode"
 	JWTSecretFlag    = "jwt_secret"
+	JWTKeySetFlag    = "jwt_key_set"
+	JWTRefreshFlag   = "jwt_key_set_refresh"
+	JWTIssuerFlag    = "jwt_issuer"
+	JWTAudienceFlag  = "jwt_audience"
+	JWTUnscopedFlag  = "jwt_unscoped_read_only"
+	TokenFileFlag    = "token_file"
+	TokenReloadFlag  = "token_file_reload"
 	RequireHTTPSFlag = "require_https"
 
+	TLSCertFlag            = "tls_cert"
+	TLSKeyFlag             = "tls_key"
+	TLSClientCAFlag        = "tls_client_ca"
+	ClientCertIdentityFlag = "client_cert_identity"
+
 	RestAPIPortFlag     = "api_port"
 	ReplicationPortFlag = "replication_port"
 
@@ -55,6 +67,7 @@ const (
 	StoreFlag         = "store"
 	StoreAddrFlag     = "store_address"
 	StorePasswordFlag = "store_password"
+	StoreNotifyFlag   = "store_configure_notifications"
 )
 
 // Flags represents the set of supported flags
@@ -77,7 +90,7 @@ var Flags = []cli.Flag{
 	cli.StringSliceFlag{
 		Name:   AuthModeFlag,
 		EnvVar: envVarFromFlag(AuthModeFlag),
-		Usage:  "Authentication modes. Supported values are: 'trusted', 'jwt'",
+		Usage:  "Authentication modes. Supported values are: 'trusted', 'jwt', 'token-file', 'client-cert'",
 	},
 
 	cli.StringFlag{
@@ -86,6 +99,75 @@ var Flags = []cli.Flag{
 		Usage:  "Secret key for JWT authentication",
 	},
 
+	cli.StringFlag{
+		Name:   JWTKeySetFlag,
+		EnvVar: envVarFromFlag(JWTKeySetFlag),
+		Usage:  "URL or file path of a JSON Web Key Set for JWT authentication",
+	},
+
+	cli.DurationFlag{
+		Name:   JWTRefreshFlag,
+		EnvVar: envVarFromFlag(JWTRefreshFlag),
+		Value:  5 * time.Minute,
+		Usage:  "Interval for reloading the JSON Web Key Set",
+	},
+
+	cli.StringFlag{
+		Name:   JWTIssuerFlag,
+		EnvVar: envVarFromFlag(JWTIssuerFlag),
+		Usage:  "Required issuer claim of JWT tokens",
+	},
+
+	cli.StringFlag{
+		Name:   JWTAudienceFlag,
+		EnvVar: envVarFromFlag(JWTAudienceFlag),
+		Usage:  "Required audience claim of JWT tokens",
+	},
+
+	cli.BoolFlag{
+		Name:   JWTUnscopedFlag,
+		EnvVar: envVarFromFlag(JWTUnscopedFlag),
+		Usage:  "Grant only read-only scopes to JWT tokens without a scope claim, which are otherwise granted all scopes",
+	},
+
+	cli.StringFlag{
+		Name:   TokenFileFlag,
+		EnvVar: envVarFromFlag(TokenFileFlag),
+		Usage:  "Path of the static token file for token-file authentication",
+	},
+
+	cli.DurationFlag{
+		Name:   TokenReloadFlag,
+		EnvVar: envVarFromFlag(TokenReloadFlag),
+		Value:  10 * time.Second,
+		Usage:  "Interval for checking the static token file for changes",
+	},
+
+	cli.StringFlag{
+		Name:   TLSCertFlag,
+		EnvVar: envVarFromFlag(TLSCertFlag),
+		Usage:  "Path of the PEM encoded certificate for serving the API over TLS",
+	},
+
+	cli.StringFlag{
+		Name:   TLSKeyFlag,
+		EnvVar: envVarFromFlag(TLSKeyFlag),
+		Usage:  "Path of the PEM encoded private key for serving the API over TLS",
+	},
+
+	cli.StringFlag{
+		Name:   TLSClientCAFlag,
+		EnvVar: envVarFromFlag(TLSClientCAFlag),
+		Usage:  "Path of the PEM encoded CA certificates for verifying client certificates",
+	},
+
+	cli.StringFlag{
+		Name:   ClientCertIdentityFlag,
+		EnvVar: envVarFromFlag(ClientCertIdentityFlag),
+		Value:  "subject",
+		Usage:  "Source of the namespace of client certificates. Supported values are: 'subject', 'uri'",
+	},
+
 	cli.BoolFlag{
 		Name:   RequireHTTPSFlag,
 		EnvVar: envVarFromFlag(RequireHTTPSFlag),
@@ -204,6 +286,12 @@ var Flags = []cli.Flag{
 		Value:  "",
 		Usage:  "Store password",
 	},
+	cli.BoolFlag{
+		Name:   StoreNotifyFlag,
+		EnvVar: envVarFromFlag(StoreNotifyFlag),
+		Usage: "Add the keyspace notifications required for tracking the changes of other registry nodes to the " +
+			"notify-keyspace-events setting of the redis store. Otherwise, the setting must include 'Kg$x'",
+	},
 }
 
 // envVarFromFlag returns the environment variable bound to the given flag
@@ -129,11 +129,34 @@ func Run(conf *config.Values) error {
 			case "trusted":
 				auths[i] = auth.NewTrustedAuthenticator()
 			case "jwt":
-				jwtAuth, err := auth.NewJWTAuthenticator([]byte(conf.JWTSecret))
+				jwtAuth, err := auth.NewJWTAuthenticatorFromConfig(auth.JWTConfig{
+					Secret:        []byte(conf.JWTSecret),
+					KeySet:        conf.JWTKeySet,
+					KeySetRefresh: conf.JWTRefresh,
+					Issuer:        conf.JWTIssuer,
+					Audience:      conf.JWTAudience,
+
+					UnscopedReadOnly: conf.JWTUnscoped,
+				})
 				if err != nil {
 					return fmt.Errorf("Failed to create the authentication module: %s", err)
 				}
 				auths[i] = jwtAuth
+			case "token-file":
+				tokenAuth, err := auth.NewTokenFileAuthenticator(conf.TokenFile, conf.TokenReload)
+				if err != nil {
+					return fmt.Errorf("Failed to create the authentication module: %s", err)
+				}
+				auths[i] = tokenAuth
+			case "client-cert":
+				if conf.TLSClientCA == "" {
+					return fmt.Errorf("Failed to create the authentication module: client-cert mode requires a TLS client CA")
+				}
+				certAuth, err := auth.NewClientCertAuthenticator(conf.ClientCertIdentity)
+				if err != nil {
+					return fmt.Errorf("Failed to create the authentication module: %s", err)
+				}
+				auths[i] = certAuth
 			default:
 				return fmt.Errorf("Failed to create the authentication module: unrecognized authentication mode '%s'", err)
 			}
@@ -185,6 +208,7 @@ func Run(conf *config.Values) error {
 		Store:             conf.Store,
 		StoreAddr:         conf.StoreAddr,
 		StorePassword:     conf.StorePassword,
+		StoreNotify:       conf.StoreNotify,
 	}
 	cm := store.New(cmConfig)
 
@@ -194,6 +218,15 @@ func Run(conf *config.Values) error {
 		Authenticator:   authenticator,
 		RequireHTTPS:    conf.RequireHTTPS,
 	}
+
+	if conf.TLSCert != "" || conf.TLSKey != "" {
+		serverConfig.TLSConfig, err = auth.NewServerTLSConfig(conf.TLSCert, conf.TLSKey, conf.TLSClientCA)
+		if err != nil {
+			return fmt.Errorf("Failed to create the TLS configuration: %s", err)
+		}
+	} else if conf.TLSClientCA != "" {
+		return fmt.Errorf("TLS client CA requires a TLS certificate and private key")
+	}
 	server, err := api.NewServer(serverConfig)
 	if err != nil {
 		return err
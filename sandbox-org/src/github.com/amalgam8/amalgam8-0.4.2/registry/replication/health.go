@@ -22,8 +22,8 @@ import (
 
 	"fmt"
 
+	"github.com/amalgam8/amalgam8/pkg/health"
 	"github.com/amalgam8/amalgam8/registry/cluster"
-	"github.com/amalgam8/amalgam8/registry/utils/health"
 	"github.com/amalgam8/amalgam8/registry/utils/logging"
 )
 
@@ -29,9 +29,9 @@ import (
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/amalgam8/amalgam8/pkg/auth"
+	"github.com/amalgam8/amalgam8/pkg/health"
 	"github.com/amalgam8/amalgam8/registry/cluster"
 	"github.com/amalgam8/amalgam8/registry/utils/channels"
-	"github.com/amalgam8/amalgam8/registry/utils/health"
 	"github.com/amalgam8/amalgam8/registry/utils/logging"
 )
 
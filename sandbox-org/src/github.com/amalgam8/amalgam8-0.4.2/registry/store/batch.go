@@ -0,0 +1,62 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package store
+
+// BatchResult is the result of registering or renewing a single instance of a batch.
+// Either the instance or the error is set.
+type BatchResult struct {
+	Instance *ServiceInstance
+	Err      error
+}
+
+// BatchCatalog is implemented by catalogs that register and renew many instances at once
+// more efficiently than one instance at a time.
+type BatchCatalog interface {
+	// RegisterBatch registers the instances, returning a result for each instance in the same order
+	RegisterBatch(instances []*ServiceInstance) []*BatchResult
+
+	// RenewBatch renews the identified instances, returning a result for each instance in the same order
+	RenewBatch(instanceIDs []string) []*BatchResult
+}
+
+// RegisterBatch registers the instances in the catalog, at once if the catalog supports batches,
+// or otherwise one instance at a time. A result is returned for each instance in the same order.
+func RegisterBatch(catalog Catalog, instances []*ServiceInstance) []*BatchResult {
+	if batch, ok := catalog.(BatchCatalog); ok {
+		return batch.RegisterBatch(instances)
+	}
+
+	results := make([]*BatchResult, len(instances))
+	for i, si := range instances {
+		instance, err := catalog.Register(si)
+		results[i] = &BatchResult{Instance: instance, Err: err}
+	}
+	return results
+}
+
+// RenewBatch renews the identified instances in the catalog, at once if the catalog supports batches,
+// or otherwise one instance at a time. A result is returned for each instance in the same order.
+func RenewBatch(catalog Catalog, instanceIDs []string) []*BatchResult {
+	if batch, ok := catalog.(BatchCatalog); ok {
+		return batch.RenewBatch(instanceIDs)
+	}
+
+	results := make([]*BatchResult, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		instance, err := catalog.Renew(instanceID)
+		results[i] = &BatchResult{Instance: instance, Err: err}
+	}
+	return results
+}
@@ -29,6 +29,7 @@ type Catalog interface {
 	Deregister(instanceID string) (*ServiceInstance, error)
 	Renew(instanceID string) (*ServiceInstance, error)
 	SetStatus(instanceID, status string) (*ServiceInstance, error)
+	Update(instanceID string, update *InstanceUpdate) (*ServiceInstance, error)
 
 	Instance(instanceID string) (*ServiceInstance, error)
 	List(serviceName string, predicate Predicate) ([]*ServiceInstance, error)
@@ -47,6 +48,15 @@ const (
 
 	// MetadataMaxLength is the maximum length of a service instance metadata, specifie in bytes
 	MetadataMaxLength int = 1024
+
+	// LabelsMaxLength is the maximum total length of the keys and values of a service instance labels, specified in bytes
+	LabelsMaxLength int = 1024
+
+	// DefaultWeight is the weight of a service instance registered without a weight
+	DefaultWeight int = 1
+
+	// WeightMaxValue is the maximum weight of a service instance, which fits the weight field of DNS SRV records
+	WeightMaxValue int = 65535
 )
 
 // Metric objects names
@@ -67,6 +67,7 @@ func New(conf *Config) CatalogMap {
 			address:           conf.StoreAddr,
 			password:          conf.StorePassword,
 			database:          conf.StoreDatabase,
+			notify:            conf.StoreNotify,
 		}
 		externalFactory := newExternalFactory(externalConfig)
 		factory = externalFactory
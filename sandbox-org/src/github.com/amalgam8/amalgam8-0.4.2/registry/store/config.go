@@ -52,6 +52,10 @@ type Config struct {
 	StoreAddr     string
 	StorePassword string
 	StoreDatabase database.Database
+
+	// StoreNotify configures the keyspace notifications of the redis store required for tracking the changes of
+	// other registry nodes. If false, they must be configured by the operator.
+	StoreNotify bool
 }
 
 // NewConfig creates a new registry configuration according to the specified TTL values
@@ -16,8 +16,12 @@ package store
 
 import (
 	"bytes"
+	"regexp"
 )
 
+// endpointNamePattern matches valid endpoint names, which are DNS labels so that endpoints may be looked up by name
+var endpointNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
 // Endpoint represents a network endpoint.
 // Immutable by convention.
 type Endpoint struct {
@@ -36,3 +40,19 @@ func (e *Endpoint) DeepClone() *Endpoint {
 	cloned := *e
 	return &cloned
 }
+
+// validateEndpoints checks that the named endpoints of a service instance have valid names and values
+func validateEndpoints(endpoints map[string]*Endpoint) error {
+	for name, endpoint := range endpoints {
+		if !endpointNamePattern.MatchString(name) {
+			return NewError(ErrorInstanceEndpointNameInvalid, "Endpoint name is invalid", name)
+		}
+		if endpoint == nil {
+			return NewError(ErrorBadRequest, "Endpoint was not specified", name)
+		}
+		if len(endpoint.Value) > EndpointValueMaxLength {
+			return NewError(ErrorInstanceEndpointValueTooLong, "Endpoint value length too long", name)
+		}
+	}
+	return nil
+}
@@ -33,6 +33,10 @@ const (
 	ErrorInstanceEndpointValueTooLong
 	ErrorInstanceStatusLengthTooLong
 	ErrorInstanceMetaDataTooLong
+	ErrorInstanceLabelsTooLong
+	ErrorInstanceLabelsInvalid
+	ErrorInstanceWeightOutOfRange
+	ErrorInstanceEndpointNameInvalid
 )
 
 // Error is an error implementation that is associated with an ErrorCode
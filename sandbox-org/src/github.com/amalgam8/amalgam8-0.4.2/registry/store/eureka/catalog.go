@@ -49,6 +49,7 @@ type eurekaCatalog struct {
 	versionDelta int64
 
 	logger *log.Entry
+	*store.ChangeLog
 }
 
 func newEurekaCatalog(client *eurekaClient) (*eurekaCatalog, error) {
@@ -57,6 +58,7 @@ func newEurekaCatalog(client *eurekaClient) (*eurekaCatalog, error) {
 		instances: instanceMap{},
 		client:    client,
 		logger:    logging.GetLogger(module),
+		ChangeLog: store.NewChangeLog(),
 	}
 
 	catalog.refresh()
@@ -137,6 +139,11 @@ func (ec *eurekaCatalog) SetStatus(instanceID, status string) (*store.ServiceIns
 	return nil, store.NewError(store.ErrorBadRequest, "Read-only Catalog: API Not Supported", "SetStatus")
 }
 
+func (ec *eurekaCatalog) Update(instanceID string, update *store.InstanceUpdate) (*store.ServiceInstance, error) {
+	ec.logger.Infof("Unsupported API (Update) called")
+	return nil, store.NewError(store.ErrorBadRequest, "Read-only Catalog: API Not Supported", "Update")
+}
+
 func (ec *eurekaCatalog) refresh() {
 	var services serviceMap
 	var instances instanceMap
@@ -157,6 +164,7 @@ func (ec *eurekaCatalog) refresh() {
 		ec.Lock()
 		defer ec.Unlock()
 
+		ec.PublishChanges(ec.instances, instances)
 		ec.services = services
 		ec.instances = instances
 	}
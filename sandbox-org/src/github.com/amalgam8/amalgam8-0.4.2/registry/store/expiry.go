@@ -0,0 +1,172 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package store
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// expiryQueue schedules the expiration checks of the instances of a catalog, using a single timer for all instances.
+//
+// Checks are rounded up to the resolution of the queue, so that checks due at about the same time are swept together.
+// Each instance is queued at most once, by the earliest time it may expire. Heartbeats push back the expiration time
+// of an instance without touching the queue, so the actual expiration time of a due instance is checked once it is
+// popped from the queue, and the instance is queued again if it was renewed in the meantime.
+type expiryQueue struct {
+	entries expiryHeap
+	byID    map[string]*expiryEntry
+
+	// sweep is invoked once the earliest queued check is due
+	sweep      func()
+	resolution time.Duration
+	timer      *time.Timer
+	armed      time.Time
+
+	mutex sync.Mutex
+}
+
+type expiryEntry struct {
+	id       string
+	deadline time.Time
+	index    int
+}
+
+func newExpiryQueue(resolution time.Duration, sweep func()) *expiryQueue {
+	return &expiryQueue{
+		byID:       make(map[string]*expiryEntry),
+		sweep:      sweep,
+		resolution: resolution,
+	}
+}
+
+// schedule queues an expiration check of the specified instance at the given time,
+// unless an earlier check of the instance is already queued.
+func (q *expiryQueue) schedule(id string, deadline time.Time) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if entry, exists := q.byID[id]; exists {
+		if !deadline.Before(entry.deadline) {
+			return
+		}
+		entry.deadline = deadline
+		heap.Fix(&q.entries, entry.index)
+	} else {
+		entry = &expiryEntry{id: id, deadline: deadline}
+		q.byID[id] = entry
+		heap.Push(&q.entries, entry)
+	}
+
+	q.arm()
+}
+
+// remove dequeues the expiration check of the specified instance, if any
+func (q *expiryQueue) remove(id string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if entry, exists := q.byID[id]; exists {
+		heap.Remove(&q.entries, entry.index)
+		delete(q.byID, id)
+	}
+}
+
+// due dequeues the instances whose expiration checks are due by the given time.
+// The timer is re-armed for the remaining checks once the sweep that called due completes, by calling rearm.
+func (q *expiryQueue) due(now time.Time) []string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var ids []string
+	for len(q.entries) > 0 && !q.entries[0].deadline.After(now) {
+		entry := heap.Pop(&q.entries).(*expiryEntry)
+		delete(q.byID, entry.id)
+		ids = append(ids, entry.id)
+	}
+
+	q.armed = time.Time{}
+	return ids
+}
+
+// rearm arms the timer for the earliest queued check
+func (q *expiryQueue) rearm() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.arm()
+}
+
+// size returns the number of queued checks
+func (q *expiryQueue) size() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return len(q.entries)
+}
+
+// arm makes sure the timer fires by the earliest queued check.
+// It assumes the queue lock is acquired by the calling goroutine.
+func (q *expiryQueue) arm() {
+	if len(q.entries) == 0 {
+		return
+	}
+
+	deadline := q.entries[0].deadline.Add(q.resolution - 1).Truncate(q.resolution)
+	if !q.armed.IsZero() && !deadline.Before(q.armed) {
+		return
+	}
+	q.armed = deadline
+
+	delay := deadline.Sub(time.Now())
+	if q.timer == nil {
+		q.timer = time.AfterFunc(delay, q.sweep)
+	} else {
+		q.timer.Reset(delay)
+	}
+}
+
+// expiryHeap implements heap.Interface, ordering entries by their deadlines
+type expiryHeap []*expiryEntry
+
+func (h expiryHeap) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap) Less(i, j int) bool {
+	return h[i].deadline.Before(h[j].deadline)
+}
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	entry := x.(*expiryEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return entry
+}
@@ -0,0 +1,174 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const benchmarkInstances = 100000
+
+func TestExpiryQueueOrder(t *testing.T) {
+
+	queue := newExpiryQueue(time.Hour, func() {})
+	now := time.Now()
+
+	queue.schedule("c", now.Add(3*time.Second))
+	queue.schedule("a", now.Add(1*time.Second))
+	queue.schedule("b", now.Add(2*time.Second))
+	queue.schedule("d", now.Add(4*time.Second))
+	queue.remove("d")
+
+	assert.Empty(t, queue.due(now))
+	assert.Equal(t, []string{"a", "b"}, queue.due(now.Add(2*time.Second)))
+	assert.Equal(t, []string{"c"}, queue.due(now.Add(time.Hour)))
+	assert.Equal(t, 0, queue.size())
+
+}
+
+func TestExpiryQueueKeepsEarliestCheck(t *testing.T) {
+
+	queue := newExpiryQueue(time.Hour, func() {})
+	now := time.Now()
+
+	queue.schedule("a", now.Add(2*time.Second))
+	queue.schedule("a", now.Add(3*time.Second))
+	assert.Equal(t, 1, queue.size())
+	assert.Equal(t, []string{"a"}, queue.due(now.Add(2*time.Second)))
+
+	queue.schedule("a", now.Add(2*time.Second))
+	queue.schedule("a", now.Add(1*time.Second))
+	assert.Equal(t, 1, queue.size())
+	assert.Equal(t, []string{"a"}, queue.due(now.Add(1*time.Second)))
+
+}
+
+func TestRenewalsDoNotQueueChecks(t *testing.T) {
+
+	catalog := newInMemoryCatalog(createNewConfig(testShortTTL))
+
+	id, err := doRegister(catalog, newServiceInstance("Calc", "192.168.0.1", 9080))
+	assert.NoError(t, err)
+
+	// Keep renewing the instance for several TTLs
+	for i := 0; i < 10; i++ {
+		_, err = catalog.Renew(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, catalog.expiry.size())
+		time.Sleep(testShortTTL / 2)
+	}
+
+	_, err = catalog.Instance(id)
+	assert.NoError(t, err)
+
+	time.Sleep(testShortTTL * 2)
+
+	_, err = catalog.Instance(id)
+	assert.EqualValues(t, ErrorNoSuchServiceInstance, extractErrorCode(err))
+	assert.Equal(t, 0, catalog.expiry.size())
+
+}
+
+func TestShorterTTLOnReregistration(t *testing.T) {
+
+	catalog := newInMemoryCatalog(createNewConfig(testMediumTTL))
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	id, err := doRegister(catalog, instance)
+	assert.NoError(t, err)
+
+	instance.TTL = testShortTTL
+	_, err = doRegister(catalog, instance)
+	assert.NoError(t, err)
+
+	time.Sleep(testShortTTL * 3)
+
+	_, err = catalog.Instance(id)
+	assert.EqualValues(t, ErrorNoSuchServiceInstance, extractErrorCode(err))
+
+}
+
+func populateBenchmarkCatalog(b *testing.B) (*inMemoryCatalog, []string) {
+	catalog := newInMemoryCatalog(&inMemoryConfig{time.Hour, time.Minute, time.Hour, -1})
+
+	ids := make([]string, benchmarkInstances)
+	for i := range ids {
+		instance, err := catalog.Register(newServiceInstance(fmt.Sprintf("Calc%d", i%100), fmt.Sprintf("10.0.%d.%d", i/256%256, i%256), uint32(9000+i/65536)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		ids[i] = instance.ID
+	}
+	return catalog, ids
+}
+
+func BenchmarkRegister100k(b *testing.B) {
+	catalog, _ := populateBenchmarkCatalog(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := catalog.Register(newServiceInstance("Bench", fmt.Sprintf("10.1.%d.%d", n/256%256, n%256), uint32(9000+n/65536))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenew100k(b *testing.B) {
+	catalog, ids := populateBenchmarkCatalog(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := catalog.Renew(ids[n%len(ids)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenew100kParallel(b *testing.B) {
+	catalog, ids := populateBenchmarkCatalog(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		n := 0
+		for pb.Next() {
+			catalog.Renew(ids[n%len(ids)])
+			n++
+		}
+	})
+}
+
+func BenchmarkSweep100k(b *testing.B) {
+	catalog, ids := populateBenchmarkCatalog(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		// Renewed instances are queued again by the sweep, rather than expired
+		b.StopTimer()
+		for _, id := range ids {
+			catalog.expiry.schedule(id, time.Time{})
+		}
+		b.StartTimer()
+
+		catalog.sweep()
+	}
+}
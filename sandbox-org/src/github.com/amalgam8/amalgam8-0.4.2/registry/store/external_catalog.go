@@ -39,11 +39,15 @@ type externalConfig struct {
 	address  string
 	password string
 	database database.Database
+
+	// notify configures the keyspace notifications in the database, which must be configured by the operator otherwise
+	notify bool
 }
 
 type externalFactory struct {
-	conf *externalConfig
-	pool *redis.Pool
+	conf    *externalConfig
+	pool    *redis.Pool
+	watcher *keyspaceWatcher
 }
 
 func newExternalFactory(conf *externalConfig) CatalogFactory {
@@ -72,13 +76,26 @@ func newExternalFactory(conf *externalConfig) CatalogFactory {
 				return err
 			},
 		}
-		return &externalFactory{conf: conf, pool: pool}
+		factory := &externalFactory{conf: conf, pool: pool}
+		if conf.database == nil {
+			factory.watcher = newKeyspaceWatcher(pool, conf.notify)
+			go factory.watcher.run()
+		}
+		return factory
 	}
 	return &externalFactory{conf: conf}
 }
 
 func (f *externalFactory) CreateCatalog(namespace auth.Namespace) (Catalog, error) {
-	return newExternalCatalog(f.conf, namespace, f.pool)
+	catalog, err := newExternalCatalog(f.conf, namespace, f.pool)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.watcher != nil {
+		f.watcher.add(catalog.(*externalCatalog))
+	}
+	return catalog, nil
 }
 
 type externalCatalog struct {
@@ -96,6 +113,14 @@ type externalCatalog struct {
 	tagsLengthMetric        metrics.Histogram
 	tagsInstancesMetric     metrics.Counter
 
+	// Changes made by other registry nodes sharing the database, and expirations of instances by the database,
+	// are tracked through keyspace notifications, by comparing the notified instances to the observed instances.
+	// The revision of the observed instances is incremented by the changes made through the catalog, and the
+	// modified instances are those changed through the catalog since the notified instances were last compared.
+	observed map[string]*ServiceInstance
+	revision uint64
+	modified map[string]uint64
+	*ChangeLog
 	sync.RWMutex
 }
 
@@ -141,12 +166,17 @@ func newExternalCatalog(conf *externalConfig, namespace auth.Namespace, pool *re
 		metadataInstancesMetric: metrics.GetOrRegister(metadataInstancesMetricName, counterFactory).(metrics.Counter),
 		tagsLengthMetric:        metrics.GetOrRegister(tagsLengthMetricName, histogramFactory).(metrics.Histogram),
 		tagsInstancesMetric:     metrics.GetOrRegister(tagsInstancesMetricName, counterFactory).(metrics.Counter),
+
+		observed:  make(map[string]*ServiceInstance),
+		modified:  make(map[string]uint64),
+		ChangeLog: NewChangeLog(),
 	}
 
 	return catalog, nil
 }
 
-func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, error) {
+// prepare validates the instance and returns a copy of it to be registered, with the defaults applied
+func (ec *externalCatalog) prepare(si *ServiceInstance) (*ServiceInstance, error) {
 	serviceName := si.ServiceName
 	if serviceName == "" {
 		return nil, NewError(ErrorNoInstanceServiceName, "Service name value was not specified", "")
@@ -160,6 +190,10 @@ func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, erro
 		return nil, NewError(ErrorInstanceEndpointValueTooLong, "Endpoint value length too long", "")
 	}
 
+	if err := validateEndpoints(si.Endpoints); err != nil {
+		return nil, err
+	}
+
 	if len(si.Status) > StatusMaxLength {
 		return nil, NewError(ErrorInstanceStatusLengthTooLong, "Status value length too long", "")
 	}
@@ -168,6 +202,14 @@ func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, erro
 		return nil, NewError(ErrorInstanceMetaDataTooLong, "Metadata value length too long", "")
 	}
 
+	if err := validateLabels(si.Labels); err != nil {
+		return nil, err
+	}
+
+	if err := validateWeight(si.Weight); err != nil {
+		return nil, err
+	}
+
 	instanceID := si.ID
 
 	if instanceID == "" {
@@ -176,6 +218,9 @@ func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, erro
 
 	newSI := si.DeepClone()
 	newSI.ID = instanceID
+	if newSI.Weight == 0 {
+		newSI.Weight = DefaultWeight
+	}
 
 	if newSI.TTL == 0 {
 		newSI.TTL = ec.conf.defaultTTL
@@ -190,18 +235,27 @@ func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, erro
 		newSI.LastRenewal = newSI.RegistrationTime
 	}
 
+	return newSI, nil
+}
+
+func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, error) {
+	newSI, err := ec.prepare(si)
+	if err != nil {
+		return nil, err
+	}
+
 	ec.Lock()
 	defer ec.Unlock()
 
 	// Existing instances are simply overwritten, but need to take into account for capacity validation and metrics collection.
-	instance, err := ec.db.ReadServiceInstanceByInstID(ec.namespace, instanceID)
+	instance, err := ec.db.ReadServiceInstanceByInstID(ec.namespace, newSI.ID)
 	if err != nil {
 		return nil, err
 	}
 	var alreadyExists bool
 	if instance != nil && instance.ID != "" {
 		alreadyExists = true
-		ec.logger.Debugf("Overwriting existing instance ID %s due to re-registration", instanceID)
+		ec.logger.Debugf("Overwriting existing instance ID %s due to re-registration", newSI.ID)
 	}
 
 	// Capacity validation - we don't check capacity for replication requests nor reregister requests
@@ -211,7 +265,7 @@ func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, erro
 			return nil, err
 		}
 		if len(hashKeys) >= ec.conf.namespaceCapacity {
-			ec.logger.Warnf("Failed to register service instance %s because quota exceeded (%d)", serviceName, len(hashKeys))
+			ec.logger.Warnf("Failed to register service instance %s because quota exceeded (%d)", newSI.ServiceName, len(hashKeys))
 			return nil, NewError(ErrorNamespaceQuotaExceeded, "Quota exceeded", "")
 		}
 
@@ -223,11 +277,115 @@ func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, erro
 		return nil, err
 	}
 
+	if !alreadyExists {
+		instance = nil
+	}
+	ec.registered(newSI, instance)
+
+	return newSI.DeepClone(), nil
+}
+
+// RegisterBatch registers the instances while holding the catalog lock once,
+// reading the existing instances and writing the registered instances in a single round trip each
+func (ec *externalCatalog) RegisterBatch(instances []*ServiceInstance) []*BatchResult {
+	results := make([]*BatchResult, len(instances))
+	prepared := make([]*ServiceInstance, 0, len(instances))
+	positions := make([]int, 0, len(instances))
+	for i, si := range instances {
+		newSI, err := ec.prepare(si)
+		if err != nil {
+			results[i] = &BatchResult{Err: err}
+			continue
+		}
+		prepared = append(prepared, newSI)
+		positions = append(positions, i)
+	}
+
+	if len(prepared) == 0 {
+		return results
+	}
+
+	failAll := func(err error) []*BatchResult {
+		for _, i := range positions {
+			results[i] = &BatchResult{Err: err}
+		}
+		return results
+	}
+
+	ec.Lock()
+	defer ec.Unlock()
+
+	// Existing instances are simply overwritten, but need to take into account for capacity validation and metrics collection.
+	ids := make([]string, len(prepared))
+	for j, newSI := range prepared {
+		ids[j] = newSI.ID
+	}
+	read, err := ec.db.ReadServiceInstancesByInstIDs(ec.namespace, ids)
+	if err != nil {
+		return failAll(err)
+	}
+	existing := make(map[string]*ServiceInstance, len(read))
+	for _, instance := range read {
+		if instance != nil && instance.ID != "" {
+			existing[instance.ID] = instance
+		}
+	}
+
+	// Capacity validation - we don't check capacity for reregister requests
+	count := 0
+	if ec.conf.namespaceCapacity >= 0 && len(existing) < len(prepared) {
+		hashKeys, err := ec.db.ReadKeys(ec.namespace)
+		if err != nil {
+			return failAll(err)
+		}
+		count = len(hashKeys)
+	}
+
+	accepted := make([]*ServiceInstance, 0, len(prepared))
+	previous := make([]*ServiceInstance, 0, len(prepared))
+	acceptedPositions := make([]int, 0, len(prepared))
+	for j, newSI := range prepared {
+		instance, alreadyExists := existing[newSI.ID]
+		if !alreadyExists && ec.conf.namespaceCapacity >= 0 {
+			if count >= ec.conf.namespaceCapacity {
+				ec.logger.Warnf("Failed to register service instance %s because quota exceeded (%d)", newSI.ServiceName, count)
+				results[positions[j]] = &BatchResult{Err: NewError(ErrorNamespaceQuotaExceeded, "Quota exceeded", "")}
+				continue
+			}
+			count++
+		}
+
+		// A later registration of the same instance within the batch overwrites this one
+		existing[newSI.ID] = newSI
+		accepted = append(accepted, newSI)
+		previous = append(previous, instance)
+		acceptedPositions = append(acceptedPositions, positions[j])
+	}
+
+	// Write the JSON registration data of all the instances to the database
+	if err = ec.db.InsertServiceInstances(ec.namespace, accepted); err != nil {
+		for _, i := range acceptedPositions {
+			results[i] = &BatchResult{Err: err}
+		}
+		return results
+	}
+
+	for k, newSI := range accepted {
+		ec.registered(newSI, previous[k])
+		results[acceptedPositions[k]] = &BatchResult{Instance: newSI.DeepClone()}
+	}
+
+	return results
+}
+
+// registered updates the metrics and publishes the registration of the instance,
+// which overwrote the existing instance unless it is nil. The catalog lock must be held by the caller.
+func (ec *externalCatalog) registered(newSI, existing *ServiceInstance) {
 	metadataLength := len(newSI.Metadata)
 	tagsLength := len(newSI.Tags)
 
 	// Update the instances/metadata/tags counter metrics
-	if !alreadyExists {
+	if existing == nil {
 		// For a newly registered instance, simply inc the instances counter,
 		// and if metadata/tags are used, inc the metadata/tags counter respectively
 		ec.instancesMetric.Inc(1)
@@ -240,8 +398,8 @@ func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, erro
 	} else {
 		// For overwriting an existing instance, no need to inc the instances counter,\
 		// but the metadata/tags counter are inc'ed/dec'ed as needed
-		prevMetadataLength := len(instance.Metadata)
-		prevTagsLength := len(instance.Tags)
+		prevMetadataLength := len(existing.Metadata)
+		prevTagsLength := len(existing.Tags)
 
 		if prevMetadataLength > 0 && metadataLength == 0 {
 			ec.metadataInstancesMetric.Dec(1)
@@ -264,7 +422,8 @@ func (ec *externalCatalog) Register(si *ServiceInstance) (*ServiceInstance, erro
 		ec.tagsLengthMetric.Update(int64(tagsLength))
 	}
 
-	return newSI.DeepClone(), nil
+	ec.observe(newSI.ID, newSI)
+	ec.Publish(EventRegister, newSI)
 }
 
 func (ec *externalCatalog) Deregister(instanceID string) (*ServiceInstance, error) {
@@ -276,6 +435,9 @@ func (ec *externalCatalog) Deregister(instanceID string) (*ServiceInstance, erro
 		return nil, NewError(ErrorNoSuchServiceInstance, "no such service instance", instanceID)
 	}
 
+	ec.observe(instanceID, nil)
+	ec.Publish(EventDeregister, instance)
+
 	return instance, nil
 }
 
@@ -300,6 +462,50 @@ func (ec *externalCatalog) Renew(instanceID string) (*ServiceInstance, error) {
 	return si.DeepClone(), nil
 }
 
+// RenewBatch renews the instances while holding the catalog lock once,
+// reading and writing the renewed instances in a single round trip each
+func (ec *externalCatalog) RenewBatch(instanceIDs []string) []*BatchResult {
+	results := make([]*BatchResult, len(instanceIDs))
+	if len(instanceIDs) == 0 {
+		return results
+	}
+
+	ec.Lock()
+	defer ec.Unlock()
+
+	instances, err := ec.db.ReadServiceInstancesByInstIDs(ec.namespace, instanceIDs)
+	if err != nil {
+		for i := range results {
+			results[i] = &BatchResult{Err: err}
+		}
+		return results
+	}
+
+	now := time.Now()
+	renewed := make([]*ServiceInstance, 0, len(instances))
+	positions := make([]int, 0, len(instances))
+	for i, si := range instances {
+		if si == nil || si.ID == "" {
+			results[i] = &BatchResult{Err: NewError(ErrorNoSuchServiceInstance, "no such service instance", instanceIDs[i])}
+			continue
+		}
+		si.LastRenewal = now
+		renewed = append(renewed, si)
+		positions = append(positions, i)
+	}
+
+	err = ec.db.InsertServiceInstances(ec.namespace, renewed)
+	for k, si := range renewed {
+		if err != nil {
+			results[positions[k]] = &BatchResult{Err: err}
+		} else {
+			results[positions[k]] = &BatchResult{Instance: si.DeepClone()}
+		}
+	}
+
+	return results
+}
+
 func (ec *externalCatalog) SetStatus(instanceID, status string) (*ServiceInstance, error) {
 	ec.Lock()
 	defer ec.Unlock()
@@ -312,12 +518,65 @@ func (ec *externalCatalog) SetStatus(instanceID, status string) (*ServiceInstanc
 		return nil, NewError(ErrorNoSuchServiceInstance, "no such service instance", instanceID)
 	}
 
+	changed := si.Status != status
 	si.Status = status
 	err = ec.db.InsertServiceInstance(ec.namespace, si)
 	if err != nil {
 		return nil, err
 	}
 
+	if changed {
+		ec.observe(si.ID, si)
+		ec.Publish(EventStatus, si)
+	}
+
+	return si.DeepClone(), nil
+}
+
+func (ec *externalCatalog) Update(instanceID string, update *InstanceUpdate) (*ServiceInstance, error) {
+	if err := update.validate(); err != nil {
+		return nil, err
+	}
+
+	ec.Lock()
+	defer ec.Unlock()
+
+	si, err := ec.db.ReadServiceInstanceByInstID(ec.namespace, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if si.ID == "" {
+		return nil, NewError(ErrorNoSuchServiceInstance, "no such service instance", instanceID)
+	}
+
+	hadMetadata := len(si.Metadata) > 0
+	hadTags := len(si.Tags) > 0
+
+	update.apply(si)
+	si.LastRenewal = time.Now()
+	err = ec.db.InsertServiceInstance(ec.namespace, si)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataLength := len(si.Metadata)
+	tagsLength := len(si.Tags)
+
+	if hadMetadata && metadataLength == 0 {
+		ec.metadataInstancesMetric.Dec(1)
+	} else if !hadMetadata && metadataLength > 0 {
+		ec.metadataInstancesMetric.Inc(1)
+	}
+
+	if hadTags && tagsLength == 0 {
+		ec.tagsInstancesMetric.Dec(1)
+	} else if !hadTags && tagsLength > 0 {
+		ec.tagsInstancesMetric.Inc(1)
+	}
+
+	ec.observe(si.ID, si)
+	ec.Publish(EventUpdate, si)
+
 	return si.DeepClone(), nil
 }
 
@@ -383,6 +642,106 @@ func (ec *externalCatalog) ListServices(predicate Predicate) []*Service {
 	return services
 }
 
+// resync reads the instances of the namespace from the database, and publishes their changes from the observed instances,
+// which may have been missed while keyspace notifications were not received. The initial read publishes no changes.
+func (ec *externalCatalog) resync(initial bool) error {
+	ec.Lock()
+	defer ec.Unlock()
+
+	services, err := ec.db.ListAllServiceInstances(ec.namespace)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]*ServiceInstance)
+	for _, service := range services {
+		for _, instance := range service {
+			current[instance.ID] = instance
+		}
+	}
+
+	if !initial {
+		ec.PublishChanges(ec.observed, current)
+	}
+	ec.observed = current
+
+	return nil
+}
+
+// keysChanged publishes the changes of the instances notified by keyspace events since the last call, unless they were
+// already observed. Most notified instances are merely renewed, so the instances set are read from the database in a
+// single round trip without holding the catalog lock. Instances modified through the catalog during the read are not
+// compared, as they are observed already and notified again.
+func (ec *externalCatalog) keysChanged(events map[string]string) error {
+	setIDs := make([]string, 0, len(events))
+	for instanceID, event := range events {
+		if event == "set" {
+			setIDs = append(setIDs, instanceID)
+		}
+	}
+
+	ec.RLock()
+	revision := ec.revision
+	ec.RUnlock()
+
+	var instances []*ServiceInstance
+	if len(setIDs) > 0 {
+		var err error
+		if instances, err = ec.db.ReadServiceInstancesByInstIDs(ec.namespace, setIDs); err != nil {
+			return err
+		}
+	}
+
+	ec.Lock()
+	defer ec.Unlock()
+
+	for i, instanceID := range setIDs {
+		instance := instances[i]
+		if ec.modified[instanceID] > revision {
+			continue
+		}
+		if instance == nil || instance.ID == "" {
+			// Deleted since, which is notified by a later event
+			continue
+		}
+		if eventType, changed := changeType(ec.observed[instanceID], instance); changed {
+			ec.observed[instanceID] = instance
+			ec.Publish(eventType, instance)
+		}
+	}
+
+	for instanceID, event := range events {
+		previous := ec.observed[instanceID]
+		if previous == nil || ec.modified[instanceID] > revision {
+			continue
+		}
+		switch event {
+		case "del":
+			delete(ec.observed, instanceID)
+			ec.Publish(EventDeregister, previous)
+		case "expired":
+			delete(ec.observed, instanceID)
+			ec.expirationMetric.Mark(1)
+			ec.Publish(EventExpire, previous)
+		}
+	}
+
+	ec.modified = make(map[string]uint64)
+	return nil
+}
+
+// observe records the instance changed through the catalog as observed, or as removed if nil.
+// It assumes the catalog's write-lock is acquired by the calling goroutine.
+func (ec *externalCatalog) observe(instanceID string, si *ServiceInstance) {
+	ec.revision++
+	ec.modified[instanceID] = ec.revision
+	if si == nil {
+		delete(ec.observed, instanceID)
+	} else {
+		ec.observed[instanceID] = si.DeepClone()
+	}
+}
+
 // delete deletes the specified instanceID from the catalog internal datastructures.
 // It assumes the catalog's write-lock is acquired by the calling goroutine.
 func (ec *externalCatalog) delete(instanceID string) *ServiceInstance {
@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"sync"
 
+	"github.com/amalgam8/amalgam8/pkg/auth"
 	"github.com/amalgam8/amalgam8/registry/utils/logging"
 	"github.com/rcrowley/go-metrics"
 	"github.com/stretchr/testify/assert"
@@ -53,6 +54,10 @@ func createExternalCatalog(conf *externalConfig, db ExternalRegistry) *externalC
 		metadataInstancesMetric: metrics.GetOrRegister(metadataInstancesMetricName, counterFactory).(metrics.Counter),
 		tagsLengthMetric:        metrics.GetOrRegister(tagsLengthMetricName, histogramFactory).(metrics.Histogram),
 		tagsInstancesMetric:     metrics.GetOrRegister(tagsInstancesMetricName, counterFactory).(metrics.Counter),
+
+		observed:  make(map[string]*ServiceInstance),
+		modified:  make(map[string]uint64),
+		ChangeLog: NewChangeLog(),
 	}
 
 	return catalog
@@ -63,7 +68,7 @@ func setupCatalogForTest() *externalCatalog {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{defaultTTL, minimumTTL, maximumTTL, 50, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{defaultTTL, minimumTTL, maximumTTL, 50, "redis", "testaddress", "testpassword", nil, false}
 	return createExternalCatalog(conf, db)
 }
 
@@ -195,7 +200,7 @@ func TestRedisRegisterInstanceWithCatalogTTL(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{2 * DefaultConfig.DefaultTTL, minimumTTL, maximumTTL, 50, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{2 * DefaultConfig.DefaultTTL, minimumTTL, maximumTTL, 50, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	si := &ServiceInstance{
@@ -433,7 +438,7 @@ func TestExternalReRegisterExpiredInstance(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, 50, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, 50, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	instance1 := newServiceInstance("Calc", "192.168.0.1", 9080)
@@ -590,7 +595,7 @@ func TestExternalSingleServiceQuota(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, namespaceCapacity, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, namespaceCapacity, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	for i := 0; i < namespaceCapacity; i++ {
@@ -629,7 +634,7 @@ func TestExternalMultipleServicesQuota(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, namespaceCapacity, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, namespaceCapacity, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	for i := 0; i < namespaceCapacity; i++ {
@@ -668,7 +673,7 @@ func TestExternalInstancesMetrics(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, 10, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, 10, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	instancesCount := func() int64 {
@@ -842,7 +847,7 @@ func TestExternalMultiCatalogsMetrics(t *testing.T) {
 	db := NewMockExternalRegistry(serviceInstances)
 	db2 := NewMockExternalRegistry(serviceInstances2)
 
-	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, 10, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{testShortTTL, testShortTTL, maximumTTL, 10, "redis", "testaddress", "testpassword", nil, false}
 	catalog1 := createExternalCatalog(conf, db)
 	catalog2 := createExternalCatalog(conf, db2)
 
@@ -868,7 +873,7 @@ func TestExternalRegisterInstancesSameServiceConcurrently(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{ttl, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{ttl, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	var wg sync.WaitGroup
@@ -918,7 +923,7 @@ func TestExternalRegisterInstancesConcurrently(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{ttl, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{ttl, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	var wg sync.WaitGroup
@@ -973,7 +978,7 @@ func TestExternalDeregisterInstancesSameServiceConcurrently(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{ttl, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{ttl, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 	var ids [numOfInstances]string
 
@@ -1027,7 +1032,7 @@ func TestExternalDeregisterInstancesConcurrently(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{ttl, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{ttl, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 	var ids [numOfInstances]string
 
@@ -1083,7 +1088,7 @@ func TestExternalRenewInstancePreventExpiration(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{defaultTTL, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{defaultTTL, testShortTTL, maximumTTL, -1, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
@@ -1135,7 +1140,7 @@ func TestExternalRenewInstancesConcurrently(t *testing.T) {
 
 	db := NewMockExternalRegistry(serviceInstances)
 
-	conf := &externalConfig{ttl, testMinTTL, testMaxTTL, -1, "redis", "testaddress", "testpassword", nil}
+	conf := &externalConfig{ttl, testMinTTL, testMaxTTL, -1, "redis", "testaddress", "testpassword", nil, false}
 	catalog := createExternalCatalog(conf, db)
 
 	var ids [numOfInstances]string
@@ -1183,6 +1188,173 @@ func TestExternalRenewInstancesConcurrently(t *testing.T) {
 
 }
 
+func TestExternalRegisterBatch(t *testing.T) {
+	catalog := setupCatalogForTest()
+
+	instance1 := newServiceInstance("Calc", "192.168.0.1", 9080)
+	instance2 := newServiceInstance("", "192.168.0.2", 9080)
+	instance3 := newServiceInstance("Calc", "192.168.0.3", 9080)
+
+	results := catalog.RegisterBatch([]*ServiceInstance{instance1, instance2, instance3})
+
+	assert.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.NotEmpty(t, results[0].Instance.ID)
+	assert.Equal(t, instance1.Endpoint, results[0].Instance.Endpoint)
+	assert.Error(t, results[1].Err)
+	assert.EqualValues(t, ErrorNoInstanceServiceName, extractErrorCode(results[1].Err))
+	assert.NoError(t, results[2].Err)
+	assert.NotEmpty(t, results[2].Instance.ID)
+	assert.Equal(t, instance3.Endpoint, results[2].Instance.Endpoint)
+
+	instances, err := catalog.List("Calc", nil)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+}
+
+func TestExternalRegisterBatchCapacity(t *testing.T) {
+	catalog := setupCatalogForTest()
+	catalog.conf.namespaceCapacity = 1
+
+	instance1 := newServiceInstance("Calc", "192.168.0.1", 9080)
+	instance2 := newServiceInstance("Calc", "192.168.0.2", 9080)
+
+	results := catalog.RegisterBatch([]*ServiceInstance{instance1, instance2})
+
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Error(t, results[1].Err)
+	assert.EqualValues(t, ErrorNamespaceQuotaExceeded, extractErrorCode(results[1].Err))
+}
+
+func TestExternalRenewBatch(t *testing.T) {
+	catalog := setupCatalogForTest()
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	id, _ := doRegister(catalog, instance)
+
+	results := catalog.RenewBatch([]string{id, "some-bogus-id"})
+
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assertSameInstance(t, instance, results[0].Instance)
+	assert.Error(t, results[1].Err)
+	assert.EqualValues(t, ErrorNoSuchServiceInstance, extractErrorCode(results[1].Err))
+}
+
+func TestExternalKeyChanged(t *testing.T) {
+	db := NewMockExternalRegistry(make(map[string]*ServiceInstance))
+	conf := &externalConfig{defaultTTL, minimumTTL, maximumTTL, 50, "redis", "testaddress", "testpassword", nil, false}
+	reader := &delayedReadRegistry{ExternalRegistry: db}
+	catalog := createExternalCatalog(conf, reader)
+	other := createExternalCatalog(conf, db)
+	assert.NoError(t, catalog.resync(true))
+	subscription := catalog.Subscribe(catalog.Index())
+
+	expectEvent := func(eventType EventType) {
+		select {
+		case event := <-subscription.Events:
+			assert.Equal(t, eventType, event.Type)
+		default:
+			t.Errorf("No %s event", eventType)
+		}
+	}
+	expectNoEvent := func() {
+		select {
+		case event := <-subscription.Events:
+			t.Errorf("Unexpected %s event", event.Type)
+		default:
+		}
+	}
+
+	keysChanged := func(events map[string]string) {
+		assert.NoError(t, catalog.keysChanged(events))
+	}
+
+	// Changes made by another registry node
+	id, err := doRegister(other, newServiceInstance("Calc", "192.168.0.1", 9080))
+	assert.NoError(t, err)
+	keysChanged(map[string]string{id: "set"})
+	expectEvent(EventRegister)
+
+	_, err = other.Renew(id)
+	assert.NoError(t, err)
+	keysChanged(map[string]string{id: "set"})
+	keysChanged(map[string]string{id: "expire"})
+	expectNoEvent()
+
+	_, err = other.SetStatus(id, OutOfService)
+	assert.NoError(t, err)
+	keysChanged(map[string]string{id: "set"})
+	expectEvent(EventStatus)
+
+	_, err = other.Deregister(id)
+	assert.NoError(t, err)
+	keysChanged(map[string]string{id: "del"})
+	expectEvent(EventDeregister)
+
+	// Changes made through the catalog are not published again
+	id, err = doRegister(catalog, newServiceInstance("Calc", "192.168.0.2", 9080))
+	assert.NoError(t, err)
+	expectEvent(EventRegister)
+	keysChanged(map[string]string{id: "set"})
+	expectNoEvent()
+
+	// Expirations by the database
+	_, err = db.DeleteServiceInstance(catalog.namespace, id)
+	assert.NoError(t, err)
+	keysChanged(map[string]string{id: "expired"})
+	expectEvent(EventExpire)
+	keysChanged(map[string]string{id: "del"})
+	expectNoEvent()
+
+	// Events of several instances are read at once
+	id1, err := doRegister(other, newServiceInstance("Calc", "192.168.0.4", 9080))
+	assert.NoError(t, err)
+	id2, err := doRegister(other, newServiceInstance("Calc", "192.168.0.5", 9080))
+	assert.NoError(t, err)
+	keysChanged(map[string]string{id1: "set", id2: "set"})
+	expectEvent(EventRegister)
+	expectEvent(EventRegister)
+
+	// Instances modified through the catalog while reading the notified instances are not compared
+	_, err = other.SetStatus(id1, Starting)
+	assert.NoError(t, err)
+	reader.afterRead = func() {
+		_, err := catalog.SetStatus(id1, OutOfService)
+		assert.NoError(t, err)
+	}
+	keysChanged(map[string]string{id1: "set"})
+	reader.afterRead = nil
+	expectEvent(EventStatus)
+	expectNoEvent()
+	instance, err := catalog.Instance(id1)
+	assert.NoError(t, err)
+	assert.Equal(t, OutOfService, instance.Status)
+	assert.Equal(t, OutOfService, catalog.observed[id1].Status)
+
+	// Changes missed while notifications were not received
+	id, err = doRegister(other, newServiceInstance("Calc", "192.168.0.3", 9080))
+	assert.NoError(t, err)
+	assert.NoError(t, catalog.resync(false))
+	expectEvent(EventRegister)
+}
+
+// delayedReadRegistry runs a function after reading instances and before returning them,
+// as if the function ran while the read instances were in transit
+type delayedReadRegistry struct {
+	ExternalRegistry
+	afterRead func()
+}
+
+func (r *delayedReadRegistry) ReadServiceInstancesByInstIDs(namespace auth.Namespace, instanceIDs []string) ([]*ServiceInstance, error) {
+	instances, err := r.ExternalRegistry.ReadServiceInstancesByInstIDs(namespace, instanceIDs)
+	if r.afterRead != nil {
+		r.afterRead()
+	}
+	return instances, err
+}
+
 func createNewExternalConfig(defaultTTL time.Duration) *externalConfig {
-	return &externalConfig{defaultTTL, testMinTTL, testMaxTTL, -1, "redis", "testaddress", "testpassword", nil}
+	return &externalConfig{defaultTTL, testMinTTL, testMaxTTL, -1, "redis", "testaddress", "testpassword", nil, false}
 }
@@ -32,9 +32,11 @@ const (
 type ExternalRegistry interface {
 	ReadKeys(namespace auth.Namespace) ([]string, error)
 	ReadServiceInstanceByInstID(namespace auth.Namespace, instanceID string) (*ServiceInstance, error)
+	ReadServiceInstancesByInstIDs(namespace auth.Namespace, instanceIDs []string) ([]*ServiceInstance, error)
 	ListServiceInstancesByName(namespace auth.Namespace, name string) (map[string]*ServiceInstance, error)
 	ListAllServiceInstances(namespace auth.Namespace) (map[string]ServiceInstanceMap, error)
 	InsertServiceInstance(namespace auth.Namespace, instance *ServiceInstance) error
+	InsertServiceInstances(namespace auth.Namespace, instances []*ServiceInstance) error
 	DeleteServiceInstance(namespace auth.Namespace, instanceID string) (int, error)
 }
 
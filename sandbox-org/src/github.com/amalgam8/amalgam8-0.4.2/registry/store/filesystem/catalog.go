@@ -58,6 +58,7 @@ type fsCatalog struct {
 	instances instanceMap
 
 	logger *log.Entry
+	*store.ChangeLog
 	sync.RWMutex
 }
 
@@ -68,6 +69,7 @@ func newFileSystemCatalog(namespace auth.Namespace, conf *Config) (*fsCatalog, e
 		services:  serviceMap{},
 		instances: instanceMap{},
 		logger:    logging.GetLogger(module).WithField("namespace", namespace),
+		ChangeLog: store.NewChangeLog(),
 	}
 
 	catalog.refresh()
@@ -149,12 +151,18 @@ func (fsc *fsCatalog) SetStatus(instanceID, status string) (*store.ServiceInstan
 	return nil, store.NewError(store.ErrorBadRequest, "Read-only Catalog: API Not Supported", "SetStatus")
 }
 
+func (fsc *fsCatalog) Update(instanceID string, update *store.InstanceUpdate) (*store.ServiceInstance, error) {
+	fsc.logger.Infof("Unsupported API (Update) called")
+	return nil, store.NewError(store.ErrorBadRequest, "Read-only Catalog: API Not Supported", "Update")
+}
+
 func (fsc *fsCatalog) refresh() {
 	fsc.Lock()
 	defer fsc.Unlock()
 
 	fsinfo, err := os.Stat(fsc.filename)
 	if os.IsNotExist(err) {
+		fsc.PublishChanges(fsc.instances, nil)
 		fsc.services = serviceMap{}
 		fsc.instances = instanceMap{}
 		fsc.modTime = time.Time{}
@@ -163,6 +171,7 @@ func (fsc *fsCatalog) refresh() {
 
 	if err != nil {
 		fsc.logger.Warnf("Failed to read file %s. %s", fsc.filename, err)
+		fsc.PublishChanges(fsc.instances, nil)
 		fsc.services = serviceMap{}
 		fsc.instances = instanceMap{}
 		return
@@ -171,6 +180,7 @@ func (fsc *fsCatalog) refresh() {
 	if fsinfo.ModTime().After(fsc.modTime) {
 		services, instances, err := fsc.getServices()
 		if err == nil {
+			fsc.PublishChanges(fsc.instances, instances)
 			fsc.services = services
 			fsc.instances = instances
 			fsc.modTime = fsinfo.ModTime()
@@ -198,11 +208,22 @@ func (fsc *fsCatalog) getServices() (serviceMap, instanceMap, error) {
 	for _, instReg := range instList.Instances {
 		var insts []*store.ServiceInstance
 
+		var endpoints map[string]*store.Endpoint
+		if len(instReg.Endpoints) > 0 {
+			endpoints = make(map[string]*store.Endpoint, len(instReg.Endpoints))
+			for name, endpoint := range instReg.Endpoints {
+				endpoints[name] = &store.Endpoint{Type: endpoint.Type, Value: endpoint.Value}
+			}
+		}
+
 		instance := &store.ServiceInstance{
 			ID:               computeInstanceID(instReg),
 			ServiceName:      instReg.ServiceName,
 			Endpoint:         &store.Endpoint{Type: instReg.Endpoint.Type, Value: instReg.Endpoint.Value},
+			Endpoints:        endpoints,
 			Tags:             instReg.Tags,
+			Labels:           instReg.Labels,
+			Weight:           instReg.Weight,
 			Status:           instReg.Status,
 			Metadata:         instReg.Metadata,
 			RegistrationTime: time.Now(),
@@ -210,6 +231,9 @@ func (fsc *fsCatalog) getServices() (serviceMap, instanceMap, error) {
 		if instance.Status == "" {
 			instance.Status = "UP"
 		}
+		if instance.Weight <= 0 {
+			instance.Weight = store.DefaultWeight
+		}
 		instance.Tags = append(instance.Tags, "filesystem")
 
 		insts = svcMap[instance.ServiceName]
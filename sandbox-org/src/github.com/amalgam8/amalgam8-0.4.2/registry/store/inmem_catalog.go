@@ -25,6 +25,13 @@ import (
 	"github.com/amalgam8/amalgam8/registry/utils/logging"
 )
 
+const (
+	// minExpiryResolution and maxExpiryResolution bound the resolution of expiration checks,
+	// which is otherwise a tenth of the minimum TTL of the catalog
+	minExpiryResolution = 10 * time.Millisecond
+	maxExpiryResolution = time.Second
+)
+
 var defaultInMemoryConfig = &inMemoryConfig{DefaultConfig.DefaultTTL, DefaultConfig.MinimumTTL, DefaultConfig.MaximumTTL, DefaultConfig.NamespaceCapacity}
 
 type inMemoryConfig struct {
@@ -47,13 +54,78 @@ func (f *inMemoryFactory) CreateCatalog(namespace auth.Namespace) (Catalog, erro
 	return newInMemoryCatalog(f.conf), nil
 }
 
-type inMemoryService map[string]*ServiceInstance
+// inMemoryService holds the instances of a service, along with secondary indexes of the instances by tag and status
+type inMemoryService struct {
+	instances map[string]*ServiceInstance
+	byTag     map[string]map[string]*ServiceInstance
+	byStatus  map[string]map[string]*ServiceInstance
+}
+
+func newInMemoryService() *inMemoryService {
+	return &inMemoryService{
+		instances: make(map[string]*ServiceInstance),
+		byTag:     make(map[string]map[string]*ServiceInstance),
+		byStatus:  make(map[string]map[string]*ServiceInstance),
+	}
+}
+
+// add adds the instance to the service and its indexes.
+// Instances must be removed before their tags or status change, and added again afterwards.
+func (s *inMemoryService) add(si *ServiceInstance) {
+	s.instances[si.ID] = si
+	for _, tag := range si.Tags {
+		addToIndex(s.byTag, tag, si)
+	}
+	addToIndex(s.byStatus, si.Status, si)
+}
+
+// remove removes the instance from the service and its indexes
+func (s *inMemoryService) remove(si *ServiceInstance) {
+	delete(s.instances, si.ID)
+	for _, tag := range si.Tags {
+		removeFromIndex(s.byTag, tag, si)
+	}
+	removeFromIndex(s.byStatus, si.Status, si)
+}
+
+// candidates returns the smallest set of instances that contains all the instances selected by the selector
+func (s *inMemoryService) candidates(selector *Selector) map[string]*ServiceInstance {
+	candidates := s.instances
+	if selector.Status != "" {
+		candidates = s.byStatus[selector.Status]
+	}
+	for _, tag := range selector.Tags {
+		if tagged := s.byTag[tag]; len(tagged) < len(candidates) {
+			candidates = tagged
+		}
+	}
+	return candidates
+}
+
+func addToIndex(index map[string]map[string]*ServiceInstance, key string, si *ServiceInstance) {
+	instances, exists := index[key]
+	if !exists {
+		instances = make(map[string]*ServiceInstance)
+		index[key] = instances
+	}
+	instances[si.ID] = si
+}
+
+func removeFromIndex(index map[string]map[string]*ServiceInstance, key string, si *ServiceInstance) {
+	if instances, exists := index[key]; exists {
+		delete(instances, si.ID)
+		if len(instances) == 0 {
+			delete(index, key)
+		}
+	}
+}
 
 type inMemoryCatalog struct {
-	services  map[string]inMemoryService
+	services  map[string]*inMemoryService
 	instances map[string]*ServiceInstance
 	conf      *inMemoryConfig
 	logger    *log.Entry
+	expiry    *expiryQueue
 
 	// Metrics
 	instancesMetric         metrics.Counter
@@ -64,6 +136,7 @@ type inMemoryCatalog struct {
 	tagsLengthMetric        metrics.Histogram
 	tagsInstancesMetric     metrics.Counter
 
+	*ChangeLog
 	sync.RWMutex
 }
 
@@ -77,7 +150,7 @@ func newInMemoryCatalog(conf *inMemoryConfig) *inMemoryCatalog {
 	histogramFactory := func() metrics.Histogram { return metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015)) }
 
 	catalog := &inMemoryCatalog{
-		services:  make(map[string]inMemoryService),
+		services:  make(map[string]*inMemoryService),
 		instances: make(map[string]*ServiceInstance),
 		conf:      conf,
 		logger:    logging.GetLogger(module),
@@ -89,30 +162,96 @@ func newInMemoryCatalog(conf *inMemoryConfig) *inMemoryCatalog {
 		metadataInstancesMetric: metrics.GetOrRegister(metadataInstancesMetricName, counterFactory).(metrics.Counter),
 		tagsLengthMetric:        metrics.GetOrRegister(tagsLengthMetricName, histogramFactory).(metrics.Histogram),
 		tagsInstancesMetric:     metrics.GetOrRegister(tagsInstancesMetricName, counterFactory).(metrics.Counter),
+
+		ChangeLog: NewChangeLog(),
+	}
+
+	resolution := conf.minimumTTL / 10
+	if resolution < minExpiryResolution {
+		resolution = minExpiryResolution
+	} else if resolution > maxExpiryResolution {
+		resolution = maxExpiryResolution
 	}
+	catalog.expiry = newExpiryQueue(resolution, catalog.sweep)
+
 	return catalog
 }
 
 func (imc *inMemoryCatalog) Register(si *ServiceInstance) (*ServiceInstance, error) {
+	newSI, isReplication, err := imc.prepare(si)
+	if err != nil {
+		return nil, err
+	}
+
+	imc.Lock()
+	defer imc.Unlock()
+
+	return imc.register(newSI, isReplication)
+}
+
+// RegisterBatch registers the instances while holding the catalog lock once
+func (imc *inMemoryCatalog) RegisterBatch(instances []*ServiceInstance) []*BatchResult {
+	results := make([]*BatchResult, len(instances))
+	prepared := make([]*ServiceInstance, len(instances))
+	replications := make([]bool, len(instances))
+	for i, si := range instances {
+		newSI, isReplication, err := imc.prepare(si)
+		if err != nil {
+			results[i] = &BatchResult{Err: err}
+			continue
+		}
+		prepared[i] = newSI
+		replications[i] = isReplication
+	}
+
+	imc.Lock()
+	defer imc.Unlock()
+
+	for i, newSI := range prepared {
+		if newSI == nil {
+			continue
+		}
+		instance, err := imc.register(newSI, replications[i])
+		results[i] = &BatchResult{Instance: instance, Err: err}
+	}
+
+	return results
+}
+
+// prepare validates the instance and returns a copy of it to be registered, with the defaults applied.
+// It also returns whether this is a replication request or a client request.
+func (imc *inMemoryCatalog) prepare(si *ServiceInstance) (*ServiceInstance, bool, error) {
 	serviceName := si.ServiceName
 	if serviceName == "" {
-		return nil, NewError(ErrorNoInstanceServiceName, "Service name value was not specified", "")
+		return nil, false, NewError(ErrorNoInstanceServiceName, "Service name value was not specified", "")
 	}
 
 	if len(serviceName) > ServiceNameMaxLength {
-		return nil, NewError(ErrorInstanceServiceNameTooLong, "Service name value length too long", "")
+		return nil, false, NewError(ErrorInstanceServiceNameTooLong, "Service name value length too long", "")
 	}
 
 	if si.Endpoint != nil && len(si.Endpoint.Value) > EndpointValueMaxLength {
-		return nil, NewError(ErrorInstanceEndpointValueTooLong, "Endpoint value length too long", "")
+		return nil, false, NewError(ErrorInstanceEndpointValueTooLong, "Endpoint value length too long", "")
+	}
+
+	if err := validateEndpoints(si.Endpoints); err != nil {
+		return nil, false, err
 	}
 
 	if len(si.Status) > StatusMaxLength {
-		return nil, NewError(ErrorInstanceStatusLengthTooLong, "Status value length too long", "")
+		return nil, false, NewError(ErrorInstanceStatusLengthTooLong, "Status value length too long", "")
 	}
 
 	if si.Metadata != nil && len(si.Metadata) > MetadataMaxLength {
-		return nil, NewError(ErrorInstanceMetaDataTooLong, "Metadata value length too long", "")
+		return nil, false, NewError(ErrorInstanceMetaDataTooLong, "Metadata value length too long", "")
+	}
+
+	if err := validateLabels(si.Labels); err != nil {
+		return nil, false, err
+	}
+
+	if err := validateWeight(si.Weight); err != nil {
+		return nil, false, err
 	}
 
 	instanceID := si.ID
@@ -123,6 +262,9 @@ func (imc *inMemoryCatalog) Register(si *ServiceInstance) (*ServiceInstance, err
 
 	newSI := si.DeepClone()
 	newSI.ID = instanceID
+	if newSI.Weight == 0 {
+		newSI.Weight = DefaultWeight
+	}
 	if newSI.TTL == 0 {
 		newSI.TTL = imc.conf.defaultTTL
 	} else if newSI.TTL < imc.conf.minimumTTL {
@@ -138,8 +280,13 @@ func (imc *inMemoryCatalog) Register(si *ServiceInstance) (*ServiceInstance, err
 		isReplication = false
 	}
 
-	imc.Lock()
-	defer imc.Unlock()
+	return newSI, isReplication, nil
+}
+
+// register adds the prepared instance to the catalog. The catalog lock must be held by the caller.
+func (imc *inMemoryCatalog) register(newSI *ServiceInstance, isReplication bool) (*ServiceInstance, error) {
+	instanceID := newSI.ID
+	serviceName := newSI.ServiceName
 
 	// Existing instances are simply overwritten, but need to take into account for capacity validation and metrics collection.
 	existingSI, alreadyExists := imc.instances[instanceID]
@@ -156,13 +303,17 @@ func (imc *inMemoryCatalog) Register(si *ServiceInstance) (*ServiceInstance, err
 
 	}
 
+	if alreadyExists {
+		imc.removeFromService(existingSI)
+	}
+
 	service, exists := imc.services[serviceName]
 	if !exists {
-		service = make(map[string]*ServiceInstance)
+		service = newInMemoryService()
 		imc.services[serviceName] = service
 	}
 
-	service[instanceID] = newSI
+	service.add(newSI)
 	imc.instances[instanceID] = newSI
 
 	imc.renew(newSI)
@@ -208,6 +359,8 @@ func (imc *inMemoryCatalog) Register(si *ServiceInstance) (*ServiceInstance, err
 		imc.tagsLengthMetric.Update(int64(tagsLength))
 	}
 
+	imc.Publish(EventRegister, newSI)
+
 	return newSI.DeepClone(), nil
 }
 
@@ -220,6 +373,8 @@ func (imc *inMemoryCatalog) Deregister(instanceID string) (*ServiceInstance, err
 		return nil, NewError(ErrorNoSuchServiceInstance, "no such service instance", instanceID)
 	}
 
+	imc.Publish(EventDeregister, instance)
+
 	return instance, nil
 }
 
@@ -236,6 +391,26 @@ func (imc *inMemoryCatalog) Renew(instanceID string) (*ServiceInstance, error) {
 	return instance.DeepClone(), nil
 }
 
+// RenewBatch renews the instances while holding the catalog lock once
+func (imc *inMemoryCatalog) RenewBatch(instanceIDs []string) []*BatchResult {
+	imc.RLock()
+	defer imc.RUnlock()
+
+	results := make([]*BatchResult, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		instance, exists := imc.instances[instanceID]
+		if !exists {
+			results[i] = &BatchResult{Err: NewError(ErrorNoSuchServiceInstance, "no such service instance", instanceID)}
+			continue
+		}
+
+		imc.renew(instance)
+		results[i] = &BatchResult{Instance: instance.DeepClone()}
+	}
+
+	return results
+}
+
 func (imc *inMemoryCatalog) SetStatus(instanceID, status string) (*ServiceInstance, error) {
 	imc.Lock()
 	defer imc.Unlock()
@@ -245,8 +420,61 @@ func (imc *inMemoryCatalog) SetStatus(instanceID, status string) (*ServiceInstan
 		return nil, NewError(ErrorNoSuchServiceInstance, "no such service instance", instanceID)
 	}
 
-	instance.Status = status
+	changed := instance.Status != status
+	if changed {
+		service := imc.services[instance.ServiceName]
+		service.remove(instance)
+		instance.Status = status
+		service.add(instance)
+	}
+	imc.renew(instance)
+
+	if changed {
+		imc.Publish(EventStatus, instance)
+	}
+
+	return instance.DeepClone(), nil
+}
+
+func (imc *inMemoryCatalog) Update(instanceID string, update *InstanceUpdate) (*ServiceInstance, error) {
+	if err := update.validate(); err != nil {
+		return nil, err
+	}
+
+	imc.Lock()
+	defer imc.Unlock()
+
+	instance, exists := imc.instances[instanceID]
+	if !exists {
+		return nil, NewError(ErrorNoSuchServiceInstance, "no such service instance", instanceID)
+	}
+
+	hadMetadata := len(instance.Metadata) > 0
+	hadTags := len(instance.Tags) > 0
+
+	service := imc.services[instance.ServiceName]
+	service.remove(instance)
+	update.apply(instance)
+	service.add(instance)
 	imc.renew(instance)
+
+	metadataLength := len(instance.Metadata)
+	tagsLength := len(instance.Tags)
+
+	if hadMetadata && metadataLength == 0 {
+		imc.metadataInstancesMetric.Dec(1)
+	} else if !hadMetadata && metadataLength > 0 {
+		imc.metadataInstancesMetric.Inc(1)
+	}
+
+	if hadTags && tagsLength == 0 {
+		imc.tagsInstancesMetric.Dec(1)
+	} else if !hadTags && tagsLength > 0 {
+		imc.tagsInstancesMetric.Inc(1)
+	}
+
+	imc.Publish(EventUpdate, instance)
+
 	return instance.DeepClone(), nil
 }
 
@@ -260,8 +488,8 @@ func (imc *inMemoryCatalog) List(serviceName string, predicate Predicate) ([]*Se
 		return nil, NewError(ErrorNoSuchServiceName, "no such service", serviceName)
 	}
 
-	instanceCollection := make([]*ServiceInstance, 0, len(service))
-	for _, instance := range service {
+	instanceCollection := make([]*ServiceInstance, 0, len(service.instances))
+	for _, instance := range service.instances {
 		if predicate == nil || predicate(instance) {
 			instanceCollection = append(instanceCollection, instance.DeepClone())
 		}
@@ -270,6 +498,29 @@ func (imc *inMemoryCatalog) List(serviceName string, predicate Predicate) ([]*Se
 	return instanceCollection, nil
 }
 
+// Select returns the selected instances of the service, evaluating only the instances indexed by the
+// status and the least common tag of the selector.
+func (imc *inMemoryCatalog) Select(serviceName string, selector *Selector) ([]*ServiceInstance, error) {
+	imc.RLock()
+	defer imc.RUnlock()
+
+	service := imc.services[serviceName]
+
+	if nil == service {
+		return nil, NewError(ErrorNoSuchServiceName, "no such service", serviceName)
+	}
+
+	candidates := service.candidates(selector)
+	instanceCollection := make([]*ServiceInstance, 0, len(candidates))
+	for _, instance := range candidates {
+		if selector.Matches(instance) {
+			instanceCollection = append(instanceCollection, instance.DeepClone())
+		}
+	}
+
+	return instanceCollection, nil
+}
+
 func (imc *inMemoryCatalog) Instance(instanceID string) (*ServiceInstance, error) {
 	imc.RLock()
 	defer imc.RUnlock()
@@ -288,7 +539,7 @@ func (imc *inMemoryCatalog) ListServices(predicate Predicate) []*Service {
 
 	services := make([]*Service, 0, len(imc.services))
 	for service, instances := range imc.services {
-		for _, instance := range instances {
+		for _, instance := range instances.instances {
 			if predicate == nil || predicate(instance) {
 				services = append(services, &Service{ServiceName: service})
 				break
@@ -299,42 +550,48 @@ func (imc *inMemoryCatalog) ListServices(predicate Predicate) []*Service {
 	return services
 }
 
-func (imc *inMemoryCatalog) checkIfExpired(instanceID string) {
-	var instance *ServiceInstance
-	func() {
-		// Ugly trick to make sure RUnlock() is called in case map lookup fails.
-		imc.RLock()
-		defer imc.RUnlock()
-		instance = imc.instances[instanceID]
-	}()
-
-	if instance == nil {
-		return
-	}
-
-	// If the status is OUT_OF_SERVICE do not expire
-	if instance.Status == OutOfService {
-		return
-	}
+// sweep expires the instances whose expiration checks are due and which were not renewed since.
+func (imc *inMemoryCatalog) sweep() {
+	imc.Lock()
+	defer imc.Unlock()
+	defer imc.expiry.rearm()
 
-	timeSinceHeartbeat := time.Now().Sub(instance.LastRenewal)
-	if timeSinceHeartbeat > instance.TTL {
+	now := time.Now()
+	for _, instanceID := range imc.expiry.due(now) {
+		instance, exists := imc.instances[instanceID]
+		if !exists {
+			continue
+		}
 
-		// Since timeSinceHeartbeat was calculated based
-		// on a possibly stale value of inst.LastRenewal,
-		// we sync our goroutine and then recalculate it.
-		// This should hopefully sync other goroutines running on the same CPU.
-		imc.Lock()
-		defer imc.Unlock()
+		// If the status is OUT_OF_SERVICE do not expire
+		if instance.Status == OutOfService {
+			continue
+		}
 
-		timeSinceHeartbeat = time.Now().Sub(instance.LastRenewal)
-		if timeSinceHeartbeat <= instance.TTL {
-			return
+		// The instance may have been renewed since its expiration check was scheduled
+		if expiration := instance.LastRenewal.Add(instance.TTL); expiration.After(now) {
+			imc.expiry.schedule(instanceID, expiration)
+			continue
 		}
 
 		imc.logger.Debugf("Instance ID %s is expired", instance.ID)
 		imc.delete(instanceID)
 		imc.expirationMetric.Mark(1)
+		imc.Publish(EventExpire, instance)
+	}
+}
+
+// removeFromService removes the instance from its service, deleting the service once it has no instances left.
+// It assumes the catalog's write-lock is acquired by the calling goroutine.
+func (imc *inMemoryCatalog) removeFromService(instance *ServiceInstance) {
+	service, exists := imc.services[instance.ServiceName]
+	if !exists {
+		return
+	}
+
+	service.remove(instance)
+	if len(service.instances) == 0 {
+		delete(imc.services, instance.ServiceName)
 	}
 }
 
@@ -345,14 +602,9 @@ func (imc *inMemoryCatalog) delete(instanceID string) *ServiceInstance {
 	if !exists {
 		return nil
 	}
-	serviceName := instance.ServiceName
-
-	delete(imc.services[serviceName], instanceID)
-	if len(imc.services[serviceName]) == 0 {
-		delete(imc.services, serviceName)
-	}
-
+	imc.removeFromService(instance)
 	delete(imc.instances, instanceID)
+	imc.expiry.remove(instanceID)
 
 	lifetime := time.Now().Sub(instance.RegistrationTime)
 	imc.lifetimeMetric.Update(int64(lifetime))
@@ -371,10 +623,9 @@ func (imc *inMemoryCatalog) delete(instanceID string) *ServiceInstance {
 	return instance
 }
 
+// renew renews the registration of the instance, scheduling an expiration check
+// unless an earlier check of the instance is already scheduled.
 func (imc *inMemoryCatalog) renew(instance *ServiceInstance) {
 	instance.LastRenewal = time.Now()
-
-	time.AfterFunc(instance.TTL, func() {
-		imc.checkIfExpired(instance.ID)
-	})
+	imc.expiry.schedule(instance.ID, instance.LastRenewal.Add(instance.TTL))
 }
@@ -597,6 +597,217 @@ func TestRenewInstanceAlreadyExpired(t *testing.T) {
 
 }
 
+func TestRegisterBatch(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	instance1 := newServiceInstance("Calc", "192.168.0.1", 9080)
+	instance2 := newServiceInstance("", "192.168.0.2", 9080)
+	instance3 := newServiceInstance("Calc", "192.168.0.3", 9080)
+
+	results := catalog.RegisterBatch([]*ServiceInstance{instance1, instance2, instance3})
+
+	assert.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.NotEmpty(t, results[0].Instance.ID)
+	assert.Equal(t, instance1.Endpoint, results[0].Instance.Endpoint)
+	assert.Error(t, results[1].Err)
+	assert.EqualValues(t, ErrorNoInstanceServiceName, extractErrorCode(results[1].Err))
+	assert.Nil(t, results[1].Instance)
+	assert.NoError(t, results[2].Err)
+	assert.NotEmpty(t, results[2].Instance.ID)
+	assert.Equal(t, instance3.Endpoint, results[2].Instance.Endpoint)
+
+	instances, err := catalog.List("Calc", nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+	assertContainsInstance(t, instances, results[0].Instance)
+	assertContainsInstance(t, instances, results[2].Instance)
+
+}
+
+func TestRenewBatch(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+
+	id, _ := doRegister(catalog, instance)
+	results := catalog.RenewBatch([]string{"some-bogus-id", id})
+
+	assert.Len(t, results, 2)
+	assert.Error(t, results[0].Err)
+	assert.EqualValues(t, ErrorNoSuchServiceInstance, extractErrorCode(results[0].Err))
+	assert.NoError(t, results[1].Err)
+	assertSameInstance(t, instance, results[1].Instance)
+
+}
+
+func TestUpdateInstance(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	instance.Tags = []string{"v1"}
+	instance.Metadata = []byte(`{"version":"v1"}`)
+
+	id, _ := doRegister(catalog, instance)
+
+	uInstance, err := catalog.Update(id, &InstanceUpdate{Tags: []string{"v2", "canary"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v2", "canary"}, uInstance.Tags)
+	assert.Equal(t, instance.Metadata, uInstance.Metadata)
+
+	uInstance, err = catalog.Update(id, &InstanceUpdate{Metadata: []byte{}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v2", "canary"}, uInstance.Tags)
+	assert.Empty(t, uInstance.Metadata)
+
+	rInstance, err := catalog.Instance(id)
+	assert.NoError(t, err)
+	assertSameInstance(t, uInstance, rInstance)
+
+}
+
+func TestUpdateInstanceNotRegistered(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	_, err := catalog.Update("some-bogus-id", &InstanceUpdate{Tags: []string{"v2"}})
+
+	assert.Error(t, err)
+	assert.EqualValues(t, ErrorNoSuchServiceInstance, extractErrorCode(err))
+
+}
+
+func TestUpdateInstanceMetadataTooLong(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	id, _ := doRegister(catalog, instance)
+
+	_, err := catalog.Update(id, &InstanceUpdate{Metadata: make([]byte, MetadataMaxLength+1)})
+
+	assert.Error(t, err)
+	assert.EqualValues(t, ErrorInstanceMetaDataTooLong, extractErrorCode(err))
+
+}
+
+func TestUpdateInstanceLabels(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	instance.Labels = map[string]string{"version": "v1"}
+	id, _ := doRegister(catalog, instance)
+
+	uInstance, err := catalog.Update(id, &InstanceUpdate{Labels: map[string]string{"version": "v2", "zone": "eu-1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"version": "v2", "zone": "eu-1"}, uInstance.Labels)
+
+	uInstance, err = catalog.Update(id, &InstanceUpdate{Tags: []string{"canary"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"version": "v2", "zone": "eu-1"}, uInstance.Labels)
+
+	_, err = catalog.Update(id, &InstanceUpdate{Labels: map[string]string{"version": "v2 beta"}})
+	assert.EqualValues(t, ErrorInstanceLabelsInvalid, extractErrorCode(err))
+
+}
+
+func TestRegisterInstanceInvalidLabels(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	cases := []struct {
+		labels map[string]string
+		code   ErrorCode
+	}{
+		{map[string]string{"": "v1"}, ErrorInstanceLabelsInvalid},
+		{map[string]string{"version": "-v1"}, ErrorInstanceLabelsInvalid},
+		{map[string]string{"version": strings.Repeat("v", LabelsMaxLength)}, ErrorInstanceLabelsTooLong},
+	}
+
+	for _, c := range cases {
+		instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+		instance.Labels = c.labels
+		_, err := catalog.Register(instance)
+		assert.Error(t, err)
+		assert.EqualValues(t, c.code, extractErrorCode(err), "labels %v", c.labels)
+	}
+
+	instances, err := catalog.List("Calc", nil)
+	assert.Error(t, err)
+	assert.Nil(t, instances)
+
+}
+
+func TestRegisterInstanceWeight(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	for _, weight := range []int{-1, WeightMaxValue + 1} {
+		instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+		instance.Weight = weight
+		_, err := catalog.Register(instance)
+		assert.Error(t, err)
+		assert.EqualValues(t, ErrorInstanceWeightOutOfRange, extractErrorCode(err), "weight %d", weight)
+	}
+
+	instance1 := newServiceInstance("Calc", "192.168.0.1", 9080)
+	instance2 := newServiceInstance("Calc", "192.168.0.2", 9080)
+	instance2.Weight = 4
+
+	registered, err := catalog.Register(instance1)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultWeight, registered.Weight)
+
+	registered, err = catalog.Register(instance2)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, registered.Weight)
+
+	instance, err := catalog.Instance(registered.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, instance.Weight)
+}
+
+func TestRegisterInstanceEndpoints(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	cases := []struct {
+		endpoints map[string]*Endpoint
+		code      ErrorCode
+	}{
+		{map[string]*Endpoint{"Admin": {Type: "http", Value: "192.168.0.1:9090"}}, ErrorInstanceEndpointNameInvalid},
+		{map[string]*Endpoint{"-admin": {Type: "http", Value: "192.168.0.1:9090"}}, ErrorInstanceEndpointNameInvalid},
+		{map[string]*Endpoint{"admin": nil}, ErrorBadRequest},
+		{map[string]*Endpoint{"admin": {Type: "http", Value: strings.Repeat("a", EndpointValueMaxLength+1)}}, ErrorInstanceEndpointValueTooLong},
+	}
+
+	for _, tc := range cases {
+		instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+		instance.Endpoints = tc.endpoints
+		_, err := catalog.Register(instance)
+		assert.Error(t, err)
+		assert.EqualValues(t, tc.code, extractErrorCode(err), "endpoints %v", tc.endpoints)
+	}
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	instance.Endpoints = map[string]*Endpoint{
+		"admin": {Type: "http", Value: "192.168.0.1:9090"},
+		"grpc":  {Type: "tcp", Value: "192.168.0.1:5000"},
+	}
+
+	registered, err := catalog.Register(instance)
+	assert.NoError(t, err)
+
+	found, err := catalog.Instance(registered.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, instance.Endpoints, found.Endpoints)
+}
+
 func TestFindInstanceByID(t *testing.T) {
 	catalog := newInMemoryCatalog(nil)
 
@@ -1440,6 +1651,7 @@ func doRegister(catalog Catalog, si *ServiceInstance) (string, error) {
 	if err == nil {
 		si.ID = registeredSI.ID
 		si.TTL = registeredSI.TTL
+		si.Weight = registeredSI.Weight
 	}
 	return si.ID, err
 }
@@ -17,12 +17,15 @@ package store
 import (
 	"fmt"
 	"time"
+
+	"github.com/amalgam8/amalgam8/pkg/labels"
 )
 
 // Registered instance status related constants
 const (
 	Starting     = "STARTING"
 	Up           = "UP"
+	Draining     = "DRAINING" // DRAINING instances are shutting down, and should only receive traffic of existing sessions
 	OutOfService = "OUT_OF_SERVICE"
 	All          = "ALL" // ALL is only a valid status for the query string param and not for the register
 )
@@ -32,31 +35,50 @@ type ServiceInstance struct {
 	ID               string
 	ServiceName      string
 	Endpoint         *Endpoint
+	Endpoints        map[string]*Endpoint
 	Status           string
 	Metadata         []byte
 	RegistrationTime time.Time
 	LastRenewal      time.Time
 	TTL              time.Duration
 	Tags             []string
+	Labels           map[string]string
+	Weight           int
 	Extension        map[string]interface{}
 }
 
 // String output the structure
 func (si *ServiceInstance) String() string {
-	return fmt.Sprintf("id: %s, service_name: %s, endpoint: %s, status: %s, registrationTime: %v, lastRenewal: %v, ttl: %d, tags: %v",
-		si.ID, si.ServiceName, si.Endpoint, si.Status, si.RegistrationTime, si.LastRenewal, si.TTL, si.Tags)
+	return fmt.Sprintf("id: %s, service_name: %s, endpoint: %s, endpoints: %v, status: %s, registrationTime: %v, lastRenewal: %v, ttl: %d, tags: %v, labels: %v, weight: %d",
+		si.ID, si.ServiceName, si.Endpoint, si.Endpoints, si.Status, si.RegistrationTime, si.LastRenewal, si.TTL, si.Tags, si.Labels, si.Weight)
 }
 
 // DeepClone creates a deep copy of the receiver
 func (si *ServiceInstance) DeepClone() *ServiceInstance {
 	cloned := *si
 	cloned.Endpoint = si.Endpoint.DeepClone()
+	if len(si.Endpoints) == 0 {
+		cloned.Endpoints = nil
+	} else {
+		cloned.Endpoints = make(map[string]*Endpoint, len(si.Endpoints))
+		for name, endpoint := range si.Endpoints {
+			cloned.Endpoints[name] = endpoint.DeepClone()
+		}
+	}
 	if si.Metadata == nil || len(si.Metadata) == 0 {
 		cloned.Metadata = nil
 	} else {
 		cloned.Metadata = make([]byte, len(si.Metadata))
 		copy(cloned.Metadata, si.Metadata)
 	}
+	if len(si.Labels) == 0 {
+		cloned.Labels = nil
+	} else {
+		cloned.Labels = make(map[string]string, len(si.Labels))
+		for k, v := range si.Labels {
+			cloned.Labels[k] = v
+		}
+	}
 	if len(si.Extension) == 0 {
 		cloned.Extension = nil
 	} else {
@@ -68,5 +90,58 @@ func (si *ServiceInstance) DeepClone() *ServiceInstance {
 	return &cloned
 }
 
+// InstanceUpdate describes changes to the tags, labels and metadata of a registered service instance.
+// Attributes set to nil are left unchanged.
+type InstanceUpdate struct {
+	Tags     []string
+	Labels   map[string]string
+	Metadata []byte
+}
+
+// apply applies the update to the service instance
+func (update *InstanceUpdate) apply(si *ServiceInstance) {
+	if update.Tags != nil {
+		si.Tags = update.Tags
+	}
+	if update.Labels != nil {
+		si.Labels = update.Labels
+	}
+	if update.Metadata != nil {
+		si.Metadata = update.Metadata
+	}
+}
+
+// validate checks the updated attributes against their maximum lengths
+func (update *InstanceUpdate) validate() error {
+	if len(update.Metadata) > MetadataMaxLength {
+		return NewError(ErrorInstanceMetaDataTooLong, "Metadata value length too long", "")
+	}
+	return validateLabels(update.Labels)
+}
+
+// validateLabels checks that the labels are well formed, and that their total length does not exceed the maximum
+func validateLabels(instanceLabels map[string]string) error {
+	length := 0
+	for key, value := range instanceLabels {
+		length += len(key) + len(value)
+	}
+	if length > LabelsMaxLength {
+		return NewError(ErrorInstanceLabelsTooLong, "Labels length too long", "")
+	}
+
+	if err := labels.Validate(instanceLabels); err != nil {
+		return NewError(ErrorInstanceLabelsInvalid, "Labels are invalid", err.Error())
+	}
+	return nil
+}
+
+// validateWeight checks that the weight is within range. A zero weight is replaced by the default weight on registration.
+func validateWeight(weight int) error {
+	if weight < 0 || weight > WeightMaxValue {
+		return NewError(ErrorInstanceWeightOutOfRange, "Weight value out of range", weight)
+	}
+	return nil
+}
+
 // ServiceInstanceMap is a map of ServiceInstances keyed by instance id and service name
 type ServiceInstanceMap map[DBKey]*ServiceInstance
@@ -28,8 +28,10 @@ func TestCloneInstance(t *testing.T) {
 		ID:          "1",
 		ServiceName: "Calc",
 		Endpoint:    &Endpoint{Value: "localhost" + ":9080", Type: "tcp"},
+		Endpoints:   map[string]*Endpoint{"admin": {Value: "localhost" + ":9090", Type: "http"}},
 		Status:      "UP",
 		Metadata:    []byte("Metadata"),
+		Labels:      map[string]string{"version": "v1"},
 		LastRenewal: time.Now(),
 		TTL:         time.Duration(30) * time.Second,
 	}
@@ -54,11 +56,19 @@ func TestCloneInstance(t *testing.T) {
 	cloned.LastRenewal = time.Now().Add(time.Hour)
 	assert.NotEqual(t, original, cloned)
 
+	cloned = original.DeepClone()
+	cloned.Labels["version"] = "v2"
+	assert.NotEqual(t, original, cloned)
+
 	cloned = original.DeepClone()
 	cloned.Endpoint.Type = "udp"
 	assert.NotEqual(t, original, cloned)
 	assert.False(t, reflect.DeepEqual(original, cloned))
 
+	cloned = original.DeepClone()
+	cloned.Endpoints["admin"].Value = "localhost:9091"
+	assert.NotEqual(t, original, cloned)
+
 }
 
 /*
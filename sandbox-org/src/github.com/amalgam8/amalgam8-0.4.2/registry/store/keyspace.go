@@ -0,0 +1,222 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package store
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/garyburd/redigo/redis"
+
+	"github.com/amalgam8/amalgam8/registry/utils/logging"
+)
+
+const (
+	// keyspaceEvents are the keyspace notifications required for tracking the changes of instances:
+	// keyspace events (K), generic commands such as DEL (g), string commands such as SET ($), and expirations (x)
+	keyspaceEvents = "Kg$x"
+
+	keyspaceChannelPrefix = "__keyspace@"
+	keyspaceChannelSep    = "__:"
+
+	keyspaceRetryInterval = 5 * time.Second
+
+	// keyspaceFlushInterval is the interval of dispatching the received notifications to the catalogs. Every renewal
+	// is notified, so the notifications of each namespace are batched, and those of the same instance collapsed.
+	keyspaceFlushInterval = 500 * time.Millisecond
+)
+
+// keyspaceWatcher receives the keyspace notifications of the instance keys from Redis,
+// and dispatches them to the external catalogs of their namespaces.
+// Changes missed while disconnected are published by resynchronizing the catalogs upon reconnection.
+//
+// The notifications must be enabled by including the keyspaceEvents in the notify-keyspace-events setting of Redis.
+// The watcher adds them to the setting only if configured to, since the setting applies to all clients of the database.
+type keyspaceWatcher struct {
+	pool      *redis.Pool
+	configure bool
+	logger    *log.Entry
+
+	catalogs map[string]*externalCatalog
+	sync.RWMutex
+
+	// pending are the latest events received for each instance of each namespace, since they were last dispatched
+	pending      map[string]map[string]string
+	pendingMutex sync.Mutex
+}
+
+func newKeyspaceWatcher(pool *redis.Pool, configure bool) *keyspaceWatcher {
+	return &keyspaceWatcher{
+		pool:      pool,
+		configure: configure,
+		logger:    logging.GetLogger(module),
+		catalogs:  make(map[string]*externalCatalog),
+		pending:   make(map[string]map[string]string),
+	}
+}
+
+// add starts dispatching the notifications of the namespace of the catalog to it
+func (kw *keyspaceWatcher) add(catalog *externalCatalog) {
+	kw.Lock()
+	kw.catalogs[catalog.namespace.String()] = catalog
+	kw.Unlock()
+
+	if err := catalog.resync(true); err != nil {
+		kw.logger.WithError(err).Warnf("Failed to read the service instances of namespace %s", catalog.namespace)
+	}
+}
+
+func (kw *keyspaceWatcher) run() {
+	go kw.flush()
+
+	reconnect := false
+	for {
+		if err := kw.watch(reconnect); err != nil {
+			kw.logger.WithError(err).Warn("Failed to receive keyspace notifications")
+		}
+		reconnect = true
+		time.Sleep(keyspaceRetryInterval)
+	}
+}
+
+// watch subscribes to the keyspace notifications of the instance keys and dispatches them until the connection fails
+func (kw *keyspaceWatcher) watch(reconnect bool) error {
+	conn := kw.pool.Get()
+	defer conn.Close()
+
+	kw.checkNotifications(conn)
+
+	psc := redis.PubSubConn{Conn: conn}
+	pattern := keyspaceChannelPrefix + "*" + keyspaceChannelSep + strings.Join([]string{registryRecordKey, namespaceKey, "*"}, keySeparator)
+	if err := psc.PSubscribe(pattern); err != nil {
+		return err
+	}
+
+	for {
+		switch reply := psc.Receive().(type) {
+		case redis.PMessage:
+			kw.dispatch(reply.Channel, string(reply.Data))
+		case redis.Subscription:
+			if reconnect {
+				kw.resync()
+			}
+		case error:
+			return reply
+		}
+	}
+}
+
+// checkNotifications checks that the required keyspace notifications are configured in Redis, and adds them to the
+// configured notifications if the watcher is configured to. Otherwise, they must be configured by the operator.
+func (kw *keyspaceWatcher) checkNotifications(conn redis.Conn) {
+	reply, err := redis.Strings(conn.Do("CONFIG", "GET", "notify-keyspace-events"))
+	if err != nil || len(reply) != 2 {
+		kw.logger.WithError(err).Warnf("Failed to read the keyspace notifications configuration, "+
+			"changes of other registry nodes are not tracked unless notify-keyspace-events includes %s", keyspaceEvents)
+		return
+	}
+
+	flags := reply[1]
+	missing := ""
+	for _, flag := range keyspaceEvents {
+		if !strings.ContainsRune(flags, flag) && !(strings.ContainsRune(flags, 'A') && flag != 'K') {
+			missing += string(flag)
+		}
+	}
+	if missing == "" {
+		return
+	}
+
+	if !kw.configure {
+		kw.logger.Warnf("The keyspace notifications configuration lacks %s, "+
+			"changes of other registry nodes are not tracked unless notify-keyspace-events includes %s", missing, keyspaceEvents)
+		return
+	}
+
+	if _, err = conn.Do("CONFIG", "SET", "notify-keyspace-events", flags+missing); err != nil {
+		kw.logger.WithError(err).Warnf("Failed to configure the keyspace notifications, "+
+			"changes of other registry nodes are not tracked unless notify-keyspace-events includes %s", keyspaceEvents)
+	}
+}
+
+// dispatch queues the event of the key notified on the channel for the catalog of its namespace
+func (kw *keyspaceWatcher) dispatch(channel, event string) {
+	i := strings.Index(channel, keyspaceChannelSep)
+	if i < 0 {
+		return
+	}
+
+	dbKey, err := parseStringIntoDBKey(channel[i+len(keyspaceChannelSep):])
+	if err != nil {
+		return
+	}
+
+	kw.RLock()
+	_, exists := kw.catalogs[dbKey.Namespace]
+	kw.RUnlock()
+	if !exists {
+		return
+	}
+
+	kw.pendingMutex.Lock()
+	events := kw.pending[dbKey.Namespace]
+	if events == nil {
+		events = make(map[string]string)
+		kw.pending[dbKey.Namespace] = events
+	}
+	events[dbKey.InstanceID] = event
+	kw.pendingMutex.Unlock()
+}
+
+// flush periodically dispatches the pending events to the catalogs of their namespaces
+func (kw *keyspaceWatcher) flush() {
+	for range time.Tick(keyspaceFlushInterval) {
+		kw.pendingMutex.Lock()
+		pending := kw.pending
+		kw.pending = make(map[string]map[string]string)
+		kw.pendingMutex.Unlock()
+
+		for namespace, events := range pending {
+			kw.RLock()
+			catalog := kw.catalogs[namespace]
+			kw.RUnlock()
+			if catalog == nil {
+				continue
+			}
+
+			if err := catalog.keysChanged(events); err != nil {
+				kw.logger.WithError(err).Warnf("Failed to read the changed service instances of namespace %s", namespace)
+			}
+		}
+	}
+}
+
+// resync publishes the changes of all catalogs which may have been missed while disconnected
+func (kw *keyspaceWatcher) resync() {
+	kw.RLock()
+	catalogs := make([]*externalCatalog, 0, len(kw.catalogs))
+	for _, catalog := range kw.catalogs {
+		catalogs = append(catalogs, catalog)
+	}
+	kw.RUnlock()
+
+	for _, catalog := range catalogs {
+		if err := catalog.resync(false); err != nil {
+			kw.logger.WithError(err).Warnf("Failed to resynchronize the service instances of namespace %s", catalog.namespace)
+		}
+	}
+}
@@ -42,6 +42,7 @@ type k8sCatalog struct {
 	instances instanceMap
 
 	logger *log.Entry
+	*store.ChangeLog
 	sync.RWMutex
 }
 
@@ -52,6 +53,7 @@ func newK8sCatalog(namespace auth.Namespace, client *k8sClient) (*k8sCatalog, er
 		namespace: namespace,
 		client:    client,
 		logger:    logging.GetLogger(module),
+		ChangeLog: store.NewChangeLog(),
 	}
 
 	catalog.refresh()
@@ -133,12 +135,18 @@ func (kc *k8sCatalog) SetStatus(instanceID, status string) (*store.ServiceInstan
 	return nil, store.NewError(store.ErrorBadRequest, "Read-only Catalog: API Not Supported", "SetStatus")
 }
 
+func (kc *k8sCatalog) Update(instanceID string, update *store.InstanceUpdate) (*store.ServiceInstance, error) {
+	kc.logger.Infof("Unsupported API (Update) called")
+	return nil, store.NewError(store.ErrorBadRequest, "Read-only Catalog: API Not Supported", "Update")
+}
+
 func (kc *k8sCatalog) refresh() {
 	kc.Lock()
 	defer kc.Unlock()
 
 	services, instances, err := kc.getServices()
 	if err == nil {
+		kc.PublishChanges(kc.instances, instances)
 		kc.services = services
 		kc.instances = instances
 	}
@@ -51,6 +51,15 @@ func (mer *mockExternalRegistry) ReadServiceInstanceByInstID(namespace auth.Name
 	return &ServiceInstance{}, nil
 }
 
+func (mer *mockExternalRegistry) ReadServiceInstancesByInstIDs(namespace auth.Namespace, instanceIDs []string) ([]*ServiceInstance, error) {
+	instances := make([]*ServiceInstance, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		instances[i], _ = mer.ReadServiceInstanceByInstID(namespace, instanceID)
+	}
+
+	return instances, nil
+}
+
 func (mer *mockExternalRegistry) ListServiceInstancesByName(namespace auth.Namespace, name string) (map[string]*ServiceInstance, error) {
 	siMap := make(map[string]*ServiceInstance)
 
@@ -91,6 +100,13 @@ func (mer *mockExternalRegistry) InsertServiceInstance(namespace auth.Namespace,
 	return nil
 }
 
+func (mer *mockExternalRegistry) InsertServiceInstances(namespace auth.Namespace, instances []*ServiceInstance) error {
+	for _, instance := range instances {
+		mer.InsertServiceInstance(namespace, instance)
+	}
+	return nil
+}
+
 func (mer *mockExternalRegistry) DeleteServiceInstance(namespace auth.Namespace, instanceID string) (int, error) {
 	var count int
 
@@ -40,8 +40,11 @@ func (f *multiFactory) CreateCatalog(namespace auth.Namespace) (Catalog, error)
 
 // MultiCatalog is a collection of catalogs.
 // The catalog at index 0 (rwCalogIndex) is the Read-Write catalog. The other catalogs are Read-Only.
+// The changes to all the catalogs are merged into the change log of the multi catalog, shortly after they are made.
 type multiCatalog struct {
 	catalogs []Catalog
+
+	*ChangeLog
 }
 
 func newMultiCatalog(namespace auth.Namespace, conf *multiConfig) (*multiCatalog, error) {
@@ -55,7 +58,35 @@ func newMultiCatalog(namespace auth.Namespace, conf *multiConfig) (*multiCatalog
 		}
 	}
 
-	return &multiCatalog{catalogs: catalogs}, nil
+	mc := &multiCatalog{
+		catalogs:  catalogs,
+		ChangeLog: NewChangeLog(),
+	}
+	for _, catalog := range catalogs {
+		if watchable, ok := catalog.(Watchable); ok {
+			go mc.forward(watchable, watchable.Subscribe(0))
+		}
+	}
+
+	return mc, nil
+}
+
+// forward publishes the events of a catalog in the change log of the multi catalog.
+// If the subscription is dropped for falling behind, it is resumed, and if some events are no longer available,
+// the change log is invalidated so that its subscribers list the catalog again.
+func (mc *multiCatalog) forward(watchable Watchable, subscription *Subscription) {
+	for {
+		index := subscription.Index
+		for event := range subscription.Events {
+			mc.Publish(event.Type, event.Instance)
+			index = event.Index
+		}
+
+		subscription = watchable.Subscribe(index)
+		if !subscription.Complete {
+			mc.invalidate()
+		}
+	}
 }
 
 func (mc *multiCatalog) Register(si *ServiceInstance) (*ServiceInstance, error) {
@@ -70,10 +101,24 @@ func (mc *multiCatalog) Renew(instanceID string) (*ServiceInstance, error) {
 	return mc.catalogs[rwCatalogIndex].Renew(instanceID)
 }
 
+// RegisterBatch registers the instances in the Read-Write catalog
+func (mc *multiCatalog) RegisterBatch(instances []*ServiceInstance) []*BatchResult {
+	return RegisterBatch(mc.catalogs[rwCatalogIndex], instances)
+}
+
+// RenewBatch renews the instances in the Read-Write catalog
+func (mc *multiCatalog) RenewBatch(instanceIDs []string) []*BatchResult {
+	return RenewBatch(mc.catalogs[rwCatalogIndex], instanceIDs)
+}
+
 func (mc *multiCatalog) SetStatus(instanceID, status string) (*ServiceInstance, error) {
 	return mc.catalogs[rwCatalogIndex].SetStatus(instanceID, status)
 }
 
+func (mc *multiCatalog) Update(instanceID string, update *InstanceUpdate) (*ServiceInstance, error) {
+	return mc.catalogs[rwCatalogIndex].Update(instanceID, update)
+}
+
 func (mc *multiCatalog) List(serviceName string, predicate Predicate) ([]*ServiceInstance, error) {
 	isErr := true
 	instanceCollection := make([]*ServiceInstance, 0, 10)
@@ -99,6 +144,29 @@ func (mc *multiCatalog) List(serviceName string, predicate Predicate) ([]*Servic
 	return instanceCollection, nil
 }
 
+// Select selects the instances of the service from each of the catalogs, using their secondary indexes if they maintain any
+func (mc *multiCatalog) Select(serviceName string, selector *Selector) ([]*ServiceInstance, error) {
+	isErr := true
+	instanceCollection := make([]*ServiceInstance, 0, 10)
+
+	for _, catalog := range mc.catalogs {
+		list, err := Select(catalog, serviceName, selector)
+		// As with List, an error ("no such service") is acceptable unless all the sub-catalogs returned one
+		if err == nil {
+			isErr = false
+			if len(list) > 0 {
+				instanceCollection = append(instanceCollection, list...)
+			}
+		}
+	}
+
+	if isErr {
+		return nil, NewError(ErrorNoSuchServiceName, "no such service", serviceName)
+	}
+
+	return instanceCollection, nil
+}
+
 func (mc *multiCatalog) Instance(instanceID string) (*ServiceInstance, error) {
 	for _, catalog := range mc.catalogs {
 		si, err := catalog.Instance(instanceID)
@@ -17,6 +17,7 @@ package store
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -48,6 +49,10 @@ func (mc *mockCatalog) SetStatus(instanceID, status string) (*ServiceInstance, e
 	return nil, nil
 }
 
+func (mc *mockCatalog) Update(instanceID string, update *InstanceUpdate) (*ServiceInstance, error) {
+	return nil, nil
+}
+
 func (mc *mockCatalog) Instance(instanceID string) (*ServiceInstance, error) {
 	return nil, nil
 }
@@ -78,6 +83,16 @@ func (mc *mockCatalog) ListServices(predicate Predicate) []*Service {
 	return list
 }
 
+// watchableCatalog is a read-only catalog publishing its changes
+type watchableCatalog struct {
+	mockCatalog
+	*ChangeLog
+}
+
+func (wc *watchableCatalog) CreateCatalog(auth.Namespace) (Catalog, error) {
+	return wc, nil
+}
+
 func TestNewMultiCatalog(t *testing.T) {
 
 	inmemF := newInMemoryFactory(nil)
@@ -185,3 +200,43 @@ func TestMultiCatalogRegister(t *testing.T) {
 	assert.EqualValues(t, nServices+1, len(l2))
 
 }
+
+func TestMultiCatalogMergesChanges(t *testing.T) {
+
+	inmemF := newInMemoryFactory(createNewConfig(testMediumTTL))
+	readOnly := &watchableCatalog{ChangeLog: NewChangeLog()}
+	conf := &multiConfig{[]CatalogFactory{inmemF, readOnly}}
+	factory := newMultiFactory(conf)
+	catalog, err := factory.CreateCatalog(auth.NamespaceFrom("ns1"))
+	assert.NoError(t, err)
+
+	watchable := catalog.(Watchable)
+	changed := watchable.Changed(watchable.Index())
+	subscription := watchable.Subscribe(watchable.Index())
+
+	expectEvent := func(eventType EventType, serviceName string) {
+		select {
+		case event := <-subscription.Events:
+			assert.Equal(t, eventType, event.Type)
+			assert.Equal(t, serviceName, event.Instance.ServiceName)
+		case <-time.After(time.Second):
+			t.Fatalf("No %s event of %s", eventType, serviceName)
+		}
+	}
+
+	// Changes of the read-only catalog
+	readOnly.Publish(EventRegister, newServiceInstance("Reviews", "192.168.1.1", 9080))
+	expectEvent(EventRegister, "Reviews")
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Error("Multi catalog not reported as changed after a change of a read-only catalog")
+	}
+
+	// Changes of the read-write catalog
+	_, err = catalog.Register(newServiceInstance("Calc", "192.168.1.2", 9080))
+	assert.NoError(t, err)
+	expectEvent(EventRegister, "Calc")
+
+}
@@ -71,6 +71,40 @@ func (rr *redisRegistry) ReadServiceInstanceByInstID(namespace auth.Namespace, i
 	return &si, nil
 }
 
+// ReadServiceInstancesByInstIDs reads the identified instances in a single round trip.
+// The instances that are not found are nil.
+func (rr *redisRegistry) ReadServiceInstancesByInstIDs(namespace auth.Namespace, instanceIDs []string) ([]*ServiceInstance, error) {
+	keys := make([]string, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		siKey := DBKey{InstanceID: instanceID, Namespace: namespace.String()}
+		keys[i] = siKey.String()
+	}
+
+	entries, err := rr.db.ReadEntries(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]*ServiceInstance, len(instanceIDs))
+	for i, entry := range entries {
+		if len(entry) == 0 {
+			continue
+		}
+
+		var si ServiceInstance
+		err = json.Unmarshal(entry, &si)
+		if err != nil {
+			// Log an error, but continue with empty SI
+			rr.logger.WithFields(log.Fields{
+				"key": keys[i],
+			}).Error("Unable to unmarshal json")
+		}
+		instances[i] = &si
+	}
+
+	return instances, nil
+}
+
 func (rr *redisRegistry) ListServiceInstancesByName(namespace auth.Namespace, name string) (map[string]*ServiceInstance, error) {
 	var matchingKeys = make(map[string]*ServiceInstance)
 
@@ -164,6 +198,23 @@ func (rr *redisRegistry) InsertServiceInstance(namespace auth.Namespace, instanc
 	return err
 }
 
+// InsertServiceInstances writes the instances in a single pipeline
+func (rr *redisRegistry) InsertServiceInstances(namespace auth.Namespace, instances []*ServiceInstance) error {
+	entries := make([]*database.Entry, len(instances))
+	for i, instance := range instances {
+		instanceJSON, _ := json.Marshal(instance)
+		siKey := DBKey{InstanceID: instance.ID, Namespace: namespace.String()}
+		entries[i] = &database.Entry{Key: siKey.String(), Value: instanceJSON, TTL: instance.TTL}
+
+		// If the status is OUT_OF_SERVICE do not expire
+		if instance.Status == OutOfService {
+			entries[i].TTL = 0
+		}
+	}
+
+	return rr.db.InsertEntries(entries)
+}
+
 func (rr *redisRegistry) DeleteServiceInstance(namespace auth.Namespace, instanceID string) (int, error) {
 	dbKey := DBKey{InstanceID: instanceID, Namespace: namespace.String()}
 	return rr.db.DeleteEntry(dbKey.String())
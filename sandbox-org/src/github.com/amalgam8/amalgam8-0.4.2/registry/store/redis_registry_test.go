@@ -35,7 +35,9 @@ func TestRedisRegistryInsertReadDelete(t *testing.T) {
 		ID:          "inst-id",
 		ServiceName: "Calc",
 		Endpoint:    &Endpoint{Value: "192.168.0.1", Type: "tcp"},
+		Endpoints:   map[string]*Endpoint{"admin": {Value: "192.168.0.1:9090", Type: "http"}},
 		Status:      "UP",
+		Labels:      map[string]string{"version": "v2", "zone": "eu-1"},
 	}
 
 	err := redisreg.InsertServiceInstance(ns, si)
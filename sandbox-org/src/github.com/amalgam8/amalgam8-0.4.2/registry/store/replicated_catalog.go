@@ -78,6 +78,13 @@ type replicatedStatus struct {
 	Status     string
 }
 
+type replicatedUpdate struct {
+	InstanceID string
+	Tags       []string
+	Labels     map[string]string
+	Metadata   []byte
+}
+
 // Enumeration implementation for the replication actions types
 const (
 	REGISTER replicationType = iota
@@ -85,6 +92,7 @@ const (
 	RENEW
 	SETSTATUS
 	READREPAIR
+	UPDATE
 )
 
 var replicationActionTypes = [...]string{
@@ -93,6 +101,7 @@ var replicationActionTypes = [...]string{
 	"RENEW",
 	"SETSTATUS",
 	"READREPAIR",
+	"UPDATE",
 }
 
 func (t replicationType) String() string {
@@ -130,7 +139,25 @@ func (rpc *replicatedCatalog) Register(si *ServiceInstance) (*ServiceInstance, e
 		return result, err
 	}
 
-	payload, _ := json.Marshal(result)
+	rpc.replicateRegister(result)
+
+	return result, nil
+}
+
+// RegisterBatch registers the instances in the local catalog, and replicates each registered instance
+func (rpc *replicatedCatalog) RegisterBatch(instances []*ServiceInstance) []*BatchResult {
+	results := RegisterBatch(rpc.local, instances)
+	for _, result := range results {
+		if result.Err == nil {
+			rpc.replicateRegister(result.Instance)
+		}
+	}
+
+	return results
+}
+
+func (rpc *replicatedCatalog) replicateRegister(si *ServiceInstance) {
+	payload, _ := json.Marshal(si)
 	msg, err := json.Marshal(&replicatedMsg{RepType: REGISTER, Payload: payload})
 	if err != nil {
 		rpc.logger.WithFields(log.Fields{
@@ -143,8 +170,6 @@ func (rpc *replicatedCatalog) Register(si *ServiceInstance) (*ServiceInstance, e
 			}).Errorf("Failed to broadcast REGISTER message for replication. instance: %v", si)
 		}
 	}
-
-	return result, nil
 }
 
 func (rpc *replicatedCatalog) Deregister(instanceID string) (*ServiceInstance, error) {
@@ -175,6 +200,24 @@ func (rpc *replicatedCatalog) Renew(instanceID string) (*ServiceInstance, error)
 		return nil, err
 	}
 
+	rpc.replicateRenew(instanceID)
+
+	return instance, nil
+}
+
+// RenewBatch renews the instances in the local catalog, and replicates each renewal
+func (rpc *replicatedCatalog) RenewBatch(instanceIDs []string) []*BatchResult {
+	results := RenewBatch(rpc.local, instanceIDs)
+	for i, result := range results {
+		if result.Err == nil {
+			rpc.replicateRenew(instanceIDs[i])
+		}
+	}
+
+	return results
+}
+
+func (rpc *replicatedCatalog) replicateRenew(instanceID string) {
 	msg, err := json.Marshal(&replicatedMsg{RepType: RENEW, Payload: []byte(instanceID)})
 	if err != nil {
 		rpc.logger.WithFields(log.Fields{
@@ -187,8 +230,6 @@ func (rpc *replicatedCatalog) Renew(instanceID string) (*ServiceInstance, error)
 			}).Errorf("Failed to broadcast RENEW message for replication. instanceID: %s", instanceID)
 		}
 	}
-
-	return instance, nil
 }
 
 func (rpc *replicatedCatalog) SetStatus(instanceID, status string) (*ServiceInstance, error) {
@@ -214,6 +255,29 @@ func (rpc *replicatedCatalog) SetStatus(instanceID, status string) (*ServiceInst
 	return instance, nil
 }
 
+func (rpc *replicatedCatalog) Update(instanceID string, update *InstanceUpdate) (*ServiceInstance, error) {
+	instance, err := rpc.local.Update(instanceID, update)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(&replicatedUpdate{instanceID, update.Tags, update.Labels, update.Metadata})
+	msg, err := json.Marshal(&replicatedMsg{RepType: UPDATE, Payload: payload})
+	if err != nil {
+		rpc.logger.WithFields(log.Fields{
+			"error": err,
+		}).Errorf("Failed to marshal UPDATE message for replication. instanceID: %s", instanceID)
+	} else {
+		if err := rpc.replicator.Broadcast(msg); err != nil {
+			rpc.logger.WithFields(log.Fields{
+				"error": err,
+			}).Errorf("Failed to broadcast UPDATE message for replication. instanceID: %s", instanceID)
+		}
+	}
+
+	return instance, nil
+}
+
 func (rpc *replicatedCatalog) Instance(instanceID string) (*ServiceInstance, error) {
 	return rpc.local.Instance(instanceID)
 }
@@ -226,6 +290,24 @@ func (rpc *replicatedCatalog) ListServices(predicate Predicate) []*Service {
 	return rpc.local.ListServices(predicate)
 }
 
+// Select selects the instances of the service from the local catalog, using its secondary indexes if it maintains any
+func (rpc *replicatedCatalog) Select(serviceName string, selector *Selector) ([]*ServiceInstance, error) {
+	return Select(rpc.local, serviceName, selector)
+}
+
+// Index returns the modification index of the local catalog, which also tracks the replicated changes
+func (rpc *replicatedCatalog) Index() uint64 {
+	return watchableOf(rpc.local).Index()
+}
+
+func (rpc *replicatedCatalog) Changed(index uint64) <-chan struct{} {
+	return watchableOf(rpc.local).Changed(index)
+}
+
+func (rpc *replicatedCatalog) Subscribe(index uint64) *Subscription {
+	return watchableOf(rpc.local).Subscribe(index)
+}
+
 func (rpc *replicatedCatalog) handleIncomingMsgs() {
 	var data replicatedMsg
 
@@ -293,6 +375,22 @@ func (rpc *replicatedCatalog) handleIncomingMsgs() {
 				}).Errorf("Failed to replicate instance status. instanceID: %s, status: %s", repStatus.InstanceID, repStatus.Status)
 			}
 			break
+		case UPDATE:
+			var repUpdate replicatedUpdate
+			if err = json.Unmarshal(data.Payload, &repUpdate); err != nil {
+				rpc.logger.WithFields(log.Fields{
+					"error": err,
+				}).Errorf("Failed to unmarshal replicated instance update. data: %s", string(data.Payload))
+				break
+			}
+			update := &InstanceUpdate{Tags: repUpdate.Tags, Labels: repUpdate.Labels, Metadata: repUpdate.Metadata}
+			_, err = rpc.local.Update(repUpdate.InstanceID, update)
+			if err != nil {
+				rpc.logger.WithFields(log.Fields{
+					"error": err,
+				}).Errorf("Failed to replicate instance update. instanceID: %s", repUpdate.InstanceID)
+			}
+			break
 		case READREPAIR:
 			instanceID := string(data.Payload)
 			result, err := rpc.local.Instance(instanceID)
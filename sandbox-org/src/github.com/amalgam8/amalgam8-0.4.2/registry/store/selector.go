@@ -0,0 +1,76 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package store
+
+import (
+	"github.com/amalgam8/amalgam8/pkg/labels"
+)
+
+// Selector selects the instances of a service that are tagged with all of the given tags, have the given status,
+// have labels matching the given label selector, and satisfy the given predicate.
+// Empty tags, an empty status, an empty label selector or a nil predicate select any instance.
+//
+// Catalogs that maintain secondary indexes use the tags and status to narrow down the instances to evaluate,
+// so these should be preferred over predicates where possible.
+type Selector struct {
+	Tags      []string
+	Status    string
+	Labels    labels.Selector
+	Predicate Predicate
+}
+
+// Matches returns whether the instance is selected by the selector
+func (s *Selector) Matches(si *ServiceInstance) bool {
+	if s.Status != "" && si.Status != s.Status {
+		return false
+	}
+
+	for _, tag := range s.Tags {
+		if !hasTag(si, tag) {
+			return false
+		}
+	}
+
+	if !s.Labels.Matches(si.Labels) {
+		return false
+	}
+
+	return s.Predicate == nil || s.Predicate(si)
+}
+
+// IndexedCatalog is implemented by catalogs that maintain secondary indexes of the instances of each service,
+// by which they select instances without evaluating all the instances of the service.
+type IndexedCatalog interface {
+	// Select returns the instances of the service that are selected by the given selector
+	Select(serviceName string, selector *Selector) ([]*ServiceInstance, error)
+}
+
+// Select returns the instances of the service in the catalog that are selected by the given selector,
+// using the secondary indexes of the catalog if it maintains any.
+func Select(catalog Catalog, serviceName string, selector *Selector) ([]*ServiceInstance, error) {
+	if indexed, ok := catalog.(IndexedCatalog); ok {
+		return indexed.Select(serviceName, selector)
+	}
+	return catalog.List(serviceName, selector.Matches)
+}
+
+func hasTag(si *ServiceInstance, tag string) bool {
+	for _, t := range si.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
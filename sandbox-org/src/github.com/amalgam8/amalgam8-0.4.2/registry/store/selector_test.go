@@ -0,0 +1,166 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package store
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/amalgam8/amalgam8/pkg/labels"
+)
+
+// unindexedCatalog hides the secondary indexes of the wrapped catalog
+type unindexedCatalog struct {
+	Catalog
+}
+
+func populateSelectorCatalog(t *testing.T) *inMemoryCatalog {
+	catalog := newInMemoryCatalog(nil)
+
+	instances := []struct {
+		host   string
+		tags   []string
+		labels map[string]string
+		status string
+	}{
+		{"192.168.0.1", []string{"v1"}, map[string]string{"version": "v1", "zone": "eu-1"}, Up},
+		{"192.168.0.2", []string{"v1", "canary"}, map[string]string{"version": "v1", "zone": "us-1"}, Up},
+		{"192.168.0.3", []string{"v2", "canary"}, map[string]string{"version": "v2"}, Starting},
+		{"192.168.0.4", nil, nil, OutOfService},
+	}
+
+	for _, inst := range instances {
+		instance := newServiceInstance("Calc", inst.host, 9080)
+		instance.Tags = inst.tags
+		instance.Labels = inst.labels
+		instance.Status = inst.status
+		_, err := doRegister(catalog, instance)
+		assert.NoError(t, err)
+	}
+
+	return catalog
+}
+
+func selectHosts(t *testing.T, catalog Catalog, serviceName string, selector *Selector) []string {
+	instances, err := Select(catalog, serviceName, selector)
+	assert.NoError(t, err)
+
+	hosts := make([]string, len(instances))
+	for i, instance := range instances {
+		hosts[i] = instance.Endpoint.Value
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func TestSelectInstances(t *testing.T) {
+
+	catalog := populateSelectorCatalog(t)
+
+	cases := []struct {
+		selector *Selector
+		expected []string
+	}{
+		{&Selector{}, []string{"192.168.0.1:9080", "192.168.0.2:9080", "192.168.0.3:9080", "192.168.0.4:9080"}},
+		{&Selector{Tags: []string{"canary"}}, []string{"192.168.0.2:9080", "192.168.0.3:9080"}},
+		{&Selector{Tags: []string{"v1", "canary"}}, []string{"192.168.0.2:9080"}},
+		{&Selector{Tags: []string{"v3"}}, []string{}},
+		{&Selector{Status: Up}, []string{"192.168.0.1:9080", "192.168.0.2:9080"}},
+		{&Selector{Status: Up, Tags: []string{"canary"}}, []string{"192.168.0.2:9080"}},
+		{&Selector{Status: Draining}, []string{}},
+		{&Selector{Predicate: func(si *ServiceInstance) bool { return len(si.Tags) == 0 }}, []string{"192.168.0.4:9080"}},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, selectHosts(t, catalog, "Calc", c.selector), "selector %+v", c.selector)
+		assert.Equal(t, c.expected, selectHosts(t, unindexedCatalog{catalog}, "Calc", c.selector), "selector %+v", c.selector)
+	}
+
+}
+
+func TestSelectInstancesByLabels(t *testing.T) {
+
+	catalog := populateSelectorCatalog(t)
+
+	cases := []struct {
+		selector string
+		expected []string
+	}{
+		{"version=v1", []string{"192.168.0.1:9080", "192.168.0.2:9080"}},
+		{"version!=v1", []string{"192.168.0.3:9080", "192.168.0.4:9080"}},
+		{"zone in (eu-1,eu-2)", []string{"192.168.0.1:9080"}},
+		{"zone notin (eu-1)", []string{"192.168.0.2:9080", "192.168.0.3:9080", "192.168.0.4:9080"}},
+		{"zone", []string{"192.168.0.1:9080", "192.168.0.2:9080"}},
+		{"!version", []string{"192.168.0.4:9080"}},
+		{"version=v1,zone=us-1", []string{"192.168.0.2:9080"}},
+	}
+
+	for _, c := range cases {
+		selector, err := labels.Parse(c.selector)
+		assert.NoError(t, err)
+
+		assert.Equal(t, c.expected, selectHosts(t, catalog, "Calc", &Selector{Labels: selector}), c.selector)
+		assert.Equal(t, c.expected, selectHosts(t, unindexedCatalog{catalog}, "Calc", &Selector{Labels: selector}), c.selector)
+	}
+
+}
+
+func TestSelectUnknownService(t *testing.T) {
+
+	catalog := populateSelectorCatalog(t)
+
+	instances, err := Select(catalog, "Bogus", &Selector{Tags: []string{"v1"}})
+	assert.Error(t, err)
+	assert.EqualValues(t, ErrorNoSuchServiceName, extractErrorCode(err))
+	assert.Nil(t, instances)
+
+}
+
+func TestSelectAfterInstanceChanges(t *testing.T) {
+
+	catalog := newInMemoryCatalog(nil)
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	instance.Tags = []string{"v1"}
+	id, err := doRegister(catalog, instance)
+	assert.NoError(t, err)
+
+	_, err = catalog.SetStatus(id, OutOfService)
+	assert.NoError(t, err)
+	assert.Empty(t, selectHosts(t, catalog, "Calc", &Selector{Status: Up}))
+	assert.Len(t, selectHosts(t, catalog, "Calc", &Selector{Status: OutOfService}), 1)
+
+	_, err = catalog.Update(id, &InstanceUpdate{Tags: []string{"v2"}})
+	assert.NoError(t, err)
+	assert.Empty(t, selectHosts(t, catalog, "Calc", &Selector{Tags: []string{"v1"}}))
+	assert.Len(t, selectHosts(t, catalog, "Calc", &Selector{Tags: []string{"v2"}, Status: OutOfService}), 1)
+
+	// Re-registration replaces the indexed instance
+	instance.Tags = []string{"v3"}
+	instance.Status = Up
+	_, err = doRegister(catalog, instance)
+	assert.NoError(t, err)
+	assert.Empty(t, selectHosts(t, catalog, "Calc", &Selector{Tags: []string{"v2"}}))
+	assert.Empty(t, selectHosts(t, catalog, "Calc", &Selector{Status: OutOfService}))
+	assert.Len(t, selectHosts(t, catalog, "Calc", &Selector{Tags: []string{"v3"}, Status: Up}), 1)
+
+	_, err = catalog.Deregister(id)
+	assert.NoError(t, err)
+	_, err = Select(catalog, "Calc", &Selector{Tags: []string{"v3"}})
+	assert.EqualValues(t, ErrorNoSuchServiceName, extractErrorCode(err))
+
+}
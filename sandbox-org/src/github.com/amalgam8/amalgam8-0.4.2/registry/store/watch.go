@@ -0,0 +1,282 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package store
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// EventType is the type of a catalog change
+type EventType string
+
+// Catalog change types
+const (
+	EventRegister   EventType = "register"
+	EventDeregister EventType = "deregister"
+	EventExpire     EventType = "expire"
+	EventStatus     EventType = "status"
+	EventUpdate     EventType = "update"
+)
+
+// Event describes a change to a catalog, identified by the modification index of the catalog following the change
+type Event struct {
+	Index    uint64
+	Type     EventType
+	Instance *ServiceInstance
+}
+
+// Watchable is implemented by catalogs that track their modifications.
+// The modification index of a catalog is incremented on every registration, deregistration, expiration, status change
+// and update of an instance, but not on heartbeats. An index of 0 means that the catalog does not track modifications.
+type Watchable interface {
+	// Index returns the current modification index of the catalog.
+	Index() uint64
+
+	// Changed returns a channel that is closed once the modification index of the catalog differs from the given index.
+	// The index may decrease, e.g., when the catalog is recreated after a restart.
+	Changed(index uint64) <-chan struct{}
+
+	// Subscribe returns a subscription to the events following the given index,
+	// or to the events following the current index if the given index is 0.
+	Subscribe(index uint64) *Subscription
+}
+
+// Subscription is a stream of catalog events
+type Subscription struct {
+	// Events is the channel of events. It is closed when the subscription is cancelled,
+	// or when the subscriber does not keep up with the changes to the catalog.
+	Events <-chan *Event
+
+	// Index is the modification index of the catalog when the subscription was made.
+	Index uint64
+
+	// Complete is set when the subscription includes all the events following the requested index.
+	// Otherwise, some events are no longer available and the subscriber should list the catalog again.
+	Complete bool
+
+	cancel func()
+}
+
+// Cancel ends the subscription
+func (s *Subscription) Cancel() {
+	s.cancel()
+}
+
+const (
+	// historySize is the number of recent events kept for resuming subscriptions
+	historySize = 1024
+
+	// subscriberBuffer is the number of events buffered for a subscriber before it is dropped
+	subscriberBuffer = 256
+)
+
+// ChangeLog tracks the modification index and recent events of a catalog.
+// Catalogs embed it to implement the Watchable interface.
+type ChangeLog struct {
+	index       uint64
+	changed     chan struct{}
+	history     []*Event
+	subscribers map[chan *Event]struct{}
+
+	mutex sync.Mutex
+}
+
+// NewChangeLog creates a change log for a catalog without any changes
+func NewChangeLog() *ChangeLog {
+	return &ChangeLog{
+		index:       1,
+		changed:     make(chan struct{}),
+		history:     make([]*Event, 0, historySize),
+		subscribers: make(map[chan *Event]struct{}),
+	}
+}
+
+// Publish increments the modification index and notifies the waiters and subscribers of the change.
+func (cl *ChangeLog) Publish(eventType EventType, instance *ServiceInstance) {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	cl.index++
+	event := &Event{Index: cl.index, Type: eventType, Instance: instance.DeepClone()}
+
+	if len(cl.history) == historySize {
+		copy(cl.history, cl.history[1:])
+		cl.history = cl.history[:historySize-1]
+	}
+	cl.history = append(cl.history, event)
+
+	close(cl.changed)
+	cl.changed = make(chan struct{})
+
+	for events := range cl.subscribers {
+		select {
+		case events <- event:
+		default:
+			// Drop subscribers that fall behind, rather than blocking changes to the catalog
+			delete(cl.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// PublishChanges publishes the registrations, changes and deregistrations of instances turning the previous instances
+// into the current instances, both keyed by instance ID. It is used by catalogs that periodically read their instances.
+func (cl *ChangeLog) PublishChanges(previous, current map[string]*ServiceInstance) {
+	for id, instance := range previous {
+		if _, exists := current[id]; !exists {
+			cl.Publish(EventDeregister, instance)
+		}
+	}
+	for id, instance := range current {
+		if eventType, changed := changeType(previous[id], instance); changed {
+			cl.Publish(eventType, instance)
+		}
+	}
+}
+
+// invalidate increments the modification index without an event, when the changes that followed the previous index are
+// unknown. The subscribers are dropped and the history is discarded, so that subscribers list the catalog again.
+func (cl *ChangeLog) invalidate() {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	cl.index++
+	cl.history = cl.history[:0]
+
+	close(cl.changed)
+	cl.changed = make(chan struct{})
+
+	for events := range cl.subscribers {
+		delete(cl.subscribers, events)
+		close(events)
+	}
+}
+
+func (cl *ChangeLog) Index() uint64 {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	return cl.index
+}
+
+func (cl *ChangeLog) Changed(index uint64) <-chan struct{} {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	if index != cl.index {
+		changed := make(chan struct{})
+		close(changed)
+		return changed
+	}
+	return cl.changed
+}
+
+func (cl *ChangeLog) Subscribe(index uint64) *Subscription {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	events := make(chan *Event, subscriberBuffer+len(cl.history))
+	complete := index == cl.index
+	if index > 0 && index < cl.index && len(cl.history) > 0 && cl.history[0].Index <= index+1 {
+		for _, event := range cl.history {
+			if event.Index > index {
+				events <- event
+			}
+		}
+		complete = true
+	}
+	cl.subscribers[events] = struct{}{}
+
+	return &Subscription{
+		Events:   events,
+		Index:    cl.index,
+		Complete: complete,
+		cancel: func() {
+			cl.mutex.Lock()
+			defer cl.mutex.Unlock()
+
+			if _, exists := cl.subscribers[events]; exists {
+				delete(cl.subscribers, events)
+				close(events)
+			}
+		},
+	}
+}
+
+// changeType returns the type of the event changing the previous state of an instance, which is nil if the instance
+// was not registered, into its current state, or false if the instance has not changed. Renewals are not changes.
+func changeType(previous, current *ServiceInstance) (EventType, bool) {
+	if previous == nil {
+		return EventRegister, true
+	}
+
+	prev, cur := canonical(previous), canonical(current)
+	if prev == cur {
+		return "", false
+	}
+
+	// Only the status differs
+	if canonical(withStatus(previous, current.Status)) == cur {
+		return EventStatus, true
+	}
+
+	return EventUpdate, true
+}
+
+// canonical returns a form of the instance that is equal for equal instances, regardless of their registration and
+// renewal times, and whether they were read from a database or not.
+func canonical(instance *ServiceInstance) string {
+	cloned := instance.DeepClone()
+	cloned.RegistrationTime = time.Time{}
+	cloned.LastRenewal = time.Time{}
+	if len(cloned.Tags) == 0 {
+		cloned.Tags = nil
+	}
+
+	data, _ := json.Marshal(cloned)
+	return string(data)
+}
+
+// withStatus returns a copy of the instance with the given status
+func withStatus(instance *ServiceInstance, status string) *ServiceInstance {
+	cloned := instance.DeepClone()
+	cloned.Status = status
+	return cloned
+}
+
+// watchableOf returns the Watchable interface of the catalog, or a catalog that never changes
+// if the catalog does not track its modifications.
+func watchableOf(catalog Catalog) Watchable {
+	if watchable, ok := catalog.(Watchable); ok {
+		return watchable
+	}
+	return unwatchable{}
+}
+
+type unwatchable struct{}
+
+func (unwatchable) Index() uint64 {
+	return 0
+}
+
+func (unwatchable) Changed(index uint64) <-chan struct{} {
+	return nil
+}
+
+func (unwatchable) Subscribe(index uint64) *Subscription {
+	return &Subscription{Events: make(chan *Event), cancel: func() {}}
+}
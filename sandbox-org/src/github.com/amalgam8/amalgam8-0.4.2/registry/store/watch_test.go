@@ -0,0 +1,244 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogIndexChanges(t *testing.T) {
+
+	catalog := newInMemoryCatalog(createNewConfig(testMediumTTL))
+	index := catalog.Index()
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	id, err := doRegister(catalog, instance)
+	assert.NoError(t, err)
+	assert.Equal(t, index+1, catalog.Index())
+
+	// Heartbeats do not change the catalog
+	_, err = catalog.Renew(id)
+	assert.NoError(t, err)
+	assert.Equal(t, index+1, catalog.Index())
+
+	_, err = catalog.SetStatus(id, OutOfService)
+	assert.NoError(t, err)
+	assert.Equal(t, index+2, catalog.Index())
+
+	_, err = catalog.SetStatus(id, OutOfService)
+	assert.NoError(t, err)
+	assert.Equal(t, index+2, catalog.Index())
+
+	_, err = catalog.Update(id, &InstanceUpdate{Tags: []string{"v2"}})
+	assert.NoError(t, err)
+	assert.Equal(t, index+3, catalog.Index())
+
+	_, err = catalog.Deregister(id)
+	assert.NoError(t, err)
+	assert.Equal(t, index+4, catalog.Index())
+
+}
+
+func TestCatalogChanged(t *testing.T) {
+
+	catalog := newInMemoryCatalog(createNewConfig(testMediumTTL))
+	index := catalog.Index()
+
+	changed := catalog.Changed(index)
+	select {
+	case <-changed:
+		t.Fatal("Catalog reported as changed before any change")
+	default:
+	}
+
+	// A different index, e.g. of a catalog before a restart, is reported as changed immediately
+	select {
+	case <-catalog.Changed(index + 10):
+	default:
+		t.Error("Catalog with a different index not reported as changed")
+	}
+
+	_, err := doRegister(catalog, newServiceInstance("Calc", "192.168.0.1", 9080))
+	assert.NoError(t, err)
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Error("Catalog not reported as changed after registration")
+	}
+
+}
+
+func TestCatalogExpirationEvent(t *testing.T) {
+
+	catalog := newInMemoryCatalog(createNewConfig(testShortTTL))
+	subscription := catalog.Subscribe(0)
+	defer subscription.Cancel()
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	id, err := doRegister(catalog, instance)
+	assert.NoError(t, err)
+
+	var events []*Event
+	timeout := time.After(testShortTTL * 10)
+	for len(events) < 2 {
+		select {
+		case event := <-subscription.Events:
+			events = append(events, event)
+		case <-timeout:
+			t.Fatalf("Expected 2 events, got %d", len(events))
+		}
+	}
+
+	assert.Equal(t, EventRegister, events[0].Type)
+	assert.Equal(t, EventExpire, events[1].Type)
+	assert.Equal(t, id, events[1].Instance.ID)
+	assert.Equal(t, events[0].Index+1, events[1].Index)
+	assert.Equal(t, catalog.Index(), events[1].Index)
+
+}
+
+func TestSubscriptionResume(t *testing.T) {
+
+	catalog := newInMemoryCatalog(createNewConfig(testMediumTTL))
+	index := catalog.Index()
+
+	for i := 0; i < 3; i++ {
+		_, err := doRegister(catalog, newServiceInstance("Calc", "192.168.0.1", uint32(9080+i)))
+		assert.NoError(t, err)
+	}
+
+	subscription := catalog.Subscribe(index + 1)
+	defer subscription.Cancel()
+
+	assert.True(t, subscription.Complete)
+	assert.Equal(t, index+3, subscription.Index)
+	assert.Len(t, subscription.Events, 2)
+	assert.Equal(t, index+2, (<-subscription.Events).Index)
+	assert.Equal(t, index+3, (<-subscription.Events).Index)
+
+	// An index ahead of the catalog cannot be resumed from
+	subscription = catalog.Subscribe(index + 10)
+	defer subscription.Cancel()
+
+	assert.False(t, subscription.Complete)
+	assert.Len(t, subscription.Events, 0)
+
+}
+
+func TestSubscriptionHistoryTruncated(t *testing.T) {
+
+	cl := NewChangeLog()
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	for i := 0; i < historySize+10; i++ {
+		cl.Publish(EventStatus, instance)
+	}
+
+	subscription := cl.Subscribe(2)
+	defer subscription.Cancel()
+
+	assert.False(t, subscription.Complete)
+	assert.Len(t, subscription.Events, 0)
+
+	subscription = cl.Subscribe(cl.Index() - historySize)
+	defer subscription.Cancel()
+
+	assert.True(t, subscription.Complete)
+	assert.Len(t, subscription.Events, historySize)
+
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+
+	cl := NewChangeLog()
+	subscription := cl.Subscribe(0)
+
+	instance := newServiceInstance("Calc", "192.168.0.1", 9080)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		cl.Publish(EventStatus, instance)
+	}
+
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	// Cancelling a dropped subscription is harmless
+	subscription.Cancel()
+
+}
+
+func TestPublishChanges(t *testing.T) {
+
+	cl := NewChangeLog()
+	unchanged := newServiceInstance("Calc", "192.168.0.1", 9080)
+	unchanged.ID = "unchanged"
+	renewed := newServiceInstance("Calc", "192.168.0.2", 9080)
+	renewed.ID = "renewed"
+	status := newServiceInstance("Calc", "192.168.0.3", 9080)
+	status.ID = "status"
+	updated := newServiceInstance("Calc", "192.168.0.4", 9080)
+	updated.ID = "updated"
+	removed := newServiceInstance("Calc", "192.168.0.5", 9080)
+	removed.ID = "removed"
+	added := newServiceInstance("Calc", "192.168.0.6", 9080)
+	added.ID = "added"
+
+	previous := map[string]*ServiceInstance{
+		unchanged.ID: unchanged,
+		renewed.ID:   renewed,
+		status.ID:    status,
+		updated.ID:   updated,
+		removed.ID:   removed,
+	}
+
+	current := map[string]*ServiceInstance{
+		unchanged.ID: unchanged.DeepClone(),
+		renewed.ID:   renewed.DeepClone(),
+		status.ID:    status.DeepClone(),
+		updated.ID:   updated.DeepClone(),
+		added.ID:     added,
+	}
+	current[renewed.ID].LastRenewal = renewed.LastRenewal.Add(time.Minute)
+	current[status.ID].Status = OutOfService
+	current[updated.ID].Tags = []string{"v2"}
+
+	index := cl.Index()
+	subscription := cl.Subscribe(index)
+	cl.PublishChanges(previous, current)
+
+	events := make(map[string]EventType)
+	for len(events) < 4 {
+		select {
+		case event := <-subscription.Events:
+			events[event.Instance.ID] = event.Type
+		case <-time.After(time.Second):
+			t.Fatalf("Missing events, received %v", events)
+		}
+	}
+
+	assert.Equal(t, map[string]EventType{
+		status.ID:  EventStatus,
+		updated.ID: EventUpdate,
+		removed.ID: EventDeregister,
+		added.ID:   EventRegister,
+	}, events)
+	assert.Equal(t, index+4, cl.Index())
+
+}
@@ -26,8 +26,17 @@ const (
 type Database interface {
 	ReadKeys(match string) ([]string, error)
 	ReadEntry(key string) ([]byte, error)
+	ReadEntries(keys []string) ([][]byte, error)
 	ReadAllEntries(match string) (map[string]string, error)
 	InsertEntry(key string, entry []byte) error
+	InsertEntries(entries []*Entry) error
 	DeleteEntry(key string) (int, error)
 	Expire(key string, ttl time.Duration) error
 }
+
+// Entry is an entry written to the database, which expires after its TTL unless the TTL is 0
+type Entry struct {
+	Key   string
+	Value []byte
+	TTL   time.Duration
+}
@@ -49,6 +49,17 @@ func (mdb *mockDB) ReadEntry(key string) ([]byte, error) {
 	return []byte(mdb.record[key]), nil
 }
 
+func (mdb *mockDB) ReadEntries(keys []string) ([][]byte, error) {
+	entries := make([][]byte, len(keys))
+	for i, key := range keys {
+		if entry, ok := mdb.record[key]; ok {
+			entries[i] = []byte(entry)
+		}
+	}
+
+	return entries, nil
+}
+
 func (mdb *mockDB) ReadAllEntries(match string) (map[string]string, error) {
 	entryList := make(map[string]string)
 	for key, value := range mdb.record {
@@ -66,6 +77,13 @@ func (mdb *mockDB) InsertEntry(key string, entry []byte) error {
 	return nil
 }
 
+func (mdb *mockDB) InsertEntries(entries []*Entry) error {
+	for _, entry := range entries {
+		mdb.record[entry.Key] = string(entry.Value[:])
+	}
+	return nil
+}
+
 func (mdb *mockDB) DeleteEntry(key string) (int, error) {
 	if _, ok := mdb.record[key]; ok {
 		delete(mdb.record, key)
@@ -119,6 +119,30 @@ func (rdb *redisDB) ReadEntry(key string) ([]byte, error) {
 	return redis.Bytes(value, err)
 }
 
+// ReadEntries reads the entries of the keys in a single round trip. The entries of missing keys are nil.
+func (rdb *redisDB) ReadEntries(keys []string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var err error
+	conn := rdb.conn
+	if rdb.conn == nil {
+		conn, err = rdb.connect()
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+	}
+
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+
+	return redis.ByteSlices(conn.Do("MGET", args...))
+}
+
 func (rdb *redisDB) ReadAllEntries(match string) (map[string]string, error) {
 	var err error
 	conn := rdb.conn
@@ -165,6 +189,51 @@ func (rdb *redisDB) InsertEntry(key string, entry []byte) error {
 	return err
 }
 
+// InsertEntries writes the entries and sets their expiration in a single pipeline,
+// returning the first error replied by the database
+func (rdb *redisDB) InsertEntries(entries []*Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var err error
+	conn := rdb.conn
+	if rdb.conn == nil {
+		conn, err = rdb.connect()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+	}
+
+	pending := 0
+	for _, entry := range entries {
+		if err = conn.Send("SET", entry.Key, entry.Value); err != nil {
+			return err
+		}
+		pending++
+		if entry.TTL > 0 {
+			if err = conn.Send("EXPIRE", entry.Key, entry.TTL.Seconds()); err != nil {
+				return err
+			}
+			pending++
+		}
+	}
+
+	if err = conn.Flush(); err != nil {
+		return err
+	}
+
+	var replyErr error
+	for ; pending > 0; pending-- {
+		if _, err = conn.Receive(); err != nil && replyErr == nil {
+			replyErr = err
+		}
+	}
+
+	return replyErr
+}
+
 func (rdb *redisDB) DeleteEntry(key string) (int, error) {
 	var err error
 	conn := rdb.conn
@@ -46,11 +46,13 @@ func (c *MockedConn) Flush() error {
 }
 
 func (c *MockedConn) Receive() (reply interface{}, err error) {
-	return nil, nil
+	margs := c.Called()
+	return margs.Get(0), margs.Error(1)
 }
 
 func (c *MockedConn) Send(commandName string, args ...interface{}) error {
-	return nil
+	margs := c.Called(commandName, args)
+	return margs.Error(0)
 }
 
 type Endpoint struct {
@@ -127,6 +129,19 @@ func TestRedisDBReadEntryErrorReturned(t *testing.T) {
 	assert.Equal(t, getError, err)
 }
 
+func TestRedisDBReadEntries(t *testing.T) {
+	mockedConn := new(MockedConn)
+	db := NewRedisDBWithConn(mockedConn, "addr", "pass")
+
+	values := []interface{}{[]byte("value1"), nil}
+	mockedConn.On("Do", "MGET", []interface{}{"key1", "key2"}).Return(values, nil)
+
+	entries, err := db.ReadEntries([]string{"key1", "key2"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("value1"), nil}, entries)
+}
+
 func TestRedisDBReadAllEntries(t *testing.T) {
 	// Setup the map that we expect to be returned from ReadlAllEntries
 	expectedMap := make(map[string]string)
@@ -209,3 +224,41 @@ func TestRedisDBExpireEntryError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, expireError, err)
 }
+
+func TestRedisDBInsertEntries(t *testing.T) {
+	mockedConn := new(MockedConn)
+	db := NewRedisDBWithConn(mockedConn, "addr", "pass")
+
+	ttl := time.Second * 120
+	mockedConn.On("Send", "SET", []interface{}{"key1", []byte("entry1")}).Return(nil)
+	mockedConn.On("Send", "EXPIRE", []interface{}{"key1", ttl.Seconds()}).Return(nil)
+	mockedConn.On("Send", "SET", []interface{}{"key2", []byte("entry2")}).Return(nil)
+	mockedConn.On("Receive").Return("OK", nil).Times(3)
+
+	err := db.InsertEntries([]*Entry{
+		{Key: "key1", Value: []byte("entry1"), TTL: ttl},
+		{Key: "key2", Value: []byte("entry2")},
+	})
+
+	assert.NoError(t, err)
+	mockedConn.AssertExpectations(t)
+}
+
+func TestRedisDBInsertEntriesError(t *testing.T) {
+	mockedConn := new(MockedConn)
+	db := NewRedisDBWithConn(mockedConn, "addr", "pass")
+
+	setError := fmt.Errorf("Set error")
+	mockedConn.On("Send", "SET", []interface{}{"key1", []byte("entry1")}).Return(nil)
+	mockedConn.On("Send", "SET", []interface{}{"key2", []byte("entry2")}).Return(nil)
+	mockedConn.On("Receive").Return(nil, setError).Once()
+	mockedConn.On("Receive").Return("OK", nil).Once()
+
+	err := db.InsertEntries([]*Entry{
+		{Key: "key1", Value: []byte("entry1")},
+		{Key: "key2", Value: []byte("entry2")},
+	})
+
+	assert.Equal(t, setError, err)
+	mockedConn.AssertExpectations(t)
+}
@@ -83,6 +83,13 @@ func Error(r *rest.Request, w rest.ResponseWriter, code int, id string, args ...
 	rest.Error(w, translated, code)
 }
 
+// Message returns the translation corresponding to 'id', parameterized by 'args' (if present),
+// for messages that are embedded in a response rather than being the error response itself
+func Message(r *rest.Request, id string, args ...interface{}) string {
+	T := TranslateFunc(r)
+	return T(id, args...)
+}
+
 // SupressTestingErrorMessages loads a minimal en-US locale for testing purposes only. Should be called in init()
 func SupressTestingErrorMessages() {
 	_ = i18n.ParseTranslationFileBytes("en-US.json", []byte(`[{"id":"test", "translation":"message"}]`))
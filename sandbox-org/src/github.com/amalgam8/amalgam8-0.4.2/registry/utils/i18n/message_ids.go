@@ -24,6 +24,7 @@ const (
 	ErrorAuthorizationMalformedHeader       = "error_auth_header_malformed"
 	ErrorAuthorizationTokenValidationFailed = "error_auth_failed_validation"
 	ErrorAuthorizationNotAuthorized         = "error_auth_not_authorized"
+	ErrorAuthorizationInsufficientScope     = "error_auth_insufficient_scope"
 	ErrorEncoding                           = "error_encoding_generic"
 	ErrorFilterBadFields                    = "error_filter_bad_fields"
 	ErrorFilterSelectionCriteria            = "error_filter_selection_criteria"
@@ -31,14 +32,18 @@ const (
 	ErrorInstanceEndpointMissing            = "error_instance_endpoint_missing"
 	ErrorInstanceEndpontMalformed           = "error_instance_endpoint_missing_type_or_value"
 	ErrorInstanceEndpointInvalidType        = "error_instance_endpoint_type_invalid"
+	ErrorInstanceEndpointNameInvalid        = "error_instance_endpoint_name_invalid"
 	ErrorInstanceIdentifierMissing          = "error_instance_identifier_missing"
 	ErrorInstanceEnumeration                = "error_instance_enumeration"
 	ErrorInstanceMetadataInvalid            = "error_instance_metadata_invalid"
+	ErrorInstanceLabelsInvalid              = "error_instance_labels_invalid"
 	ErrorInstanceStatusInvalid              = "error_instance_status_invalid"
 	ErrorInstanceNotFound                   = "error_instance_not_found"
 	ErrorInstanceDeletionFailed             = "error_instance_deletion_failure"
 	ErrorInstanceHeartbeatFailed            = "error_instance_heartbeat_failure"
 	ErrorInstanceRegistrationFailed         = "error_instance_registration_failure"
+	ErrorInstanceStatusUpdateFailed         = "error_instance_status_update_failure"
+	ErrorInstanceUpdateFailed               = "error_instance_update_failure"
 	ErrorInternalServer                     = "error_internal"
 	ErrorInternalWithString                 = "error_internal_generic_with_string"
 	ErrorNilObject                          = "error_nil_object"
@@ -51,6 +56,13 @@ const (
 	ErrorEndpointValueTooLong               = "error_instance_endpoint_too_long"
 	ErrorStatusLengthTooLong                = "error_status_too_long"
 	ErrorMetaDataTooLong                    = "error_meta_data_too_long"
+	ErrorLabelsTooLong                      = "error_instance_labels_too_long"
+	ErrorInstanceWeightOutOfRange           = "error_instance_weight_out_of_range"
+	ErrorWatchParameters                    = "error_watch_parameters"
+	ErrorLocalityParameters                 = "error_locality_parameters"
+	ErrorWatchUnsupported                   = "error_watch_unsupported"
+	ErrorBatchMalformed                     = "error_batch_malformed"
+	ErrorBatchTooLarge                      = "error_batch_too_large"
 )
 
 // EurekaErrorApplicationEnumeration and other constants denote Eureka specific errors. In addition, Eureka API may
@@ -20,12 +20,14 @@ import (
 	"io/ioutil"
 	"net"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"net/url"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/amalgam8/amalgam8/pkg/locality"
 	"github.com/urfave/cli"
 	"gopkg.in/yaml.v2"
 )
@@ -57,6 +59,17 @@ type Endpoint struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
 	Type string `yaml:"type"`
+
+	// Weight is the relative share of traffic the service instance should receive. Zero registers the default weight.
+	Weight int `yaml:"weight"`
+}
+
+// NamedEndpoint configuration of an additional service endpoint, such as an admin or a gRPC port
+type NamedEndpoint struct {
+	// Host defaults to the host of the service endpoint
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	Type string `yaml:"type"`
 }
 
 // Registry configuration
@@ -73,6 +86,24 @@ type Controller struct {
 	Poll  time.Duration `yaml:"poll"`
 }
 
+// Locality configuration
+type Locality struct {
+	Region string `yaml:"region"`
+	Zone   string `yaml:"zone"`
+
+	// MinHealthy is the minimum number of healthy instances of a service to use from the nearest locality,
+	// below which instances from farther localities are used as well
+	MinHealthy int `yaml:"min_healthy"`
+}
+
+// Preference returns the locality preference for selecting instances of other services.
+func (l Locality) Preference() locality.Preference {
+	return locality.Preference{
+		Locality:   locality.Locality{Region: l.Region, Zone: l.Zone},
+		MinHealthy: l.MinHealthy,
+	}
+}
+
 // Dnsconfig - DNS server configuration
 type Dnsconfig struct {
 	Port   int    `yaml:"port"`
@@ -107,14 +138,23 @@ type Config struct {
 	Service  Service  `yaml:"service"`
 	Endpoint Endpoint `yaml:"endpoint"`
 
+	// Endpoints are additional service endpoints, keyed by name
+	Endpoints map[string]NamedEndpoint `yaml:"endpoints"`
+
 	Registry   Registry   `yaml:"registry"`
 	Controller Controller `yaml:"controller"`
 	Dnsconfig  Dnsconfig  `yaml:"dnsconfig"`
 
+	// Locality of the sidecar, by which nearby instances of other services are preferred
+	Locality Locality `yaml:"locality"`
+
 	Supervise bool `yaml:"supervise"`
 
 	HealthChecks []HealthCheck `yaml:"healthchecks"`
 
+	// DrainPeriod is the time a registered service is kept in DRAINING status on shutdown, before it is deregistered
+	DrainPeriod time.Duration `yaml:"drain_period"`
+
 	LogLevel string `yaml:"log_level"`
 
 	Commands []Command `yaml:"commands"`
@@ -201,6 +241,7 @@ func (c *Config) loadFromContext(context *cli.Context) error {
 	loadFromContextIfSet(&c.Endpoint.Host, endpointHostFlag)
 	loadFromContextIfSet(&c.Endpoint.Port, endpointPortFlag)
 	loadFromContextIfSet(&c.Endpoint.Type, endpointTypeFlag)
+	loadFromContextIfSet(&c.Endpoint.Weight, endpointWeightFlag)
 	loadFromContextIfSet(&c.Registry.URL, registryURLFlag)
 	loadFromContextIfSet(&c.Registry.Token, registryTokenFlag)
 	loadFromContextIfSet(&c.Registry.Poll, registryPollFlag)
@@ -208,8 +249,12 @@ func (c *Config) loadFromContext(context *cli.Context) error {
 	loadFromContextIfSet(&c.Controller.Token, controllerTokenFlag)
 	loadFromContextIfSet(&c.Controller.Poll, controllerPollFlag)
 	loadFromContextIfSet(&c.Supervise, superviseFlag)
+	loadFromContextIfSet(&c.DrainPeriod, drainPeriodFlag)
 	loadFromContextIfSet(&c.Dnsconfig.Port, dnsConfigPortFlag)
 	loadFromContextIfSet(&c.Dnsconfig.Domain, dnsConfigDomainFlag)
+	loadFromContextIfSet(&c.Locality.Region, regionFlag)
+	loadFromContextIfSet(&c.Locality.Zone, zoneFlag)
+	loadFromContextIfSet(&c.Locality.MinHealthy, localityMinHealthyFlag)
 	loadFromContextIfSet(&c.LogLevel, logLevelFlag)
 	loadFromContextIfSet(&c.Debug, debugFlag)
 
@@ -219,6 +264,19 @@ func (c *Config) loadFromContext(context *cli.Context) error {
 		c.Service.Tags = tags
 	}
 
+	if context.IsSet(endpointsFlag) {
+		for _, value := range context.StringSlice(endpointsFlag) {
+			name, endpoint, err := parseNamedEndpoint(value)
+			if err != nil {
+				return err
+			}
+			if c.Endpoints == nil {
+				c.Endpoints = make(map[string]NamedEndpoint)
+			}
+			c.Endpoints[name] = endpoint
+		}
+	}
+
 	// For health check flags, we only support default values.
 	if context.IsSet(healthchecksFlag) {
 		hcValues := context.StringSlice(healthchecksFlag)
@@ -312,7 +370,17 @@ func (c *Config) Validate() error {
 			IsNotEmpty("Service Name", c.Service.Name),
 			IsInRange("Service Endpoint Port", c.Endpoint.Port, 1, 65535),
 			IsInSet("Service Endpoint Type", c.Endpoint.Type, []string{"http", "https", "tcp", "udp", "user"}),
+			IsInRange("Service Endpoint Weight", c.Endpoint.Weight, 0, 65535),
+			IsInRangeDuration("Drain period", c.DrainPeriod, 0, 10*time.Minute),
 		)
+
+		for name, endpoint := range c.Endpoints {
+			validators = append(validators,
+				IsValidEndpointName("Service Endpoint Name", name),
+				IsInRange(fmt.Sprintf("Service Endpoint '%v' Port", name), endpoint.Port, 1, 65535),
+				IsInSet(fmt.Sprintf("Service Endpoint '%v' Type", name), endpoint.Type, []string{"http", "https", "tcp", "udp", "user"}),
+			)
+		}
 	}
 
 	if c.Proxy {
@@ -330,9 +398,39 @@ func (c *Config) Validate() error {
 		)
 	}
 
+	validators = append(validators,
+		IsValidLabels("Locality", c.Locality.Preference().Locality.Labels()),
+		IsInRange("Locality minimum healthy instances", c.Locality.MinHealthy, 0, 1000),
+	)
+
 	return Validate(validators)
 }
 
+// parseNamedEndpoint parses a named endpoint of the form 'name=type://[host]:port'
+func parseNamedEndpoint(value string) (string, NamedEndpoint, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return "", NamedEndpoint{}, fmt.Errorf("Could not parse endpoint: '%s'", value)
+	}
+
+	u, err := url.Parse(parts[1])
+	if err != nil || u.Scheme == "" {
+		return "", NamedEndpoint{}, fmt.Errorf("Could not parse endpoint: '%s'", value)
+	}
+
+	host, portStr, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return "", NamedEndpoint{}, fmt.Errorf("Could not parse endpoint port: '%s'", value)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", NamedEndpoint{}, fmt.Errorf("Could not parse endpoint port: '%s'", value)
+	}
+
+	return parts[0], NamedEndpoint{Host: host, Port: port, Type: u.Scheme}, nil
+}
+
 // waitForLocalIP waits until a local IP is available
 func waitForLocalIP() string {
 	ip := ""
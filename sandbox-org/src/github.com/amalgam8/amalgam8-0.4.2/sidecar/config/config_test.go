@@ -56,10 +56,13 @@ var _ = Describe("Config", func() {
 			Expect(c.Service).To(Equal(DefaultConfig.Service))
 			Expect(c.Endpoint.Port).To(Equal(DefaultConfig.Endpoint.Port))
 			Expect(c.Endpoint.Type).To(Equal(DefaultConfig.Endpoint.Type))
+			Expect(c.Endpoint.Weight).To(Equal(DefaultConfig.Endpoint.Weight))
 			Expect(c.Registry).To(Equal(DefaultConfig.Registry))
 			Expect(c.Controller).To(Equal(DefaultConfig.Controller))
 			Expect(c.Dnsconfig).To(Equal(DefaultConfig.Dnsconfig))
 			Expect(c.HealthChecks).To(Equal(DefaultConfig.HealthChecks))
+			Expect(c.DrainPeriod).To(Equal(DefaultConfig.DrainPeriod))
+			Expect(c.Locality).To(Equal(DefaultConfig.Locality))
 			Expect(c.LogLevel).To(Equal(DefaultConfig.LogLevel))
 			Expect(c.Commands).To(HaveLen(0))
 		})
@@ -92,6 +95,9 @@ var _ = Describe("Config", func() {
 				"--endpoint_host=localhost",
 				"--endpoint_port=9080",
 				"--endpoint_type=https",
+				"--endpoint_weight=4",
+				"--endpoints=admin=http://:9090",
+				"--endpoints=grpc=tcp://127.0.0.1:5000",
 				"--registry_url=http://registry:8080",
 				"--registry_token=local",
 				"--registry_poll=5s",
@@ -102,6 +108,10 @@ var _ = Describe("Config", func() {
 				"--dns_domain=someServer",
 				"--healthchecks=http://localhost:8082/health1",
 				"--healthchecks=http://localhost:8082/health2",
+				"--drain_period=10s",
+				"--region=us-south",
+				"--zone=dal10",
+				"--locality_min_healthy=2",
 				"--log_level=debug",
 				"python", "productpage.py",
 			}...)
@@ -118,6 +128,11 @@ var _ = Describe("Config", func() {
 			Expect(c.Endpoint.Host).To(Equal("localhost"))
 			Expect(c.Endpoint.Port).To(Equal(9080))
 			Expect(c.Endpoint.Type).To(Equal("https"))
+			Expect(c.Endpoint.Weight).To(Equal(4))
+			Expect(c.Endpoints).To(Equal(map[string]NamedEndpoint{
+				"admin": {Port: 9090, Type: "http"},
+				"grpc":  {Host: "127.0.0.1", Port: 5000, Type: "tcp"},
+			}))
 			Expect(c.Registry.URL).To(Equal("http://registry:8080"))
 			Expect(c.Registry.Token).To(Equal("local"))
 			Expect(c.Registry.Poll).To(Equal(time.Duration(5) * time.Second))
@@ -128,6 +143,10 @@ var _ = Describe("Config", func() {
 			Expect(c.Dnsconfig.Domain).To(Equal("someServer"))
 			Expect(c.HealthChecks[0].Value).To(Equal("http://localhost:8082/health1"))
 			Expect(c.HealthChecks[1].Value).To(Equal("http://localhost:8082/health2"))
+			Expect(c.DrainPeriod).To(Equal(time.Duration(10) * time.Second))
+			Expect(c.Locality.Region).To(Equal("us-south"))
+			Expect(c.Locality.Zone).To(Equal("dal10"))
+			Expect(c.Locality.MinHealthy).To(Equal(2))
 			Expect(c.LogLevel).To(Equal("debug"))
 			Expect(c.Commands).To(HaveLen(1))
 			Expect(c.Commands[0].OnExit).To(Equal(TerminateProcess))
@@ -155,6 +174,7 @@ var _ = Describe("Config", func() {
 			os.Setenv("A8_ENDPOINT_HOST", "localhost")
 			os.Setenv("A8_ENDPOINT_PORT", "9080")
 			os.Setenv("A8_ENDPOINT_TYPE", "https")
+			os.Setenv("A8_ENDPOINT_WEIGHT", "4")
 			os.Setenv("A8_REGISTRY_URL", "http://registry:8080")
 			os.Setenv("A8_REGISTRY_TOKEN", "local")
 			os.Setenv("A8_REGISTRY_POLL", "5s")
@@ -179,6 +199,7 @@ var _ = Describe("Config", func() {
 			os.Unsetenv("A8_ENDPOINT_HOST")
 			os.Unsetenv("A8_ENDPOINT_PORT")
 			os.Unsetenv("A8_ENDPOINT_TYPE")
+			os.Unsetenv("A8_ENDPOINT_WEIGHT")
 			os.Unsetenv("A8_REGISTRY_URL")
 			os.Unsetenv("A8_REGISTRY_TOKEN")
 			os.Unsetenv("A8_REGISTRY_POLL")
@@ -200,6 +221,7 @@ var _ = Describe("Config", func() {
 			Expect(c.Endpoint.Host).To(Equal("localhost"))
 			Expect(c.Endpoint.Port).To(Equal(9080))
 			Expect(c.Endpoint.Type).To(Equal("https"))
+			Expect(c.Endpoint.Weight).To(Equal(4))
 			Expect(c.Registry.URL).To(Equal("http://registry:8080"))
 			Expect(c.Registry.Token).To(Equal("local"))
 			Expect(c.Registry.Poll).To(Equal(time.Duration(5) * time.Second))
@@ -243,6 +265,16 @@ endpoint:
   host: localhost
   port: 9080
   type: https
+  weight: 4
+
+endpoints:
+  admin:
+    port: 9090
+    type: http
+  grpc:
+    host: 127.0.0.1
+    port: 5000
+    type: tcp
 
 registry:
   url:   http://registry:8080
@@ -273,6 +305,13 @@ healthchecks:
     method: POST
     code: 201
 
+drain_period: 10s
+
+locality:
+  region: us-south
+  zone: dal10
+  min_healthy: 2
+
 commands:
   - cmd: [ "sleep", "720" ]
     env: [ "GODEBUG=netdns=go" ]
@@ -306,6 +345,11 @@ log_level: debug
 			Expect(c.Endpoint.Host).To(Equal("localhost"))
 			Expect(c.Endpoint.Port).To(Equal(9080))
 			Expect(c.Endpoint.Type).To(Equal("https"))
+			Expect(c.Endpoint.Weight).To(Equal(4))
+			Expect(c.Endpoints).To(Equal(map[string]NamedEndpoint{
+				"admin": {Port: 9090, Type: "http"},
+				"grpc":  {Host: "127.0.0.1", Port: 5000, Type: "tcp"},
+			}))
 			Expect(c.Registry.URL).To(Equal("http://registry:8080"))
 			Expect(c.Registry.Token).To(Equal("local"))
 			Expect(c.Registry.Poll).To(Equal(time.Duration(5) * time.Second))
@@ -326,6 +370,10 @@ log_level: debug
 			Expect(c.HealthChecks[1].Timeout).To(Equal(time.Duration(3) * time.Second))
 			Expect(c.HealthChecks[1].Method).To(Equal("POST"))
 			Expect(c.HealthChecks[1].Code).To(Equal(201))
+			Expect(c.DrainPeriod).To(Equal(time.Duration(10) * time.Second))
+			Expect(c.Locality.Region).To(Equal("us-south"))
+			Expect(c.Locality.Zone).To(Equal("dal10"))
+			Expect(c.Locality.MinHealthy).To(Equal(2))
 			Expect(c.LogLevel).To(Equal("debug"))
 			Expect(c.Commands).To(HaveLen(2))
 			Expect(c.Commands[0].OnExit).To(Equal(TerminateProcess))
@@ -398,6 +446,36 @@ log_level: debug
 			c.Commands[0].Cmd = []string{}
 			Expect(c.Validate()).To(HaveOccurred())
 		})
+
+		It("rejects a negative endpoint weight", func() {
+			c.Endpoint.Weight = -1
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("accepts named endpoints", func() {
+			c.Endpoints = map[string]NamedEndpoint{"admin": {Port: 9091, Type: "http"}}
+			Expect(c.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("rejects an invalid endpoint name", func() {
+			c.Endpoints = map[string]NamedEndpoint{"Admin_Port": {Port: 9091, Type: "http"}}
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects a named endpoint without a port", func() {
+			c.Endpoints = map[string]NamedEndpoint{"admin": {Type: "http"}}
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects an invalid locality zone", func() {
+			c.Locality.Zone = "dal 10"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects a negative locality minimum of healthy instances", func() {
+			c.Locality.MinHealthy = -1
+			Expect(c.Validate()).To(HaveOccurred())
+		})
 	})
 
 })
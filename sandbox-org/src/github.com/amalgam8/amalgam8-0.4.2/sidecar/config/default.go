@@ -27,9 +27,10 @@ var DefaultConfig = Config{
 		Tags: nil,
 	},
 	Endpoint: Endpoint{
-		Host: "",
-		Port: 0,
-		Type: "http",
+		Host:   "",
+		Port:   0,
+		Type:   "http",
+		Weight: 1,
 	},
 
 	Registry: Registry{
@@ -48,8 +49,16 @@ var DefaultConfig = Config{
 		Domain: "amalgam8",
 	},
 
+	Locality: Locality{
+		Region:     "",
+		Zone:       "",
+		MinHealthy: 1,
+	},
+
 	HealthChecks: nil,
 
+	DrainPeriod: 0,
+
 	LogLevel: "info",
 
 	Commands: nil,
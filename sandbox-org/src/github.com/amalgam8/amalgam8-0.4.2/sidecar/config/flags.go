@@ -22,26 +22,32 @@ import (
 )
 
 const (
-	configFlag          = "config"
-	registerFlag        = "register"
-	proxyFlag           = "proxy"
-	serviceFlag         = "service"
-	endpointHostFlag    = "endpoint_host"
-	endpointPortFlag    = "endpoint_port"
-	endpointTypeFlag    = "endpoint_type"
-	registryTokenFlag   = "registry_token"
-	registryURLFlag     = "registry_url"
-	registryPollFlag    = "registry_poll"
-	controllerURLFlag   = "controller_url"
-	controllerTokenFlag = "controller_token"
-	controllerPollFlag  = "controller_poll"
-	superviseFlag       = "supervise"
-	healthchecksFlag    = "healthchecks"
-	logLevelFlag        = "log_level"
-	dnsFlag             = "dns"
-	dnsConfigPortFlag   = "dns_port"
-	dnsConfigDomainFlag = "dns_domain"
-	debugFlag           = "debug"
+	configFlag             = "config"
+	registerFlag           = "register"
+	proxyFlag              = "proxy"
+	serviceFlag            = "service"
+	endpointHostFlag       = "endpoint_host"
+	endpointPortFlag       = "endpoint_port"
+	endpointTypeFlag       = "endpoint_type"
+	endpointWeightFlag     = "endpoint_weight"
+	endpointsFlag          = "endpoints"
+	registryTokenFlag      = "registry_token"
+	registryURLFlag        = "registry_url"
+	registryPollFlag       = "registry_poll"
+	controllerURLFlag      = "controller_url"
+	controllerTokenFlag    = "controller_token"
+	controllerPollFlag     = "controller_poll"
+	superviseFlag          = "supervise"
+	healthchecksFlag       = "healthchecks"
+	drainPeriodFlag        = "drain_period"
+	logLevelFlag           = "log_level"
+	dnsFlag                = "dns"
+	dnsConfigPortFlag      = "dns_port"
+	dnsConfigDomainFlag    = "dns_domain"
+	regionFlag             = "region"
+	zoneFlag               = "zone"
+	localityMinHealthyFlag = "locality_min_healthy"
+	debugFlag              = "debug"
 )
 
 // Flags is the set of supported flags
@@ -90,6 +96,16 @@ var Flags = []cli.Flag{
 		EnvVar: envVar(endpointTypeFlag),
 		Usage:  "Service endpoint type (http, https, tcp, udp, user)",
 	},
+	cli.IntFlag{
+		Name:   endpointWeightFlag,
+		EnvVar: envVar(endpointWeightFlag),
+		Usage:  "Service endpoint weight, relative to other instances of the service",
+	},
+	cli.StringSliceFlag{
+		Name:   endpointsFlag,
+		EnvVar: envVar(endpointsFlag),
+		Usage:  "List of additional named service endpoints (name=type://[host]:port)",
+	},
 	cli.StringFlag{
 		Name:   registryURLFlag,
 		EnvVar: envVar(registryURLFlag),
@@ -103,7 +119,7 @@ var Flags = []cli.Flag{
 	cli.DurationFlag{
 		Name:   registryPollFlag,
 		EnvVar: envVar(registryPollFlag),
-		Usage:  "Interval for polling Controller",
+		Usage:  "Maximum interval between Registry catalog checks, which otherwise occur as soon as the catalog changes",
 	},
 	cli.StringFlag{
 		Name:   controllerURLFlag,
@@ -131,6 +147,21 @@ var Flags = []cli.Flag{
 		EnvVar: envVar(dnsConfigDomainFlag),
 		Usage:  "DNS server authorization domain name",
 	},
+	cli.StringFlag{
+		Name:   regionFlag,
+		EnvVar: envVar(regionFlag),
+		Usage:  "Region of the service, by which instances of other services in the same region are preferred",
+	},
+	cli.StringFlag{
+		Name:   zoneFlag,
+		EnvVar: envVar(zoneFlag),
+		Usage:  "Zone of the service, by which instances of other services in the same zone are preferred",
+	},
+	cli.IntFlag{
+		Name:   localityMinHealthyFlag,
+		EnvVar: envVar(localityMinHealthyFlag),
+		Usage:  "Minimum number of healthy instances to use from the nearest locality, before falling back to farther ones",
+	},
 	cli.BoolFlag{
 		Name:   superviseFlag,
 		EnvVar: envVar(superviseFlag),
@@ -141,6 +172,11 @@ var Flags = []cli.Flag{
 		EnvVar: envVar(healthchecksFlag),
 		Usage:  "List of health check URLs",
 	},
+	cli.DurationFlag{
+		Name:   drainPeriodFlag,
+		EnvVar: envVar(drainPeriodFlag),
+		Usage:  "Time to keep the service registered as draining on shutdown, before deregistering it",
+	},
 	cli.StringFlag{
 		Name:   logLevelFlag,
 		EnvVar: envVar(logLevelFlag),
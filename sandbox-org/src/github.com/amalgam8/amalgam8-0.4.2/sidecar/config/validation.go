@@ -18,8 +18,10 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
 	"time"
 
+	"github.com/amalgam8/amalgam8/pkg/labels"
 	"github.com/miekg/dns"
 )
 
@@ -112,3 +114,25 @@ func IsValidDomain(name, value string) ValidatorFunc {
 		return nil
 	}
 }
+
+// IsValidLabels ensures the label keys and values are well formed.
+func IsValidLabels(name string, value map[string]string) ValidatorFunc {
+	return func() error {
+		if err := labels.Validate(value); err != nil {
+			return fmt.Errorf("%v: %v", name, err)
+		}
+		return nil
+	}
+}
+
+var endpointNamePattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$")
+
+// IsValidEndpointName ensures the endpoint name is a valid DNS label in lower case.
+func IsValidEndpointName(name, value string) ValidatorFunc {
+	return func() error {
+		if !endpointNamePattern.MatchString(value) {
+			return fmt.Errorf("%v '%v' must consist of lower case alphanumeric characters or '-'", name, value)
+		}
+		return nil
+	}
+}
@@ -21,11 +21,13 @@ import (
 	"strconv"
 	"strings"
 
+	"math"
 	"math/rand"
 
 	"sort"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/amalgam8/amalgam8/pkg/locality"
 	"github.com/amalgam8/amalgam8/registry/client"
 	"github.com/miekg/dns"
 )
@@ -34,6 +36,7 @@ import (
 type Server struct {
 	dnsServer       *dns.Server
 	discoveryClient client.Discovery
+	locality        locality.Preference
 
 	domain       string
 	domainLabels int
@@ -44,6 +47,9 @@ type Config struct {
 	DiscoveryClient client.Discovery
 	Port            uint16
 	Domain          string
+
+	// Locality of the sidecar, by which instances in the same zone or region are preferred in answers
+	Locality locality.Preference
 }
 
 // NewServer creates a new instance of a DNS server with the given configurations
@@ -54,6 +60,7 @@ func NewServer(config Config) (*Server, error) {
 	}
 	s := &Server{
 		discoveryClient: config.DiscoveryClient,
+		locality:        config.Locality,
 		domain:          config.Domain,
 		domainLabels:    len(dns.Split(config.Domain)),
 	}
@@ -135,33 +142,38 @@ func (s *Server) handleQuestion(question dns.Question, request, response *dns.Ms
 		return fmt.Errorf("unsupported DNS question type: %v", dns.Type(question.Qtype).String())
 	}
 
-	instances, err := s.retrieveInstances(question, request, response)
+	instances, endpoint, err := s.retrieveInstances(question, request, response)
 	if err != nil {
 		return err
 	}
-	return s.createRecords(question, request, response, instances)
+	return s.createRecords(question, request, response, instances, endpoint)
 }
 
-func (s *Server) retrieveInstances(question dns.Question, request, response *dns.Msg) ([]*client.ServiceInstance, error) {
+// retrieveInstances returns the instances answering the question, and the name of the endpoint of the instances
+// selected by the question, or an empty string for their primary endpoint.
+func (s *Server) retrieveInstances(question dns.Question, request, response *dns.Msg) ([]*client.ServiceInstance, string, error) {
 	// Validate the domain name in the question.
 	_, isValidDomain := dns.IsDomainName(question.Name)
 	if !isValidDomain {
 		response.SetRcode(request, dns.RcodeNameError)
-		return nil, fmt.Errorf("invalid domain name")
+		return nil, "", fmt.Errorf("invalid domain name")
 	}
 	labels := dns.SplitDomainName(question.Name)
 
 	// Query format can be either of the following:
 	// 1. [tag|protocol|instanceID]*.<service>.<domain> (A/AAAA query)
-	// 2. _<service>._<tag|protocol|instanceID>.domain> (SRV query per RFC 2782)
+	// 2. <endpoint>.<instanceID>.<service>.<domain> (A/AAAA query for a named endpoint, as targeted by SRV records of 4.)
+	// 3. _<service>._<tag|protocol|instanceID>.domain> (SRV query)
+	// 4. _<endpoint>._<protocol>.<service>.<domain> (SRV query for a named endpoint per RFC 2782)
 
 	if len(labels) < 1+s.domainLabels {
 		response.SetRcode(request, dns.RcodeNameError)
-		return nil, fmt.Errorf("no service specified")
+		return nil, "", fmt.Errorf("no service specified")
 	}
 
 	// Extract service name and filtering labels (tags / protocol / instance ID)
 	var service string
+	var endpoint string
 	var filters []string
 	switch question.Qtype {
 	case dns.TypeA, dns.TypeAAAA:
@@ -169,29 +181,78 @@ func (s *Server) retrieveInstances(question dns.Question, request, response *dns
 		service = labels[servicePos]
 		filters = labels[0:servicePos]
 	case dns.TypeSRV:
-		// Make sure the query syntax complies to RFC 2782
-		if len(labels) != 2+s.domainLabels ||
-			!strings.HasPrefix(labels[0], "_") ||
-			!strings.HasPrefix(labels[1], "_") {
+		// Make sure the query syntax complies to RFC 2782: the labels preceding the name of the service are
+		// prefixed with an underscore, and in a named endpoint query the name of the service is not
+		queryLabels := len(labels) - s.domainLabels
+		if queryLabels != 2 && queryLabels != 3 {
 			response.SetRcode(request, dns.RcodeNameError)
-			return nil, fmt.Errorf("invalid SRV query syntax")
+			return nil, "", fmt.Errorf("invalid SRV query syntax")
+		}
+		for i, label := range labels[0:queryLabels] {
+			if strings.HasPrefix(label, "_") != (i < 2) {
+				response.SetRcode(request, dns.RcodeNameError)
+				return nil, "", fmt.Errorf("invalid SRV query syntax")
+			}
+		}
+		if queryLabels == 2 {
+			service = strings.TrimPrefix(labels[0], "_")
+			filters = []string{strings.TrimPrefix(labels[1], "_")}
+		} else {
+			endpoint = strings.TrimPrefix(labels[0], "_")
+			filters = []string{strings.TrimPrefix(labels[1], "_")}
+			service = labels[2]
 		}
-		service = strings.TrimPrefix(labels[0], "_")
-		filters = []string{strings.TrimPrefix(labels[1], "_")}
 	}
 
 	// Dispatch query to registry
 	instances, err := s.discoveryClient.ListServiceInstances(service)
 	if err != nil {
 		response.SetRcode(request, dns.RcodeServerFailure)
-		return nil, err
+		return nil, "", err
+	}
+
+	// Draining instances only serve existing sessions, so they are left out of new answers
+	active := make([]*client.ServiceInstance, 0, len(instances))
+	for _, instance := range instances {
+		if instance.Status != client.StatusDraining {
+			active = append(active, instance)
+		}
 	}
 
-	filteredInstances, err := s.filterInstances(instances, filters)
+	// A named endpoint query is answered with the named endpoint of each instance that exposes it
+	if endpoint != "" {
+		active = selectEndpoint(active, endpoint)
+	} else if question.Qtype != dns.TypeSRV && len(filters) == 2 {
+		// An address query for the named endpoint of an instance is answered with the address of that endpoint
+		for _, instance := range active {
+			if instance.ID != filters[1] {
+				continue
+			}
+			if selected := selectEndpoint([]*client.ServiceInstance{instance}, filters[0]); len(selected) > 0 {
+				return selected, filters[0], nil
+			}
+		}
+	}
+
+	filteredInstances, err := s.filterInstances(active, filters)
 	if err != nil {
 		response.SetRcode(request, dns.RcodeNameError)
+		return nil, "", err
 	}
-	return filteredInstances, err
+	return client.PreferLocality(filteredInstances, s.locality), endpoint, nil
+}
+
+// selectEndpoint returns copies of the instances having the given named endpoint, with that endpoint as their primary endpoint.
+func selectEndpoint(instances []*client.ServiceInstance, name string) []*client.ServiceInstance {
+	selected := make([]*client.ServiceInstance, 0, len(instances))
+	for _, instance := range instances {
+		if endpoint, ok := instance.Endpoints[name]; ok {
+			copied := *instance
+			copied.Endpoint = endpoint
+			selected = append(selected, &copied)
+		}
+	}
+	return selected
 }
 
 func (s *Server) filterInstances(instances []*client.ServiceInstance, filters []string) ([]*client.ServiceInstance, error) {
@@ -268,7 +329,9 @@ func (s *Server) filterInstances(instances []*client.ServiceInstance, filters []
 	return instances[0:k], nil
 }
 
-func (s *Server) createRecords(question dns.Question, request, response *dns.Msg, instances []*client.ServiceInstance) error {
+// createRecords creates the records answering the question with the instances. The SRV records of a named endpoint
+// target the address of that endpoint, as <endpoint>.<instanceID>.<service>.<domain>.
+func (s *Server) createRecords(question dns.Question, request, response *dns.Msg, instances []*client.ServiceInstance, endpoint string) error {
 	answer := make([]dns.RR, 0, 3)
 	extra := make([]dns.RR, 0, 3)
 
@@ -293,7 +356,10 @@ func (s *Server) createRecords(question dns.Question, request, response *dns.Msg
 			}
 		case dns.TypeSRV:
 			target := fmt.Sprintf("%s.%s.%s", instance.ID, instance.ServiceName, s.domain)
-			answer = append(answer, createSRVRecord(question.Name, port, target))
+			if endpoint != "" {
+				target = endpoint + "." + target
+			}
+			answer = append(answer, createSRVRecord(question.Name, port, weight(instance), target))
 
 			ipV4 := ip.To4()
 			if ipV4 != nil {
@@ -411,7 +477,18 @@ func createAAAARecord(name string, ip net.IP) *dns.AAAA {
 	return record
 }
 
-func createSRVRecord(name string, port uint16, target string) *dns.SRV {
+// weight returns the SRV record weight of the instance. Instances without a weight have the default weight of 1.
+func weight(instance *client.ServiceInstance) uint16 {
+	switch {
+	case instance.Weight <= 0:
+		return 1
+	case instance.Weight > math.MaxUint16:
+		return math.MaxUint16
+	}
+	return uint16(instance.Weight)
+}
+
+func createSRVRecord(name string, port, weight uint16, target string) *dns.SRV {
 	record := &dns.SRV{
 		Hdr: dns.RR_Header{
 			Name:   name,
@@ -421,7 +498,7 @@ func createSRVRecord(name string, port uint16, target string) *dns.SRV {
 		},
 		Port:     port,
 		Priority: 0,
-		Weight:   0,
+		Weight:   weight,
 		Target:   target,
 	}
 	return record
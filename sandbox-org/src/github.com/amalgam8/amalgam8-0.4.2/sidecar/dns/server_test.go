@@ -11,6 +11,7 @@ import (
 
 	"sort"
 
+	"github.com/amalgam8/amalgam8/pkg/locality"
 	"github.com/amalgam8/amalgam8/registry/client"
 	"github.com/miekg/dns"
 	"github.com/stretchr/testify/suite"
@@ -77,6 +78,12 @@ func (m *mySimpleServiceDiscovery) ListServiceInstances(serviceName string) ([]*
 	return servicesToReturn, nil
 }
 
+// Watch returns the service instances immediately, as the mock catalog does not track changes.
+func (m *mySimpleServiceDiscovery) Watch(filter client.InstanceFilter, index uint64, wait time.Duration) ([]*client.ServiceInstance, uint64, error) {
+	instances, err := m.ListInstances(filter)
+	return instances, 0, err
+}
+
 // create the dns server with a client , initialize registry with service instances.
 func (suite *TestSuite) SetupTest() {
 	var err error
@@ -111,7 +118,7 @@ func (suite *TestSuite) SetupTest() {
 		ID: "2", Endpoint: client.NewTCPEndpoint("127.0.0.5", 5050)})
 
 	suite.myClient.services = append(suite.myClient.services, &client.ServiceInstance{Tags: []string{"first", "second"},
-		ServiceName: "shoppingCart", ID: "3", Endpoint: client.NewTCPEndpoint("127.0.0.4", 3050)})
+		ServiceName: "shoppingCart", ID: "3", Endpoint: client.NewTCPEndpoint("127.0.0.4", 3050), Weight: 5})
 
 	suite.myClient.services = append(suite.myClient.services, &client.ServiceInstance{ServiceName: "Orders",
 		ID: "4", Endpoint: client.NewTCPEndpoint("127.0.0.10", 3050)})
@@ -123,11 +130,27 @@ func (suite *TestSuite) SetupTest() {
 		ID: "7", Endpoint: client.NewTCPEndpoint("132.68.5.6", 1010)})
 
 	suite.myClient.services = append(suite.myClient.services, &client.ServiceInstance{ServiceName: "Reviews",
-		ID: "8", Endpoint: client.NewTCPEndpoint("132.68.5.6", 1010)})
+		ID: "8", Endpoint: client.NewTCPEndpoint("132.68.5.6", 1010),
+		Endpoints: map[string]client.ServiceEndpoint{"grpc": client.NewTCPEndpoint("132.68.5.8", 5000)}})
 
 	suite.myClient.services = append(suite.myClient.services, &client.ServiceInstance{ServiceName: "httpService",
 		ID: "9", Endpoint: client.NewHTTPEndpoint(url1)})
 
+	suite.myClient.services = append(suite.myClient.services, &client.ServiceInstance{ServiceName: "Reviews",
+		ID: "10", Status: client.StatusDraining, Endpoint: client.NewTCPEndpoint("132.68.5.7", 1010)})
+
+	suite.myClient.services = append(suite.myClient.services, &client.ServiceInstance{ServiceName: "Ratings",
+		ID: "11", Labels: map[string]string{"region": "us-south", "zone": "dal10"},
+		Endpoint: client.NewTCPEndpoint("10.0.0.11", 2020)})
+
+	suite.myClient.services = append(suite.myClient.services, &client.ServiceInstance{ServiceName: "Ratings",
+		ID: "12", Labels: map[string]string{"region": "us-south", "zone": "dal12"},
+		Endpoint: client.NewTCPEndpoint("10.0.0.12", 2020)})
+
+	suite.myClient.services = append(suite.myClient.services, &client.ServiceInstance{ServiceName: "Ratings",
+		ID: "13", Labels: map[string]string{"region": "eu-de", "zone": "fra02"},
+		Endpoint: client.NewTCPEndpoint("10.0.0.13", 2020)})
+
 	go suite.server.ListenAndServe()
 	time.Sleep((200) * time.Millisecond)
 
@@ -200,6 +223,72 @@ func (suite *TestSuite) TestRequestsWithSubTags() {
 	suite.Empty(r.Answer, "Should be No records for serive unregistred")
 }
 
+func (suite *TestSuite) TestDrainingInstancesExcluded() {
+	r, err := suite.doDNSQuery("Reviews.amalgam8.", dns.TypeA)
+
+	suite.NoError(err)
+	suite.Equal(dns.RcodeSuccess, r.Rcode)
+	suite.Len(r.Answer, 1, "Should be 1 record for Reviews")
+
+	suite.IsType(&dns.A{}, r.Answer[0])
+	suite.Equal(net.ParseIP("132.68.5.6").To4(), r.Answer[0].(*dns.A).A.To4())
+
+	r, err = suite.doDNSQuery("10.Reviews.amalgam8.", dns.TypeA)
+
+	suite.NoError(err)
+	suite.Equal(dns.RcodeNameError, r.Rcode)
+	suite.Empty(r.Answer, "No records for draining instance")
+}
+
+func (suite *TestSuite) TestLocalityPreferred() {
+	cases := []struct {
+		locality locality.Preference
+		ips      []string
+	}{
+		{ // No locality, all instances
+			locality: locality.Preference{MinHealthy: 1},
+			ips:      []string{"10.0.0.13", "10.0.0.12", "10.0.0.11"},
+		},
+		{ // Same zone only
+			locality: locality.Preference{Locality: locality.Locality{Region: "us-south", Zone: "dal10"}, MinHealthy: 1},
+			ips:      []string{"10.0.0.11"},
+		},
+		{ // Falls back to the same region
+			locality: locality.Preference{Locality: locality.Locality{Region: "us-south", Zone: "dal10"}, MinHealthy: 2},
+			ips:      []string{"10.0.0.12", "10.0.0.11"},
+		},
+		{ // Falls back to remote regions
+			locality: locality.Preference{Locality: locality.Locality{Region: "jp-tok"}, MinHealthy: 1},
+			ips:      []string{"10.0.0.13", "10.0.0.12", "10.0.0.11"},
+		},
+	}
+
+	for i, c := range cases {
+		// Serve each locality from a fresh server, on a port of its own
+		suite.server.Shutdown()
+		suite.config.Port += uint16(1000 + i)
+		suite.config.Locality = c.locality
+
+		server, err := NewServer(suite.config)
+		suite.Require().NoError(err)
+		suite.server = server
+		go suite.server.ListenAndServe()
+		time.Sleep((200) * time.Millisecond)
+
+		r, err := suite.doDNSQuery("Ratings.amalgam8.", dns.TypeA)
+		suite.Require().NoError(err)
+		suite.Equal(dns.RcodeSuccess, r.Rcode)
+		suite.Len(r.Answer, len(c.ips), "Wrong number of records for locality %v", c.locality)
+
+		sort.Sort(ByIP(r.Answer))
+		for i, ip := range c.ips {
+			if i < len(r.Answer) {
+				suite.Equal(net.ParseIP(ip).To4(), r.Answer[i].(*dns.A).A.To4())
+			}
+		}
+	}
+}
+
 func (suite *TestSuite) TestRequestsSRVNoTags() {
 	r, err := suite.doDNSQuery("_shoppingCart._tcp.amalgam8.", dns.TypeSRV)
 
@@ -220,6 +309,8 @@ func (suite *TestSuite) TestRequestsSRVNoTags() {
 	suite.Equal(target2, r.Answer[1].(*dns.SRV).Target, "Wrong target for SRV record")
 	suite.EqualValues(5050, r.Answer[0].(*dns.SRV).Port, "Wrong port for SRV record")
 	suite.EqualValues(3050, r.Answer[1].(*dns.SRV).Port, "Wrong port for SRV record")
+	suite.EqualValues(1, r.Answer[0].(*dns.SRV).Weight, "Wrong default weight for SRV record")
+	suite.EqualValues(5, r.Answer[1].(*dns.SRV).Weight, "Wrong weight for SRV record")
 
 	suite.IsType(&dns.A{}, r.Extra[0])
 	suite.IsType(&dns.A{}, r.Extra[1])
@@ -247,6 +338,44 @@ func (suite *TestSuite) TestRequestsSRVWithTag() {
 	suite.Equal(net.ParseIP("127.0.0.4").To4(), r.Extra[0].(*dns.A).A.To4())
 }
 
+func (suite *TestSuite) TestRequestsSRVWithEndpoint() {
+	r, err := suite.doDNSQuery("_grpc._tcp.Reviews.amalgam8.", dns.TypeSRV)
+
+	suite.NoError(err)
+	suite.Len(r.Answer, 1, "Should be 1 record for the grpc endpoint of Reviews")
+	suite.Equal(dns.RcodeSuccess, r.Rcode)
+
+	target := fmt.Sprintf("grpc.%s.Reviews.amalgam8.", suite.myClient.services[6].ID)
+
+	suite.IsType(&dns.SRV{}, r.Answer[0])
+	suite.Equal(target, r.Answer[0].(*dns.SRV).Target, "Wrong target name in SRV record")
+	suite.EqualValues(5000, r.Answer[0].(*dns.SRV).Port, "Wrong port in SRV record")
+
+	suite.Len(r.Extra, 1)
+	suite.Equal(target, r.Extra[0].Header().Name)
+	suite.Equal("132.68.5.8", r.Extra[0].(*dns.A).A.String(), "Wrong address of the grpc endpoint")
+
+	// The target resolves to the address of the named endpoint
+	r, err = suite.doDNSQuery(target, dns.TypeA)
+	suite.NoError(err)
+	suite.Len(r.Answer, 1)
+	suite.Equal("132.68.5.8", r.Answer[0].(*dns.A).A.String(), "Wrong address of the grpc endpoint")
+
+	// The protocol filter applies to the named endpoint
+	r, err = suite.doDNSQuery("_grpc._udp.Reviews.amalgam8.", dns.TypeSRV)
+	suite.NoError(err)
+	suite.Len(r.Answer, 0)
+
+	r, err = suite.doDNSQuery("_admin._tcp.Reviews.amalgam8.", dns.TypeSRV)
+	suite.NoError(err)
+	suite.Len(r.Answer, 0)
+
+	// The name of the service is not prefixed with an underscore
+	r, err = suite.doDNSQuery("_grpc._tcp._Reviews.amalgam8.", dns.TypeSRV)
+	suite.NoError(err)
+	suite.Equal(dns.RcodeNameError, r.Rcode)
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestTestSuite(t *testing.T) {
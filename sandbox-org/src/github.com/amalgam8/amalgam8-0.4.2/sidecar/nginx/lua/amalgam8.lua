@@ -178,10 +178,48 @@ local function create_instance(i)
    instance.ip = ip
    instance.port = port
 
+   -- instances registered without a weight get the default weight
+   instance.weight = 1
+   if is_valid_number(i.weight) and i.weight > 0 then
+      instance.weight = i.weight
+   end
+
    return instance
 end
 
 
+-- pick an instance at random, in proportion to the instance weights.
+-- instances that were already tried are removed from the list, leaving holes,
+-- so the list is scanned up to its original length.
+local function pick_weighted(instances, count)
+   local total = 0
+   for i = 1, count do
+      if instances[i] then
+         total = total + instances[i].weight
+      end
+   end
+
+   if total <= 0 then
+      return nil, nil
+   end
+
+   local point = math.random() * total
+   local last = nil
+   for i = 1, count do
+      if instances[i] then
+         last = i
+         point = point - instances[i].weight
+         if point < 0 then
+            return instances[i], i
+         end
+      end
+   end
+
+   -- guard against floating point rounding
+   return instances[last], last
+end
+
+
 local function compare_rules_descending(a, b)
    return a.priority > b.priority
 end
@@ -787,6 +825,7 @@ function Amalgam8:apply_rules()
    end
 
    ngx.ctx.a8_upstreams = selected_instances
+   ngx.ctx.a8_upstreams_count = #selected_instances
    ngx.var.a8_upstream_tags = ""
    if selected_backend then
       ngx.ctx.a8_timeout = selected_backend.timeout
@@ -876,15 +915,8 @@ function Amalgam8:load_balance()
       end
    end
 
-   local upstream = nil
-   local count = #selected_instances
-   local pick = 1
-   while not upstream and count > 0 do
-      pick = math.random(#selected_instances)
-      upstream = selected_instances[pick]
-      if upstream then break end
-      count = count -1
-   end
+   local count = ngx.ctx.a8_upstreams_count or #selected_instances
+   local upstream, pick = pick_weighted(selected_instances, count)
 
    -- we didn't get any upstream from the list. More retries than instances
    -- available
@@ -904,7 +936,7 @@ function Amalgam8:load_balance()
    ngx.var.a8_upstream_tags = upstream.tags
 
    if not retries then
-      retries = #selected_instances
+      retries = count
    end
 
    if not ngx.ctx.tries then
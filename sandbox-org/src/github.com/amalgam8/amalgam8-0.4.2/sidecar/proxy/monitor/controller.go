@@ -15,11 +15,15 @@
 package monitor
 
 import (
+	"reflect"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/amalgam8/amalgam8/controller/client"
 	"github.com/amalgam8/amalgam8/controller/rules"
+	registryclient "github.com/amalgam8/amalgam8/registry/client"
 )
 
 // ControllerListener is notified of changes to controller
@@ -32,24 +36,46 @@ type ControllerConfig struct {
 	Client       client.Client
 	Listeners    []ControllerListener
 	PollInterval time.Duration
+
+	// Source, if set, is the service the sidecar proxies requests from. Only the rules that can apply to its requests
+	// are retrieved from the controller.
+	Source *rules.Source
+
+	// Registry, if set along with Source, is monitored for the services the sidecar can reach. Only the rules for
+	// destinations in the registry catalog are retrieved from the controller.
+	Registry RegistryMonitor
 }
 
 type controller struct {
 	ticker       *time.Ticker
 	controller   client.Client
 	pollInterval time.Duration
+	source       *rules.Source
 	revision     int64
 	listeners    []ControllerListener
+
+	// destinations are the services in the registry catalog, or nil if the rules are not scoped by destination.
+	// The rules are retrieved again once the destinations have changed, even if the revision has not.
+	destinations []string
+	stale        bool
+	lock         sync.Mutex
 }
 
 // NewController instantiates a new instance
 func NewController(conf ControllerConfig) Monitor {
-	return &controller{
+	c := &controller{
 		controller:   conf.Client,
 		listeners:    conf.Listeners,
 		pollInterval: conf.PollInterval,
+		source:       conf.Source,
 		revision:     -1,
 	}
+
+	if conf.Source != nil && conf.Registry != nil {
+		conf.Registry.AddListener(c)
+	}
+
+	return c
 }
 
 // Start monitoring the A8 controller. This is a blocking operation.
@@ -83,15 +109,26 @@ func (c *controller) Start() error {
 // poll the A8 controller for changes and notify listeners
 func (c *controller) poll() error {
 
+	c.lock.Lock()
+	filter := rules.Filter{
+		Source:       c.source,
+		Destinations: c.destinations,
+	}
+	stale := c.stale
+	c.stale = false
+	c.lock.Unlock()
+
 	// Get the latest rules from the A8 controller.
-	resp, err := c.controller.GetRules(rules.Filter{})
+	resp, err := c.controller.GetRules(filter)
 	if err != nil {
 		logrus.WithError(err).Error("Call to controller failed")
+		c.markStale(stale)
 		return err
 	}
 
-	// Short-circuit if the controller's revision is not newer than our revision
-	if c.revision >= resp.Revision {
+	// Short-circuit if the controller's revision is not newer than our revision, unless the rules were retrieved for
+	// a different set of destinations
+	if c.revision >= resp.Revision && !stale {
 		return nil
 	}
 
@@ -108,6 +145,41 @@ func (c *controller) poll() error {
 	return nil
 }
 
+// CatalogChange updates the destinations the rules are retrieved for to the services in the registry catalog.
+func (c *controller) CatalogChange(instances []registryclient.ServiceInstance) error {
+	services := make(map[string]struct{})
+	for _, instance := range instances {
+		services[instance.ServiceName] = struct{}{}
+	}
+
+	destinations := make([]string, 0, len(services))
+	for service := range services {
+		destinations = append(destinations, service)
+	}
+	sort.Strings(destinations)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.destinations != nil && reflect.DeepEqual(c.destinations, destinations) {
+		return nil
+	}
+	c.destinations = destinations
+	c.stale = true
+
+	return nil
+}
+
+// markStale marks the rules to be retrieved again on the next poll if they were stale before a failed poll.
+func (c *controller) markStale(stale bool) {
+	if !stale {
+		return
+	}
+
+	c.lock.Lock()
+	c.stale = true
+	c.lock.Unlock()
+}
+
 // Stop monitoring the A8 controller
 func (c *controller) Stop() error {
 	// Stop ticker if necessary
@@ -0,0 +1,82 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package monitor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amalgam8/amalgam8/controller/client"
+	"github.com/amalgam8/amalgam8/controller/rules"
+	registryclient "github.com/amalgam8/amalgam8/registry/client"
+)
+
+type mockControllerClient struct {
+	filters  []rules.Filter
+	revision int64
+}
+
+func (c *mockControllerClient) GetRules(f rules.Filter) (client.RuleResponse, error) {
+	c.filters = append(c.filters, f)
+	return client.RuleResponse{Revision: c.revision}, nil
+}
+
+type mockControllerListener struct {
+	changes int
+}
+
+func (l *mockControllerListener) RuleChange([]rules.Rule) error {
+	l.changes++
+	return nil
+}
+
+func TestControllerDestinations(t *testing.T) {
+	controllerClient := &mockControllerClient{revision: 1}
+	listener := &mockControllerListener{}
+	registry := NewRegistry(RegistryConfig{}).(*registry)
+	source := &rules.Source{Name: "reviews"}
+
+	c := NewController(ControllerConfig{
+		Client:    controllerClient,
+		Listeners: []ControllerListener{listener},
+		Source:    source,
+		Registry:  registry,
+	}).(*controller)
+
+	// Until the registry catalog is known, the rules are not scoped by destination
+	c.poll()
+	if listener.changes != 1 || controllerClient.filters[0].Destinations != nil {
+		t.Errorf("expected unscoped rules, got %d changes with filter %+v", listener.changes, controllerClient.filters[0])
+	}
+
+	// A change to the services in the catalog retrieves the rules again at the same revision
+	for _, l := range registry.listeners {
+		l.CatalogChange([]registryclient.ServiceInstance{{ServiceName: "ratings"}, {ServiceName: "details"}, {ServiceName: "ratings"}})
+	}
+	c.poll()
+	if listener.changes != 2 || !reflect.DeepEqual(controllerClient.filters[1].Destinations, []string{"details", "ratings"}) {
+		t.Errorf("expected rules for the catalog services, got %d changes with filter %+v", listener.changes, controllerClient.filters[1])
+	}
+	if controllerClient.filters[1].Source != source {
+		t.Errorf("expected the rules to be scoped by source, got filter %+v", controllerClient.filters[1])
+	}
+
+	// Changes to instances of the same services do not
+	c.CatalogChange([]registryclient.ServiceInstance{{ServiceName: "details", ID: "1"}, {ServiceName: "ratings", ID: "2"}})
+	c.poll()
+	if listener.changes != 2 {
+		t.Errorf("expected no rule change, got %d changes", listener.changes)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/amalgam8/amalgam8/pkg/labels"
 	"github.com/amalgam8/amalgam8/registry/client"
 )
 
@@ -29,12 +30,18 @@ type RegistryListener interface {
 	CatalogChange([]client.ServiceInstance) error
 }
 
+// minWatchInterval is the minimum interval between consecutive catalog queries,
+// which limits the rate of queries if the registry index changes frequently
+const minWatchInterval = time.Second
+
 type registry struct {
 	pollInterval   time.Duration
 	registryClient client.Discovery
 	listeners      []RegistryListener
-	ticker         *time.Ticker
+	stop           chan struct{}
 	cache          map[string][]*client.ServiceInstance
+	index          uint64
+	changed        chan struct{}
 	lock           sync.RWMutex
 }
 
@@ -57,41 +64,48 @@ func NewRegistry(conf RegistryConfig) RegistryMonitor {
 		listeners:      []RegistryListener{},
 		registryClient: conf.RegistryClient,
 		pollInterval:   conf.PollInterval,
+		changed:        make(chan struct{}),
 	}
 }
 
 // Start monitoring registry
 func (m *registry) Start() error {
-	// Stop existing ticker if necessary
-	if m.ticker != nil {
+	// Stop existing monitoring if necessary
+	if m.stop != nil {
 		if err := m.Stop(); err != nil {
-			logrus.WithError(err).Error("Could not stop existing periodic poll")
+			logrus.WithError(err).Error("Could not stop existing registry watch")
 			return err
 		}
 	}
 
-	// Create new ticker
-	m.ticker = time.NewTicker(m.pollInterval)
-
-	// Do initial poll
-	if err := m.poll(); err != nil {
-		logrus.WithError(err).Error("Catalog check failed")
-	}
+	stop := make(chan struct{})
+	m.stop = stop
 
-	// Start periodic poll
-	for range m.ticker.C {
+	// Watch the catalog for changes. Each watch returns once the registry catalog has changed,
+	// or the poll interval has elapsed.
+	for {
+		start := time.Now()
+		delay := minWatchInterval
 		if err := m.poll(); err != nil {
 			logrus.WithError(err).Error("Catalog check failed")
+			delay = m.pollInterval
+		} else if m.lastIndex() == 0 {
+			// The registry does not track changes, so fall back to periodic polling
+			delay = m.pollInterval
 		}
-	}
 
-	return nil
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(delay - time.Since(start)):
+		}
+	}
 }
 
 // poll registry for changes in the catalog
 func (m *registry) poll() error {
 	// Get newest catalog from registry
-	instances, err := m.getRegistryInstances()
+	instances, index, err := m.registryClient.Watch(client.InstanceFilter{}, m.lastIndex(), m.pollInterval)
 	if err != nil {
 		logrus.WithError(err).Warn("Could not get latest catalog from registry")
 		return err
@@ -101,12 +115,18 @@ func (m *registry) poll() error {
 	// Check for changes
 	if m.compareToCache(catalog) {
 		// Match, nothing else to do
+		m.lock.Lock()
+		m.index = index
+		m.lock.Unlock()
 		return nil
 	}
 
 	// Update cached catalog
 	m.lock.Lock()
 	m.cache = catalog
+	m.index = index
+	close(m.changed)
+	m.changed = make(chan struct{})
 	listeners := m.listeners
 	m.lock.Unlock()
 
@@ -121,6 +141,12 @@ func (m *registry) poll() error {
 	return nil
 }
 
+func (m *registry) lastIndex() uint64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.index
+}
+
 // compareToCache compares the given catalog to the cached one, by comparing all instance attributes
 // except for heartbeat and TTL. Instance list for each service is assumed to be presorted.
 // Return 'true' if catalog match, and 'false' otherwise.
@@ -149,7 +175,10 @@ func (m *registry) compareToCache(catalog map[string][]*client.ServiceInstance)
 				instance.Status != cachedInstance.Status ||
 				instance.Endpoint.Type != cachedInstance.Endpoint.Type ||
 				instance.Endpoint.Value != cachedInstance.Endpoint.Value ||
+				instance.Weight != cachedInstance.Weight ||
+				!reflect.DeepEqual(instance.Endpoints, cachedInstance.Endpoints) ||
 				!reflect.DeepEqual(instance.Tags, cachedInstance.Tags) ||
+				!reflect.DeepEqual(instance.Labels, cachedInstance.Labels) ||
 				!reflect.DeepEqual(instance.Metadata, cachedInstance.Metadata) {
 				return false
 			}
@@ -159,16 +188,12 @@ func (m *registry) compareToCache(catalog map[string][]*client.ServiceInstance)
 	return true
 }
 
-func (m *registry) getRegistryInstances() ([]*client.ServiceInstance, error) {
-	return m.registryClient.ListInstances(client.InstanceFilter{})
-}
-
 // Stop monitoring registry
 func (m *registry) Stop() error {
-	// Stop ticker if necessary
-	if m.ticker != nil {
-		m.ticker.Stop()
-		m.ticker = nil
+	// Stop watch if necessary
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
 	}
 
 	return nil
@@ -203,6 +228,11 @@ func (m *registry) ListServices() ([]string, error) {
 }
 
 func (m *registry) ListInstances(filter client.InstanceFilter) ([]*client.ServiceInstance, error) {
+	selector, err := labels.Parse(filter.Selector)
+	if err != nil {
+		return nil, err
+	}
+
 	servicesToReturn := []*client.ServiceInstance{}
 	count := 0
 	m.lock.RLock()
@@ -217,12 +247,12 @@ func (m *registry) ListInstances(filter client.InstanceFilter) ([]*client.Servic
 					}
 				}
 			}
-			if count == len(filter.Tags) {
+			if count == len(filter.Tags) && selector.Matches(service.Labels) {
 				servicesToReturn = append(servicesToReturn, service)
 			}
 		}
 	}
-	return servicesToReturn, nil
+	return client.PreferLocality(servicesToReturn, filter.Locality), nil
 }
 
 func (m *registry) ListServiceInstances(serviceName string) ([]*client.ServiceInstance, error) {
@@ -235,6 +265,31 @@ func (m *registry) ListServiceInstances(serviceName string) ([]*client.ServiceIn
 	return servicesToReturn, nil
 }
 
+// Watch returns the cached instances matching the filter once the cached catalog has changed since the given index,
+// or the wait duration has elapsed. The index is that of the registry catalog the cache was last updated from.
+func (m *registry) Watch(filter client.InstanceFilter, index uint64, wait time.Duration) ([]*client.ServiceInstance, uint64, error) {
+	m.lock.RLock()
+	changed := m.changed
+	current := m.index
+	m.lock.RUnlock()
+
+	if index > 0 && index == current {
+		timer := time.NewTimer(wait)
+		select {
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+
+	m.lock.RLock()
+	current = m.index
+	m.lock.RUnlock()
+
+	instances, err := m.ListInstances(filter)
+	return instances, current, err
+}
+
 func (m *registry) AddListener(listener RegistryListener) {
 	m.lock.Lock()
 	copyOfListeners := m.listeners
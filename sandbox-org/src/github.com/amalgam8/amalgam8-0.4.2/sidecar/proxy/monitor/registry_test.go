@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/amalgam8/amalgam8/pkg/locality"
 	"github.com/amalgam8/amalgam8/registry/client"
 )
 
@@ -80,6 +81,57 @@ func TestCatalogComparison(t *testing.T) {
 			},
 			Equal: false,
 		},
+		{ // Label changes should be detected
+			A: map[string][]*client.ServiceInstance{
+				"Service": []*client.ServiceInstance{
+					{
+						Labels: map[string]string{"version": "v1"},
+					},
+				},
+			},
+			B: map[string][]*client.ServiceInstance{
+				"Service": []*client.ServiceInstance{
+					{
+						Labels: map[string]string{"version": "v2"},
+					},
+				},
+			},
+			Equal: false,
+		},
+		{ // Weight changes should be detected
+			A: map[string][]*client.ServiceInstance{
+				"Service": []*client.ServiceInstance{
+					{
+						Weight: 1,
+					},
+				},
+			},
+			B: map[string][]*client.ServiceInstance{
+				"Service": []*client.ServiceInstance{
+					{
+						Weight: 4,
+					},
+				},
+			},
+			Equal: false,
+		},
+		{ // Named endpoint changes should be detected
+			A: map[string][]*client.ServiceInstance{
+				"Service": []*client.ServiceInstance{
+					{
+						Endpoints: map[string]client.ServiceEndpoint{"admin": client.NewTCPEndpoint("10.0.0.1", 9090)},
+					},
+				},
+			},
+			B: map[string][]*client.ServiceInstance{
+				"Service": []*client.ServiceInstance{
+					{
+						Endpoints: map[string]client.ServiceEndpoint{"admin": client.NewTCPEndpoint("10.0.0.1", 9091)},
+					},
+				},
+			},
+			Equal: false,
+		},
 	}
 	for i, c := range cases {
 		r.cache = c.A
@@ -89,3 +141,55 @@ func TestCatalogComparison(t *testing.T) {
 		}
 	}
 }
+
+func TestListInstancesBySelector(t *testing.T) {
+	r := registry{
+		cache: map[string][]*client.ServiceInstance{
+			"Service": []*client.ServiceInstance{
+				{ID: "1", ServiceName: "Service", Labels: map[string]string{"version": "v1"}},
+				{ID: "2", ServiceName: "Service", Labels: map[string]string{"version": "v2"}},
+			},
+		},
+	}
+
+	instances, err := r.ListInstances(client.InstanceFilter{ServiceName: "Service", Selector: "version=v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 1 || instances[0].ID != "2" {
+		t.Errorf("ListInstances(version=v2): expected instance 2, got %v", instances)
+	}
+
+	instances, err = r.ListInstances(client.InstanceFilter{ServiceName: "Service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 2 {
+		t.Errorf("ListInstances(): expected 2 instances, got %v", instances)
+	}
+
+	if _, err = r.ListInstances(client.InstanceFilter{ServiceName: "Service", Selector: "version in (v1"}); err == nil {
+		t.Error("ListInstances(version in (v1): expected an error")
+	}
+}
+
+func TestListInstancesByLocality(t *testing.T) {
+	r := registry{
+		cache: map[string][]*client.ServiceInstance{
+			"Service": []*client.ServiceInstance{
+				{ID: "1", ServiceName: "Service", Status: "UP", Labels: map[string]string{"region": "us-south", "zone": "dal12"}},
+				{ID: "2", ServiceName: "Service", Status: "UP", Labels: map[string]string{"region": "us-south", "zone": "dal10"}},
+				{ID: "3", ServiceName: "Service", Status: "UP", Labels: map[string]string{"region": "eu-de", "zone": "fra02"}},
+			},
+		},
+	}
+
+	preference := locality.Preference{Locality: locality.Locality{Region: "us-south", Zone: "dal10"}, MinHealthy: 2}
+	instances, err := r.ListInstances(client.InstanceFilter{ServiceName: "Service", Locality: preference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 2 || instances[0].ID != "2" || instances[1].ID != "1" {
+		t.Errorf("ListInstances(%v): expected instances 2 and 1, got %v", preference, instances)
+	}
+}
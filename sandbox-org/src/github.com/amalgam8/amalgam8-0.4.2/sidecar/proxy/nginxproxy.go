@@ -15,10 +15,13 @@
 package proxy
 
 import (
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/amalgam8/amalgam8/controller/rules"
+	"github.com/amalgam8/amalgam8/pkg/locality"
 	"github.com/amalgam8/amalgam8/registry/client"
 	"github.com/amalgam8/amalgam8/sidecar/proxy/monitor"
 	"github.com/amalgam8/amalgam8/sidecar/proxy/nginx"
@@ -35,15 +38,18 @@ type nginxProxy struct {
 	instances []client.ServiceInstance
 	rules     []rules.Rule
 	nginx     nginx.Manager
+	locality  locality.Preference
 	mutex     sync.Mutex
 }
 
-// NewNGINXProxy instantiates a new instance
-func NewNGINXProxy(nginxClient nginx.Manager) NGINXProxy {
+// NewNGINXProxy instantiates a new instance. Instances of each service version are selected
+// by their proximity to the given locality.
+func NewNGINXProxy(nginxClient nginx.Manager, preference locality.Preference) NGINXProxy {
 	return &nginxProxy{
 		rules:     []rules.Rule{},
 		instances: []client.ServiceInstance{},
 		nginx:     nginxClient,
+		locality:  preference,
 	}
 }
 
@@ -52,7 +58,7 @@ func (n *nginxProxy) CatalogChange(instances []client.ServiceInstance) error {
 	n.mutex.Lock()
 	defer n.mutex.Unlock()
 
-	n.instances = instances
+	n.instances = preferLocality(instances, n.locality)
 	return n.updateNGINX()
 }
 
@@ -76,3 +82,41 @@ func (n *nginxProxy) GetState() ([]client.ServiceInstance, []rules.Rule) {
 
 	return n.instances, n.rules
 }
+
+// preferLocality selects the instances of each service version by the locality preference.
+// Versions are selected separately, so that rules routing to any version keep their fallback instances.
+func preferLocality(instances []client.ServiceInstance, preference locality.Preference) []client.ServiceInstance {
+	if preference.Locality.Empty() {
+		return instances
+	}
+
+	var versions []string
+	versionInstances := make(map[string][]int)
+	for i, instance := range instances {
+		version := versionKey(instance)
+		if _, exists := versionInstances[version]; !exists {
+			versions = append(versions, version)
+		}
+		versionInstances[version] = append(versionInstances[version], i)
+	}
+
+	selected := make([]client.ServiceInstance, 0, len(instances))
+	for _, version := range versions {
+		indexes := versionInstances[version]
+		selection := preference.Select(len(indexes),
+			func(i int) locality.Locality { return instances[indexes[i]].Locality() },
+			func(i int) bool { return instances[indexes[i]].Healthy() })
+		for _, i := range selection {
+			selected = append(selected, instances[indexes[i]])
+		}
+	}
+	return selected
+}
+
+// versionKey identifies the version of a service instance by its service name and tags.
+func versionKey(instance client.ServiceInstance) string {
+	tags := make([]string, len(instance.Tags))
+	copy(tags, instance.Tags)
+	sort.Strings(tags)
+	return instance.ServiceName + ":" + strings.Join(tags, ",")
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 IBM Corporation
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package proxy
+
+import (
+	"github.com/amalgam8/amalgam8/pkg/locality"
+	"github.com/amalgam8/amalgam8/registry/client"
+	"github.com/amalgam8/amalgam8/sidecar/proxy/nginx"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NGINX proxy", func() {
+
+	var (
+		manager   *nginx.MockManager
+		instances []client.ServiceInstance
+	)
+
+	ids := func(instances []client.ServiceInstance) []string {
+		res := make([]string, len(instances))
+		for i, instance := range instances {
+			res[i] = instance.ID
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		manager = &nginx.MockManager{}
+		instances = []client.ServiceInstance{
+			{ID: "1", ServiceName: "reviews", Tags: []string{"v1"}, Status: "UP",
+				Labels: map[string]string{"region": "us-south", "zone": "dal12"}},
+			{ID: "2", ServiceName: "reviews", Tags: []string{"v1"}, Status: "UP",
+				Labels: map[string]string{"region": "us-south", "zone": "dal10"}},
+			{ID: "3", ServiceName: "reviews", Tags: []string{"v2"}, Status: "UP",
+				Labels: map[string]string{"region": "eu-de", "zone": "fra02"}},
+			{ID: "4", ServiceName: "reviews", Tags: []string{"v2"}, Status: "UP",
+				Labels: map[string]string{"region": "us-south", "zone": "dal12"}},
+			{ID: "5", ServiceName: "ratings", Status: "UP",
+				Labels: map[string]string{"region": "us-south", "zone": "dal10"}},
+		}
+	})
+
+	It("keeps all instances when the locality is unknown", func() {
+		proxy := NewNGINXProxy(manager, locality.Preference{MinHealthy: 1})
+		Expect(proxy.CatalogChange(instances)).To(Succeed())
+
+		state, _ := proxy.GetState()
+		Expect(ids(state)).To(Equal([]string{"1", "2", "3", "4", "5"}))
+		Expect(manager.UpdateCount).To(Equal(1))
+	})
+
+	It("prefers the nearest instances of each service version", func() {
+		proxy := NewNGINXProxy(manager, locality.Preference{
+			Locality:   locality.Locality{Region: "us-south", Zone: "dal10"},
+			MinHealthy: 1,
+		})
+		Expect(proxy.CatalogChange(instances)).To(Succeed())
+
+		state, _ := proxy.GetState()
+		Expect(ids(state)).To(Equal([]string{"2", "4", "5"}))
+	})
+
+	It("falls back to farther instances when too few nearby instances are healthy", func() {
+		instances[1].Status = "OUT_OF_SERVICE"
+		proxy := NewNGINXProxy(manager, locality.Preference{
+			Locality:   locality.Locality{Region: "us-south", Zone: "dal10"},
+			MinHealthy: 1,
+		})
+		Expect(proxy.CatalogChange(instances)).To(Succeed())
+
+		state, _ := proxy.GetState()
+		Expect(ids(state)).To(Equal([]string{"2", "1", "4", "5"}))
+	})
+})
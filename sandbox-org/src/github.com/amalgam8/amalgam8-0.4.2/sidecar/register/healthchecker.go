@@ -75,6 +75,18 @@ func (checker *HealthChecker) Stop() {
 	<-checker.stop
 }
 
+// Drain the registration, if it supports draining. The registration is drained even if it is currently stopped for
+// being unhealthy, so that it is not started again once healthy.
+// Blocks until the drain attempt is complete.
+func (checker *HealthChecker) Drain() {
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+
+	if drainer, ok := checker.registration.(Drainer); ok {
+		drainer.Drain()
+	}
+}
+
 // maintainRegistration
 func (checker *HealthChecker) maintainRegistration() {
 	// Receives a value whenever the status of a health check agent changes from healthy to unhealthy or vice versa.
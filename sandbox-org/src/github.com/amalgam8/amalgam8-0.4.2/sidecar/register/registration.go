@@ -34,6 +34,11 @@ type Lifecycle interface {
 	Stop()
 }
 
+// Drainer is the interface implemented by objects that can stop receiving new traffic ahead of being stopped.
+type Drainer interface {
+	Drain()
+}
+
 // RegistrationConfig options
 type RegistrationConfig struct {
 	Client          client.Registry
@@ -42,10 +47,12 @@ type RegistrationConfig struct {
 
 // RegistrationAgent maintains a registration with registry.
 type RegistrationAgent struct {
-	config RegistrationConfig
-	active bool
-	stop   chan struct{}
-	mutex  sync.Mutex
+	config   RegistrationConfig
+	active   bool
+	draining bool
+	stop     chan struct{}
+	drain    chan struct{}
+	mutex    sync.Mutex
 }
 
 // NewRegistrationAgent instantiates a new instance of the agent
@@ -55,6 +62,7 @@ func NewRegistrationAgent(config RegistrationConfig) (*RegistrationAgent, error)
 	agent := &RegistrationAgent{
 		config: config,
 		stop:   make(chan struct{}),
+		drain:  make(chan struct{}),
 	}
 
 	return agent, nil
@@ -69,7 +77,8 @@ func (agent *RegistrationAgent) Start() {
 	agent.mutex.Lock()
 	defer agent.mutex.Unlock()
 
-	if agent.active {
+	// A drained registration is not started again, e.g., by a health checker, as the service is shutting down
+	if agent.active || agent.draining {
 		return
 	}
 	agent.active = true
@@ -95,6 +104,28 @@ func (agent *RegistrationAgent) Stop() {
 	<-agent.stop
 }
 
+// Drain sets the status of the registration to DRAINING, so that the service stops receiving new traffic while
+// the registration is maintained until the agent is stopped. A drained agent is not started again.
+// Blocks until the status update attempt is complete.
+func (agent *RegistrationAgent) Drain() {
+	logrus.WithField("service_name", agent.config.ServiceInstance.ServiceName).
+		Info("Draining Amalgam8 service registration")
+
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	if agent.draining {
+		return
+	}
+	agent.draining = true
+	if !agent.active {
+		return
+	}
+
+	agent.drain <- struct{}{}
+	<-agent.drain
+}
+
 func (agent *RegistrationAgent) register() {
 	for {
 		logrus.WithField("service_name", agent.config.ServiceInstance.ServiceName).
@@ -117,6 +148,11 @@ func (agent *RegistrationAgent) register() {
 		select {
 		case <-time.After(DefaultReregistrationDelay):
 			continue
+		case <-agent.drain:
+			// A draining service should not receive new traffic, so there is no point in registering it
+			agent.drain <- struct{}{}
+			agent.awaitStop()
+			return
 		case <-agent.stop:
 			agent.stop <- struct{}{}
 			return
@@ -126,6 +162,7 @@ func (agent *RegistrationAgent) register() {
 
 func (agent *RegistrationAgent) renew(instance *client.ServiceInstance) {
 	interval := time.Duration(instance.TTL) * time.Second / DefaultHeartbeatsPerTTL
+	draining := false
 
 	for {
 		select {
@@ -143,11 +180,20 @@ func (agent *RegistrationAgent) renew(instance *client.ServiceInstance) {
 				}).Warn("Service registration renewal had failed")
 
 				if cErr, ok := err.(client.Error); ok && cErr.Code == client.ErrorCodeUnknownInstance {
+					if draining {
+						agent.awaitStop()
+						return
+					}
 					go agent.register()
 					return
 				}
 			}
 
+		case <-agent.drain:
+			agent.setDraining(instance)
+			draining = true
+			agent.drain <- struct{}{}
+
 		case <-agent.stop:
 			agent.deregister(instance)
 			agent.stop <- struct{}{}
@@ -156,6 +202,32 @@ func (agent *RegistrationAgent) renew(instance *client.ServiceInstance) {
 	}
 }
 
+// awaitStop blocks until the agent is stopped
+func (agent *RegistrationAgent) awaitStop() {
+	<-agent.stop
+	agent.stop <- struct{}{}
+}
+
+func (agent *RegistrationAgent) setDraining(instance *client.ServiceInstance) {
+	logrus.WithFields(logrus.Fields{
+		"service_name": instance.ServiceName,
+		"instance_id":  instance.ID,
+	}).Info("Attempting to set service status to draining with Amalgam8")
+
+	err := agent.config.Client.SetStatus(instance.ID, client.StatusDraining)
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"service_name": instance.ServiceName,
+			"instance_id":  instance.ID,
+		}).Warn("Service status update had failed")
+	} else {
+		logrus.WithFields(logrus.Fields{
+			"service_name": instance.ServiceName,
+			"instance_id":  instance.ID,
+		}).Info("Service successfully set to draining with Amalgam8")
+	}
+}
+
 func (agent *RegistrationAgent) deregister(instance *client.ServiceInstance) {
 	logrus.WithFields(logrus.Fields{
 		"service_name": instance.ServiceName,
@@ -89,15 +89,65 @@ var _ = Describe("Registration agent", func() {
 		})
 	})
 
+	Context("When registration agent is drained", func() {
+
+		BeforeEach(func() {
+			// Avoid race condition on registration
+			time.Sleep(100 * time.Millisecond)
+
+			agent.Drain()
+		})
+
+		It("Sets the status of the service to draining", func() {
+			Expect(mockClient.status).To(Equal(client.StatusDraining))
+		})
+
+		It("Keeps renewing the registration until stopped", func() {
+			ttl := time.Duration(config.ServiceInstance.TTL) * time.Second
+			time.Sleep(ttl)
+
+			Expect(mockClient.registered).To(BeTrue())
+			Expect(mockClient.lastHeartbeat).To(BeTemporally("~", time.Now(), ttl))
+
+			agent.Stop()
+			Expect(mockClient.registered).To(BeFalse())
+		})
+
+		It("Is not started again", func() {
+			agent.Stop()
+			agent.Start()
+			time.Sleep(250 * time.Millisecond)
+
+			Expect(mockClient.registered).To(BeFalse())
+		})
+	})
+
+	Context("When stopped registration agent is drained", func() {
+
+		BeforeEach(func() {
+			agent.Stop()
+			agent.Drain()
+		})
+
+		It("Is not started again", func() {
+			agent.Start()
+			time.Sleep(250 * time.Millisecond)
+
+			Expect(mockClient.registered).To(BeFalse())
+		})
+	})
+
 })
 
 type mockRegistryClient struct {
 	registered    bool
+	status        string
 	lastHeartbeat time.Time
 }
 
 func (c *mockRegistryClient) Register(instance *client.ServiceInstance) (*client.ServiceInstance, error) {
 	c.registered = true
+	c.status = instance.Status
 	c.lastHeartbeat = time.Now()
 	return &client.ServiceInstance{
 		ID:            "1234567890",
@@ -121,6 +171,29 @@ func (c *mockRegistryClient) Renew(id string) error {
 	return nil
 }
 
+func (c *mockRegistryClient) SetStatus(id string, status string) error {
+	c.status = status
+	return nil
+}
+
+func (c *mockRegistryClient) Update(id string, update client.InstanceUpdate) error {
+	return nil
+}
+
+func (c *mockRegistryClient) RegisterBatch(instances []*client.ServiceInstance) ([]*client.ServiceInstance, []error, error) {
+	registered := make([]*client.ServiceInstance, len(instances))
+	for i, instance := range instances {
+		registered[i], _ = c.Register(instance)
+	}
+	return registered, make([]error, len(instances)), nil
+}
+
+func (c *mockRegistryClient) RenewBatch(ids []string) ([]error, error) {
+	c.lastHeartbeat = time.Now()
+	return make([]error, len(ids)), nil
+}
+
 func (c *mockRegistryClient) Reset() {
 	c.registered = false
+	c.status = ""
 }
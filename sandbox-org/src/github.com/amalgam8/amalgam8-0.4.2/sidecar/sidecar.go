@@ -18,7 +18,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -92,6 +94,10 @@ func Run(conf config.Config) error {
 	}
 	logrus.SetLevel(logrusLevel)
 
+	// Intercept SIGTERM/SIGINT from the start, so that the registration is drained and deregistered on shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+
 	registryClient, err := registryclient.New(registryclient.Config{
 		URL:       conf.Registry.URL,
 		AuthToken: conf.Registry.Token,
@@ -116,6 +122,7 @@ func Run(conf config.Config) error {
 				DiscoveryClient: registryMonitor,
 				Port:            uint16(conf.Dnsconfig.Port),
 				Domain:          conf.Dnsconfig.Domain,
+				Locality:        conf.Locality.Preference(),
 			}
 			server, err := dns.NewServer(dnsConfig)
 			if err != nil {
@@ -142,7 +149,24 @@ func Run(conf config.Config) error {
 				Type:  conf.Endpoint.Type,
 				Value: address,
 			},
-			TTL: 60,
+			Weight: conf.Endpoint.Weight,
+			TTL:    60,
+			Labels: conf.Locality.Preference().Locality.Labels(),
+		}
+
+		// Named endpoints are exposed on the service endpoint host, unless configured otherwise
+		for name, endpoint := range conf.Endpoints {
+			host := endpoint.Host
+			if host == "" {
+				host = conf.Endpoint.Host
+			}
+			if serviceInstance.Endpoints == nil {
+				serviceInstance.Endpoints = make(map[string]registryclient.ServiceEndpoint, len(conf.Endpoints))
+			}
+			serviceInstance.Endpoints[name] = registryclient.ServiceEndpoint{
+				Type:  endpoint.Type,
+				Value: fmt.Sprintf("%v:%v", host, endpoint.Port),
+			}
 		}
 
 		registrationAgent, err := register.NewRegistrationAgent(register.RegistrationConfig{
@@ -174,7 +198,7 @@ func Run(conf config.Config) error {
 	}
 
 	appSupervisor := supervisor.NewAppSupervisor(&conf, lifecycle)
-	appSupervisor.DoAppSupervision()
+	appSupervisor.DoAppSupervision(sigChan)
 
 	return nil
 }
@@ -189,7 +213,7 @@ func startProxy(conf *config.Config, registryMonitor monitor.RegistryMonitor) er
 			Client:  nginxClient,
 		},
 	)
-	nginxProxy := proxy.NewNGINXProxy(nginxManager)
+	nginxProxy := proxy.NewNGINXProxy(nginxManager, conf.Locality.Preference())
 
 	controllerClient, err := controllerclient.New(controllerclient.Config{
 		URL:       conf.Controller.URL,
@@ -200,12 +224,24 @@ func startProxy(conf *config.Config, registryMonitor monitor.RegistryMonitor) er
 		return err
 	}
 
+	// Only the rules that can apply to requests from the service, for destinations in the registry, are needed by
+	// the proxy
+	var source *rules.Source
+	if conf.Service.Name != "" {
+		source = &rules.Source{
+			Name: conf.Service.Name,
+			Tags: conf.Service.Tags,
+		}
+	}
+
 	controllerMonitor := monitor.NewController(monitor.ControllerConfig{
 		Client: controllerClient,
 		Listeners: []monitor.ControllerListener{
 			nginxProxy,
 		},
 		PollInterval: conf.Controller.Poll,
+		Source:       source,
+		Registry:     registryMonitor,
 	})
 	go func() {
 		if err = controllerMonitor.Start(); err != nil {
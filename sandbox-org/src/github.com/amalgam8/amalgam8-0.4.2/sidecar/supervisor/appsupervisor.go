@@ -30,6 +30,7 @@ import (
 // AppSupervisor manages process in sidecar
 type AppSupervisor struct {
 	registration register.Lifecycle
+	drainPeriod  time.Duration
 	processes    []*process
 }
 
@@ -43,6 +44,7 @@ type process struct {
 func NewAppSupervisor(conf *config.Config, registration register.Lifecycle) *AppSupervisor {
 	a := AppSupervisor{
 		registration: registration,
+		drainPeriod:  conf.DrainPeriod,
 		processes:    []*process{},
 	}
 
@@ -77,8 +79,9 @@ type processError struct {
 	Proc *process
 }
 
-// DoAppSupervision starts subprocesses and manages their lifecycle - exiting if necessary
-func (a *AppSupervisor) DoAppSupervision() {
+// DoAppSupervision starts subprocesses and manages their lifecycle - exiting if necessary.
+// Signals received on sigChan drain the registration and stop the subprocesses before exiting.
+func (a *AppSupervisor) DoAppSupervision(sigChan <-chan os.Signal) {
 	appChan := make(chan processError, len(a.processes))
 	for _, proc := range a.processes {
 		log.Infof("Launching app '%v' with args '%v'", proc.Cmd.Args[0], strings.Join(proc.Cmd.Args[1:], " "))
@@ -102,14 +105,14 @@ func (a *AppSupervisor) DoAppSupervision() {
 	// listen for SIGCHLDs from any of the supervised processes and any orphans spun off from those
 	go reapZombies()
 
-	// Intercept SIGTERM/SIGINT and stop
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
 	for {
 		select {
 		case sig := <-sigChan:
 			log.Infof("Intercepted signal '%v'", sig)
 
+			// stop new traffic to the supervised applications while they still serve existing sessions
+			a.drain(sigChan, appChan)
+
 			// forwarding signal to supervised applications to exit gracefully
 			terminateSubprocesses(a.processes, sig)
 
@@ -145,6 +148,26 @@ func (a *AppSupervisor) DoAppSupervision() {
 	}
 }
 
+// drain keeps the app registered as draining for the drain period, or until another signal is intercepted
+// or an app exits, as its sessions are then over
+func (a *AppSupervisor) drain(sigChan <-chan os.Signal, appChan <-chan processError) {
+	drainer, ok := a.registration.(register.Drainer)
+	if !ok || a.drainPeriod <= 0 {
+		return
+	}
+
+	drainer.Drain()
+
+	log.Infof("Draining for %v before shutting down", a.drainPeriod)
+	select {
+	case <-time.After(a.drainPeriod):
+	case sig := <-sigChan:
+		log.Infof("Intercepted signal '%v' while draining", sig)
+	case err := <-appChan:
+		log.WithError(err.Err).Warnf("App '%v' with args '%v' exited while draining", err.Proc.Cmd.Args[0], strings.Join(err.Proc.Cmd.Args[1:], " "))
+	}
+}
+
 // Shutdown deregister the app with registry and exit sidecar
 func (a *AppSupervisor) Shutdown(sig int) {
 	if a.registration != nil {